@@ -12,8 +12,10 @@ import (
 	"trade-bot/internal/pkg/repository"
 	"trade-bot/internal/pkg/repository/postgresRepo"
 	"trade-bot/internal/pkg/repository/redisRepo"
+	"trade-bot/internal/pkg/repository/sqliteRepo"
 	"trade-bot/internal/pkg/service"
 	"trade-bot/internal/pkg/tradeAlgorithm"
+	"trade-bot/internal/pkg/tradeAlgorithm/types"
 	"trade-bot/internal/pkg/web"
 	"trade-bot/pkg/krakenFuturesSDK"
 	"trade-bot/pkg/krakenFuturesWSSDK"
@@ -22,6 +24,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -36,6 +39,8 @@ var (
 	ErrCouldNotShutdownServer       = errors.New("could not shut down server normally")
 	ErrCouldNotCloseDBConnection    = errors.New("could not close db connection normally")
 	ErrCouldNotCloseRedisConnection = errors.New("could not close redis connection normally")
+	ErrMigrate                      = errors.New("migrate")
+	ErrUnknownStorage               = errors.New("unknown storage, use postgres or sqlite")
 )
 
 const (
@@ -60,34 +65,71 @@ func main() {
 		log.Panicf("%s: %s", ErrUnableToInitConfig, err)
 	}
 
-	db, err := postgresRepo.NewPostgresDB(config.PostgreDatabase)
-	if err != nil {
-		log.Panicf("%s: %s", ErrUnableToConnectToDB, err)
-	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			log.Panicf("%s: %s", ErrCouldNotCloseDBConnection, err)
+	var repo *repository.Repository
+	switch config.Storage {
+	case configs.SQLiteStorage:
+		db, err := sqliteRepo.NewSQLiteDB(config.SQLiteDatabase)
+		if err != nil {
+			log.Panicf("%s: %s", ErrUnableToConnectToDB, err)
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				log.Panicf("%s: %s", ErrCouldNotCloseDBConnection, err)
+			}
+		}()
+
+		if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+			log.Info("sqlite schema is created on server start, there is nothing to migrate")
+			return
 		}
-	}()
 
-	redisClient, err := redisRepo.NewRedisClient(config.RedisDatabase)
-	if err != nil {
-		log.Panicf("%s: %s", ErrUnableToConnectToJWTDB, err)
-	}
-	defer func() {
-		if err := redisClient.Close(); err != nil {
-			log.Panicf("%s: %s", ErrCouldNotCloseRedisConnection, err)
+		repo = repository.NewSQLiteRepository(db)
+	case configs.PostgresStorage, "":
+		db, err := postgresRepo.NewPostgresDB(config.PostgreDatabase)
+		if err != nil {
+			log.Panicf("%s: %s", ErrUnableToConnectToDB, err)
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				log.Panicf("%s: %s", ErrCouldNotCloseDBConnection, err)
+			}
+		}()
+
+		if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+			if err := runMigrate(db, os.Args[2:]); err != nil {
+				log.Fatalf("%s: %s", ErrMigrate, err)
+			}
+			return
+		}
+		if config.PostgreDatabase.AutoMigrate {
+			if err := runMigrate(db, []string{migrateUp}); err != nil {
+				log.Panicf("%s: %s", ErrMigrate, err)
+			}
 		}
-	}()
 
-	krakenAPI := krakenFuturesSDK.NewAPI(os.Getenv(publicAPIKey), os.Getenv(privateAPIKey), config.Kraken.APIURL)
+		redisClient, err := redisRepo.NewRedisClient(config.RedisDatabase)
+		if err != nil {
+			log.Panicf("%s: %s", ErrUnableToConnectToJWTDB, err)
+		}
+		defer func() {
+			if err := redisClient.Close(); err != nil {
+				log.Panicf("%s: %s", ErrCouldNotCloseRedisConnection, err)
+			}
+		}()
+
+		repo = repository.NewRepository(db, redisClient)
+	default:
+		log.Panicf("%s: %s", ErrUnknownStorage, config.Storage)
+	}
+
+	krakenAPI := krakenFuturesSDK.NewAPI(os.Getenv(publicAPIKey), os.Getenv(privateAPIKey), config.Kraken)
 	krakenWSAPI := krakenFuturesWSSDK.NewWSAPI(config.KrakenWS)
 
-	repo := repository.NewRepository(db, redisClient)
 	newWeb := web.NewWeb(krakenAPI, krakenWSAPI)
-	newTrader := tradeAlgorithm.NewTradeAlgorithm(newWeb)
+	newTrader := tradeAlgorithm.NewTradeAlgorithm(newWeb, repo.Candles)
 
 	validate := validator.New()
+	validate.RegisterCustomTypeFunc(types.DecimalValue, decimal.Decimal{})
 	upgrader := websocket.Upgrader{
 		WriteBufferSize: config.Server.Websocket.WriteBufferSize,
 		ReadBufferSize:  config.Server.Websocket.ReadBufferSize,
@@ -96,19 +138,39 @@ func main() {
 		},
 	}
 
-	services := service.NewService(repo, newWeb, newTrader)
+	services := service.NewService(repo, newWeb, newTrader, config.Reconciler, config.Auth, config.Reports)
 	handlers := handler.NewHandler(services, validate, &upgrader)
 
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
+	reconcilerCtx, stopReconciler := context.WithCancel(context.Background())
+	defer stopReconciler()
+	go services.Reconciler.Run(reconcilerCtx)
+
+	routes, err := handlers.InitRoutes(config.Server.TrustedProxies)
+	if err != nil {
+		log.Panicf("%s: %s", ErrRunServer, err)
+	}
+
 	srv := new(app.Server)
 	go func() {
-		if err := srv.Run(config.Server.Port, handlers.InitRoutes()); err != nil && err != http.ErrServerClosed {
+		if err := srv.Run(config.Server.Port, routes); err != nil && err != http.ErrServerClosed {
 			log.Panicf("%s: %s", ErrRunServer, err)
 		}
 	}()
 
+	var metricsSrv *app.Server
+	if config.Server.MetricsPort != "" {
+		metricsSrv = new(app.Server)
+		go func() {
+			err := metricsSrv.Run(config.Server.MetricsPort, handlers.InitMetricsRoutes())
+			if err != nil && err != http.ErrServerClosed {
+				log.Panicf("%s: %s", ErrRunServer, err)
+			}
+		}()
+	}
+
 	log.Info("Trade bot server started")
 
 	<-interrupt
@@ -116,9 +178,16 @@ func main() {
 	log.Info("interrupt signal caught")
 	log.Info("Trade bot server shutting down")
 
+	stopReconciler()
+
 	if err := srv.Shutdown(context.Background()); err != nil {
 		log.Panicf("%s: %s", ErrCouldNotShutdownServer, err)
 	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(context.Background()); err != nil {
+			log.Panicf("%s: %s", ErrCouldNotShutdownServer, err)
+		}
+	}
 
 	log.Info("Trade bot server shut down")
 }
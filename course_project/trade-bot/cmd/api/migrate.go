@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"trade-bot/internal/pkg/repository/postgresRepo"
+	"trade-bot/schema"
+)
+
+var (
+	ErrUnknownMigrateCommand = errors.New("unknown migrate command, use up, down [steps] or status")
+	ErrInvalidSteps          = errors.New("invalid number of steps")
+)
+
+const (
+	migrateCommand = "migrate"
+	migrateUp      = "up"
+	migrateDown    = "down"
+	migrateStatus  = "status"
+)
+
+// runMigrate runs migrations embedded into binary:
+//
+//	trade-bot migrate up           applies every new migration
+//	trade-bot migrate down [steps] reverts last migrations, one by default
+//	trade-bot migrate status       lists migrations and whether they are applied
+func runMigrate(db *sqlx.DB, args []string) error {
+	migrator, err := postgresRepo.NewMigrator(db, schema.Migrations)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return ErrUnknownMigrateCommand
+	}
+
+	switch args[0] {
+	case migrateUp:
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		version, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+		log.Infof("%d migrations applied, database version is %d", applied, version)
+	case migrateDown:
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("%w: %s", ErrInvalidSteps, args[1])
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		log.Infof("%d migrations reverted", reverted)
+	case migrateStatus:
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownMigrateCommand, args[0])
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/internal/pkg/repository/postgresRepo"
+	"trade-bot/internal/pkg/repository/sqliteRepo"
+	"trade-bot/internal/pkg/service"
+	"trade-bot/internal/pkg/web/webKraken"
+	"trade-bot/pkg/krakenFuturesSDK"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+var (
+	ErrUnableToInitConfig        = errors.New("unable to init config files")
+	ErrReadConfig                = errors.New("read config")
+	ErrUnableToConnectToDB       = errors.New("unable to connect to database")
+	ErrUnableToLoadEnvVariables  = errors.New("unable to load enviroment variables")
+	ErrCouldNotCloseDBConnection = errors.New("could not close db connection normally")
+	ErrInvalidFlags              = errors.New("invalid flags")
+	ErrBackfill                  = errors.New("backfill")
+	ErrUnknownStorage            = errors.New("unknown storage, use postgres or sqlite")
+)
+
+const (
+	publicAPIKey  = "PUBLIC_API_KEY"
+	privateAPIKey = "PRIVATE_API_KEY"
+)
+
+// backfill loads historical candles from kraken charts api into candles table:
+//
+//	go run ./cmd/backfill -symbol PI_XBTUSD -type trade -interval 1h -from 2022-01-01T00:00:00Z
+func main() {
+	symbol := flag.String("symbol", "", "futures symbol, e.g. PI_XBTUSD")
+	candlesType := flag.String("type", string(krakenFuturesWSSDK.TradeCandles), "candles type: trade, mark or spot")
+	interval := flag.String("interval", string(krakenFuturesWSSDK.OneMinute), "candles interval: 1m, 5m, 15m, 1h, 4h, 12h, 1d or 1w")
+	fromFlag := flag.String("from", "", "start of the period in RFC3339")
+	toFlag := flag.String("to", "", "end of the period in RFC3339, now by default")
+	flag.Parse()
+
+	from, to, err := parsePeriod(*fromFlag, *toFlag)
+	if err != nil || *symbol == "" {
+		flag.Usage()
+		log.Fatalf("%s: %v", ErrInvalidFlags, err)
+	}
+
+	config, err := initConfig()
+	if err != nil {
+		log.Fatalf("%s: %s", ErrUnableToInitConfig, err)
+	}
+
+	// failed backfill exits with non-zero status after database is closed, so that cron and CI see the failure
+	n, err := backfill(config, krakenFuturesWSSDK.CandlesType(*candlesType), *symbol,
+		krakenFuturesWSSDK.CandlesInterval(*interval), from, to)
+	if err != nil {
+		log.Fatalf("%s: %s", ErrBackfill, err)
+	}
+
+	log.Infof("%d %s %s candles of %s stored", n, *candlesType, *interval, *symbol)
+}
+
+// backfill stores candles of the period into database of configured storage
+func backfill(config configs.Configuration, candlesType krakenFuturesWSSDK.CandlesType, symbol string,
+	interval krakenFuturesWSSDK.CandlesInterval, from, to time.Time) (int, error) {
+	var (
+		db   *sqlx.DB
+		repo repository.Candles
+		err  error
+	)
+	switch config.Storage {
+	case configs.SQLiteStorage:
+		if db, err = sqliteRepo.NewSQLiteDB(config.SQLiteDatabase); err != nil {
+			return 0, fmt.Errorf("%s: %w", ErrUnableToConnectToDB, err)
+		}
+		repo = sqliteRepo.NewCandlesSQLite(db)
+	case configs.PostgresStorage, "":
+		if db, err = postgresRepo.NewPostgresDB(config.PostgreDatabase); err != nil {
+			return 0, fmt.Errorf("%s: %w", ErrUnableToConnectToDB, err)
+		}
+		repo = postgresRepo.NewCandlesPostgres(db)
+	default:
+		return 0, fmt.Errorf("%s: %s", ErrUnknownStorage, config.Storage)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Errorf("%s: %s", ErrCouldNotCloseDBConnection, err)
+		}
+	}()
+
+	krakenAPI := krakenFuturesSDK.NewAPI(os.Getenv(publicAPIKey), os.Getenv(privateAPIKey), config.Kraken)
+	candles := service.NewCandlesService(repo, webKraken.NewKrakenChartsWebSDK(krakenAPI))
+
+	return candles.Backfill(candlesType, symbol, interval, from, to)
+}
+
+func parsePeriod(fromFlag, toFlag string) (time.Time, time.Time, error) {
+	from, err := time.Parse(time.RFC3339, fromFlag)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to := time.Now()
+	if toFlag != "" {
+		if to, err = time.Parse(time.RFC3339, toFlag); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from %s is not before to %s", from, to)
+	}
+
+	return from, to, nil
+}
+
+func initConfig() (configs.Configuration, error) {
+	viper.SetConfigName("config")
+	viper.AddConfigPath("configs")
+	viper.AddConfigPath(".")
+	viper.SetConfigType("yml")
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return configs.Configuration{}, fmt.Errorf("%s: %w", ErrReadConfig, err)
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		return configs.Configuration{}, fmt.Errorf("%s: %w", ErrUnableToLoadEnvVariables, err)
+	}
+
+	var c configs.Configuration
+	err := viper.Unmarshal(&c)
+	c.PostgreDatabase.Password = os.Getenv("DB_PASSWORD")
+	return c, err
+}
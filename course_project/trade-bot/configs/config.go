@@ -1,18 +1,36 @@
 package configs
 
 type Configuration struct {
-	Server          ServerConfiguration
-	Client          ClientConfiguration
-	Telegram        TelegramBotConfiguration
+	Server   ServerConfiguration
+	Client   ClientConfiguration
+	Telegram TelegramBotConfiguration
+	// Storage is postgres or sqlite, postgres with redis is used when it is empty
+	Storage         string
 	PostgreDatabase PostgreDatabaseConfiguration
 	RedisDatabase   RedisDatabaseConfiguration
+	SQLiteDatabase  SQLiteDatabaseConfiguration
 	Kraken          KrakenConfiguration
 	KrakenWS        KrakenWSConfiguration
+	Reconciler      ReconcilerConfiguration
+	Auth            AuthConfiguration
+	Reports         ReportsConfiguration
 }
 
+const (
+	PostgresStorage = "postgres"
+	// SQLiteStorage keeps all data in one file, neither postgres nor redis is needed
+	SQLiteStorage = "sqlite"
+)
+
 type ServerConfiguration struct {
 	Port      string
 	Websocket ServerWebsocketConfiguration
+	// TrustedProxies are addresses or CIDRs of proxies whose X-Forwarded-For and X-Real-IP headers
+	// are used as client ip, headers are ignored when it is empty
+	TrustedProxies []string
+	// MetricsPort serves prometheus metrics apart from api, so that they are reachable by prometheus only.
+	// Metrics are not served when it is empty
+	MetricsPort string
 }
 
 type ServerWebsocketConfiguration struct {
@@ -37,6 +55,8 @@ type PostgreDatabaseConfiguration struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate applies new migrations on server start
+	AutoMigrate bool
 }
 
 type RedisDatabaseConfiguration struct {
@@ -44,8 +64,23 @@ type RedisDatabaseConfiguration struct {
 	Port string
 }
 
+type SQLiteDatabaseConfiguration struct {
+	// Path of database file, :memory: keeps data until server stops
+	Path string
+}
+
 type KrakenConfiguration struct {
-	APIURL string
+	APIURL   string
+	Requests KrakenAPIRequestsConfiguration
+}
+
+type KrakenAPIRequestsConfiguration struct {
+	TimeoutInSeconds            int
+	MaxRetries                  int
+	MinRetryDelayInMilliseconds int
+	MaxRetryDelayInSeconds      int
+	RateLimitBudget             int
+	RateLimitWindowInSeconds    int
 }
 
 type KrakenWSConfiguration struct {
@@ -63,3 +98,31 @@ type KrakenWSAPIRequestsConfiguration struct {
 	PingPeriodInSeconds int
 	MaxMessageSize      int
 }
+
+type ReconcilerConfiguration struct {
+	IntervalInSeconds    int
+	FillsLookbackInHours int
+}
+
+type AuthConfiguration struct {
+	LoginProtection LoginProtectionConfiguration
+	RateLimit       RateLimitConfiguration
+}
+
+type LoginProtectionConfiguration struct {
+	MaxFailures             int
+	FailuresWindowInMinutes int
+	LockoutInMinutes        int
+	BaseDelayInMilliseconds int
+	MaxDelayInSeconds       int
+}
+
+type RateLimitConfiguration struct {
+	Requests        int
+	WindowInSeconds int
+}
+
+type ReportsConfiguration struct {
+	// FeeRate is share of traded value paid as fee, fees of trades are estimated by it
+	FeeRate float64
+}
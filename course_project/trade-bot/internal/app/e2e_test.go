@@ -0,0 +1,259 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/handler"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/internal/pkg/repository/memoryRepo"
+	"trade-bot/internal/pkg/service"
+	"trade-bot/internal/pkg/tradeAlgorithm"
+	"trade-bot/internal/pkg/tradeAlgorithm/types"
+	"trade-bot/internal/pkg/web/webFake"
+)
+
+// app is the whole application behind real router with data in memory and market of script
+type app struct {
+	server        *httptest.Server
+	metricsServer *httptest.Server
+	repo          *repository.Repository
+	exchange      *webFake.Exchange
+}
+
+func newApp(t *testing.T, script *webFake.Script) *app {
+	t.Setenv("JWT_ACCESS_SIGNING_KEY", "access")
+	t.Setenv("JWT_REFRESH_SIGNING_KEY", "refresh")
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewMemoryRepository(memoryRepo.NewDB())
+	exchange := webFake.NewExchange(script)
+	fakeWeb := webFake.NewWeb(script, exchange)
+	trader := tradeAlgorithm.NewTradeAlgorithm(fakeWeb, repo.Candles)
+
+	validate := validator.New()
+	validate.RegisterCustomTypeFunc(types.DecimalValue, decimal.Decimal{})
+	upgrader := websocket.Upgrader{}
+
+	services := service.NewService(repo, fakeWeb, trader, configs.ReconcilerConfiguration{},
+		configs.AuthConfiguration{}, configs.ReportsConfiguration{})
+	handlers := handler.NewHandler(services, validate, &upgrader)
+
+	routes, err := handlers.InitRoutes(nil)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(routes)
+	t.Cleanup(server.Close)
+	metricsServer := httptest.NewServer(handlers.InitMetricsRoutes())
+	t.Cleanup(metricsServer.Close)
+
+	return &app{server: server, metricsServer: metricsServer, repo: repo, exchange: exchange}
+}
+
+func (a *app) do(t *testing.T, method, path, token string, headers map[string]string, body, out interface{}) int {
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&payload).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, a.server.URL+path, &payload)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+// metrics returns exposition of metrics, they are shared by every app of the test binary
+func (a *app) metrics(t *testing.T) string {
+	resp, err := a.metricsServer.Client().Get(a.metricsServer.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+// metricValue returns value of series in metrics scrape, 0 when the series is missing
+func metricValue(metrics, series string) float64 {
+	for _, line := range strings.Split(metrics, "\n") {
+		if strings.HasPrefix(line, series+" ") {
+			value, _ := strconv.ParseFloat(strings.TrimPrefix(line, series+" "), 64)
+			return value
+		}
+	}
+	return 0
+}
+
+// signUp creates user and returns its id with access token
+func (a *app) signUp(t *testing.T, username string) (int, string) {
+	user := map[string]string{
+		"name":            "Trader",
+		"username":        username,
+		"password":        "qwerty",
+		"public_api_key":  "public",
+		"private_api_key": "private",
+	}
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.Equal(t, http.StatusOK, a.do(t, http.MethodPost, "/auth/sign-up", "", nil, user, &created))
+
+	var tokens models.Tokens
+	credentials := map[string]string{"username": username, "password": "qwerty"}
+	require.Equal(t, http.StatusOK, a.do(t, http.MethodPost, "/auth/sign-in", "", nil, credentials, &tokens))
+	require.NotEmpty(t, tokens.AccessToken)
+
+	return created.ID, tokens.AccessToken
+}
+
+func (a *app) startTrade(t *testing.T, token string) *websocket.Conn {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	url := "ws" + strings.TrimPrefix(a.server.URL, "http") + "/orderManager/ws/start-trade"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{
+		"event": "start_trading",
+		"trading_details": map[string]interface{}{
+			"exchange":           "paper",
+			"order_type":         "mkt",
+			"symbol":             "PI_XBTUSD",
+			"side":               "buy",
+			"size":               1,
+			"stop_loss_border":   "10",
+			"take_profit_border": "10",
+		},
+	}))
+	return conn
+}
+
+func TestEndToEnd_Trading(t *testing.T) {
+	script := webFake.NewScript(decimal.NewFromInt(100),
+		decimal.NewFromInt(101), decimal.NewFromInt(105), decimal.NewFromInt(111))
+	a := newApp(t, script)
+
+	// metrics are global, so they are compared with values before the test
+	const (
+		sendOrderRequests = `trade_bot_http_request_duration_seconds_count{method="POST",route="/orderManager/send-order",status="200"}`
+		replayedOrders    = `trade_bot_orders_total{exchange="paper",result="replayed"}`
+	)
+	before := a.metrics(t)
+
+	userID, token := a.signUp(t, "trader")
+
+	// retried order with the same idempotency key is sent once
+	request := map[string]interface{}{"exchange": "paper", "order_type": "mkt", "symbol": "PI_XBTUSD", "side": "buy", "size": 2}
+	idempotency := map[string]string{"Idempotency-Key": "first-order"}
+	var first, retried models.Order
+	require.Equal(t, http.StatusOK, a.do(t, http.MethodPost, "/orderManager/send-order", token, idempotency, request, &first))
+	require.Equal(t, http.StatusOK, a.do(t, http.MethodPost, "/orderManager/send-order", token, idempotency, request, &retried))
+	assert.Equal(t, first.ID, retried.ID)
+	assert.True(t, first.Price.Equal(decimal.NewFromInt(100)))
+	assert.Len(t, a.exchange.Orders(), 1)
+
+	// position is bought at 100 and sold once price goes above take profit border
+	conn := a.startTrade(t, token)
+	var finish models.Order
+	require.NoError(t, conn.ReadJSON(&finish))
+	assert.Equal(t, "sell", finish.Side)
+	assert.True(t, finish.Price.Equal(decimal.NewFromInt(111)), finish.Price.String())
+
+	var myOrders struct {
+		Orders []models.Order `json:"orders"`
+	}
+	require.Equal(t, http.StatusOK, a.do(t, http.MethodGet, "/orderManager/my-orders", token, nil, nil, &myOrders))
+	require.Len(t, myOrders.Orders, 3)
+
+	// history sorts orders sent within one second by id, repository keeps them in order they were stored
+	stored, err := a.repo.KrakenOrdersManager.GetUserOrders(userID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, stored, myOrders.Orders)
+	assert.Equal(t, first.ID, stored[0].ID)
+	assert.Equal(t, "buy", stored[1].Side)
+	assert.Equal(t, finish.ID, stored[2].ID)
+
+	submission, err := a.repo.KrakenOrdersManager.GetOrderSubmission(first.ClientOrderID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, submission.OrderID)
+
+	// trading session is reported as one winning trade, the separate order is not a trade
+	var report models.Report
+	require.Equal(t, http.StatusOK, a.do(t, http.MethodGet, "/reports", token, nil, nil, &report))
+	assert.Equal(t, 1, report.Total.Trades)
+	assert.Equal(t, 1, report.Total.Wins)
+	require.Len(t, report.Strategies, 1)
+	assert.Equal(t, types.StopLossTakeProfitStrategy, report.Strategies[0].Strategy)
+	assert.True(t, report.Total.NetProfit.IsPositive(), report.Total.NetProfit.String())
+
+	// requests are measured by route and orders by result, the retried order is replayed
+	metrics := a.metrics(t)
+	assert.Equal(t, metricValue(before, sendOrderRequests)+2, metricValue(metrics, sendOrderRequests))
+	assert.Equal(t, metricValue(before, replayedOrders)+1, metricValue(metrics, replayedOrders))
+	assert.Contains(t, metrics, `trade_bot_active_trading_sessions 0`)
+}
+
+func TestEndToEnd_CancelTrading(t *testing.T) {
+	script := webFake.NewScript(decimal.NewFromInt(100))
+	a := newApp(t, script)
+
+	userID, token := a.signUp(t, "trader")
+
+	conn := a.startTrade(t, token)
+	// price never leaves borders, so trading goes on until it is canceled
+	script.Push(decimal.NewFromInt(102), decimal.NewFromInt(98))
+	require.NoError(t, conn.WriteJSON(map[string]string{"event": "cancel_trading"}))
+
+	var response struct {
+		Message string `json:"message"`
+	}
+	require.NoError(t, conn.ReadJSON(&response))
+	assert.Equal(t, "trading have been canceled", response.Message)
+
+	// only position opening order is stored
+	orders, err := a.repo.KrakenOrdersManager.GetUserOrders(userID)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "buy", orders[0].Side)
+}
+
+func TestEndToEnd_Unauthorized(t *testing.T) {
+	a := newApp(t, webFake.NewScript(decimal.NewFromInt(100)))
+
+	request := map[string]interface{}{"order_type": "mkt", "symbol": "PI_XBTUSD", "side": "buy", "size": 1}
+	assert.Equal(t, http.StatusUnauthorized, a.do(t, http.MethodPost, "/orderManager/send-order", "", nil, request, nil))
+	assert.Equal(t, http.StatusUnauthorized, a.do(t, http.MethodPost, "/orderManager/send-order", "invalid", nil, request, nil))
+	assert.Empty(t, a.exchange.Orders())
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/service"
+)
+
+type setUserRoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// @Summary AdminUsers
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description get all users with their roles
+// @ID adminUsers
+// @Produce  json
+// @Success 200 {object} []models.UserSummary
+// @Failure 401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /admin/users [get]
+func (h *Handler) adminUsers(c *gin.Context) {
+	users, err := h.services.Admin.GetUsers()
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"users": users,
+	})
+}
+
+// @Summary SetUserRole
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description set role of user: trader, viewer or admin
+// @ID setUserRole
+// @Accept  json
+// @Produce  json
+// @Param id path int true "user id"
+// @Param input body setUserRoleInput true "role"
+// @Success 200 {string} string "message"
+// @Failure 400,401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /admin/users/{id}/role [put]
+func (h *Handler) setUserRole(c *gin.Context) {
+	var input setUserRoleInput
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, ErrInvalidInputBody)
+		return
+	}
+
+	adminID, userID, ok := getAdminAndTargetIDs(c)
+	if !ok {
+		return
+	}
+
+	err := h.services.Admin.SetUserRole(c.Request.Context(), adminID, userID, input.Role)
+	if err != nil {
+		newAdminErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "role changed",
+	})
+}
+
+// @Summary DisableUser
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description disable account of user and log out all of its sessions
+// @ID disableUser
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200 {string} string "message"
+// @Failure 400,401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /admin/users/{id}/disable [post]
+func (h *Handler) disableUser(c *gin.Context) {
+	adminID, userID, ok := getAdminAndTargetIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Admin.DisableUser(c.Request.Context(), adminID, userID); err != nil {
+		newAdminErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "user disabled",
+	})
+}
+
+// @Summary EnableUser
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description enable disabled account of user
+// @ID enableUser
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200 {string} string "message"
+// @Failure 400,401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /admin/users/{id}/enable [post]
+func (h *Handler) enableUser(c *gin.Context) {
+	adminID, userID, ok := getAdminAndTargetIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Admin.EnableUser(c.Request.Context(), adminID, userID); err != nil {
+		newAdminErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "user enabled",
+	})
+}
+
+// @Summary ForceLogout
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description log user out of every session
+// @ID forceLogout
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200 {integer} integer "revoked sessions"
+// @Failure 400,401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /admin/users/{id}/sessions [delete]
+func (h *Handler) forceLogout(c *gin.Context) {
+	adminID, userID, ok := getAdminAndTargetIDs(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.services.Admin.ForceLogout(c.Request.Context(), adminID, userID)
+	if err != nil {
+		newAdminErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"revoked": revoked,
+	})
+}
+
+// @Summary AdminSessions
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description get sessions of every user
+// @ID adminSessions
+// @Produce  json
+// @Success 200 {object} []models.Session
+// @Failure 401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /admin/sessions [get]
+func (h *Handler) adminSessions(c *gin.Context) {
+	sessions, err := h.services.Admin.GetAllSessions()
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// @Summary RiskUsage
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description get orders count, open and traded notional of every user
+// @ID riskUsage
+// @Produce  json
+// @Success 200 {object} []models.RiskUsage
+// @Failure 401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /admin/risk [get]
+func (h *Handler) riskUsage(c *gin.Context) {
+	usage, err := h.services.Admin.GetRiskUsage()
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"usage": usage,
+	})
+}
+
+// getAdminAndTargetIDs returns id of admin making request and id of user from path
+func getAdminAndTargetIDs(c *gin.Context) (int, int, bool) {
+	adminID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return 0, 0, false
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("%s: %s", ErrInvalidUserID, c.Param("id")))
+		return 0, 0, false
+	}
+
+	return adminID, userID, true
+}
+
+func newAdminErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrUnknownRole), errors.Is(err, service.ErrModifySelf):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
+)
+
+var (
+	ErrInvalidAPITokenID = errors.New("invalid api token id")
+)
+
+// @Summary CreateAPIToken
+// @Security ApiKeyAuth
+// @Tags users
+// @Description create long-lived api token with scopes read-orders, trade and admin, token is shown only once.
+// @Description Requests from ips out of allowlist are rejected, empty allowlist allows any ip
+// @ID createAPIToken
+// @Accept  json
+// @Produce  json
+// @Param X-TOTP-Code header string false "TOTP or recovery code, required with two-factor authentication"
+// @Param input body models.APITokenInput true "token name, scopes, ip allowlist and lifetime"
+// @Success 200 {object} models.NewAPIToken
+// @Failure 400,401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /users/me/tokens [post]
+func (h *Handler) createAPIToken(c *gin.Context) {
+	var input models.APITokenInput
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, ErrInvalidInputBody)
+		return
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	token, err := h.services.APITokens.CreateAPIToken(c.Request.Context(), userID, input)
+	switch {
+	case errors.Is(err, service.ErrUnknownScope), errors.Is(err, service.ErrInvalidIPAllowlistItem):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, service.ErrScopeNotPermitted):
+		newErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// @Summary APITokens
+// @Security ApiKeyAuth
+// @Tags users
+// @Description get api tokens of user without the tokens themselves
+// @ID apiTokens
+// @Produce  json
+// @Success 200 {object} []models.APIToken
+// @Failure 401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /users/me/tokens [get]
+func (h *Handler) apiTokens(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	tokens, err := h.services.APITokens.GetAPITokens(userID)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"tokens": tokens,
+	})
+}
+
+// @Summary RevokeAPIToken
+// @Security ApiKeyAuth
+// @Tags users
+// @Description revoke api token by id
+// @ID revokeAPIToken
+// @Produce  json
+// @Param id path int true "token id"
+// @Success 200 {string} string "message"
+// @Failure 400,401,403,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /users/me/tokens/{id} [delete]
+func (h *Handler) revokeAPIToken(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("%s: %s", ErrInvalidAPITokenID, c.Param("id")))
+		return
+	}
+
+	err = h.services.APITokens.RevokeAPIToken(c.Request.Context(), userID, id)
+	if errors.Is(err, service.ErrAPITokenNotFound) {
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "api token revoked",
+	})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"trade-bot/internal/pkg/models"
+)
+
+// @Summary AuditEntries
+// @Security ApiKeyAuth
+// @Tags audit
+// @Description get audit log entries of user: order requests and responses, strategy decisions, logins and logouts
+// @ID auditEntries
+// @Produce  json
+// @Param action query string false "action"
+// @Param entity_id query string false "order id, client order id or symbol"
+// @Param correlation_id query string false "correlation id"
+// @Param from query string false "RFC3339 time, inclusive"
+// @Param to query string false "RFC3339 time, exclusive"
+// @Param limit query int false "max entries, 100 by default"
+// @Param offset query int false "entries to skip"
+// @Success 200 {object} []models.AuditEntry
+// @Failure 400,401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /audit [get]
+func (h *Handler) auditEntries(c *gin.Context) {
+	filter, ok := h.bindAuditFilter(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.services.Journal.GetAuditEntries(filter)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"entries": entries,
+	})
+}
+
+// @Summary ExportAuditEntries
+// @Security ApiKeyAuth
+// @Tags audit
+// @Description export all audit log entries of user matching filters as JSON lines
+// @ID exportAuditEntries
+// @Produce  application/x-ndjson
+// @Param action query string false "action"
+// @Param entity_id query string false "order id, client order id or symbol"
+// @Param correlation_id query string false "correlation id"
+// @Param from query string false "RFC3339 time, inclusive"
+// @Param to query string false "RFC3339 time, exclusive"
+// @Success 200 {string} string "one audit entry per line"
+// @Failure 400,401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /audit/export [get]
+func (h *Handler) exportAuditEntries(c *gin.Context) {
+	filter, ok := h.bindAuditFilter(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	c.Status(http.StatusOK)
+
+	if err := h.services.Journal.ExportAuditEntries(filter, c.Writer); err != nil {
+		// part of export may be already written, so status can't be changed
+		log.Error(err)
+	}
+}
+
+func (h *Handler) bindAuditFilter(c *gin.Context) (models.AuditFilter, bool) {
+	var filter models.AuditFilter
+
+	if err := c.BindQuery(&filter); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return models.AuditFilter{}, false
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return models.AuditFilter{}, false
+	}
+	filter.UserID = userID
+
+	return filter, true
+}
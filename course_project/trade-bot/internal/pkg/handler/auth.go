@@ -3,9 +3,11 @@ package handler
 import (
 	"net/http"
 	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
 	"trade-bot/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -15,6 +17,12 @@ var (
 type signInInput struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// TOTPCode is required for accounts with two-factor authentication, recovery code is accepted too
+	TOTPCode string `json:"totp_code"`
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // @Summary SignIn
@@ -24,8 +32,8 @@ type signInInput struct {
 // @Accept  json
 // @Produce  json
 // @Param input body signInInput true "credentials"
-// @Success 200 {string} string "access_token"
-// @Failure 400,404 {object} errResponse
+// @Success 200 {object} models.Tokens
+// @Failure 400,401,404,429 {object} errResponse
 // @Failure 500 {object} errResponse
 // @Failure default {object} errResponse
 // @Router /auth/sign-in [post]
@@ -37,15 +45,52 @@ func (h *Handler) signIn(c *gin.Context) {
 		return
 	}
 
-	accessToken, err := h.services.Authorization.GenerateJWT(input.Username, input.Password)
+	device := models.Device{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+	credentials := models.Credentials{Username: input.Username, Password: input.Password, TOTPCode: input.TOTPCode}
+	tokens, err := h.services.Authorization.GenerateJWT(c.Request.Context(), credentials, device)
+	if errors.Is(err, service.ErrTooManyLoginAttempts) {
+		newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrTOTPRequired) || errors.Is(err, service.ErrInvalidTOTPCode) {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"access_token": accessToken,
-	})
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Refresh
+// @Tags auth
+// @Description exchange refresh token for new access and refresh tokens, every refresh token can be used once
+// @ID refresh
+// @Accept  json
+// @Produce  json
+// @Param input body refreshInput true "refresh token"
+// @Success 200 {object} models.Tokens
+// @Failure 400,401 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /auth/refresh [post]
+func (h *Handler) refresh(c *gin.Context) {
+	var input refreshInput
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, ErrInvalidInputBody)
+		return
+	}
+
+	tokens, err := h.services.Authorization.RefreshJWT(c.Request.Context(), input.RefreshToken)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
 }
 
 // @Summary SignUp
@@ -97,7 +142,7 @@ func (h *Handler) logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.Authorization.LogoutUser(token); err != nil {
+	if err := h.services.Authorization.LogoutUser(c.Request.Context(), token); err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -106,3 +151,72 @@ func (h *Handler) logout(c *gin.Context) {
 		"message": "successfully logged out",
 	})
 }
+
+// @Summary Sessions
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description get logged-in devices of user
+// @ID sessions
+// @Produce  json
+// @Success 200 {object} []models.Session
+// @Failure 401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /auth/sessions [get]
+func (h *Handler) sessions(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	token, err := utils.GetBearerToken(c.Request)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	sessions, err := h.services.Authorization.GetSessions(userID, token)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// @Summary RevokeSession
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description log device out by session id
+// @ID revokeSession
+// @Produce  json
+// @Param id path string true "session id"
+// @Success 200 {string} string "message"
+// @Failure 401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /auth/sessions/{id} [delete]
+func (h *Handler) revokeSession(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.services.Authorization.RevokeSession(c.Request.Context(), userID, c.Param("id"))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "session revoked",
+	})
+}
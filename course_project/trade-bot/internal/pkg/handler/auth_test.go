@@ -11,6 +11,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"trade-bot/internal/pkg/models"
 	"trade-bot/internal/pkg/service"
@@ -129,10 +130,11 @@ func TestHandler_signIn(t *testing.T) {
 			username:  "username",
 			password:  "qwerty",
 			mockBehaviour: func(s *mockService.MockAuthorization, username, password string) {
-				s.EXPECT().GenerateJWT(username, password).Return("token", nil)
+				s.EXPECT().GenerateJWT(gomock.Any(), models.Credentials{Username: username, Password: password}, gomock.Any()).
+					Return(models.Tokens{AccessToken: "token", RefreshToken: "refresh", ExpiresIn: 900}, nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `{"access_token":"token"}`,
+			expectedRequestBody: `{"access_token":"token","refresh_token":"refresh","expires_in":900}`,
 		},
 		{
 			name:                "Wrong Input",
@@ -141,13 +143,39 @@ func TestHandler_signIn(t *testing.T) {
 			expectedStatusCode:  400,
 			expectedRequestBody: fmt.Sprintf(`{"message":"%s"}`, ErrInvalidInputBody),
 		},
+		{
+			name:      "TOTP required",
+			inputBody: `{"username":"username", "password":"qwerty"}`,
+			username:  "username",
+			password:  "qwerty",
+			mockBehaviour: func(s *mockService.MockAuthorization, username, password string) {
+				s.EXPECT().GenerateJWT(gomock.Any(), models.Credentials{Username: username, Password: password}, gomock.Any()).
+					Return(models.Tokens{}, fmt.Errorf("%s: %w", service.ErrGenerateJWT, service.ErrTOTPRequired))
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"generate jwt: totp code is required"}`,
+		},
+		{
+			name:      "Locked out",
+			inputBody: `{"username":"username", "password":"qwerty"}`,
+			username:  "username",
+			password:  "qwerty",
+			mockBehaviour: func(s *mockService.MockAuthorization, username, password string) {
+				s.EXPECT().GenerateJWT(gomock.Any(), models.Credentials{Username: username, Password: password}, gomock.Any()).
+					Return(models.Tokens{}, fmt.Errorf("%s: %w", service.ErrGenerateJWT,
+						fmt.Errorf("%w: retry in 15m0s", service.ErrTooManyLoginAttempts)))
+			},
+			expectedStatusCode:  429,
+			expectedRequestBody: `{"message":"generate jwt: too many login attempts: retry in 15m0s"}`,
+		},
 		{
 			name:      "Service error",
 			inputBody: `{"username":"username", "password":"qwerty"}`,
 			username:  "username",
 			password:  "qwerty",
 			mockBehaviour: func(s *mockService.MockAuthorization, username, password string) {
-				s.EXPECT().GenerateJWT(username, password).Return("", errors.New("something went wrong"))
+				s.EXPECT().GenerateJWT(gomock.Any(), models.Credentials{Username: username, Password: password}, gomock.Any()).
+					Return(models.Tokens{}, errors.New("something went wrong"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"something went wrong"}`,
@@ -180,6 +208,99 @@ func TestHandler_signIn(t *testing.T) {
 	}
 }
 
+func TestHandler_signInForwardedFor(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	// login attempts are counted by device ip, spoofed header must not change it
+	auth := mockService.NewMockAuthorization(c)
+	auth.EXPECT().GenerateJWT(gomock.Any(), gomock.Any(), models.Device{UserAgent: "agent", IP: "192.0.2.1"}).
+		Return(models.Tokens{AccessToken: "token", RefreshToken: "refresh", ExpiresIn: 900}, nil)
+
+	handler := Handler{&service.Service{Authorization: auth}, nil, nil}
+
+	r, err := newRouter(nil)
+	require.NoError(t, err)
+	r.POST("/sign-in", handler.signIn)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/sign-in",
+		bytes.NewBufferString(`{"username":"username", "password":"qwerty"}`))
+	req.Header.Set("User-Agent", "agent")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandler_refresh(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockAuthorization, refreshToken string)
+
+	tests := []struct {
+		name                string
+		inputBody           string
+		refreshToken        string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:         "OK",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehaviour: func(s *mockService.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshJWT(gomock.Any(), refreshToken).
+					Return(models.Tokens{AccessToken: "token", RefreshToken: "next", ExpiresIn: 900}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"access_token":"token","refresh_token":"next","expires_in":900}`,
+		},
+		{
+			name:                "Wrong Input",
+			inputBody:           `{}`,
+			mockBehaviour:       func(s *mockService.MockAuthorization, refreshToken string) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: fmt.Sprintf(`{"message":"%s"}`, ErrInvalidInputBody),
+		},
+		{
+			name:         "Reused token",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehaviour: func(s *mockService.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshJWT(gomock.Any(), refreshToken).Return(models.Tokens{}, service.ErrRefreshTokenReused)
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: fmt.Sprintf(`{"message":"%s"}`, service.ErrRefreshTokenReused),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mockService.NewMockAuthorization(c)
+			test.mockBehaviour(repo, test.refreshToken)
+
+			services := &service.Service{Authorization: repo}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.POST("/refresh", handler.refresh)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/refresh",
+				bytes.NewBufferString(test.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
 func TestHandler_logout(t *testing.T) {
 	type mockBehaviour func(s *mockService.MockAuthorization, token string)
 
@@ -198,7 +319,7 @@ func TestHandler_logout(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehaviour: func(s *mockService.MockAuthorization, token string) {
-				s.EXPECT().LogoutUser(token).Return(nil)
+				s.EXPECT().LogoutUser(gomock.Any(), token).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"successfully logged out"}`,
@@ -236,7 +357,7 @@ func TestHandler_logout(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehaviour: func(s *mockService.MockAuthorization, token string) {
-				s.EXPECT().LogoutUser(token).Return(errors.New("invalid token"))
+				s.EXPECT().LogoutUser(gomock.Any(), token).Return(errors.New("invalid token"))
 			},
 			expectedStatusCode:  http.StatusInternalServerError,
 			expectedRequestBody: `{"message":"invalid token"}`,
@@ -268,3 +389,61 @@ func TestHandler_logout(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_revokeSession(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockAuthorization, sessionID string)
+
+	tests := []struct {
+		name                string
+		sessionID           string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			sessionID: "session",
+			mockBehaviour: func(s *mockService.MockAuthorization, sessionID string) {
+				s.EXPECT().RevokeSession(gomock.Any(), 1, sessionID).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"session revoked"}`,
+		},
+		{
+			name:      "Not found",
+			sessionID: "other",
+			mockBehaviour: func(s *mockService.MockAuthorization, sessionID string) {
+				s.EXPECT().RevokeSession(gomock.Any(), 1, sessionID).
+					Return(fmt.Errorf("%s: %w", service.ErrRevokeSession, service.ErrSessionNotFound))
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"message":"revoke session: session not found"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mockService.NewMockAuthorization(c)
+			test.mockBehaviour(repo, test.sessionID)
+
+			services := &service.Service{Authorization: repo}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.DELETE("/sessions/:id", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+			}, handler.revokeSession)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/sessions/"+test.sessionID, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
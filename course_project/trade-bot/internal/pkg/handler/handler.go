@@ -1,16 +1,22 @@
 package handler
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 	swaggerfiles "github.com/swaggo/files"     // swagger embed files
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
 
 	_ "trade-bot/docs" // docs for swagger
+	"trade-bot/internal/pkg/models"
 	"trade-bot/internal/pkg/service"
 )
 
+var ErrTrustedProxies = errors.New("invalid trusted proxies")
+
 type Handler struct {
 	services   *service.Service
 	validate   *validator.Validate
@@ -21,26 +27,90 @@ func NewHandler(services *service.Service, validate *validator.Validate, wsUpgra
 	return &Handler{services: services, validate: validate, wsUpgrader: wsUpgrader}
 }
 
-func (h *Handler) InitRoutes() *gin.Engine {
+// newRouter creates engine which takes client ip from forwarding headers of trusted proxies only,
+// so that ip allowlists and limits can't be bypassed by a spoofed header
+func newRouter(trustedProxies []string) (*gin.Engine, error) {
 	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrTrustedProxies, err)
+	}
+	return router, nil
+}
+
+func (h *Handler) InitRoutes(trustedProxies []string) (*gin.Engine, error) {
+	router, err := newRouter(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
 	router.Use(gin.Logger())
+	router.Use(h.instrument)
 	router.Use(gin.Recovery())
+	router.Use(h.correlationID)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	auth := router.Group("/auth")
+	auth := router.Group("/auth", h.rateLimit(service.AuthRateLimitScope))
 	{
 		auth.POST("sign-in", h.signIn)
 		auth.POST("sign-up", h.signUp)
-		auth.DELETE("logout", h.userIdentity, h.logout)
+		auth.POST("refresh", h.refresh)
+		auth.DELETE("logout", h.userIdentity, h.sessionOnly, h.logout)
+		auth.GET("sessions", h.userIdentity, h.sessionOnly, h.sessions)
+		auth.DELETE("sessions/:id", h.userIdentity, h.sessionOnly, h.revokeSession)
+	}
+
+	twoFactor := router.Group("/auth/2fa", h.rateLimit(service.AuthRateLimitScope), h.userIdentity, h.sessionOnly)
+	{
+		twoFactor.POST("enroll", h.enrollTOTP)
+		twoFactor.POST("confirm", h.confirmTOTP)
+		twoFactor.POST("recovery-codes", h.secondFactor, h.regenerateRecoveryCodes)
+		twoFactor.DELETE("", h.secondFactor, h.disableTOTP)
+	}
+
+	users := router.Group("/users/me", h.userIdentity, h.sessionOnly)
+	{
+		limit := h.rateLimit(service.AuthRateLimitScope)
+		users.GET("", h.profile)
+		users.PATCH("", limit, h.secondFactor, h.updateProfile)
+		users.POST("password", limit, h.secondFactor, h.changePassword)
+		users.DELETE("", limit, h.secondFactor, h.deleteAccount)
+		users.GET("tokens", h.apiTokens)
+		users.POST("tokens", limit, h.secondFactor, h.createAPIToken)
+		users.DELETE("tokens/:id", h.revokeAPIToken)
 	}
 
 	orderManager := router.Group("/orderManager", h.userIdentity)
 	{
-		orderManager.POST("send-order", h.sendOrder)
-		orderManager.GET("ws/start-trade", h.startTrade)
-		orderManager.GET("my-orders", h.myOrders)
+		trade := h.permission(models.PermissionTrade)
+		orderManager.POST("send-order", trade, h.sendOrder)
+		orderManager.POST("batch", trade, h.batchOrder)
+		orderManager.GET("ws/start-trade", trade, h.startTrade)
+		orderManager.GET("my-orders", h.permission(models.PermissionReadOrders), h.myOrders)
+		orderManager.GET("my-orders/export", h.permission(models.PermissionReadOrders), h.exportMyOrders)
+	}
+
+	reports := router.Group("/reports", h.userIdentity, h.permission(models.PermissionReadOrders))
+	{
+		reports.GET("", h.report)
+		reports.GET("html", h.reportHTML)
+	}
+
+	audit := router.Group("/audit", h.userIdentity, h.permission(models.PermissionReadAudit))
+	{
+		audit.GET("", h.auditEntries)
+		audit.GET("export", h.exportAuditEntries)
+	}
+
+	admin := router.Group("/admin", h.userIdentity, h.permission(models.PermissionAdmin))
+	{
+		admin.GET("users", h.adminUsers)
+		admin.PUT("users/:id/role", h.setUserRole)
+		admin.POST("users/:id/disable", h.disableUser)
+		admin.POST("users/:id/enable", h.enableUser)
+		admin.DELETE("users/:id/sessions", h.forceLogout)
+		admin.GET("sessions", h.adminSessions)
+		admin.GET("risk", h.riskUsage)
 	}
 
-	return router
+	return router, nil
 }
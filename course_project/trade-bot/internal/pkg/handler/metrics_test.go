@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_instrument(t *testing.T) {
+	handler := Handler{}
+
+	r := gin.New()
+	r.Use(handler.instrument)
+	r.GET("/instrumented/:id", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	const (
+		routed    = `trade_bot_http_request_duration_seconds_count{method="GET",route="/instrumented/:id",status="202"}`
+		unmatched = `trade_bot_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"}`
+	)
+	metricsRoutes := handler.InitMetricsRoutes()
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		metricsRoutes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	before := scrape()
+
+	for _, path := range []string{"/instrumented/1", "/instrumented/2", "/instrumented-unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// requests are labeled by route template, paths without route share one label
+	metrics := scrape()
+	assert.Equal(t, metricValue(before, routed)+2, metricValue(metrics, routed))
+	assert.Equal(t, metricValue(before, unmatched)+1, metricValue(metrics, unmatched))
+	assert.NotContains(t, metrics, "instrumented-unknown")
+}
+
+func TestHandler_InitRoutesWithoutMetrics(t *testing.T) {
+	handler := Handler{}
+
+	routes, err := handler.InitRoutes(nil)
+	require.NoError(t, err)
+
+	// metrics are served on their own port only
+	for _, route := range routes.Routes() {
+		assert.NotEqual(t, "/metrics", route.Path)
+	}
+}
+
+// metricValue returns value of series in metrics scrape, 0 when the series is missing
+func metricValue(metrics, series string) float64 {
+	for _, line := range strings.Split(metrics, "\n") {
+		if strings.HasPrefix(line, series+" ") {
+			value, _ := strconv.ParseFloat(strings.TrimPrefix(line, series+" "), 64)
+			return value
+		}
+	}
+	return 0
+}
@@ -2,7 +2,11 @@ package handler
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
 	"trade-bot/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -15,14 +19,39 @@ var (
 	ErrUserNotFound   = errors.New("user not found")
 	ErrAPIKeyNotFound = errors.New("api key not found")
 	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrForbidden      = errors.New("permission denied")
+	ErrSessionOnly    = errors.New("api tokens can't be used here, sign in")
 )
 
 const (
 	userIDCtx            = "userID"
 	userPublicAPIKeyCtx  = "publicAPIKey"
 	userPrivateAPIKeyCtx = "privateAPIKey"
+	// apiTokenScopesCtx is set when request is authenticated by api token rather than jwt
+	apiTokenScopesCtx = "apiTokenScopes"
 )
 
+const totpCodeHeader = "X-TOTP-Code"
+
+const (
+	correlationIDHeader    = "X-Correlation-ID"
+	maxCorrelationIDLength = 64
+)
+
+// correlationID puts correlation id of request into request context and response header,
+// id is taken from X-Correlation-ID header or generated if header is missing or too long
+func (h *Handler) correlationID(c *gin.Context) {
+	id := c.GetHeader(correlationIDHeader)
+	if id == "" || len(id) > maxCorrelationIDLength {
+		id = utils.NewCorrelationID()
+	}
+
+	c.Request = c.Request.WithContext(utils.WithCorrelationID(c.Request.Context(), id))
+	c.Header(correlationIDHeader, id)
+}
+
+// userIdentity authenticates request by jwt of session or by api token, scopes of api token are
+// put into context for permission
 func (h *Handler) userIdentity(c *gin.Context) {
 	bearerToken, err := utils.GetBearerToken(c.Request)
 	if err != nil {
@@ -31,11 +60,23 @@ func (h *Handler) userIdentity(c *gin.Context) {
 		return
 	}
 
-	userID, err := h.services.Authorization.GetUserIDByJWT(bearerToken)
-	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized,
-			fmt.Sprintf("%s: %s", ErrUserIdentity.Error(), err.Error()))
-		return
+	var userID int
+	if utils.IsAPIToken(bearerToken) {
+		token, err := h.services.APITokens.AuthenticateAPIToken(bearerToken, c.ClientIP())
+		if err != nil {
+			newErrorResponse(c, http.StatusUnauthorized,
+				fmt.Sprintf("%s: %s", ErrUserIdentity.Error(), err.Error()))
+			return
+		}
+		userID = token.UserID
+		c.Set(apiTokenScopesCtx, []string(token.Scopes))
+	} else {
+		userID, err = h.services.Authorization.GetUserIDByJWT(bearerToken)
+		if err != nil {
+			newErrorResponse(c, http.StatusUnauthorized,
+				fmt.Sprintf("%s: %s", ErrUserIdentity.Error(), err.Error()))
+			return
+		}
 	}
 
 	publicKey, privateKey, err := h.services.Authorization.GetUserAPIKeys(userID)
@@ -50,6 +91,92 @@ func (h *Handler) userIdentity(c *gin.Context) {
 	c.Set(userPrivateAPIKeyCtx, privateKey)
 }
 
+// sessionOnly rejects requests authenticated by api token, account and session management
+// need signed in user. It goes after userIdentity
+func (h *Handler) sessionOnly(c *gin.Context) {
+	if _, ok := c.Get(apiTokenScopesCtx); ok {
+		newErrorResponse(c, http.StatusForbidden, ErrSessionOnly.Error())
+		return
+	}
+}
+
+// permission allows request only if role of identified user grants p and, for api tokens,
+// one of token scopes grants p too. It goes after userIdentity
+func (h *Handler) permission(p models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getUserID(c)
+		if err != nil {
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		role, err := h.services.Authorization.GetUserRole(userID)
+		if errors.Is(err, service.ErrUserDisabled) {
+			newErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if err != nil {
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if !models.HasPermission(role, p) {
+			newErrorResponse(c, http.StatusForbidden, fmt.Sprintf("%s: %s", ErrForbidden, p))
+			return
+		}
+
+		if scopes, ok := c.Get(apiTokenScopesCtx); ok {
+			if scopes, _ := scopes.([]string); !models.ScopesGrant(scopes, p) {
+				newErrorResponse(c, http.StatusForbidden, fmt.Sprintf("%s: %s: not in token scopes", ErrForbidden, p))
+				return
+			}
+		}
+	}
+}
+
+// secondFactor requires fresh TOTP or recovery code in X-TOTP-Code header before sensitive actions
+// of users with two-factor authentication, it goes after userIdentity. Invalid codes delay and then
+// lock out next checks and sign ins like wrong passwords do
+func (h *Handler) secondFactor(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.services.Authorization.VerifySecondFactor(c.Request.Context(), userID, c.ClientIP(),
+		c.GetHeader(totpCodeHeader))
+	if errors.Is(err, service.ErrTooManyLoginAttempts) {
+		newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrTOTPRequired) || errors.Is(err, service.ErrInvalidTOTPCode) {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+// rateLimit allows configured number of requests of client ip per window in scope,
+// over the limit 429 is returned with Retry-After header
+func (h *Handler) rateLimit(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		retryAfter, err := h.services.RateLimiter.Allow(scope, c.ClientIP())
+		if errors.Is(err, service.ErrRateLimitExceeded) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		if err != nil {
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+}
+
 func getUserID(c *gin.Context) (int, error) {
 	id, ok := c.Get(userIDCtx)
 	if !ok {
@@ -1,15 +1,21 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository/memoryRepo"
 	"trade-bot/internal/pkg/service"
 	mockService "trade-bot/internal/pkg/service/mocks"
 )
@@ -128,6 +134,414 @@ func TestHandler_userIdentity(t *testing.T) {
 	}
 }
 
+func TestHandler_userIdentityAPIToken(t *testing.T) {
+	type mockBehaviour func(a *mockService.MockAPITokens, s *mockService.MockAuthorization)
+
+	tests := []struct {
+		name                string
+		token               string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:  "OK",
+			token: "tbt_token",
+			mockBehaviour: func(a *mockService.MockAPITokens, s *mockService.MockAuthorization) {
+				a.EXPECT().AuthenticateAPIToken("tbt_token", "192.0.2.1").
+					Return(models.APIToken{UserID: 1, Scopes: models.StringList{models.ScopeTrade}}, nil)
+				s.EXPECT().GetUserAPIKeys(1).Return("public", "private", nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{1, [trade]}`,
+		},
+		{
+			name:  "Not allowed ip",
+			token: "tbt_token",
+			mockBehaviour: func(a *mockService.MockAPITokens, s *mockService.MockAuthorization) {
+				a.EXPECT().AuthenticateAPIToken("tbt_token", "192.0.2.1").
+					Return(models.APIToken{}, service.ErrAPITokenIPNotAllowed)
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"message":"user identity: ip is not allowed for api token"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tokens := mockService.NewMockAPITokens(c)
+			auth := mockService.NewMockAuthorization(c)
+			test.mockBehaviour(tokens, auth)
+
+			services := &service.Service{Authorization: auth, APITokens: tokens}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.GET("/identity", handler.userIdentity, func(c *gin.Context) {
+				id, _ := c.Get(userIDCtx)
+				scopes, _ := c.Get(apiTokenScopesCtx)
+				c.String(http.StatusOK, "{%d, %v}", id, scopes)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/identity", nil)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_userIdentityForwardedFor(t *testing.T) {
+	tests := []struct {
+		name                string
+		trustedProxies      []string
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:                "Spoofed header",
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"message":"user identity: ip is not allowed for api token"}`,
+		},
+		{
+			name:                "Trusted proxy",
+			trustedProxies:      []string{"192.0.2.1"},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: "1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			// token is allowed for 203.0.113.7 only
+			tokens := mockService.NewMockAPITokens(c)
+			tokens.EXPECT().AuthenticateAPIToken("tbt_token", gomock.Any()).
+				DoAndReturn(func(token, ip string) (models.APIToken, error) {
+					if ip != "203.0.113.7" {
+						return models.APIToken{}, service.ErrAPITokenIPNotAllowed
+					}
+					return models.APIToken{UserID: 1, Scopes: models.StringList{models.ScopeTrade}}, nil
+				})
+			auth := mockService.NewMockAuthorization(c)
+			auth.EXPECT().GetUserAPIKeys(1).Return("public", "private", nil).AnyTimes()
+
+			services := &service.Service{Authorization: auth, APITokens: tokens}
+			handler := Handler{services, nil, nil}
+
+			r, err := newRouter(test.trustedProxies)
+			require.NoError(t, err)
+			r.GET("/identity", handler.userIdentity, func(c *gin.Context) {
+				id, _ := c.Get(userIDCtx)
+				c.String(http.StatusOK, "%d", id)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/identity", nil)
+			req.Header.Set("Authorization", "Bearer tbt_token")
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_permission(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockAuthorization)
+
+	tests := []struct {
+		name                string
+		permission          models.Permission
+		scopes              []string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:       "OK",
+			permission: models.PermissionTrade,
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().GetUserRole(1).Return(models.RoleTrader, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: "ok",
+		},
+		{
+			name:       "Viewer can't trade",
+			permission: models.PermissionTrade,
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().GetUserRole(1).Return(models.RoleViewer, nil)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"message":"permission denied: orders:trade"}`,
+		},
+		{
+			name:       "Trader isn't admin",
+			permission: models.PermissionAdmin,
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().GetUserRole(1).Return(models.RoleTrader, nil)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"message":"permission denied: admin"}`,
+		},
+		{
+			name:       "Token scopes",
+			permission: models.PermissionTrade,
+			scopes:     []string{models.ScopeTrade},
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().GetUserRole(1).Return(models.RoleTrader, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: "ok",
+		},
+		{
+			name:       "Not in token scopes",
+			permission: models.PermissionTrade,
+			scopes:     []string{models.ScopeReadOrders},
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().GetUserRole(1).Return(models.RoleTrader, nil)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"message":"permission denied: orders:trade: not in token scopes"}`,
+		},
+		{
+			name:       "Disabled user",
+			permission: models.PermissionReadOrders,
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().GetUserRole(1).Return("", service.ErrUserDisabled)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"message":"user is disabled"}`,
+		},
+		{
+			name:       "Service error",
+			permission: models.PermissionReadOrders,
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().GetUserRole(1).Return("", errors.New("something went wrong"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"something went wrong"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mockService.NewMockAuthorization(c)
+			test.mockBehaviour(repo)
+
+			services := &service.Service{Authorization: repo}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.GET("/protected", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+				if test.scopes != nil {
+					c.Set(apiTokenScopesCtx, test.scopes)
+				}
+			}, handler.permission(test.permission), func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_secondFactor(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockAuthorization)
+
+	tests := []struct {
+		name                string
+		code                string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			code: "123456",
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifySecondFactor(gomock.Any(), 1, "192.0.2.1", "123456").Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: "ok",
+		},
+		{
+			name: "Invalid code",
+			code: "000000",
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifySecondFactor(gomock.Any(), 1, "192.0.2.1", "000000").
+					Return(fmt.Errorf("%s: %w", service.ErrVerifySecondFactor, service.ErrInvalidTOTPCode))
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"message":"verify second factor: invalid totp code"}`,
+		},
+		{
+			name: "Too many invalid codes",
+			code: "000000",
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifySecondFactor(gomock.Any(), 1, "192.0.2.1", "000000").
+					Return(fmt.Errorf("%s: %w", service.ErrVerifySecondFactor,
+						fmt.Errorf("%w: retry in 15m0s", service.ErrTooManyLoginAttempts)))
+			},
+			expectedStatusCode:  http.StatusTooManyRequests,
+			expectedRequestBody: `{"message":"verify second factor: too many login attempts: retry in 15m0s"}`,
+		},
+		{
+			name: "Service error",
+			mockBehaviour: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifySecondFactor(gomock.Any(), 1, "192.0.2.1", "").
+					Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"something went wrong"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mockService.NewMockAuthorization(c)
+			test.mockBehaviour(auth)
+
+			services := &service.Service{Authorization: auth}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.POST("/sensitive", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+			}, handler.secondFactor, func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/sensitive", nil)
+			if test.code != "" {
+				req.Header.Set(totpCodeHeader, test.code)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_rateLimit(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockRateLimiter)
+
+	tests := []struct {
+		name                string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRetryAfter  string
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehaviour: func(s *mockService.MockRateLimiter) {
+				s.EXPECT().Allow(service.AuthRateLimitScope, "192.0.2.1").Return(time.Duration(0), nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: "ok",
+		},
+		{
+			name: "Limit exceeded",
+			mockBehaviour: func(s *mockService.MockRateLimiter) {
+				s.EXPECT().Allow(service.AuthRateLimitScope, "192.0.2.1").
+					Return(1500*time.Millisecond, service.ErrRateLimitExceeded)
+			},
+			expectedStatusCode:  http.StatusTooManyRequests,
+			expectedRetryAfter:  "2",
+			expectedRequestBody: `{"message":"rate limit exceeded"}`,
+		},
+		{
+			name: "Service error",
+			mockBehaviour: func(s *mockService.MockRateLimiter) {
+				s.EXPECT().Allow(service.AuthRateLimitScope, "192.0.2.1").
+					Return(time.Duration(0), errors.New("something went wrong"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"something went wrong"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			limiter := mockService.NewMockRateLimiter(c)
+			test.mockBehaviour(limiter)
+
+			services := &service.Service{RateLimiter: limiter}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.GET("/limited", handler.rateLimit(service.AuthRateLimitScope), func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRetryAfter, w.Header().Get("Retry-After"))
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_rateLimitForwardedFor(t *testing.T) {
+	limiter := service.NewRateLimiterService(memoryRepo.NewLimitsMemory(memoryRepo.NewDB()),
+		map[string]configs.RateLimitConfiguration{service.AuthRateLimitScope: {Requests: 2, WindowInSeconds: 60}})
+	handler := Handler{&service.Service{RateLimiter: limiter}, nil, nil}
+
+	r, err := newRouter(nil)
+	require.NoError(t, err)
+	r.GET("/limited", handler.rateLimit(service.AuthRateLimitScope), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	// every request pretends to come from another client, all of them are counted for remote address
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+		req.Header.Set("X-Real-IP", fmt.Sprintf("198.51.100.%d", i+1))
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, expected, w.Code)
+	}
+}
+
 func TestHandler_getUserID(t *testing.T) {
 	setContext := func(value interface{}) *gin.Context {
 		ctx := &gin.Context{}
@@ -5,39 +5,63 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
 	"trade-bot/internal/pkg/tradeAlgorithm/types"
-	"trade-bot/pkg/krakenFuturesSDK"
+	"trade-bot/pkg/utils"
+)
+
+var (
+	ErrIdempotencyKeyTooLong = errors.New("idempotency key is too long")
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
 )
 
 // @Summary SendOrder
 // @Security ApiKeyAuth
 // @Tags orderManager
-// @Description sendOrder to kraken futures API
+// @Description sendOrder to exchange, kraken futures by default
 // @ID sendOrder
 // @Accept  json
 // @Produce  json
-// @Param input body krakenFuturesSDK.SendOrderArguments true "send order info"
-// @Success 200 {string} string "order_id"
-// @Failure 400,401,404 {object} errResponse
+// @Param Idempotency-Key header string false "retried requests with the same key return the original order"
+// @Param input body models.OrderRequest true "send order info"
+// @Success 200 {object} models.Order
+// @Failure 400,401,404,409 {object} errResponse
 // @Failure 500 {object} errResponse
 // @Failure default {object} errResponse
 // @Router /orderManager/send-order [post]
 func (h *Handler) sendOrder(c *gin.Context) {
-	var input krakenFuturesSDK.SendOrderArguments
+	var input models.OrderRequest
 
 	if err := c.BindJSON(&input); err != nil {
 		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		newErrorResponse(c, http.StatusBadRequest, ErrIdempotencyKeyTooLong.Error())
+		return
+	}
+
 	userID, err := getUserID(c)
 	if err != nil {
 		newErrorResponse(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
-	order, err := h.services.KrakenOrdersManager.SendOrder(userID, input)
+	order, err := h.services.KrakenOrdersManager.SendOrder(c.Request.Context(), userID, idempotencyKey, input)
+	if errors.Is(err, service.ErrOrderSubmissionInProgress) {
+		newErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -46,6 +70,51 @@ func (h *Handler) sendOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+type batchOrderInput struct {
+	Exchange string                    `json:"exchange"`
+	Orders   []models.BatchInstruction `json:"orders" binding:"required,min=1,dive"`
+}
+
+// @Summary BatchOrder
+// @Security ApiKeyAuth
+// @Tags orderManager
+// @Description send, edit and cancel many orders in one request to exchange, kraken futures by default
+// @ID batchOrder
+// @Accept  json
+// @Produce  json
+// @Param input body batchOrderInput true "batch instructions"
+// @Success 200 {object} models.BatchResult
+// @Failure 400,401,404,409 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /orderManager/batch [post]
+func (h *Handler) batchOrder(c *gin.Context) {
+	var input batchOrderInput
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	result, err := h.services.KrakenOrdersManager.BatchOrder(c.Request.Context(), userID, input.Exchange, input.Orders)
+	if errors.Is(err, service.ErrOrderSubmissionInProgress) {
+		newErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 type tradingDetails struct {
 	Event          string               `json:"event"`
 	TradingDetails types.TradingDetails `json:"trading_details,omitempty"`
@@ -77,12 +146,17 @@ func (h *Handler) startTrade(c *gin.Context) {
 		newWebsocketErrResponse(c, http.StatusBadRequest, conn, err.Error())
 		return
 	}
+	if err := input.TradingDetails.Validate(); err != nil {
+		newWebsocketErrResponse(c, http.StatusBadRequest, conn, err.Error())
+		return
+	}
 	if input.Event != startTrading {
 		newWebsocketErrResponse(c, http.StatusBadRequest, conn, err.Error())
 		return
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// trading outlives request context, only correlation id is kept
+	ctx, cancel := context.WithCancel(utils.WithCorrelationID(context.Background(), utils.CorrelationID(c.Request.Context())))
 	var isCancelled bool
 	defer cancel()
 
@@ -128,28 +202,99 @@ func (h *Handler) startTrade(c *gin.Context) {
 // @Summary MyOrders
 // @Security ApiKeyAuth
 // @Tags orderManager
-// @Description get all orders of user
+// @Description get page of orders of user sorted by time, next_cursor of response continues listing
 // @ID myOrders
 // @Produce  json
-// @Success 200 {object} []models.Order
-// @Failure 401,404 {object} errResponse
+// @Param symbol query string false "symbol"
+// @Param side query string false "buy or sell"
+// @Param type query string false "order type"
+// @Param from query string false "RFC3339 time, inclusive"
+// @Param to query string false "RFC3339 time, exclusive"
+// @Param sort query string false "asc by default or desc"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "max orders, 100 by default"
+// @Success 200 {object} models.OrderPage
+// @Failure 400,401,404 {object} errResponse
 // @Failure 500 {object} errResponse
 // @Failure default {object} errResponse
 // @Router /orderManager/my-orders [get]
 func (h *Handler) myOrders(c *gin.Context) {
-	userID, err := getUserID(c)
-	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	var filter models.OrderFilter
+	if !h.bindOrderFilter(c, &filter, &filter) {
 		return
 	}
 
-	orders, err := h.services.KrakenOrdersManager.GetUserOrders(userID)
+	page, err := h.services.KrakenOrdersManager.GetUserOrders(filter)
+	if errors.Is(err, models.ErrInvalidOrderCursor) {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
-		"orders": orders,
-	})
+	c.JSON(http.StatusOK, page)
+}
+
+type exportOrdersInput struct {
+	models.OrderFilter
+	Format string `form:"format" binding:"omitempty,oneof=csv json"`
+}
+
+// @Summary ExportMyOrders
+// @Security ApiKeyAuth
+// @Tags orderManager
+// @Description export all orders of user matching filters sorted by time as CSV or JSON
+// @ID exportMyOrders
+// @Produce  text/csv
+// @Produce  json
+// @Param format query string false "csv by default or json"
+// @Param symbol query string false "symbol"
+// @Param side query string false "buy or sell"
+// @Param type query string false "order type"
+// @Param from query string false "RFC3339 time, inclusive"
+// @Param to query string false "RFC3339 time, exclusive"
+// @Param sort query string false "asc by default or desc"
+// @Success 200 {string} string "orders"
+// @Failure 400,401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /orderManager/my-orders/export [get]
+func (h *Handler) exportMyOrders(c *gin.Context) {
+	var input exportOrdersInput
+	if !h.bindOrderFilter(c, &input, &input.OrderFilter) {
+		return
+	}
+
+	if input.Format == service.JSONExport {
+		c.Header("Content-Type", "application/json")
+		c.Header("Content-Disposition", `attachment; filename="orders.json"`)
+	} else {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", `attachment; filename="orders.csv"`)
+	}
+	c.Status(http.StatusOK)
+
+	if err := h.services.KrakenOrdersManager.ExportUserOrders(input.OrderFilter, input.Format, c.Writer); err != nil {
+		// part of export may be already written, so status can't be changed
+		log.Error(err)
+	}
+}
+
+// bindOrderFilter binds query to input and sets user of filter, filter is a part of input
+func (h *Handler) bindOrderFilter(c *gin.Context, input interface{}, filter *models.OrderFilter) bool {
+	if err := c.BindQuery(input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return false
+	}
+	filter.UserID = userID
+
+	return true
 }
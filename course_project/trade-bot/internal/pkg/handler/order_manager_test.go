@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
+	mockService "trade-bot/internal/pkg/service/mocks"
+)
+
+func TestHandler_myOrders(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockKrakenOrdersManager)
+
+	from := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name                string
+		query               string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:  "OK",
+			query: "?symbol=pi_xbtusd&side=buy&from=2022-01-02T03:04:05Z&sort=desc&cursor=next&limit=2",
+			mockBehaviour: func(s *mockService.MockKrakenOrdersManager) {
+				s.EXPECT().GetUserOrders(models.OrderFilter{UserID: 1, Symbol: "pi_xbtusd", Side: "buy", From: from,
+					Sort: models.SortDesc, Cursor: "next", Limit: 2}).
+					Return(models.OrderPage{Orders: []models.Order{{ID: "1", UserID: 1}}, NextCursor: "cursor"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"orders":[{"id":"1","user_id":1,"client_order_id":"","type":"","symbol":"",` +
+				`"quantity":"0","side":"","filled":"0","timestamp":"","last_update_timestamp":"","price":"0","exchange":""}],"next_cursor":"cursor"}`,
+		},
+		{
+			name:                "Wrong Input",
+			query:               "?side=short",
+			mockBehaviour:       func(s *mockService.MockKrakenOrdersManager) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"Key: 'OrderFilter.Side' Error:Field validation for 'Side' failed on the 'oneof' tag"}`,
+		},
+		{
+			name:  "Invalid cursor",
+			query: "?cursor=broken",
+			mockBehaviour: func(s *mockService.MockKrakenOrdersManager) {
+				s.EXPECT().GetUserOrders(models.OrderFilter{UserID: 1, Cursor: "broken"}).
+					Return(models.OrderPage{}, fmt.Errorf("%s: %w", service.ErrGetUserOrders, models.ErrInvalidOrderCursor))
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"get user orders: invalid order cursor"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			orders := mockService.NewMockKrakenOrdersManager(c)
+			test.mockBehaviour(orders)
+
+			services := &service.Service{KrakenOrdersManager: orders}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.GET("/orderManager/my-orders", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+			}, handler.myOrders)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/orderManager/my-orders"+test.query, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_exportMyOrders(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	orders := mockService.NewMockKrakenOrdersManager(c)
+	orders.EXPECT().ExportUserOrders(models.OrderFilter{UserID: 1, Symbol: "pi_xbtusd"}, service.JSONExport, gomock.Any()).
+		DoAndReturn(func(filter models.OrderFilter, format string, w io.Writer) error {
+			_, err := io.WriteString(w, "[]")
+			return err
+		})
+
+	services := &service.Service{KrakenOrdersManager: orders}
+	handler := Handler{services, nil, nil}
+
+	r := gin.New()
+	r.GET("/orderManager/my-orders/export", func(c *gin.Context) {
+		c.Set(userIDCtx, 1)
+	}, handler.exportMyOrders)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/orderManager/my-orders/export?format=json&symbol=pi_xbtusd", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "[]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/orderManager/my-orders/export?format=xml", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"trade-bot/internal/pkg/models"
+)
+
+// @Summary Report
+// @Security ApiKeyAuth
+// @Tags reports
+// @Description get performance of finished trading sessions of user in total, by strategy and symbol and by periods
+// @ID report
+// @Produce  json
+// @Param period query string false "day or month, periods are not summarized by default"
+// @Param from query string false "RFC3339 time, inclusive"
+// @Param to query string false "RFC3339 time, exclusive"
+// @Success 200 {object} models.Report
+// @Failure 400,401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /reports [get]
+func (h *Handler) report(c *gin.Context) {
+	report, ok := h.getReport(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary ReportHTML
+// @Security ApiKeyAuth
+// @Tags reports
+// @Description get performance report of user as printable HTML page
+// @ID reportHTML
+// @Produce  html
+// @Param period query string false "day or month, periods are not summarized by default"
+// @Param from query string false "RFC3339 time, inclusive"
+// @Param to query string false "RFC3339 time, exclusive"
+// @Success 200 {string} string "report"
+// @Failure 400,401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /reports/html [get]
+func (h *Handler) reportHTML(c *gin.Context) {
+	report, ok := h.getReport(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := reportTemplate.Execute(c.Writer, report); err != nil {
+		// part of page may be already written, so status can't be changed
+		log.Error(err)
+	}
+}
+
+func (h *Handler) getReport(c *gin.Context) (models.Report, bool) {
+	var filter models.ReportFilter
+
+	if err := c.BindQuery(&filter); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return models.Report{}, false
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return models.Report{}, false
+	}
+	filter.UserID = userID
+
+	report, err := h.services.Reports.GetReport(filter)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return models.Report{}, false
+	}
+
+	return report, true
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Trading report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child, th:nth-child(2), td:nth-child(2) { text-align: left; }
+@media print { h2 { page-break-before: auto; } table { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Trading report</h1>
+<p>
+{{if .From.IsZero}}Since the first trade{{else}}From {{.From.Format "2006-01-02 15:04 MST"}}{{end}},
+{{if .To.IsZero}}till now{{else}}to {{.To.Format "2006-01-02 15:04 MST"}}{{end}}.
+Profits are net of fees estimated at fee rate {{.FeeRate}}.
+</p>
+{{define "stats"}}
+<table>
+<tr><th>Strategy</th><th>Symbol</th><th>Trades</th><th>Win rate</th><th>Average win</th><th>Average loss</th>
+<th>Profit factor</th><th>Net profit</th><th>Max drawdown</th><th>Exposure, s</th><th>Fees</th></tr>
+{{range .Strategies}}
+<tr><td>{{.Strategy}}</td><td>{{.Symbol}}</td>{{template "row" .TradeStats}}</tr>
+{{end}}
+<tr><th>Total</th><th></th>{{template "row" .Total}}</tr>
+</table>
+{{end}}
+{{define "row"}}<td>{{.Trades}}</td><td>{{.WinRate}}</td><td>{{.AverageWin.StringFixed 2}}</td>
+<td>{{.AverageLoss.StringFixed 2}}</td><td>{{.ProfitFactor}}</td><td>{{.NetProfit.StringFixed 2}}</td>
+<td>{{.MaxDrawdown.StringFixed 2}}</td><td>{{.ExposureSeconds}}</td><td>{{.Fees.StringFixed 2}}</td>{{end}}
+<h2>Summary</h2>
+{{template "stats" .}}
+{{$period := .Period}}
+{{range .Periods}}
+<h2>{{if eq $period "month"}}{{.Start.Format "January 2006"}}{{else}}{{.Start.Format "2006-01-02"}}{{end}}</h2>
+{{template "stats" .}}
+{{end}}
+</body>
+</html>
+`))
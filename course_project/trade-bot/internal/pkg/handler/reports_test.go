@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
+	mockService "trade-bot/internal/pkg/service/mocks"
+)
+
+func TestHandler_report(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockReports)
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := models.Report{
+		UserID:  1,
+		From:    from,
+		Period:  models.MonthlyReport,
+		FeeRate: decimal.RequireFromString("0.0005"),
+		Total:   models.TradeStats{Trades: 1, Wins: 1, NetProfit: decimal.RequireFromString("9.9")},
+		Strategies: []models.StrategyStats{{Strategy: "stop_loss_take_profit", Symbol: "PI_XBTUSD",
+			TradeStats: models.TradeStats{Trades: 1, Wins: 1, NetProfit: decimal.RequireFromString("9.9")}}},
+	}
+
+	tests := []struct {
+		name               string
+		path               string
+		mockBehaviour      mockBehaviour
+		expectedStatusCode int
+		expectedBody       []string
+	}{
+		{
+			name: "JSON",
+			path: "/reports?from=2022-01-01T00:00:00Z&period=month",
+			mockBehaviour: func(s *mockService.MockReports) {
+				s.EXPECT().GetReport(models.ReportFilter{UserID: 1, From: from, Period: models.MonthlyReport}).
+					Return(report, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: []string{`"period":"month"`, `"fee_rate":"0.0005"`, `"net_profit":"9.9"`,
+				`"strategy":"stop_loss_take_profit","symbol":"PI_XBTUSD","trades":1`},
+		},
+		{
+			name: "HTML",
+			path: "/reports/html?from=2022-01-01T00:00:00Z&period=month",
+			mockBehaviour: func(s *mockService.MockReports) {
+				s.EXPECT().GetReport(models.ReportFilter{UserID: 1, From: from, Period: models.MonthlyReport}).
+					Return(report, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       []string{"<title>Trading report</title>", "From 2022-01-01 00:00 UTC", "<td>9.90</td>"},
+		},
+		{
+			name:               "Wrong Input",
+			path:               "/reports?period=week",
+			mockBehaviour:      func(s *mockService.MockReports) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       []string{`'Period' failed on the 'oneof' tag`},
+		},
+		{
+			name: "Service Failure",
+			path: "/reports/html",
+			mockBehaviour: func(s *mockService.MockReports) {
+				s.EXPECT().GetReport(models.ReportFilter{UserID: 1}).
+					Return(models.Report{}, errors.New("get report: some error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       []string{`{"message":"get report: some error"}`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			reports := mockService.NewMockReports(c)
+			test.mockBehaviour(reports)
+
+			services := &service.Service{Reports: reports}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			group := r.Group("/reports", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+			})
+			group.GET("", handler.report)
+			group.GET("html", handler.reportHTML)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			for _, part := range test.expectedBody {
+				assert.Contains(t, w.Body.String(), part)
+			}
+		})
+	}
+}
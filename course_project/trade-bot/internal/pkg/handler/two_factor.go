@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/service"
+)
+
+type confirmTOTPInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// @Summary EnrollTOTP
+// @Security ApiKeyAuth
+// @Tags 2fa
+// @Description generate secret of authenticator app, two-factor authentication is enabled after confirmation
+// @ID enrollTOTP
+// @Produce  json
+// @Success 200 {object} models.TOTPEnrollment
+// @Failure 401,404,409 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /auth/2fa/enroll [post]
+func (h *Handler) enrollTOTP(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	enrollment, err := h.services.Authorization.EnrollTOTP(userID)
+	if errors.Is(err, service.ErrTOTPAlreadyEnabled) {
+		newErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary ConfirmTOTP
+// @Security ApiKeyAuth
+// @Tags 2fa
+// @Description enable two-factor authentication by code of authenticator app, recovery codes are returned once
+// @ID confirmTOTP
+// @Accept  json
+// @Produce  json
+// @Param input body confirmTOTPInput true "code of authenticator app"
+// @Success 200 {object} []string
+// @Failure 400,401,404,409 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /auth/2fa/confirm [post]
+func (h *Handler) confirmTOTP(c *gin.Context) {
+	var input confirmTOTPInput
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, ErrInvalidInputBody)
+		return
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	codes, err := h.services.Authorization.ConfirmTOTP(c.Request.Context(), userID, input.Code)
+	switch {
+	case errors.Is(err, service.ErrInvalidTOTPCode):
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled), errors.Is(err, service.ErrTOTPNotEnrolled):
+		newErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+// @Summary RegenerateRecoveryCodes
+// @Security ApiKeyAuth
+// @Tags 2fa
+// @Description replace recovery codes, requires fresh code in X-TOTP-Code header
+// @ID regenerateRecoveryCodes
+// @Produce  json
+// @Param X-TOTP-Code header string true "totp or recovery code"
+// @Success 200 {object} []string
+// @Failure 401,404,409 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /auth/2fa/recovery-codes [post]
+func (h *Handler) regenerateRecoveryCodes(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	codes, err := h.services.Authorization.RegenerateRecoveryCodes(c.Request.Context(), userID)
+	if errors.Is(err, service.ErrTOTPNotEnabled) {
+		newErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+// @Summary DisableTOTP
+// @Security ApiKeyAuth
+// @Tags 2fa
+// @Description disable two-factor authentication, requires fresh code in X-TOTP-Code header
+// @ID disableTOTP
+// @Produce  json
+// @Param X-TOTP-Code header string true "totp or recovery code"
+// @Success 200 {string} string "message"
+// @Failure 401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /auth/2fa [delete]
+func (h *Handler) disableTOTP(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.services.Authorization.DisableTOTP(c.Request.Context(), userID); err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "two-factor authentication disabled",
+	})
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
+)
+
+type changePasswordInput struct {
+	Password    string `json:"password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type deleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// @Summary Profile
+// @Security ApiKeyAuth
+// @Tags users
+// @Description get account of user, private api key is masked
+// @ID profile
+// @Produce  json
+// @Success 200 {object} models.Profile
+// @Failure 401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /users/me [get]
+func (h *Handler) profile(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	profile, err := h.services.Account.GetProfile(userID)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// @Summary UpdateProfile
+// @Security ApiKeyAuth
+// @Tags users
+// @Description change name and api keys of user, omitted fields are kept
+// @ID updateProfile
+// @Accept  json
+// @Produce  json
+// @Param X-TOTP-Code header string false "TOTP or recovery code, required with two-factor authentication"
+// @Param input body models.ProfileUpdate true "fields to change"
+// @Success 200 {object} models.Profile
+// @Failure 400,401,404 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /users/me [patch]
+func (h *Handler) updateProfile(c *gin.Context) {
+	var input models.ProfileUpdate
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, ErrInvalidInputBody)
+		return
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	profile, err := h.services.Account.UpdateProfile(c.Request.Context(), userID, input)
+	switch {
+	case errors.Is(err, service.ErrEmptyProfileUpdate):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// @Summary ChangePassword
+// @Security ApiKeyAuth
+// @Tags users
+// @Description change password of user, every session including the current one is revoked
+// @ID changePassword
+// @Accept  json
+// @Produce  json
+// @Param X-TOTP-Code header string false "TOTP or recovery code, required with two-factor authentication"
+// @Param input body changePasswordInput true "current and new password"
+// @Success 200 {string} string "message"
+// @Failure 400,401,404,429 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /users/me/password [post]
+func (h *Handler) changePassword(c *gin.Context) {
+	var input changePasswordInput
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, ErrInvalidInputBody)
+		return
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.services.Account.ChangePassword(c.Request.Context(), userID, input.Password, input.NewPassword)
+	switch {
+	case errors.Is(err, service.ErrMismatchedPassword):
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, service.ErrSamePassword):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "password changed, sign in again",
+	})
+}
+
+// @Summary DeleteAccount
+// @Security ApiKeyAuth
+// @Tags users
+// @Description delete account of user with its orders and revoke its sessions
+// @ID deleteAccount
+// @Accept  json
+// @Produce  json
+// @Param X-TOTP-Code header string false "TOTP or recovery code, required with two-factor authentication"
+// @Param input body deleteAccountInput true "current password"
+// @Success 200 {string} string "message"
+// @Failure 400,401,404,429 {object} errResponse
+// @Failure 500 {object} errResponse
+// @Failure default {object} errResponse
+// @Router /users/me [delete]
+func (h *Handler) deleteAccount(c *gin.Context) {
+	var input deleteAccountInput
+
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, ErrInvalidInputBody)
+		return
+	}
+
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	err = h.services.Account.DeleteAccount(c.Request.Context(), userID, input.Password)
+	switch {
+	case errors.Is(err, service.ErrMismatchedPassword):
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "account deleted",
+	})
+}
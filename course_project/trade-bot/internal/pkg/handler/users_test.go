@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/service"
+	mockService "trade-bot/internal/pkg/service/mocks"
+)
+
+func TestHandler_updateProfile(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockAccount)
+
+	name := "new name"
+	tests := []struct {
+		name                string
+		inputBody           string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"name":"new name"}`,
+			mockBehaviour: func(s *mockService.MockAccount) {
+				s.EXPECT().UpdateProfile(gomock.Any(), 1, models.ProfileUpdate{Name: &name}).
+					Return(models.Profile{ID: 1, Name: name, Username: "username", Role: models.RoleTrader}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"id":1,"name":"new name","username":"username","role":"trader",` +
+				`"public_api_key":"","private_api_key":"","totp_enabled":false}`,
+		},
+		{
+			name:                "Wrong Input",
+			inputBody:           `{"name":""}`,
+			mockBehaviour:       func(s *mockService.MockAccount) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: fmt.Sprintf(`{"message":"%s"}`, ErrInvalidInputBody),
+		},
+		{
+			name:      "Nothing to update",
+			inputBody: `{}`,
+			mockBehaviour: func(s *mockService.MockAccount) {
+				s.EXPECT().UpdateProfile(gomock.Any(), 1, models.ProfileUpdate{}).
+					Return(models.Profile{}, fmt.Errorf("%s: %w", service.ErrUpdateProfile, service.ErrEmptyProfileUpdate))
+			},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"update profile: nothing to update"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			test.mockBehaviour(account)
+
+			services := &service.Service{Account: account}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.PATCH("/users/me", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+			}, handler.updateProfile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/users/me", bytes.NewBufferString(test.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_changePassword(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockAccount)
+
+	tests := []struct {
+		name                string
+		inputBody           string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"password":"qwerty", "new_password":"new password"}`,
+			mockBehaviour: func(s *mockService.MockAccount) {
+				s.EXPECT().ChangePassword(gomock.Any(), 1, "qwerty", "new password").Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"password changed, sign in again"}`,
+		},
+		{
+			name:                "Wrong Input",
+			inputBody:           `{"password":"qwerty"}`,
+			mockBehaviour:       func(s *mockService.MockAccount) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: fmt.Sprintf(`{"message":"%s"}`, ErrInvalidInputBody),
+		},
+		{
+			name:      "Wrong password",
+			inputBody: `{"password":"wrong", "new_password":"new password"}`,
+			mockBehaviour: func(s *mockService.MockAccount) {
+				s.EXPECT().ChangePassword(gomock.Any(), 1, "wrong", "new password").
+					Return(fmt.Errorf("%s: %w", service.ErrChangePassword, service.ErrMismatchedPassword))
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"message":"change password: mismatched password"}`,
+		},
+		{
+			name:      "Service error",
+			inputBody: `{"password":"qwerty", "new_password":"new password"}`,
+			mockBehaviour: func(s *mockService.MockAccount) {
+				s.EXPECT().ChangePassword(gomock.Any(), 1, "qwerty", "new password").
+					Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"something went wrong"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			test.mockBehaviour(account)
+
+			services := &service.Service{Account: account}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.POST("/users/me/password", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+			}, handler.changePassword)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/me/password", bytes.NewBufferString(test.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_deleteAccount(t *testing.T) {
+	type mockBehaviour func(s *mockService.MockAccount)
+
+	tests := []struct {
+		name                string
+		inputBody           string
+		mockBehaviour       mockBehaviour
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"password":"qwerty"}`,
+			mockBehaviour: func(s *mockService.MockAccount) {
+				s.EXPECT().DeleteAccount(gomock.Any(), 1, "qwerty").Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"account deleted"}`,
+		},
+		{
+			name:      "Wrong password",
+			inputBody: `{"password":"wrong"}`,
+			mockBehaviour: func(s *mockService.MockAccount) {
+				s.EXPECT().DeleteAccount(gomock.Any(), 1, "wrong").
+					Return(fmt.Errorf("%s: %w", service.ErrDeleteAccount, service.ErrMismatchedPassword))
+			},
+			expectedStatusCode:  http.StatusUnauthorized,
+			expectedRequestBody: `{"message":"delete account: mismatched password"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			test.mockBehaviour(account)
+
+			services := &service.Service{Account: account}
+			handler := Handler{services, nil, nil}
+
+			r := gin.New()
+			r.DELETE("/users/me", func(c *gin.Context) {
+				c.Set(userIDCtx, 1)
+			}, handler.deleteAccount)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/users/me", bytes.NewBufferString(test.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// ScopeReadOrders lets token read orders of user
+	ScopeReadOrders = "read-orders"
+	// ScopeTrade lets token send orders and start trading
+	ScopeTrade = "trade"
+	// ScopeAdmin lets token use admin API, user has to be admin
+	ScopeAdmin = "admin"
+)
+
+var scopePermissions = map[string]Permission{
+	ScopeReadOrders: PermissionReadOrders,
+	ScopeTrade:      PermissionTrade,
+	ScopeAdmin:      PermissionAdmin,
+}
+
+// IsScope reports whether scope is one of known scopes of api tokens
+func IsScope(scope string) bool {
+	_, ok := scopePermissions[scope]
+	return ok
+}
+
+// ScopePermission returns permission granted by scope
+func ScopePermission(scope string) Permission {
+	return scopePermissions[scope]
+}
+
+// ScopesGrant reports whether one of scopes grants permission, token is still limited by role of its user
+func ScopesGrant(scopes []string, permission Permission) bool {
+	for _, scope := range scopes {
+		if scopePermissions[scope] == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken is long-lived token of user for scripts, only its hash is stored and Prefix tells tokens apart.
+// Empty AllowedIPs allow any ip
+type APIToken struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"-" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Scopes     StringList `json:"scopes" db:"scopes"`
+	AllowedIPs StringList `json:"allowed_ips" db:"allowed_ips"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+// APITokenInput describes token to create, ip allowlist holds ips and CIDR ranges
+type APITokenInput struct {
+	Name          string   `json:"name" binding:"required,max=255"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	AllowedIPs    []string `json:"allowed_ips"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1"`
+}
+
+// NewAPIToken is returned once on creation, Token can't be shown again
+type NewAPIToken struct {
+	Token string `json:"token"`
+	APIToken
+}
+
+// StringList is stored as comma separated string
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+func (l *StringList) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case nil:
+	default:
+		return fmt.Errorf("unable to scan %T into string list", src)
+	}
+
+	*l = StringList{}
+	if s != "" {
+		*l = strings.Split(s, ",")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+const (
+	// AuditOrderCorrected is written when stored order is updated to the exchange state
+	AuditOrderCorrected = "order_corrected"
+	// AuditOrderRestored is written when exchange order lost before it was stored is found by client order id
+	AuditOrderRestored = "order_restored"
+	// AuditExchangeOnlyOrder flags exchange order which is not stored
+	AuditExchangeOnlyOrder = "exchange_only_order"
+	// AuditDatabaseOnlyOrder flags stored open order which exchange does not know
+	AuditDatabaseOnlyOrder = "database_only_order"
+
+	AuditOrderRequest   = "order_request"
+	AuditOrderResponse  = "order_response"
+	AuditBatchRequest   = "batch_request"
+	AuditBatchResponse  = "batch_response"
+	AuditTradingStarted = "trading_started"
+	// AuditStrategyDecision records indicator values strategy closed position on
+	AuditStrategyDecision = "strategy_decision"
+	AuditTradingStopped   = "trading_stopped"
+	AuditLogin            = "login"
+	AuditLoginFailed      = "login_failed"
+	AuditLogout           = "logout"
+	// AuditLoginLocked is written when username or ip is locked out after too many failed sign ins
+	AuditLoginLocked    = "login_locked"
+	AuditSessionRevoked = "session_revoked"
+	// AuditRefreshTokenReused is written when already rotated refresh token is presented and its session is revoked
+	AuditRefreshTokenReused = "refresh_token_reused"
+	AuditRoleChanged        = "role_changed"
+	AuditUserDisabled       = "user_disabled"
+	AuditUserEnabled        = "user_enabled"
+	AuditForcedLogout       = "forced_logout"
+	AuditTOTPEnabled        = "totp_enabled"
+	AuditTOTPDisabled       = "totp_disabled"
+	AuditRecoveryCodeUsed   = "recovery_code_used"
+	AuditProfileUpdated     = "profile_updated"
+	// AuditPasswordChanged is written when user changes password and all sessions of user are revoked
+	AuditPasswordChanged = "password_changed"
+	AuditAccountDeleted  = "account_deleted"
+	AuditAPITokenCreated = "api_token_created"
+	AuditAPITokenRevoked = "api_token_revoked"
+
+	AuditRecoveryCodesRegenerated = "recovery_codes_regenerated"
+)
+
+// AuditEntry records a change made to user data or an event of user session,
+// UserID is 0 when the entry is not attributed to any user
+type AuditEntry struct {
+	ID            int       `json:"id" db:"id"`
+	UserID        int       `json:"user_id" db:"user_id"`
+	Action        string    `json:"action" db:"action"`
+	EntityID      string    `json:"entity_id" db:"entity_id"`
+	Details       string    `json:"details" db:"details"`
+	CorrelationID string    `json:"correlation_id" db:"correlation_id"`
+	Data          AuditData `json:"data" db:"data"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// AuditData is JSON document stored with audit entry as is
+type AuditData []byte
+
+func (d AuditData) MarshalJSON() ([]byte, error) {
+	if len(d) == 0 {
+		return []byte("{}"), nil
+	}
+	return d, nil
+}
+
+func (d *AuditData) UnmarshalJSON(data []byte) error {
+	*d = append((*d)[:0], data...)
+	return nil
+}
+
+func (d AuditData) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return "{}", nil
+	}
+	return string(d), nil
+}
+
+func (d *AuditData) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		*d = append((*d)[:0], v...)
+	case string:
+		*d = AuditData(v)
+	case nil:
+		*d = nil
+	default:
+		return fmt.Errorf("unable to scan %T into audit data", src)
+	}
+	return nil
+}
+
+// AuditFilter selects audit entries of user, zero fields are not used for filtering
+type AuditFilter struct {
+	UserID        int       `form:"-"`
+	Action        string    `form:"action"`
+	EntityID      string    `form:"entity_id"`
+	CorrelationID string    `form:"correlation_id"`
+	From          time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To            time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit         int       `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset        int       `form:"offset" binding:"omitempty,min=0"`
+}
+
+// ReconcileReport counts what one reconciliation run found
+type ReconcileReport struct {
+	Corrected    int `json:"corrected"`
+	Restored     int `json:"restored"`
+	ExchangeOnly int `json:"exchange_only"`
+	DatabaseOnly int `json:"database_only"`
+}
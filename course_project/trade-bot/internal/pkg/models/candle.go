@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Candle struct {
+	Symbol   string          `json:"symbol" db:"symbol"`
+	Type     string          `json:"type" db:"candles_type"`
+	Interval string          `json:"interval" db:"timeframe"`
+	Time     time.Time       `json:"time" db:"time"`
+	Open     decimal.Decimal `json:"open" db:"open"`
+	High     decimal.Decimal `json:"high" db:"high"`
+	Low      decimal.Decimal `json:"low" db:"low"`
+	Close    decimal.Decimal `json:"close" db:"close"`
+	Volume   decimal.Decimal `json:"volume" db:"volume"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	BuySide  = "buy"
+	SellSide = "sell"
+)
+
+// OrderRequest describes order independently of exchange it is sent to,
+// empty Exchange means the default one
+type OrderRequest struct {
+	Exchange      string          `json:"exchange"`
+	OrderType     string          `json:"order_type" binding:"required"`
+	Symbol        string          `json:"symbol" binding:"required"`
+	Side          string          `json:"side" binding:"required,oneof=buy sell"`
+	Size          uint            `json:"size" binding:"required"`
+	LimitPrice    decimal.Decimal `json:"limit_price"`
+	StopPrice     decimal.Decimal `json:"stop_price"`
+	TriggerSignal string          `json:"trigger_signal"`
+	CliOrderID    string          `json:"cli_order_id"`
+	ReduceOnly    bool            `json:"reduce_only"`
+}
+
+// Opposite returns request closing position opened by r
+func (r OrderRequest) Opposite() OrderRequest {
+	if r.Side == BuySide {
+		r.Side = SellSide
+	} else {
+		r.Side = BuySide
+	}
+	return r
+}
+
+// Batch instructions
+const (
+	BatchSend   = "send"
+	BatchEdit   = "edit"
+	BatchCancel = "cancel"
+)
+
+// BatchInstruction sends, edits or cancels one order of batch
+type BatchInstruction struct {
+	Order         string          `json:"order" binding:"required,oneof=send edit cancel"`
+	OrderTag      string          `json:"order_tag"`
+	OrderID       string          `json:"order_id"`
+	OrderType     string          `json:"order_type"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Size          uint            `json:"size"`
+	LimitPrice    decimal.Decimal `json:"limit_price"`
+	StopPrice     decimal.Decimal `json:"stop_price"`
+	TriggerSignal string          `json:"trigger_signal"`
+	CliOrderID    string          `json:"cli_order_id"`
+	ReduceOnly    bool            `json:"reduce_only"`
+}
+
+type Instrument struct {
+	Symbol       string          `json:"symbol"`
+	Type         string          `json:"type"`
+	Tradeable    bool            `json:"tradeable"`
+	TickSize     decimal.Decimal `json:"tick_size"`
+	ContractSize decimal.Decimal `json:"contract_size"`
+}
+
+// Fill is one execution of exchange order
+type Fill struct {
+	ID            string          `json:"id"`
+	OrderID       string          `json:"order_id"`
+	ClientOrderID string          `json:"client_order_id"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Size          decimal.Decimal `json:"size"`
+	Price         decimal.Decimal `json:"price"`
+	Time          time.Time       `json:"time"`
+}
@@ -1,15 +1,144 @@
 package models
 
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidOrderCursor = errors.New("invalid order cursor")
+)
+
 type Order struct {
-	ID                  string  `json:"id" db:"order_id"`
-	UserID              int     `json:"user_id" db:"user_id"`
-	ClientOrderID       string  `json:"client_order_id" db:"cli_order_id"`
-	Type                string  `json:"type" db:"type"`
-	Symbol              string  `json:"symbol" db:"symbol"`
-	Quantity            float64 `json:"quantity" db:"quantity"`
-	Side                string  `json:"side" db:"side"`
-	Filled              float64 `json:"filled" db:"filled"`
-	Timestamp           string  `json:"timestamp" db:"timestamp"`
-	LastUpdateTimestamp string  `json:"last_update_timestamp" db:"last_update_timestamp"`
-	Price               float64 `json:"price" db:"price"`
+	ID                  string          `json:"id" db:"order_id"`
+	UserID              int             `json:"user_id" db:"user_id"`
+	ClientOrderID       string          `json:"client_order_id" db:"cli_order_id"`
+	Type                string          `json:"type" db:"type"`
+	Symbol              string          `json:"symbol" db:"symbol"`
+	Quantity            decimal.Decimal `json:"quantity" db:"quantity"`
+	Side                string          `json:"side" db:"side"`
+	Filled              decimal.Decimal `json:"filled" db:"filled"`
+	Timestamp           string          `json:"timestamp" db:"timestamp"`
+	LastUpdateTimestamp string          `json:"last_update_timestamp" db:"last_update_timestamp"`
+	Price               decimal.Decimal `json:"price" db:"price"`
+	Exchange            string          `json:"exchange" db:"exchange"`
+}
+
+// RejectedInstruction describes batch instruction which kraken did not execute
+type RejectedInstruction struct {
+	OrderTag string `json:"order_tag,omitempty"`
+	OrderID  string `json:"order_id,omitempty"`
+	Status   string `json:"status"`
+}
+
+type BatchResult struct {
+	Orders   []Order               `json:"orders"`
+	Rejected []RejectedInstruction `json:"rejected,omitempty"`
+}
+
+// Order types are named after the last event happened to order
+const (
+	ExecutionOrder = "EXECUTION"
+	PlaceOrder     = "PLACE"
+	EditOrder      = "EDIT"
+	CancelOrder    = "CANCEL"
+)
+
+// OrderSubmission is stored before order is sent to exchange, so the order can be found
+// by its client order id when response is lost and the same request is not executed twice
+type OrderSubmission struct {
+	ID             int       `json:"id" db:"id"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	IdempotencyKey string    `json:"idempotency_key" db:"idempotency_key"`
+	CliOrderID     string    `json:"cli_order_id" db:"cli_order_id"`
+	OrderID        string    `json:"order_id" db:"order_id"`
+	Failed         bool      `json:"failed" db:"failed"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// Sent reports whether exchange accepted the order and it is stored
+func (s OrderSubmission) Sent() bool {
+	return s.OrderID != ""
+}
+
+// Time returns when order was placed, orders without valid timestamp are placed at unix epoch,
+// so they go first in history
+func (o Order) Time() time.Time {
+	t, err := time.Parse(time.RFC3339, o.Timestamp)
+	if err != nil {
+		return time.Unix(0, 0).UTC()
+	}
+	return t.UTC()
+}
+
+// Sorting of order history by time of orders
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// OrderFilter selects orders of user, zero fields are not used for filtering.
+// Orders are sorted by time and then by id, listing continues after order After points at
+type OrderFilter struct {
+	UserID int       `form:"-"`
+	Symbol string    `form:"symbol"`
+	Side   string    `form:"side" binding:"omitempty,oneof=buy sell"`
+	Type   string    `form:"type"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Sort   string    `form:"sort" binding:"omitempty,oneof=asc desc"`
+	Cursor string    `form:"cursor"`
+	Limit  int       `form:"limit" binding:"omitempty,min=1,max=1000"`
+	// After is decoded Cursor
+	After *OrderCursor `form:"-"`
+}
+
+// Descending reports whether the newest orders go first
+func (f OrderFilter) Descending() bool {
+	return f.Sort == SortDesc
+}
+
+// OrderCursor is position of order in history
+type OrderCursor struct {
+	Time    time.Time
+	OrderID string
+}
+
+// NewOrderCursor returns cursor pointing at order
+func NewOrderCursor(order Order) OrderCursor {
+	return OrderCursor{Time: order.Time(), OrderID: order.ID}
+}
+
+// Encode returns opaque cursor which is passed between pages
+func (c OrderCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.Time.UnixMilli(), 10) + ":" + c.OrderID))
+}
+
+func DecodeOrderCursor(cursor string) (OrderCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return OrderCursor{}, ErrInvalidOrderCursor
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return OrderCursor{}, ErrInvalidOrderCursor
+	}
+	ms, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return OrderCursor{}, ErrInvalidOrderCursor
+	}
+
+	return OrderCursor{Time: time.UnixMilli(ms).UTC(), OrderID: parts[1]}, nil
+}
+
+// OrderPage is one page of order history, NextCursor is empty on the last page
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
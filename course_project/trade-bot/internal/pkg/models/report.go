@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TradingSessionStopped is reason of session which ended without closing position,
+// e.g. it was canceled or the exchange rejected the order
+const TradingSessionStopped = "stopped"
+
+// TradingSession records one run of strategy from the order opening position to the order closing it.
+// FinishedAt is nil while strategy is running
+type TradingSession struct {
+	ID            int        `json:"id" db:"id"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	Strategy      string     `json:"strategy" db:"strategy"`
+	Exchange      string     `json:"exchange" db:"exchange"`
+	Symbol        string     `json:"symbol" db:"symbol"`
+	Side          string     `json:"side" db:"side"`
+	StartOrderID  string     `json:"start_order_id" db:"start_order_id"`
+	FinishOrderID string     `json:"finish_order_id" db:"finish_order_id"`
+	Reason        string     `json:"reason" db:"reason"`
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// Trade is finished trading session with prices and size of its orders
+type Trade struct {
+	TradingSession
+	EntryPrice decimal.Decimal `db:"entry_price"`
+	ExitPrice  decimal.Decimal `db:"exit_price"`
+	// Quantity is ordered size of opening order, Filled is its executed part
+	Quantity decimal.Decimal `db:"quantity"`
+	Filled   decimal.Decimal `db:"filled"`
+}
+
+// Size returns size of position, ordered size is used when order has no fills recorded
+func (t Trade) Size() decimal.Decimal {
+	if t.Filled.IsZero() {
+		return t.Quantity
+	}
+	return t.Filled
+}
+
+// Profit returns gross profit of trade in quote currency, negative for losses
+func (t Trade) Profit() decimal.Decimal {
+	profit := t.ExitPrice.Sub(t.EntryPrice).Mul(t.Size())
+	if t.Side == "sell" {
+		return profit.Neg()
+	}
+	return profit
+}
+
+// Periods of report summaries
+const (
+	DailyReport   = "day"
+	MonthlyReport = "month"
+)
+
+// ReportFilter selects trades of user finished in [From, To), zero times are not used for filtering
+type ReportFilter struct {
+	UserID int       `form:"-"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Period string    `form:"period" binding:"omitempty,oneof=day month"`
+}
+
+// TradeStats summarizes trades, profits are net of fees. ProfitFactor is 0 when there are no losing trades
+type TradeStats struct {
+	Trades          int             `json:"trades"`
+	Wins            int             `json:"wins"`
+	Losses          int             `json:"losses"`
+	WinRate         decimal.Decimal `json:"win_rate"`
+	AverageWin      decimal.Decimal `json:"average_win"`
+	AverageLoss     decimal.Decimal `json:"average_loss"`
+	ProfitFactor    decimal.Decimal `json:"profit_factor"`
+	NetProfit       decimal.Decimal `json:"net_profit"`
+	MaxDrawdown     decimal.Decimal `json:"max_drawdown"`
+	ExposureSeconds int64           `json:"exposure_seconds"`
+	Fees            decimal.Decimal `json:"fees"`
+}
+
+// StrategyStats are stats of trades of one strategy on one symbol
+type StrategyStats struct {
+	Strategy string `json:"strategy"`
+	Symbol   string `json:"symbol"`
+	TradeStats
+}
+
+// PeriodReport summarizes trades finished in day or month started at Start
+type PeriodReport struct {
+	Start      time.Time       `json:"start"`
+	Total      TradeStats      `json:"total"`
+	Strategies []StrategyStats `json:"strategies"`
+}
+
+// Report summarizes trades of user in total, by strategy and symbol and by periods when period is set
+type Report struct {
+	UserID     int             `json:"user_id"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Period     string          `json:"period,omitempty"`
+	FeeRate    decimal.Decimal `json:"fee_rate"`
+	Total      TradeStats      `json:"total"`
+	Strategies []StrategyStats `json:"strategies"`
+	Periods    []PeriodReport  `json:"periods,omitempty"`
+}
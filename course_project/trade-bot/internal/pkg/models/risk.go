@@ -0,0 +1,14 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// RiskUsage sums up stored orders of user. Open orders are placed or edited orders not filled yet,
+// their notional is the unfilled quantity at order price. Traded notional is filled quantity at price of executions
+type RiskUsage struct {
+	UserID         int             `json:"user_id" db:"user_id"`
+	Username       string          `json:"username" db:"username"`
+	Orders         int             `json:"orders" db:"orders"`
+	OpenOrders     int             `json:"open_orders" db:"open_orders"`
+	OpenNotional   decimal.Decimal `json:"open_notional" db:"open_notional"`
+	TradedNotional decimal.Decimal `json:"traded_notional" db:"traded_notional"`
+}
@@ -0,0 +1,42 @@
+package models
+
+const (
+	// RoleTrader is given to every new user, traders manage their own orders
+	RoleTrader = "trader"
+	// RoleViewer can only read orders and audit log
+	RoleViewer = "viewer"
+	// RoleAdmin has every permission and administers other users
+	RoleAdmin = "admin"
+)
+
+// Permission is required by route, route is allowed when role of user has its permission
+type Permission string
+
+const (
+	PermissionReadOrders Permission = "orders:read"
+	PermissionTrade      Permission = "orders:trade"
+	PermissionReadAudit  Permission = "audit:read"
+	PermissionAdmin      Permission = "admin"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer: {PermissionReadOrders, PermissionReadAudit},
+	RoleTrader: {PermissionReadOrders, PermissionReadAudit, PermissionTrade},
+	RoleAdmin:  {PermissionReadOrders, PermissionReadAudit, PermissionTrade, PermissionAdmin},
+}
+
+// IsRole reports whether role is one of known roles
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants permission, unknown roles have no permissions
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Device describes client a session was opened from
+type Device struct {
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+}
+
+// Session is a logged-in device of user, it lives while its refresh token is rotated in time
+type Session struct {
+	ID     string `json:"id"`
+	UserID int    `json:"user_id"`
+	Device
+	CreatedAt   time.Time `json:"created_at"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
+// Tokens are issued on sign in and on every refresh, refresh token can be used once
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
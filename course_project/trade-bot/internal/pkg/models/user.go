@@ -9,10 +9,57 @@ type User struct {
 	Password      string `json:"password" binding:"required" db:"password_hash"`
 	PublicAPIKey  string `json:"public_api_key" binding:"required" db:"public_api_key"`
 	PrivateAPIKey string `json:"private_api_key" binding:"required" db:"private_api_key"`
+	Role          string `json:"-" db:"role"`
+	Disabled      bool   `json:"-" db:"disabled"`
+	TOTPSecret    string `json:"-" db:"totp_secret"`
+	TOTPEnabled   bool   `json:"-" db:"totp_enabled"`
+	// TOTPLastStep is the time step of the last accepted code, codes can't be used twice
+	TOTPLastStep int64 `json:"-" db:"totp_last_step"`
+}
+
+// Credentials are checked on sign in, TOTPCode is required when two-factor authentication is enabled
+// and may be a recovery code
+type Credentials struct {
+	Username string
+	Password string
+	TOTPCode string
+}
+
+// TOTPEnrollment is shown once to add account to authenticator app
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// UserSummary describes account to admin without password hash and api keys
+type UserSummary struct {
+	ID       int    `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Username string `json:"username" db:"username"`
+	Role     string `json:"role" db:"role"`
+	Disabled bool   `json:"disabled" db:"disabled"`
+}
+
+// Profile is account of user as user sees it, private api key is masked
+type Profile struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Username      string `json:"username"`
+	Role          string `json:"role"`
+	PublicAPIKey  string `json:"public_api_key"`
+	PrivateAPIKey string `json:"private_api_key"`
+	TOTPEnabled   bool   `json:"totp_enabled"`
+}
+
+// ProfileUpdate changes fields of account which are not nil
+type ProfileUpdate struct {
+	Name          *string `json:"name" binding:"omitempty,min=1,max=255"`
+	PublicAPIKey  *string `json:"public_api_key" binding:"omitempty,min=1,max=255"`
+	PrivateAPIKey *string `json:"private_api_key" binding:"omitempty,min=1,max=255"`
 }
 
 func (u *User) GeneratePasswordHash(password string) error {
-	byteHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	byteHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
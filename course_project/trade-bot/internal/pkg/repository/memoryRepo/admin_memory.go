@@ -0,0 +1,74 @@
+package memoryRepo
+
+import (
+	"trade-bot/internal/pkg/models"
+)
+
+type AdminMemory struct {
+	db *DB
+}
+
+func NewAdminMemory(db *DB) *AdminMemory {
+	return &AdminMemory{db: db}
+}
+
+func (a *AdminMemory) GetUsers() ([]models.UserSummary, error) {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	users := []models.UserSummary{}
+	for _, u := range a.db.users {
+		users = append(users, models.UserSummary{ID: u.ID, Name: u.Name, Username: u.Username, Role: u.Role,
+			Disabled: u.Disabled})
+	}
+	return users, nil
+}
+
+func (a *AdminMemory) update(userID int, f func(user *models.User)) bool {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	i := a.db.userIndex(userID)
+	if i < 0 {
+		return false
+	}
+	f(&a.db.users[i])
+	return true
+}
+
+// SetUserRole changes role of user, false is returned if there is no such user
+func (a *AdminMemory) SetUserRole(userID int, role string) (bool, error) {
+	return a.update(userID, func(user *models.User) { user.Role = role }), nil
+}
+
+// SetUserDisabled disables or enables account of user, false is returned if there is no such user
+func (a *AdminMemory) SetUserDisabled(userID int, disabled bool) (bool, error) {
+	return a.update(userID, func(user *models.User) { user.Disabled = disabled }), nil
+}
+
+func (a *AdminMemory) GetRiskUsage() ([]models.RiskUsage, error) {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	usage := []models.RiskUsage{}
+	for _, u := range a.db.users {
+		risk := models.RiskUsage{UserID: u.ID, Username: u.Username}
+		for _, o := range a.db.orders {
+			if o.UserID != u.ID {
+				continue
+			}
+			risk.Orders++
+
+			open := (o.Type == models.PlaceOrder || o.Type == models.EditOrder) && o.Filled.LessThan(o.Quantity)
+			if open {
+				risk.OpenOrders++
+				risk.OpenNotional = risk.OpenNotional.Add(o.Quantity.Sub(o.Filled).Mul(o.Price))
+			}
+			if o.Type == models.ExecutionOrder {
+				risk.TradedNotional = risk.TradedNotional.Add(o.Filled.Mul(o.Price))
+			}
+		}
+		usage = append(usage, risk)
+	}
+	return usage, nil
+}
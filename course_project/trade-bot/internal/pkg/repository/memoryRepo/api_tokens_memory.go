@@ -0,0 +1,81 @@
+package memoryRepo
+
+import (
+	"database/sql"
+
+	"trade-bot/internal/pkg/models"
+)
+
+type APITokensMemory struct {
+	db *DB
+}
+
+func NewAPITokensMemory(db *DB) *APITokensMemory {
+	return &APITokensMemory{db: db}
+}
+
+// CreateAPIToken stores token and returns it with id and creation time
+func (r *APITokensMemory) CreateAPIToken(token models.APIToken) (models.APIToken, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	token.ID = r.db.nextID()
+	token.CreatedAt = r.db.now().UTC()
+	token.LastUsedAt = nil
+	r.db.apiTokens = append(r.db.apiTokens, token)
+	return token, nil
+}
+
+func (r *APITokensMemory) GetAPITokens(userID int) ([]models.APIToken, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	tokens := []models.APIToken{}
+	for _, token := range r.db.apiTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// GetAPITokenByHash returns token by hash, sql.ErrNoRows is returned when there is no such token
+func (r *APITokensMemory) GetAPITokenByHash(tokenHash string) (models.APIToken, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, token := range r.db.apiTokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return models.APIToken{}, sql.ErrNoRows
+}
+
+// TouchAPIToken remembers when token was used the last time
+func (r *APITokensMemory) TouchAPIToken(id int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for i := range r.db.apiTokens {
+		if r.db.apiTokens[i].ID == id {
+			now := r.db.now().UTC()
+			r.db.apiTokens[i].LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+// DeleteAPIToken revokes token of user, false is returned if user has no such token
+func (r *APITokensMemory) DeleteAPIToken(userID, id int) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for i, token := range r.db.apiTokens {
+		if token.ID == id && token.UserID == userID {
+			r.db.apiTokens = append(r.db.apiTokens[:i], r.db.apiTokens[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
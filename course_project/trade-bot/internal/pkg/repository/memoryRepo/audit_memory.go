@@ -0,0 +1,60 @@
+package memoryRepo
+
+import (
+	"trade-bot/internal/pkg/models"
+)
+
+const defaultAuditEntriesLimit = 100
+
+// AuditMemory keeps append-only audit log, entries can't be changed or deleted
+type AuditMemory struct {
+	db *DB
+}
+
+func NewAuditMemory(db *DB) *AuditMemory {
+	return &AuditMemory{db: db}
+}
+
+func (a *AuditMemory) CreateAuditEntry(entry models.AuditEntry) error {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	entry.ID = a.db.nextID()
+	entry.Data = append(models.AuditData(nil), entry.Data...)
+	entry.CreatedAt = a.db.now().UTC()
+	a.db.auditEntries = append(a.db.auditEntries, entry)
+	return nil
+}
+
+// GetAuditEntries returns entries matching filter in order they were written
+func (a *AuditMemory) GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	a.db.mu.Lock()
+	defer a.db.mu.Unlock()
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditEntriesLimit
+	}
+
+	entries := []models.AuditEntry{}
+	skipped := 0
+	for _, entry := range a.db.auditEntries {
+		if len(entries) == limit {
+			break
+		}
+		if filter.UserID != 0 && entry.UserID != filter.UserID ||
+			filter.Action != "" && entry.Action != filter.Action ||
+			filter.EntityID != "" && entry.EntityID != filter.EntityID ||
+			filter.CorrelationID != "" && entry.CorrelationID != filter.CorrelationID ||
+			!filter.From.IsZero() && entry.CreatedAt.Before(filter.From) ||
+			!filter.To.IsZero() && !entry.CreatedAt.Before(filter.To) {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
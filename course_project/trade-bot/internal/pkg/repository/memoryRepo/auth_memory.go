@@ -0,0 +1,213 @@
+package memoryRepo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"trade-bot/internal/pkg/models"
+)
+
+type AuthMemory struct {
+	db *DB
+}
+
+func NewAuthMemory(db *DB) *AuthMemory {
+	return &AuthMemory{db: db}
+}
+
+func (r *AuthMemory) CreateUser(user models.User) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, u := range r.db.users {
+		if u.Username == user.Username {
+			return 0, fmt.Errorf("%s: %s", ErrUsernameTaken, user.Username)
+		}
+	}
+
+	user.ID = r.db.nextID()
+	user.Role = models.RoleTrader
+	user.Disabled, user.TOTPSecret, user.TOTPEnabled, user.TOTPLastStep = false, "", false, 0
+	r.db.users = append(r.db.users, user)
+	return user.ID, nil
+}
+
+// GetUser returns user by username, sql.ErrNoRows is returned when there is no such user
+func (r *AuthMemory) GetUser(username string) (models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, u := range r.db.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return models.User{}, sql.ErrNoRows
+}
+
+// GetUserByID returns user by id, sql.ErrNoRows is returned when there is no such user
+func (r *AuthMemory) GetUserByID(userID int) (models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	i := r.db.userIndex(userID)
+	if i < 0 {
+		return models.User{}, sql.ErrNoRows
+	}
+	return r.db.users[i], nil
+}
+
+func (r *AuthMemory) GetUserAPIKeys(userID int) (string, string, error) {
+	user, err := r.GetUserByID(userID)
+	return user.PublicAPIKey, user.PrivateAPIKey, err
+}
+
+// update calls f with user if it exists and reports whether it does
+func (r *AuthMemory) update(userID int, f func(user *models.User)) bool {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	i := r.db.userIndex(userID)
+	if i < 0 {
+		return false
+	}
+	f(&r.db.users[i])
+	return true
+}
+
+// SetTOTPSecret stores secret of enrolment which is not enabled until it is confirmed by code
+func (r *AuthMemory) SetTOTPSecret(userID int, secret string) error {
+	r.update(userID, func(user *models.User) {
+		user.TOTPSecret, user.TOTPEnabled, user.TOTPLastStep = secret, false, 0
+	})
+	return nil
+}
+
+// EnableTOTP turns two-factor authentication on and replaces recovery codes
+func (r *AuthMemory) EnableTOTP(userID int, recoveryCodeHashes []string) error {
+	r.update(userID, func(user *models.User) {
+		user.TOTPEnabled = true
+		r.db.recoveryCodes[userID] = newRecoveryCodes(recoveryCodeHashes)
+	})
+	return nil
+}
+
+// SetRecoveryCodes replaces unused and used recovery codes of user
+func (r *AuthMemory) SetRecoveryCodes(userID int, recoveryCodeHashes []string) error {
+	r.update(userID, func(user *models.User) {
+		r.db.recoveryCodes[userID] = newRecoveryCodes(recoveryCodeHashes)
+	})
+	return nil
+}
+
+func newRecoveryCodes(hashes []string) []recoveryCode {
+	codes := make([]recoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, recoveryCode{hash: hash})
+	}
+	return codes
+}
+
+func (r *AuthMemory) DisableTOTP(userID int) error {
+	r.update(userID, func(user *models.User) {
+		user.TOTPSecret, user.TOTPEnabled, user.TOTPLastStep = "", false, 0
+		delete(r.db.recoveryCodes, userID)
+	})
+	return nil
+}
+
+// UseTOTPStep remembers step of accepted code, false is returned if code of this or later step was already used
+func (r *AuthMemory) UseTOTPStep(userID int, step int64) (bool, error) {
+	used := false
+	r.update(userID, func(user *models.User) {
+		if user.TOTPLastStep < step {
+			user.TOTPLastStep, used = step, true
+		}
+	})
+	return used, nil
+}
+
+// UseRecoveryCode marks recovery code as used, false is returned if there is no such unused code
+func (r *AuthMemory) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	codes := r.db.recoveryCodes[userID]
+	for i := range codes {
+		if codes[i].hash == codeHash && !codes[i].used {
+			codes[i].used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UpdateUser changes fields of update which are not nil, false is returned if there is no such user
+func (r *AuthMemory) UpdateUser(userID int, update models.ProfileUpdate) (bool, error) {
+	return r.update(userID, func(user *models.User) {
+		if update.Name != nil {
+			user.Name = *update.Name
+		}
+		if update.PublicAPIKey != nil {
+			user.PublicAPIKey = *update.PublicAPIKey
+		}
+		if update.PrivateAPIKey != nil {
+			user.PrivateAPIKey = *update.PrivateAPIKey
+		}
+	}), nil
+}
+
+// SetPassword replaces password hash of user, false is returned if there is no such user
+func (r *AuthMemory) SetPassword(userID int, passwordHash string) (bool, error) {
+	return r.update(userID, func(user *models.User) { user.Password = passwordHash }), nil
+}
+
+// DeleteUser deletes user with its orders, order submissions, trading sessions, recovery codes and api tokens.
+// False is returned if there is no such user
+func (r *AuthMemory) DeleteUser(userID int) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	i := r.db.userIndex(userID)
+	if i < 0 {
+		return false, nil
+	}
+	r.db.users = append(r.db.users[:i], r.db.users[i+1:]...)
+	delete(r.db.recoveryCodes, userID)
+
+	orders := r.db.orders[:0]
+	for _, order := range r.db.orders {
+		if r.db.usersOrders[order.ID] == userID {
+			delete(r.db.usersOrders, order.ID)
+			continue
+		}
+		orders = append(orders, order)
+	}
+	r.db.orders = orders
+
+	submissions := r.db.submissions[:0]
+	for _, submission := range r.db.submissions {
+		if submission.UserID != userID {
+			submissions = append(submissions, submission)
+		}
+	}
+	r.db.submissions = submissions
+
+	sessions := r.db.tradingSessions[:0]
+	for _, session := range r.db.tradingSessions {
+		if session.UserID != userID {
+			sessions = append(sessions, session)
+		}
+	}
+	r.db.tradingSessions = sessions
+
+	tokens := r.db.apiTokens[:0]
+	for _, token := range r.db.apiTokens {
+		if token.UserID != userID {
+			tokens = append(tokens, token)
+		}
+	}
+	r.db.apiTokens = tokens
+
+	return true, nil
+}
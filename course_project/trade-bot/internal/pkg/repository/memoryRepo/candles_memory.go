@@ -0,0 +1,77 @@
+package memoryRepo
+
+import (
+	"sort"
+	"time"
+
+	"trade-bot/internal/pkg/models"
+)
+
+type CandlesMemory struct {
+	db *DB
+}
+
+func NewCandlesMemory(db *DB) *CandlesMemory {
+	return &CandlesMemory{db: db}
+}
+
+func sameCandle(a, b models.Candle) bool {
+	return a.Symbol == b.Symbol && a.Type == b.Type && a.Interval == b.Interval && a.Time.Equal(b.Time)
+}
+
+// SaveCandles inserts candles, already stored ones are updated. Candles are kept sorted by time
+func (r *CandlesMemory) SaveCandles(candles []models.Candle) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, c := range candles {
+		c.Time = c.Time.UTC()
+		i := sort.Search(len(r.db.candles), func(i int) bool { return !r.db.candles[i].Time.Before(c.Time) })
+
+		updated := false
+		for j := i; j < len(r.db.candles) && r.db.candles[j].Time.Equal(c.Time); j++ {
+			if sameCandle(r.db.candles[j], c) {
+				r.db.candles[j], updated = c, true
+				break
+			}
+		}
+		if !updated {
+			r.db.candles = append(r.db.candles, models.Candle{})
+			copy(r.db.candles[i+1:], r.db.candles[i:])
+			r.db.candles[i] = c
+		}
+	}
+	return nil
+}
+
+func (r *CandlesMemory) filter(symbol, candlesType, interval string, keep func(c models.Candle) bool) []models.Candle {
+	var candles []models.Candle
+	for _, c := range r.db.candles {
+		if c.Symbol == symbol && c.Type == candlesType && c.Interval == interval && keep(c) {
+			candles = append(candles, c)
+		}
+	}
+	return candles
+}
+
+// GetLastCandles returns n latest candles sorted by time
+func (r *CandlesMemory) GetLastCandles(symbol, candlesType, interval string, n int) ([]models.Candle, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	candles := r.filter(symbol, candlesType, interval, func(models.Candle) bool { return true })
+	if len(candles) > n {
+		candles = candles[len(candles)-n:]
+	}
+	return candles, nil
+}
+
+// GetCandles returns candles started in [from, to) sorted by time
+func (r *CandlesMemory) GetCandles(symbol, candlesType, interval string, from, to time.Time) ([]models.Candle, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.filter(symbol, candlesType, interval, func(c models.Candle) bool {
+		return !c.Time.Before(from) && c.Time.Before(to)
+	}), nil
+}
@@ -0,0 +1,142 @@
+package memoryRepo
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/pkg/utils"
+)
+
+// JWTMemory stores access tokens and sessions like redis does, expired ones are never returned
+type JWTMemory struct {
+	db *DB
+}
+
+func NewJWTMemory(db *DB) *JWTMemory {
+	return &JWTMemory{db: db}
+}
+
+// GetJWTUserID returns user of alive access token, sql.ErrNoRows is returned when token is unknown or expired
+func (r *JWTMemory) GetJWTUserID(ad utils.AccessDetails) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	token, ok := r.db.accessTokens[ad.AccessUUID]
+	if !ok || !token.expiresAt.After(r.db.now()) {
+		return 0, sql.ErrNoRows
+	}
+	return token.userID, nil
+}
+
+func (r *JWTMemory) DeleteJWT(ad utils.AccessDetails) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.accessTokens, ad.AccessUUID)
+	return nil
+}
+
+// CreateSession stores access token and session of td, session expires together with refresh token
+func (r *JWTMemory) CreateSession(s models.Session, td utils.TokenDetails) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := r.db.now().Truncate(time.Second).UTC()
+	r.db.accessTokens[td.AccessUUID] = accessToken{userID: s.UserID, expiresAt: time.Unix(td.AtExpires, 0)}
+
+	s.ID = td.SessionID
+	s.CreatedAt, s.RefreshedAt, s.ExpiresAt = now, now, time.Unix(td.RtExpires, 0).UTC()
+	s.Current = false
+	r.db.sessions[td.SessionID] = session{Session: s, accessUUID: td.AccessUUID, refreshUUID: td.RefreshUUID}
+	return nil
+}
+
+// aliveSession returns session which is not expired
+func (r *JWTMemory) aliveSession(sessionID string) (session, bool) {
+	s, ok := r.db.sessions[sessionID]
+	if !ok || !s.ExpiresAt.After(r.db.now()) {
+		return session{}, false
+	}
+	return s, true
+}
+
+// RotateSession replaces tokens of session by td if refresh token of rd is the latest one issued for it.
+// Both flags are false when there is no such session. Presenting older refresh token means it was stolen
+// or replayed, so the whole session is revoked and revoked flag is true
+func (r *JWTMemory) RotateSession(rd utils.RefreshDetails, td utils.TokenDetails) (rotated bool, revoked bool, err error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	s, ok := r.aliveSession(rd.SessionID)
+	if !ok || int64(s.UserID) != rd.UserID {
+		return false, false, nil
+	}
+
+	delete(r.db.accessTokens, s.accessUUID)
+	if s.refreshUUID != rd.RefreshUUID {
+		delete(r.db.sessions, rd.SessionID)
+		return false, true, nil
+	}
+
+	r.db.accessTokens[td.AccessUUID] = accessToken{userID: s.UserID, expiresAt: time.Unix(td.AtExpires, 0)}
+	s.RefreshedAt = r.db.now().Truncate(time.Second).UTC()
+	s.ExpiresAt = time.Unix(td.RtExpires, 0).UTC()
+	s.accessUUID, s.refreshUUID = td.AccessUUID, td.RefreshUUID
+	r.db.sessions[rd.SessionID] = s
+	return true, false, nil
+}
+
+// GetSessions returns alive sessions of user ordered by creation time
+func (r *JWTMemory) GetSessions(userID int) ([]models.Session, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	sessions := []models.Session{}
+	for id := range r.db.sessions {
+		if s, ok := r.aliveSession(id); ok && s.UserID == userID {
+			sessions = append(sessions, s.Session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].ID < sessions[j].ID
+		}
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// DeleteSession revokes session of user with its access token, false is returned if user has no such session
+func (r *JWTMemory) DeleteSession(userID int, sessionID string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	s, ok := r.aliveSession(sessionID)
+	if !ok || s.UserID != userID {
+		return false, nil
+	}
+	delete(r.db.accessTokens, s.accessUUID)
+	delete(r.db.sessions, sessionID)
+	return true, nil
+}
+
+// DeleteUserSessions revokes every session of user with their access tokens and returns number of revoked sessions
+func (r *JWTMemory) DeleteUserSessions(userID int) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	revoked := 0
+	for id, s := range r.db.sessions {
+		if s.UserID != userID {
+			continue
+		}
+		if _, ok := r.aliveSession(id); ok {
+			revoked++
+		}
+		delete(r.db.accessTokens, s.accessUUID)
+		delete(r.db.sessions, id)
+	}
+	return revoked, nil
+}
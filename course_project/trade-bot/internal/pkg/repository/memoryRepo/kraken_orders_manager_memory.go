@@ -0,0 +1,206 @@
+package memoryRepo
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrDuplicateCliOrderID = errors.New("client order id is already used")
+)
+
+type KrakenOrdersManagerMemory struct {
+	db *DB
+}
+
+func NewKrakenOrdersManagerMemory(db *DB) *KrakenOrdersManagerMemory {
+	return &KrakenOrdersManagerMemory{db: db}
+}
+
+func (k *KrakenOrdersManagerMemory) CreateOrder(userID int, order models.Order) error {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	if k.db.orderIndex(order.ID) >= 0 {
+		return fmt.Errorf("%s: %s", ErrOrderExists, order.ID)
+	}
+	k.db.orders = append(k.db.orders, order)
+	k.db.usersOrders[order.ID] = userID
+	return nil
+}
+
+// CreateOrders saves all orders, orders already stored are updated.
+// Submissions of orders are completed by their client order id
+func (k *KrakenOrdersManagerMemory) CreateOrders(userID int, orders []models.Order) error {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	for _, order := range orders {
+		if i := k.db.orderIndex(order.ID); i >= 0 {
+			stored := &k.db.orders[i]
+			stored.Type, stored.Quantity, stored.Filled = order.Type, order.Quantity, order.Filled
+			stored.LastUpdateTimestamp, stored.Price = order.LastUpdateTimestamp, order.Price
+		} else {
+			k.db.orders = append(k.db.orders, order)
+		}
+		if _, ok := k.db.usersOrders[order.ID]; !ok {
+			k.db.usersOrders[order.ID] = userID
+		}
+
+		if i := k.db.submissionIndex(order.ClientOrderID); order.ClientOrderID != "" && i >= 0 {
+			k.db.submissions[i].OrderID, k.db.submissions[i].Failed = order.ID, false
+		}
+	}
+	return nil
+}
+
+// GetOrder returns order by id, sql.ErrNoRows is returned when there is no such order
+func (k *KrakenOrdersManagerMemory) GetOrder(orderID string) (models.Order, error) {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	i := k.db.orderIndex(orderID)
+	if i < 0 {
+		return models.Order{}, sql.ErrNoRows
+	}
+	return k.db.orders[i], nil
+}
+
+// GetUserOrders returns orders of user in order they were stored
+func (k *KrakenOrdersManagerMemory) GetUserOrders(userID int) ([]models.Order, error) {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	var orders []models.Order
+	for _, order := range k.db.orders {
+		if order.UserID == userID {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
+const defaultOrdersLimit = 100
+
+// FindUserOrders returns orders matching filter sorted by time and id, starting after filter.After
+func (k *KrakenOrdersManagerMemory) FindUserOrders(filter models.OrderFilter) ([]models.Order, error) {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	// times are compared with precision of cursors
+	cursor := func(order models.Order) models.OrderCursor {
+		c := models.NewOrderCursor(order)
+		c.Time = c.Time.Truncate(time.Millisecond)
+		return c
+	}
+	less := func(a, b models.OrderCursor) bool {
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		return a.OrderID < b.OrderID
+	}
+	if filter.Descending() {
+		asc := less
+		less = func(a, b models.OrderCursor) bool { return asc(b, a) }
+	}
+
+	orders := []models.Order{}
+	for _, order := range k.db.orders {
+		t := cursor(order).Time
+		switch {
+		case order.UserID != filter.UserID,
+			filter.Symbol != "" && order.Symbol != filter.Symbol,
+			filter.Side != "" && order.Side != filter.Side,
+			filter.Type != "" && order.Type != filter.Type,
+			!filter.From.IsZero() && t.Before(filter.From),
+			!filter.To.IsZero() && !t.Before(filter.To),
+			filter.After != nil && !less(*filter.After, cursor(order)):
+			continue
+		}
+		orders = append(orders, order)
+	}
+	sort.Slice(orders, func(i, j int) bool { return less(cursor(orders[i]), cursor(orders[j])) })
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultOrdersLimit
+	}
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+// GetOrders returns orders of all users
+func (k *KrakenOrdersManagerMemory) GetOrders() ([]models.Order, error) {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	return append([]models.Order(nil), k.db.orders...), nil
+}
+
+// CreateOrderSubmission stores submission and returns true. When submission with the same
+// idempotency key is already stored it is returned instead with false
+func (k *KrakenOrdersManagerMemory) CreateOrderSubmission(submission models.OrderSubmission) (models.OrderSubmission, bool, error) {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	if submission.IdempotencyKey != "" {
+		for _, existing := range k.db.submissions {
+			if existing.UserID == submission.UserID && existing.IdempotencyKey == submission.IdempotencyKey {
+				return existing, false, nil
+			}
+		}
+	}
+	if k.db.submissionIndex(submission.CliOrderID) >= 0 {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %s", ErrDuplicateCliOrderID, submission.CliOrderID)
+	}
+
+	submission.ID = k.db.nextID()
+	submission.OrderID, submission.Failed = "", false
+	submission.CreatedAt = k.db.now().UTC()
+	k.db.submissions = append(k.db.submissions, submission)
+	return submission, true, nil
+}
+
+// RetryOrderSubmission marks failed submission as pending again,
+// false is returned when submission is not failed, e.g. it is being retried concurrently
+func (k *KrakenOrdersManagerMemory) RetryOrderSubmission(cliOrderID string) (bool, error) {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	i := k.db.submissionIndex(cliOrderID)
+	if i < 0 || !k.db.submissions[i].Failed {
+		return false, nil
+	}
+	k.db.submissions[i].Failed = false
+	return true, nil
+}
+
+func (k *KrakenOrdersManagerMemory) FailOrderSubmission(cliOrderID string) error {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	if i := k.db.submissionIndex(cliOrderID); i >= 0 && k.db.submissions[i].OrderID == "" {
+		k.db.submissions[i].Failed = true
+	}
+	return nil
+}
+
+// GetOrderSubmission returns submission by client order id, sql.ErrNoRows is returned when there is no such submission
+func (k *KrakenOrdersManagerMemory) GetOrderSubmission(cliOrderID string) (models.OrderSubmission, error) {
+	k.db.mu.Lock()
+	defer k.db.mu.Unlock()
+
+	i := k.db.submissionIndex(cliOrderID)
+	if i < 0 {
+		return models.OrderSubmission{}, sql.ErrNoRows
+	}
+	return k.db.submissions[i], nil
+}
@@ -0,0 +1,62 @@
+package memoryRepo
+
+import (
+	"time"
+)
+
+// LimitsMemory keeps counters of events in fixed windows and temporary blocks, both expire by themselves
+type LimitsMemory struct {
+	db *DB
+}
+
+func NewLimitsMemory(db *DB) *LimitsMemory {
+	return &LimitsMemory{db: db}
+}
+
+// IncrementCounter counts event in window started by the first event and returns count and time left in window
+func (l *LimitsMemory) IncrementCounter(key string, window time.Duration) (int, time.Duration, error) {
+	l.db.mu.Lock()
+	defer l.db.mu.Unlock()
+
+	now := l.db.now()
+	c, ok := l.db.counters[key]
+	if !ok || !c.expiresAt.After(now) {
+		c = counter{expiresAt: now.Add(window)}
+	}
+	c.count++
+	l.db.counters[key] = c
+	return c.count, c.expiresAt.Sub(now), nil
+}
+
+func (l *LimitsMemory) ResetCounters(keys ...string) error {
+	l.db.mu.Lock()
+	defer l.db.mu.Unlock()
+
+	for _, key := range keys {
+		delete(l.db.counters, key)
+	}
+	return nil
+}
+
+// Block blocks key for d, longer existing block is kept
+func (l *LimitsMemory) Block(key string, d time.Duration) error {
+	l.db.mu.Lock()
+	defer l.db.mu.Unlock()
+
+	if until := l.db.now().Add(d); until.After(l.db.blocks[key]) {
+		l.db.blocks[key] = until
+	}
+	return nil
+}
+
+// BlockedFor returns time left until block of key expires, 0 if key is not blocked
+func (l *LimitsMemory) BlockedFor(key string) (time.Duration, error) {
+	l.db.mu.Lock()
+	defer l.db.mu.Unlock()
+
+	left := l.db.blocks[key].Sub(l.db.now())
+	if left < 0 {
+		return 0, nil
+	}
+	return left, nil
+}
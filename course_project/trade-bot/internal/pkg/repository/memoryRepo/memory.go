@@ -0,0 +1,109 @@
+// Package memoryRepo keeps data of every repository in memory, it is meant for tests
+// which run the whole application without databases
+package memoryRepo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrUsernameTaken = errors.New("username is already taken")
+	ErrOrderExists   = errors.New("order already exists")
+)
+
+type recoveryCode struct {
+	hash string
+	used bool
+}
+
+type session struct {
+	models.Session
+	accessUUID  string
+	refreshUUID string
+}
+
+type accessToken struct {
+	userID    int
+	expiresAt time.Time
+}
+
+type counter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// DB holds tables of all repositories behind one lock, so deleting user removes its data
+// from every table at once like cascades of postgres do. Stored values are copied in and out
+type DB struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	users         []models.User
+	recoveryCodes map[int][]recoveryCode
+	orders        []models.Order
+	// usersOrders links order id to user it was stored for
+	usersOrders map[string]int
+	submissions []models.OrderSubmission
+	// tradingSessions are stored with FinishedAt copied on every change
+	tradingSessions []models.TradingSession
+	candles         []models.Candle
+	auditEntries    []models.AuditEntry
+	apiTokens       []models.APIToken
+
+	accessTokens map[string]accessToken
+	sessions     map[string]session
+	counters     map[string]counter
+	blocks       map[string]time.Time
+
+	lastID int
+}
+
+func NewDB() *DB {
+	return &DB{
+		now:           time.Now,
+		recoveryCodes: make(map[int][]recoveryCode),
+		usersOrders:   make(map[string]int),
+		accessTokens:  make(map[string]accessToken),
+		sessions:      make(map[string]session),
+		counters:      make(map[string]counter),
+		blocks:        make(map[string]time.Time),
+	}
+}
+
+// nextID returns id unique across tables like serial columns do
+func (db *DB) nextID() int {
+	db.lastID++
+	return db.lastID
+}
+
+func (db *DB) userIndex(userID int) int {
+	for i := range db.users {
+		if db.users[i].ID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (db *DB) orderIndex(orderID string) int {
+	for i := range db.orders {
+		if db.orders[i].ID == orderID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (db *DB) submissionIndex(cliOrderID string) int {
+	for i := range db.submissions {
+		if db.submissions[i].CliOrderID == cliOrderID {
+			return i
+		}
+	}
+	return -1
+}
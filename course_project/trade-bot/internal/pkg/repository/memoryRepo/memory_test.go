@@ -0,0 +1,126 @@
+package memoryRepo
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func createTestUser(t *testing.T, db *DB, username string) int {
+	id, err := NewAuthMemory(db).CreateUser(models.User{Name: "name", Username: username, Password: "hash"})
+	require.NoError(t, err)
+	return id
+}
+
+func TestAuthMemory_CreateUser(t *testing.T) {
+	db := NewDB()
+	auth := NewAuthMemory(db)
+
+	id := createTestUser(t, db, "trader")
+	user, err := auth.GetUser("trader")
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, models.RoleTrader, user.Role)
+
+	_, err = auth.CreateUser(models.User{Username: "trader"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrUsernameTaken.Error())
+
+	_, err = auth.GetUserByID(id + 100)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestKrakenOrdersManagerMemory_CreateOrderSubmission(t *testing.T) {
+	db := NewDB()
+	k := NewKrakenOrdersManagerMemory(db)
+	userID := createTestUser(t, db, "trader")
+
+	created, ok, err := k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, IdempotencyKey: "key", CliOrderID: "cli-1"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// retried request gets the original submission
+	existing, ok, err := k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, IdempotencyKey: "key", CliOrderID: "cli-2"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, created, existing)
+
+	_, _, err = k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, CliOrderID: "cli-1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrDuplicateCliOrderID.Error())
+
+	// order completes submission, so it can't fail anymore
+	order := models.Order{ID: "order-1", UserID: userID, ClientOrderID: "cli-1"}
+	require.NoError(t, k.CreateOrders(userID, []models.Order{order}))
+	require.NoError(t, k.FailOrderSubmission("cli-1"))
+	submission, err := k.GetOrderSubmission("cli-1")
+	require.NoError(t, err)
+	assert.Equal(t, "order-1", submission.OrderID)
+	assert.False(t, submission.Failed)
+}
+
+func TestAuthMemory_DeleteUser(t *testing.T) {
+	db := NewDB()
+	auth := NewAuthMemory(db)
+	k := NewKrakenOrdersManagerMemory(db)
+	userID := createTestUser(t, db, "trader")
+	otherID := createTestUser(t, db, "other")
+
+	require.NoError(t, k.CreateOrder(userID, models.Order{ID: "order-1", UserID: userID}))
+	require.NoError(t, k.CreateOrder(otherID, models.Order{ID: "order-2", UserID: otherID}))
+	_, _, err := k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, CliOrderID: "cli-1"})
+	require.NoError(t, err)
+
+	deleted, err := auth.DeleteUser(userID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = k.GetOrder("order-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	_, err = k.GetOrderSubmission("cli-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	orders, err := k.GetOrders()
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "order-2", orders[0].ID)
+
+	deleted, err = auth.DeleteUser(userID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestLimitsMemory(t *testing.T) {
+	db := NewDB()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	db.now = func() time.Time { return now }
+	l := NewLimitsMemory(db)
+
+	for i := 1; i <= 2; i++ {
+		count, left, err := l.IncrementCounter("login", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, count)
+		assert.Equal(t, time.Minute, left)
+	}
+
+	now = now.Add(time.Minute)
+	count, _, err := l.IncrementCounter("login", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, l.Block("login", time.Minute))
+	require.NoError(t, l.Block("login", time.Second))
+	left, err := l.BlockedFor("login")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, left)
+
+	now = now.Add(2 * time.Minute)
+	left, err = l.BlockedFor("login")
+	require.NoError(t, err)
+	assert.Zero(t, left)
+}
@@ -0,0 +1,73 @@
+package memoryRepo
+
+import (
+	"sort"
+	"time"
+
+	"trade-bot/internal/pkg/models"
+)
+
+type TradingSessionsMemory struct {
+	db *DB
+}
+
+func NewTradingSessionsMemory(db *DB) *TradingSessionsMemory {
+	return &TradingSessionsMemory{db: db}
+}
+
+func (r *TradingSessionsMemory) CreateTradingSession(session models.TradingSession) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session.ID = r.db.nextID()
+	session.FinishOrderID, session.Reason, session.FinishedAt = "", "", nil
+	r.db.tradingSessions = append(r.db.tradingSessions, session)
+	return session.ID, nil
+}
+
+func (r *TradingSessionsMemory) FinishTradingSession(id int, finishOrderID, reason string, finishedAt time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for i := range r.db.tradingSessions {
+		if r.db.tradingSessions[i].ID == id {
+			session := &r.db.tradingSessions[i]
+			session.FinishOrderID, session.Reason, session.FinishedAt = finishOrderID, reason, &finishedAt
+		}
+	}
+	return nil
+}
+
+// GetTrades returns sessions of user finished in the range of filter which closed position, in order they finished
+func (r *TradingSessionsMemory) GetTrades(filter models.ReportFilter) ([]models.Trade, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	trades := []models.Trade{}
+	for _, session := range r.db.tradingSessions {
+		if session.UserID != filter.UserID || session.FinishedAt == nil {
+			continue
+		}
+		finishedAt := *session.FinishedAt
+		if !filter.From.IsZero() && finishedAt.Before(filter.From) || !filter.To.IsZero() && !finishedAt.Before(filter.To) {
+			continue
+		}
+
+		entry, exit := r.db.orderIndex(session.StartOrderID), r.db.orderIndex(session.FinishOrderID)
+		if entry < 0 || exit < 0 {
+			continue
+		}
+
+		session.FinishedAt = &finishedAt
+		trades = append(trades, models.Trade{
+			TradingSession: session,
+			EntryPrice:     r.db.orders[entry].Price,
+			ExitPrice:      r.db.orders[exit].Price,
+			Quantity:       r.db.orders[entry].Quantity,
+			Filled:         r.db.orders[entry].Filled,
+		})
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].FinishedAt.Before(*trades[j].FinishedAt) })
+	return trades, nil
+}
@@ -0,0 +1,88 @@
+package postgresRepo
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrGetUsers        = errors.New("get users")
+	ErrSetUserRole     = errors.New("set user role")
+	ErrSetUserDisabled = errors.New("set user disabled")
+	ErrGetRiskUsage    = errors.New("get risk usage")
+)
+
+type AdminPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAdminPostgres(db *sqlx.DB) *AdminPostgres {
+	return &AdminPostgres{db: db}
+}
+
+const getUsersQuery = `SELECT id, name, username, role, disabled FROM users ORDER BY id`
+
+func (a *AdminPostgres) GetUsers() ([]models.UserSummary, error) {
+	users := []models.UserSummary{}
+	if err := a.db.Select(&users, getUsersQuery); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetUsers, err)
+	}
+	return users, nil
+}
+
+const setUserRoleQuery = `UPDATE users SET role = $1 WHERE id = $2`
+
+// SetUserRole changes role of user, false is returned if there is no such user
+func (a *AdminPostgres) SetUserRole(userID int, role string) (bool, error) {
+	result, err := a.db.Exec(setUserRoleQuery, role, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrSetUserRole, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrSetUserRole, err)
+	}
+	return affected == 1, nil
+}
+
+const setUserDisabledQuery = `UPDATE users SET disabled = $1 WHERE id = $2`
+
+// SetUserDisabled disables or enables account of user, false is returned if there is no such user
+func (a *AdminPostgres) SetUserDisabled(userID int, disabled bool) (bool, error) {
+	result, err := a.db.Exec(setUserDisabledQuery, disabled, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrSetUserDisabled, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrSetUserDisabled, err)
+	}
+	return affected == 1, nil
+}
+
+const getRiskUsageQuery = `
+	SELECT u.id AS user_id, u.username,
+	       COUNT(o.order_id) AS orders,
+	       COUNT(o.order_id) FILTER (WHERE o.type IN ($1, $2) AND o.filled < o.quantity) AS open_orders,
+	       COALESCE(SUM((o.quantity - o.filled) * o.price) FILTER (WHERE o.type IN ($1, $2) AND o.filled < o.quantity), 0)
+	           AS open_notional,
+	       COALESCE(SUM(o.filled * o.price) FILTER (WHERE o.type = $3), 0) AS traded_notional
+	FROM users u
+	LEFT JOIN orders o ON o.user_id = u.id
+	GROUP BY u.id, u.username
+	ORDER BY u.id`
+
+func (a *AdminPostgres) GetRiskUsage() ([]models.RiskUsage, error) {
+	usage := []models.RiskUsage{}
+	err := a.db.Select(&usage, getRiskUsageQuery, models.PlaceOrder, models.EditOrder, models.ExecutionOrder)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetRiskUsage, err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,131 @@
+package postgresRepo
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestAdminPostgres_SetUserRole(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAdminPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET role").
+					WithArgs(models.RoleViewer, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET role").
+					WithArgs(models.RoleViewer, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+		{
+			name: "Update error",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET role").
+					WithArgs(models.RoleViewer, 1).WillReturnError(errors.New("update error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.SetUserRole(1, models.RoleViewer)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAdminPostgres_GetRiskUsage(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAdminPostgres(sqlxDB)
+
+	columns := []string{"user_id", "username", "orders", "open_orders", "open_notional", "traded_notional"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []models.RiskUsage
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "trader", 3, 1, "250.5", "1000").
+					AddRow(2, "viewer", 0, 0, "0", "0")
+				mock.ExpectQuery("SELECT (.+) FROM users u LEFT JOIN orders o").
+					WithArgs(models.PlaceOrder, models.EditOrder, models.ExecutionOrder).WillReturnRows(rows)
+			},
+			want: []models.RiskUsage{
+				{UserID: 1, Username: "trader", Orders: 3, OpenOrders: 1,
+					OpenNotional: decimal.RequireFromString("250.5"), TradedNotional: decimal.NewFromInt(1000)},
+				{UserID: 2, Username: "viewer", OpenNotional: decimal.NewFromInt(0), TradedNotional: decimal.NewFromInt(0)},
+			},
+		},
+		{
+			name: "Select error",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM users u LEFT JOIN orders o").
+					WithArgs(models.PlaceOrder, models.EditOrder, models.ExecutionOrder).
+					WillReturnError(errors.New("select error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.GetRiskUsage()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
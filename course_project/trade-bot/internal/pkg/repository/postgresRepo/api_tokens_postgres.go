@@ -0,0 +1,87 @@
+package postgresRepo
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateAPIToken = errors.New("create api token")
+	ErrGetAPITokens   = errors.New("get api tokens")
+	ErrGetAPIToken    = errors.New("get api token")
+	ErrTouchAPIToken  = errors.New("touch api token")
+	ErrDeleteAPIToken = errors.New("delete api token")
+)
+
+type APITokensPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAPITokensPostgres(db *sqlx.DB) *APITokensPostgres {
+	return &APITokensPostgres{db: db}
+}
+
+const insertAPITokenQuery = `
+	INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, allowed_ips, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, created_at`
+
+// CreateAPIToken stores token and returns it with id and creation time
+func (r *APITokensPostgres) CreateAPIToken(token models.APIToken) (models.APIToken, error) {
+	row := r.db.QueryRow(insertAPITokenQuery, token.UserID, token.Name, token.Prefix, token.TokenHash,
+		token.Scopes, token.AllowedIPs, token.ExpiresAt)
+	if err := row.Scan(&token.ID, &token.CreatedAt); err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrCreateAPIToken, err)
+	}
+	return token, nil
+}
+
+const getAPITokensQuery = `SELECT * FROM api_tokens WHERE user_id = $1 ORDER BY id`
+
+func (r *APITokensPostgres) GetAPITokens(userID int) ([]models.APIToken, error) {
+	tokens := []models.APIToken{}
+	if err := r.db.Select(&tokens, getAPITokensQuery, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetAPITokens, err)
+	}
+	return tokens, nil
+}
+
+const getAPITokenByHashQuery = `SELECT * FROM api_tokens WHERE token_hash = $1`
+
+func (r *APITokensPostgres) GetAPITokenByHash(tokenHash string) (models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.Get(&token, getAPITokenByHashQuery, tokenHash); err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrGetAPIToken, err)
+	}
+	return token, nil
+}
+
+const touchAPITokenQuery = `UPDATE api_tokens SET last_used_at = now() WHERE id = $1`
+
+// TouchAPIToken remembers when token was used the last time
+func (r *APITokensPostgres) TouchAPIToken(id int) error {
+	if _, err := r.db.Exec(touchAPITokenQuery, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrTouchAPIToken, err)
+	}
+	return nil
+}
+
+const deleteAPITokenQuery = `DELETE FROM api_tokens WHERE id = $1 AND user_id = $2`
+
+// DeleteAPIToken revokes token of user, false is returned if user has no such token
+func (r *APITokensPostgres) DeleteAPIToken(userID, id int) (bool, error) {
+	result, err := r.db.Exec(deleteAPITokenQuery, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteAPIToken, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteAPIToken, err)
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,179 @@
+package postgresRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestAPITokensPostgres_CreateAPIToken(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAPITokensPostgres(sqlxDB)
+
+	createdAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	input := models.APIToken{UserID: 1, Name: "ci", Prefix: "tbt_01234567", TokenHash: "hash",
+		Scopes: models.StringList{models.ScopeReadOrders, models.ScopeTrade}, AllowedIPs: models.StringList{}}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.APIToken
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt)
+				mock.ExpectQuery("INSERT INTO api_tokens").
+					WithArgs(1, "ci", "tbt_01234567", "hash", "read-orders,trade", "", nil).WillReturnRows(rows)
+			},
+			want: models.APIToken{ID: 1, UserID: 1, Name: "ci", Prefix: "tbt_01234567", TokenHash: "hash",
+				Scopes: models.StringList{models.ScopeReadOrders, models.ScopeTrade}, AllowedIPs: models.StringList{},
+				CreatedAt: createdAt},
+		},
+		{
+			name: "Duplicate hash",
+			mock: func() {
+				mock.ExpectQuery("INSERT INTO api_tokens").
+					WithArgs(1, "ci", "tbt_01234567", "hash", "read-orders,trade", "", nil).
+					WillReturnError(errors.New("duplicate key"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.CreateAPIToken(input)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPITokensPostgres_GetAPITokenByHash(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAPITokensPostgres(sqlxDB)
+
+	createdAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"id", "user_id", "name", "prefix", "token_hash", "scopes", "allowed_ips",
+		"created_at", "expires_at", "last_used_at"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.APIToken
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, 1, "ci", "tbt_01234567", "hash", "trade", "10.0.0.0/8,192.0.2.1/32", createdAt, nil, nil)
+				mock.ExpectQuery("SELECT (.+) FROM api_tokens WHERE token_hash").
+					WithArgs("hash").WillReturnRows(rows)
+			},
+			want: models.APIToken{ID: 1, UserID: 1, Name: "ci", Prefix: "tbt_01234567", TokenHash: "hash",
+				Scopes: models.StringList{models.ScopeTrade}, AllowedIPs: models.StringList{"10.0.0.0/8", "192.0.2.1/32"},
+				CreatedAt: createdAt},
+		},
+		{
+			name: "Not found",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM api_tokens WHERE token_hash").
+					WithArgs("hash").WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.GetAPITokenByHash("hash")
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPITokensPostgres_DeleteAPIToken(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAPITokensPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectExec("DELETE FROM api_tokens").
+					WithArgs(5, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "Token of other user",
+			mock: func() {
+				mock.ExpectExec("DELETE FROM api_tokens").
+					WithArgs(5, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.DeleteAPIToken(1, 5)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
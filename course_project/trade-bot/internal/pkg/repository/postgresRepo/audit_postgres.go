@@ -0,0 +1,90 @@
+package postgresRepo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateAuditEntry = errors.New("create audit entry")
+	ErrGetAuditEntries  = errors.New("get audit entries")
+)
+
+const defaultAuditEntriesLimit = 100
+
+type AuditPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAuditPostgres(db *sqlx.DB) *AuditPostgres {
+	return &AuditPostgres{db: db}
+}
+
+const createAuditEntryQuery = `
+	INSERT INTO audit_log(user_id, action, entity_id, details, correlation_id, data) VALUES ($1, $2, $3, $4, $5, $6)`
+
+func (a *AuditPostgres) CreateAuditEntry(entry models.AuditEntry) error {
+	_, err := a.db.Exec(createAuditEntryQuery, entry.UserID, entry.Action, entry.EntityID, entry.Details,
+		entry.CorrelationID, entry.Data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateAuditEntry, err)
+	}
+	return nil
+}
+
+const getAuditEntriesQuery = `
+	SELECT id, user_id, action, entity_id, details, correlation_id, data, created_at FROM audit_log`
+
+// GetAuditEntries returns entries matching filter in order they were written
+func (a *AuditPostgres) GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		where("user_id = $%d", filter.UserID)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.EntityID != "" {
+		where("entity_id = $%d", filter.EntityID)
+	}
+	if filter.CorrelationID != "" {
+		where("correlation_id = $%d", filter.CorrelationID)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+
+	query := getAuditEntriesQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditEntriesLimit
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	entries := []models.AuditEntry{}
+	if err := a.db.Select(&entries, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetAuditEntries, err)
+	}
+	return entries, nil
+}
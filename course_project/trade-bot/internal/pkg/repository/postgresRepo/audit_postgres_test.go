@@ -0,0 +1,137 @@
+package postgresRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestAuditPostgres_CreateAuditEntry(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuditPostgres(sqlxDB)
+
+	entry := models.AuditEntry{UserID: 1, Action: models.AuditOrderCorrected, EntityID: "order", Details: "filled: 0 -> 1",
+		CorrelationID: "correlation", Data: models.AuditData(`{"filled":"1"}`)}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_log").
+					WithArgs(entry.UserID, entry.Action, entry.EntityID, entry.Details, entry.CorrelationID, `{"filled":"1"}`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name: "Insert error",
+			mock: func() {
+				mock.ExpectExec("INSERT INTO audit_log").
+					WithArgs(entry.UserID, entry.Action, entry.EntityID, entry.Details, entry.CorrelationID, `{"filled":"1"}`).
+					WillReturnError(errors.New("insert error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			err := r.CreateAuditEntry(entry)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuditPostgres_GetAuditEntries(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuditPostgres(sqlxDB)
+
+	createdAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"id", "user_id", "action", "entity_id", "details", "correlation_id", "data", "created_at"}
+
+	tests := []struct {
+		name    string
+		filter  models.AuditFilter
+		mock    func()
+		want    []models.AuditEntry
+		wantErr bool
+	}{
+		{
+			name:   "All filters",
+			filter: models.AuditFilter{UserID: 1, Action: models.AuditLogin, From: createdAt, Limit: 10, Offset: 20},
+			mock: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, 1, models.AuditLogin, "", "", "correlation", []byte(`{"username":"user"}`), createdAt)
+				mock.ExpectQuery(`SELECT (.+) FROM audit_log WHERE user_id = \$1 AND action = \$2 AND created_at >= \$3 `+
+					`ORDER BY id LIMIT \$4 OFFSET \$5`).
+					WithArgs(1, models.AuditLogin, createdAt, 10, 20).
+					WillReturnRows(rows)
+			},
+			want: []models.AuditEntry{
+				{ID: 1, UserID: 1, Action: models.AuditLogin, CorrelationID: "correlation",
+					Data: models.AuditData(`{"username":"user"}`), CreatedAt: createdAt},
+			},
+		},
+		{
+			name:   "Default limit",
+			filter: models.AuditFilter{},
+			mock: func() {
+				mock.ExpectQuery(`SELECT (.+) FROM audit_log ORDER BY id LIMIT \$1 OFFSET \$2`).
+					WithArgs(defaultAuditEntriesLimit, 0).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			want: []models.AuditEntry{},
+		},
+		{
+			name:   "Query error",
+			filter: models.AuditFilter{UserID: 1},
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM audit_log").WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			entries, err := r.GetAuditEntries(test.filter)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, entries)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,11 +1,27 @@
 package postgresRepo
 
 import (
+	"database/sql"
+	"fmt"
+
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 
 	"trade-bot/internal/pkg/models"
 )
 
+var (
+	ErrSetTOTPSecret    = errors.New("set totp secret")
+	ErrEnableTOTP       = errors.New("enable totp")
+	ErrDisableTOTP      = errors.New("disable totp")
+	ErrUseTOTPStep      = errors.New("use totp step")
+	ErrUseRecoveryCode  = errors.New("use recovery code")
+	ErrSetRecoveryCodes = errors.New("set recovery codes")
+	ErrUpdateUser       = errors.New("update user")
+	ErrSetPassword      = errors.New("set password")
+	ErrDeleteUser       = errors.New("delete user")
+)
+
 type AuthPostgres struct {
 	db *sqlx.DB
 }
@@ -34,6 +50,14 @@ func (r *AuthPostgres) GetUser(username string) (models.User, error) {
 	return user, err
 }
 
+const getUserByIDQuery = "SELECT * FROM users WHERE id=$1"
+
+func (r *AuthPostgres) GetUserByID(userID int) (models.User, error) {
+	var user models.User
+	err := r.db.Get(&user, getUserByIDQuery, userID)
+	return user, err
+}
+
 const getUserAPIKeysQuery = "SELECT * FROM users WHERE id=$1"
 
 func (r *AuthPostgres) GetUserAPIKeys(userID int) (string, string, error) {
@@ -41,3 +65,218 @@ func (r *AuthPostgres) GetUserAPIKeys(userID int) (string, string, error) {
 	err := r.db.Get(&user, getUserAPIKeysQuery, userID)
 	return user.PublicAPIKey, user.PrivateAPIKey, err
 }
+
+const setTOTPSecretQuery = `
+	UPDATE users SET totp_secret = $1, totp_enabled = false, totp_last_step = 0 WHERE id = $2`
+
+// SetTOTPSecret stores secret of enrolment which is not enabled until it is confirmed by code
+func (r *AuthPostgres) SetTOTPSecret(userID int, secret string) error {
+	if _, err := r.db.Exec(setTOTPSecretQuery, secret, userID); err != nil {
+		return fmt.Errorf("%s: %w", ErrSetTOTPSecret, err)
+	}
+	return nil
+}
+
+const enableTOTPQuery = `UPDATE users SET totp_enabled = true WHERE id = $1`
+
+// EnableTOTP turns two-factor authentication on and replaces recovery codes in one transaction
+func (r *AuthPostgres) EnableTOTP(userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+
+	if _, err := tx.Exec(enableTOTPQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+	return nil
+}
+
+// SetRecoveryCodes replaces unused and used recovery codes of user
+func (r *AuthPostgres) SetRecoveryCodes(userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrSetRecoveryCodes, err)
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrSetRecoveryCodes, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrSetRecoveryCodes, err)
+	}
+	return nil
+}
+
+const (
+	deleteRecoveryCodesQuery = `DELETE FROM recovery_codes WHERE user_id = $1`
+	insertRecoveryCodeQuery  = `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+)
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int, hashes []string) error {
+	if _, err := tx.Exec(deleteRecoveryCodesQuery, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec(insertRecoveryCodeQuery, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const disableTOTPQuery = `
+	UPDATE users SET totp_secret = '', totp_enabled = false, totp_last_step = 0 WHERE id = $1`
+
+func (r *AuthPostgres) DisableTOTP(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+
+	if _, err := tx.Exec(disableTOTPQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+	if _, err := tx.Exec(deleteRecoveryCodesQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+	return nil
+}
+
+const useTOTPStepQuery = `UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1`
+
+// UseTOTPStep remembers step of accepted code, false is returned if code of this or later step was already used
+func (r *AuthPostgres) UseTOTPStep(userID int, step int64) (bool, error) {
+	result, err := r.db.Exec(useTOTPStepQuery, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUseTOTPStep, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUseTOTPStep, err)
+	}
+	return affected == 1, nil
+}
+
+const useRecoveryCodeQuery = `
+	UPDATE recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+// UseRecoveryCode marks recovery code as used, false is returned if there is no such unused code
+func (r *AuthPostgres) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	result, err := r.db.Exec(useRecoveryCodeQuery, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUseRecoveryCode, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUseRecoveryCode, err)
+	}
+	return affected == 1, nil
+}
+
+const updateUserQuery = `
+	UPDATE users SET name = COALESCE($1, name),
+	                 public_api_key = COALESCE($2, public_api_key),
+	                 private_api_key = COALESCE($3, private_api_key)
+	WHERE id = $4`
+
+// UpdateUser changes fields of update which are not nil, false is returned if there is no such user
+func (r *AuthPostgres) UpdateUser(userID int, update models.ProfileUpdate) (bool, error) {
+	result, err := r.db.Exec(updateUserQuery, update.Name, update.PublicAPIKey, update.PrivateAPIKey, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUpdateUser, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUpdateUser, err)
+	}
+	return affected == 1, nil
+}
+
+const setPasswordQuery = `UPDATE users SET password_hash = $1 WHERE id = $2`
+
+// SetPassword replaces password hash of user, false is returned if there is no such user
+func (r *AuthPostgres) SetPassword(userID int, passwordHash string) (bool, error) {
+	result, err := r.db.Exec(setPasswordQuery, passwordHash, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrSetPassword, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrSetPassword, err)
+	}
+	return affected == 1, nil
+}
+
+const (
+	deleteUserOrdersQuery = `
+	DELETE FROM orders WHERE order_id IN (SELECT order_id FROM users_orders WHERE user_id = $1)`
+	deleteUserQuery = `DELETE FROM users WHERE id = $1`
+)
+
+// DeleteUser deletes orders of user and user in one transaction, links in users_orders, order submissions
+// and recovery codes are deleted by cascade. False is returned if there is no such user
+func (r *AuthPostgres) DeleteUser(userID int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+
+	if _, err := tx.Exec(deleteUserOrdersQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, ErrCouldNotRollbackTransaction
+		}
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+
+	result, err := tx.Exec(deleteUserQuery, userID)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, ErrCouldNotRollbackTransaction
+		}
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, ErrCouldNotRollbackTransaction
+		}
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+	return affected == 1, nil
+}
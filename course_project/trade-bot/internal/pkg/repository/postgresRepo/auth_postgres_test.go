@@ -3,6 +3,7 @@ package postgresRepo
 import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"testing"
@@ -207,3 +208,295 @@ func TestAuthPostgres_GetUserAPIKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthPostgres_EnableTOTP(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET totp_enabled = true").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM recovery_codes").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO recovery_codes").
+					WithArgs(1, "hash1").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO recovery_codes").
+					WithArgs(1, "hash2").WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Insert error",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET totp_enabled = true").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM recovery_codes").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO recovery_codes").
+					WithArgs(1, "hash1").WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			err := r.EnableTOTP(1, []string{"hash1", "hash2"})
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthPostgres_UseTOTPStep(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET totp_last_step").
+					WithArgs(int64(100), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "Replayed code",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET totp_last_step").
+					WithArgs(int64(100), 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.UseTOTPStep(1, 100)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthPostgres_UpdateUser(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(sqlxDB)
+
+	name := "new name"
+	tests := []struct {
+		name    string
+		mock    func()
+		input   models.ProfileUpdate
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET name").
+					WithArgs("new name", nil, nil, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			input: models.ProfileUpdate{Name: &name},
+			want:  true,
+		},
+		{
+			name: "No user",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET name").
+					WithArgs("new name", nil, nil, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			input: models.ProfileUpdate{Name: &name},
+			want:  false,
+		},
+		{
+			name: "Update error",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET name").
+					WithArgs("new name", nil, nil, 1).WillReturnError(errors.New("update error"))
+			},
+			input:   models.ProfileUpdate{Name: &name},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.UpdateUser(1, test.input)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthPostgres_SetPassword(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET password_hash").
+					WithArgs("hash", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "No user",
+			mock: func() {
+				mock.ExpectExec("UPDATE users SET password_hash").
+					WithArgs("hash", 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.SetPassword(1, "hash")
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthPostgres_DeleteUser(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM orders WHERE order_id IN").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("DELETE FROM users").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+		{
+			name: "No user",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM orders WHERE order_id IN").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM users").
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: false,
+		},
+		{
+			name: "Delete orders error",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM orders WHERE order_id IN").
+					WithArgs(1).WillReturnError(errors.New("delete error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.DeleteUser(1)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package postgresRepo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrSaveCandles    = errors.New("save candles")
+	ErrGetLastCandles = errors.New("get last candles")
+	ErrGetCandles     = errors.New("get candles")
+)
+
+type CandlesPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCandlesPostgres(db *sqlx.DB) *CandlesPostgres {
+	return &CandlesPostgres{db: db}
+}
+
+const saveCandleQuery = `
+	INSERT INTO candles(symbol, candles_type, timeframe, time, open, high, low, close, volume)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (symbol, candles_type, timeframe, time) DO UPDATE
+	SET open=EXCLUDED.open, high=EXCLUDED.high, low=EXCLUDED.low, close=EXCLUDED.close, volume=EXCLUDED.volume`
+
+// SaveCandles inserts candles, already stored ones are updated
+func (r *CandlesPostgres) SaveCandles(candles []models.Candle) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrSaveCandles, err)
+	}
+
+	for _, c := range candles {
+		_, err := tx.Exec(saveCandleQuery, c.Symbol, c.Type, c.Interval, c.Time, c.Open, c.High, c.Low, c.Close, c.Volume)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return ErrCouldNotRollbackTransaction
+			}
+			return fmt.Errorf("%s: %w", ErrSaveCandles, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+const getLastCandlesQuery = `
+	SELECT * FROM (
+		SELECT * FROM candles WHERE symbol=$1 AND candles_type=$2 AND timeframe=$3 ORDER BY time DESC LIMIT $4
+	) AS last_candles ORDER BY time`
+
+// GetLastCandles returns n latest candles sorted by time
+func (r *CandlesPostgres) GetLastCandles(symbol, candlesType, interval string, n int) ([]models.Candle, error) {
+	var candles []models.Candle
+	if err := r.db.Select(&candles, getLastCandlesQuery, symbol, candlesType, interval, n); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetLastCandles, err)
+	}
+	return candles, nil
+}
+
+const getCandlesQuery = `
+	SELECT * FROM candles WHERE symbol=$1 AND candles_type=$2 AND timeframe=$3 AND time >= $4 AND time < $5 ORDER BY time`
+
+// GetCandles returns candles started in [from, to) sorted by time
+func (r *CandlesPostgres) GetCandles(symbol, candlesType, interval string, from, to time.Time) ([]models.Candle, error) {
+	var candles []models.Candle
+	if err := r.db.Select(&candles, getCandlesQuery, symbol, candlesType, interval, from, to); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetCandles, err)
+	}
+	return candles, nil
+}
@@ -0,0 +1,135 @@
+package postgresRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var candlesColumns = []string{"symbol", "candles_type", "timeframe", "time", "open", "high", "low", "close", "volume"}
+
+func TestCandlesPostgres_SaveCandles(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCandlesPostgres(sqlxDB)
+
+	candles := []models.Candle{
+		{Symbol: "PI_XBTUSD", Type: "trade", Interval: "1m", Time: time.Unix(60, 0), Open: decimal.NewFromInt(1), High: decimal.NewFromInt(2), Low: decimal.NewFromInt(1), Close: decimal.NewFromInt(2), Volume: decimal.NewFromInt(10)},
+		{Symbol: "PI_XBTUSD", Type: "trade", Interval: "1m", Time: time.Unix(120, 0), Open: decimal.NewFromInt(2), High: decimal.NewFromInt(3), Low: decimal.NewFromInt(2), Close: decimal.NewFromInt(3), Volume: decimal.NewFromInt(5)},
+	}
+
+	tests := []struct {
+		name    string
+		input   []models.Candle
+		mock    func(candles []models.Candle)
+		wantErr bool
+	}{
+		{
+			name:  "OK",
+			input: candles,
+			mock: func(candles []models.Candle) {
+				mock.ExpectBegin()
+				for _, c := range candles {
+					mock.ExpectExec("INSERT INTO candles").
+						WithArgs(c.Symbol, c.Type, c.Interval, c.Time, c.Open, c.High, c.Low, c.Close, c.Volume).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "Insert error",
+			input: candles,
+			mock: func(candles []models.Candle) {
+				mock.ExpectBegin()
+				c := candles[0]
+				mock.ExpectExec("INSERT INTO candles").
+					WithArgs(c.Symbol, c.Type, c.Interval, c.Time, c.Open, c.High, c.Low, c.Close, c.Volume).
+					WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.input)
+
+			err := r.SaveCandles(test.input)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCandlesPostgres_GetLastCandles(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCandlesPostgres(sqlxDB)
+
+	candle := models.Candle{Symbol: "PI_XBTUSD", Type: "trade", Interval: "1h", Time: time.Unix(3600, 0), Open: decimal.NewFromInt(1), High: decimal.NewFromInt(2), Low: decimal.NewFromInt(1), Close: decimal.NewFromInt(2), Volume: decimal.NewFromInt(10)}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []models.Candle
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				rows := sqlmock.NewRows(candlesColumns).
+					AddRow(candle.Symbol, candle.Type, candle.Interval, candle.Time, candle.Open, candle.High,
+						candle.Low, candle.Close, candle.Volume)
+				mock.ExpectQuery("SELECT (.+) FROM candles").
+					WithArgs("PI_XBTUSD", "trade", "1h", 1).WillReturnRows(rows)
+			},
+			want: []models.Candle{candle},
+		},
+		{
+			name: "Query error",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM candles").
+					WithArgs("PI_XBTUSD", "trade", "1h", 1).WillReturnError(errors.New("select error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.GetLastCandles("PI_XBTUSD", "trade", "1h", 1)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package postgresRepo
 
 import (
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
@@ -12,6 +14,14 @@ import (
 var (
 	ErrCouldNotRollbackTransaction = errors.New("could not rollback transaction")
 	ErrGetUsersOrder               = errors.New("get user orders")
+	ErrFindUserOrders              = errors.New("find user orders")
+	ErrCreateOrders                = errors.New("create orders")
+	ErrGetOrders                   = errors.New("get orders")
+	ErrCreateOrderSubmission       = errors.New("create order submission")
+	ErrDuplicateCliOrderID         = errors.New("client order id is already used")
+	ErrRetryOrderSubmission        = errors.New("retry order submission")
+	ErrFailOrderSubmission         = errors.New("fail order submission")
+	ErrGetOrderSubmission          = errors.New("get order submission")
 )
 
 type KrakenOrdersManagerPostgres struct {
@@ -22,11 +32,19 @@ func NewKrakenOrdersManagerPostgres(db *sqlx.DB) *KrakenOrdersManagerPostgres {
 	return &KrakenOrdersManagerPostgres{db: db}
 }
 
+// orderColumns selects timestamps of orders as RFC 3339 strings in UTC, the way exchanges send them
+const orderColumns = `
+	order_id, user_id, cli_order_id, type, symbol, quantity, side, filled,
+	COALESCE(to_char(timestamp AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), '') AS timestamp,
+	COALESCE(to_char(last_update_timestamp AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), '')
+	    AS last_update_timestamp,
+	price, exchange`
+
 const createOrderQuery = `
 	INSERT INTO orders(order_id, user_id, cli_order_id, type, symbol, quantity, side, filled,
-	                  timestamp, last_update_timestamp, price)
+	                  timestamp, last_update_timestamp, price, exchange)
 	VALUES($1, $2, $3, $4, $5, $6, $7, $8,
-	                  $9, $10, $11)`
+	                  NULLIF($9, '')::timestamptz, NULLIF($10, '')::timestamptz, $11, $12)`
 
 const createUsersOrdersQuery = `
 	INSERT INTO users_orders(user_id, order_id) VALUES ($1, $2)
@@ -39,7 +57,7 @@ func (k *KrakenOrdersManagerPostgres) CreateOrder(userID int, order models.Order
 	}
 
 	_, err = tx.Exec(createOrderQuery, order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
-		order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price)
+		order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return ErrCouldNotRollbackTransaction
@@ -57,8 +75,52 @@ func (k *KrakenOrdersManagerPostgres) CreateOrder(userID int, order models.Order
 	return tx.Commit()
 }
 
+const upsertOrderQuery = `
+	INSERT INTO orders(order_id, user_id, cli_order_id, type, symbol, quantity, side, filled,
+	                  timestamp, last_update_timestamp, price, exchange)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8,
+	                  NULLIF($9, '')::timestamptz, NULLIF($10, '')::timestamptz, $11, $12)
+	ON CONFLICT (order_id) DO UPDATE SET type = excluded.type, quantity = excluded.quantity, filled = excluded.filled,
+	                  last_update_timestamp = excluded.last_update_timestamp, price = excluded.price`
+
+const createMissingUsersOrdersQuery = `
+	INSERT INTO users_orders(user_id, order_id)
+	SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM users_orders WHERE order_id = $2)
+`
+
+const completeOrderSubmissionQuery = `
+	UPDATE order_submissions SET order_id = $1, failed = false WHERE cli_order_id = $2 AND $2 <> ''`
+
+// CreateOrders saves all orders in one transaction, orders already stored are updated.
+// Submissions of orders are completed by their client order id
+func (k *KrakenOrdersManagerPostgres) CreateOrders(userID int, orders []models.Order) error {
+	tx, err := k.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateOrders, err)
+	}
+
+	for _, order := range orders {
+		_, err = tx.Exec(upsertOrderQuery, order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
+			order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange)
+		if err == nil {
+			_, err = tx.Exec(createMissingUsersOrdersQuery, userID, order.ID)
+		}
+		if err == nil {
+			_, err = tx.Exec(completeOrderSubmissionQuery, order.ID, order.ClientOrderID)
+		}
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return ErrCouldNotRollbackTransaction
+			}
+			return fmt.Errorf("%s: %w", ErrCreateOrders, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 const getOrderByIDQuery = `
-SELECT * FROM orders WHERE order_id like $1
+SELECT ` + orderColumns + ` FROM orders WHERE order_id like $1
 `
 
 func (k *KrakenOrdersManagerPostgres) GetOrder(orderID string) (models.Order, error) {
@@ -67,7 +129,7 @@ func (k *KrakenOrdersManagerPostgres) GetOrder(orderID string) (models.Order, er
 	return order, err
 }
 
-const getUserOrdersQuery = `SELECT * FROM orders WHERE user_id=$1`
+const getUserOrdersQuery = `SELECT ` + orderColumns + ` FROM orders WHERE user_id=$1`
 
 func (k *KrakenOrdersManagerPostgres) GetUserOrders(userID int) ([]models.Order, error) {
 	rows, err := k.db.Query(getUserOrdersQuery, userID)
@@ -81,7 +143,7 @@ func (k *KrakenOrdersManagerPostgres) GetUserOrders(userID int) ([]models.Order,
 		var order models.Order
 
 		if err := rows.Scan(&order.ID, &order.UserID, &order.ClientOrderID, &order.Type, &order.Symbol, &order.Quantity,
-			&order.Side, &order.Filled, &order.Timestamp, &order.LastUpdateTimestamp, &order.Price); err != nil {
+			&order.Side, &order.Filled, &order.Timestamp, &order.LastUpdateTimestamp, &order.Price, &order.Exchange); err != nil {
 			return nil, fmt.Errorf("%s: %w", ErrGetUsersOrder, err)
 		}
 		orders = append(orders, order)
@@ -89,3 +151,152 @@ func (k *KrakenOrdersManagerPostgres) GetUserOrders(userID int) ([]models.Order,
 
 	return orders, nil
 }
+
+// orderTimeColumn sorts orders placed without timestamp first like models.Order.Time does,
+// orders_user_id_time_idx is built on it
+const orderTimeColumn = `COALESCE(timestamp, 'epoch'::timestamptz)`
+
+const findUserOrdersQuery = `SELECT ` + orderColumns + ` FROM orders`
+
+const defaultOrdersLimit = 100
+
+// FindUserOrders returns orders matching filter sorted by time and id, starting after filter.After
+func (k *KrakenOrdersManagerPostgres) FindUserOrders(filter models.OrderFilter) ([]models.Order, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	where("user_id = $%d", filter.UserID)
+	if filter.Symbol != "" {
+		where("symbol = $%d", filter.Symbol)
+	}
+	if filter.Side != "" {
+		where("side = $%d", filter.Side)
+	}
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	if !filter.From.IsZero() {
+		where(orderTimeColumn+" >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where(orderTimeColumn+" < $%d", filter.To)
+	}
+
+	order, compare := "ASC", ">"
+	if filter.Descending() {
+		order, compare = "DESC", "<"
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Time, filter.After.OrderID)
+		conditions = append(conditions, fmt.Sprintf("(%s, order_id) %s ($%d, $%d)", orderTimeColumn, compare, len(args)-1, len(args)))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultOrdersLimit
+	}
+	args = append(args, limit)
+
+	query := findUserOrdersQuery + " WHERE " + strings.Join(conditions, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, order_id %s LIMIT $%d", orderTimeColumn, order, order, len(args))
+
+	orders := []models.Order{}
+	if err := k.db.Select(&orders, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrFindUserOrders, err)
+	}
+	return orders, nil
+}
+
+const getOrdersQuery = `SELECT ` + orderColumns + ` FROM orders`
+
+// GetOrders returns orders of all users
+func (k *KrakenOrdersManagerPostgres) GetOrders() ([]models.Order, error) {
+	var orders []models.Order
+	if err := k.db.Select(&orders, getOrdersQuery); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetOrders, err)
+	}
+	return orders, nil
+}
+
+const createOrderSubmissionQuery = `
+	INSERT INTO order_submissions(user_id, idempotency_key, cli_order_id) VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING
+	RETURNING id, created_at`
+
+const getOrderSubmissionByIdempotencyKeyQuery = `
+	SELECT id, user_id, idempotency_key, cli_order_id, order_id, failed, created_at
+	FROM order_submissions WHERE user_id = $1 AND idempotency_key = $2`
+
+// CreateOrderSubmission stores submission and returns true. When submission with the same
+// idempotency key is already stored it is returned instead with false
+func (k *KrakenOrdersManagerPostgres) CreateOrderSubmission(submission models.OrderSubmission) (models.OrderSubmission, bool, error) {
+	err := k.db.QueryRowx(createOrderSubmissionQuery, submission.UserID, submission.IdempotencyKey, submission.CliOrderID).
+		Scan(&submission.ID, &submission.CreatedAt)
+	if err == nil {
+		return submission, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %w", ErrCreateOrderSubmission, err)
+	}
+	if submission.IdempotencyKey == "" {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %s: %s", ErrCreateOrderSubmission, ErrDuplicateCliOrderID, submission.CliOrderID)
+	}
+
+	var existing models.OrderSubmission
+	err = k.db.Get(&existing, getOrderSubmissionByIdempotencyKeyQuery, submission.UserID, submission.IdempotencyKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %s: %s", ErrCreateOrderSubmission, ErrDuplicateCliOrderID, submission.CliOrderID)
+	}
+	if err != nil {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %w", ErrCreateOrderSubmission, err)
+	}
+
+	return existing, false, nil
+}
+
+const retryOrderSubmissionQuery = `
+	UPDATE order_submissions SET failed = false WHERE cli_order_id = $1 AND failed`
+
+// RetryOrderSubmission marks failed submission as pending again,
+// false is returned when submission is not failed, e.g. it is being retried concurrently
+func (k *KrakenOrdersManagerPostgres) RetryOrderSubmission(cliOrderID string) (bool, error) {
+	result, err := k.db.Exec(retryOrderSubmissionQuery, cliOrderID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrRetryOrderSubmission, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrRetryOrderSubmission, err)
+	}
+	return affected == 1, nil
+}
+
+const failOrderSubmissionQuery = `
+	UPDATE order_submissions SET failed = true WHERE cli_order_id = $1 AND order_id = ''`
+
+func (k *KrakenOrdersManagerPostgres) FailOrderSubmission(cliOrderID string) error {
+	if _, err := k.db.Exec(failOrderSubmissionQuery, cliOrderID); err != nil {
+		return fmt.Errorf("%s: %w", ErrFailOrderSubmission, err)
+	}
+	return nil
+}
+
+const getOrderSubmissionQuery = `
+	SELECT id, user_id, idempotency_key, cli_order_id, order_id, failed, created_at
+	FROM order_submissions WHERE cli_order_id = $1`
+
+// GetOrderSubmission returns submission by client order id, sql.ErrNoRows is wrapped when there is no such submission
+func (k *KrakenOrdersManagerPostgres) GetOrderSubmission(cliOrderID string) (models.OrderSubmission, error) {
+	var submission models.OrderSubmission
+	if err := k.db.Get(&submission, getOrderSubmissionQuery, cliOrderID); err != nil {
+		return models.OrderSubmission{}, fmt.Errorf("%s: %w", ErrGetOrderSubmission, err)
+	}
+	return submission, nil
+}
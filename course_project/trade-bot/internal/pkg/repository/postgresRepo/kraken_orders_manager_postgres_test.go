@@ -1,11 +1,14 @@
 package postgresRepo
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 
 	"trade-bot/internal/pkg/models"
@@ -42,12 +45,12 @@ func TestKrakenOrdersManagerPostgres_CreateOrder(t *testing.T) {
 					ClientOrderID:       "1",
 					Type:                "type",
 					Symbol:              "symbol",
-					Quantity:            10,
+					Quantity:            decimal.NewFromInt(10),
 					Side:                "buy",
-					Filled:              2,
+					Filled:              decimal.NewFromInt(2),
 					Timestamp:           "timestamp",
 					LastUpdateTimestamp: "timestamp",
-					Price:               10,
+					Price:               decimal.NewFromInt(10),
 				},
 				userID: 1,
 			},
@@ -56,7 +59,7 @@ func TestKrakenOrdersManagerPostgres_CreateOrder(t *testing.T) {
 
 				mock.ExpectExec("INSERT INTO orders").
 					WithArgs(order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
-						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price).
+						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectExec("INSERT INTO users_orders").WithArgs(userID, order.ID).
@@ -80,7 +83,7 @@ func TestKrakenOrdersManagerPostgres_CreateOrder(t *testing.T) {
 
 				mock.ExpectExec("INSERT INTO orders").
 					WithArgs(order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
-						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price).
+						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange).
 					WillReturnError(errors.New("insert error"))
 
 				mock.ExpectRollback()
@@ -96,12 +99,12 @@ func TestKrakenOrdersManagerPostgres_CreateOrder(t *testing.T) {
 					ClientOrderID:       "1",
 					Type:                "type",
 					Symbol:              "symbol",
-					Quantity:            10,
+					Quantity:            decimal.NewFromInt(10),
 					Side:                "buy",
-					Filled:              2,
+					Filled:              decimal.NewFromInt(2),
 					Timestamp:           "timestamp",
 					LastUpdateTimestamp: "timestamp",
-					Price:               10,
+					Price:               decimal.NewFromInt(10),
 				},
 			},
 			mock: func(userID int, order models.Order) {
@@ -109,7 +112,7 @@ func TestKrakenOrdersManagerPostgres_CreateOrder(t *testing.T) {
 
 				mock.ExpectExec("INSERT INTO orders").
 					WithArgs(order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
-						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price).
+						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
 				mock.ExpectExec("INSERT INTO users_orders").WithArgs(userID, order.ID).
@@ -136,6 +139,94 @@ func TestKrakenOrdersManagerPostgres_CreateOrder(t *testing.T) {
 	}
 }
 
+func TestKrakenOrdersManagerPostgres_CreateOrders(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewKrakenOrdersManagerPostgres(sqlxDB)
+
+	orders := []models.Order{
+		{ID: "1", UserID: 1, Type: "PLACE", Symbol: "symbol", Quantity: decimal.NewFromInt(10), Side: "buy",
+			Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(100)},
+		{ID: "2", UserID: 1, Type: "CANCEL", Symbol: "symbol", Quantity: decimal.NewFromInt(5), Side: "sell",
+			Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(110)},
+	}
+
+	expectOrder := func(userID int, order models.Order) {
+		mock.ExpectExec("(?s)INSERT INTO orders.+ON CONFLICT").
+			WithArgs(order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
+				order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO users_orders").WithArgs(userID, order.ID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE order_submissions").WithArgs(order.ID, order.ClientOrderID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	type mockBehaviour func(userID int, orders []models.Order)
+
+	tests := []struct {
+		name    string
+		userID  int
+		orders  []models.Order
+		mock    mockBehaviour
+		wantErr bool
+	}{
+		{
+			name:   "OK",
+			userID: 1,
+			orders: orders,
+			mock: func(userID int, orders []models.Order) {
+				mock.ExpectBegin()
+				for _, order := range orders {
+					expectOrder(userID, order)
+				}
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "Rollback all orders on error",
+			userID: 1,
+			orders: orders,
+			mock: func(userID int, orders []models.Order) {
+				mock.ExpectBegin()
+				expectOrder(userID, orders[0])
+				mock.ExpectExec("(?s)INSERT INTO orders.+ON CONFLICT").
+					WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name:   "Begin error",
+			userID: 1,
+			orders: orders,
+			mock: func(userID int, orders []models.Order) {
+				mock.ExpectBegin().WillReturnError(errors.New("begin error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.userID, test.orders)
+
+			err := r.CreateOrders(test.userID, test.orders)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestKrakenOrdersManagerPostgres_GetOrder(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	if err != nil {
@@ -168,13 +259,16 @@ func TestKrakenOrdersManagerPostgres_GetOrder(t *testing.T) {
 				},
 			},
 			want: models.Order{
-				ID: "1",
+				ID:       "1",
+				Quantity: decimal.NewFromInt(0),
+				Filled:   decimal.NewFromInt(0),
+				Price:    decimal.NewFromInt(0),
 			},
 			mock: func(orderID string, order models.Order) {
 				rows := sqlmock.NewRows([]string{"order_id", "user_id", "cli_order_id", "type", "symbol", "quantity",
-					"side", "filled", "timestamp", "last_update_timestamp", "price"}).
+					"side", "filled", "timestamp", "last_update_timestamp", "price", "exchange"}).
 					AddRow(order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
-						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price)
+						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange)
 				mock.ExpectQuery("SELECT (.+) FROM orders").
 					WithArgs(orderID).WillReturnRows(rows)
 			},
@@ -193,7 +287,7 @@ func TestKrakenOrdersManagerPostgres_GetOrder(t *testing.T) {
 			},
 			mock: func(orderID string, order models.Order) {
 				rows := sqlmock.NewRows([]string{"order_id", "user_id", "cli_order_id", "type", "symbol", "quantity",
-					"side", "filled", "timestamp", "last_update_timestamp", "price"})
+					"side", "filled", "timestamp", "last_update_timestamp", "price", "exchange"})
 				mock.ExpectQuery("SELECT (.+) FROM orders").
 					WithArgs(orderID).WillReturnRows(rows)
 			},
@@ -244,18 +338,18 @@ func TestKrakenOrdersManagerPostgres_GetUserOrders(t *testing.T) {
 				ClientOrderID:       "1",
 				Type:                "type",
 				Symbol:              "symbol",
-				Quantity:            10,
+				Quantity:            decimal.NewFromInt(10),
 				Side:                "buy",
-				Filled:              10,
+				Filled:              decimal.NewFromInt(10),
 				Timestamp:           "time",
 				LastUpdateTimestamp: "time",
-				Price:               100,
+				Price:               decimal.NewFromInt(100),
 			},
 			mock: func(userID int, order models.Order) {
 				rows := sqlmock.NewRows([]string{"order_id", "user_id", "cli_order_id", "type", "symbol", "quantity",
-					"side", "filled", "timestamp", "last_update_timestamp", "price"}).
+					"side", "filled", "timestamp", "last_update_timestamp", "price", "exchange"}).
 					AddRow(order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
-						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price)
+						order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange)
 				mock.ExpectQuery("SELECT (.+) FROM orders").
 					WithArgs(userID).WillReturnRows(rows)
 			},
@@ -265,12 +359,12 @@ func TestKrakenOrdersManagerPostgres_GetUserOrders(t *testing.T) {
 				ClientOrderID:       "1",
 				Type:                "type",
 				Symbol:              "symbol",
-				Quantity:            10,
+				Quantity:            decimal.NewFromInt(10),
 				Side:                "buy",
-				Filled:              10,
+				Filled:              decimal.NewFromInt(10),
 				Timestamp:           "time",
 				LastUpdateTimestamp: "time",
-				Price:               100,
+				Price:               decimal.NewFromInt(100),
 			}},
 			wantErr: false,
 		},
@@ -291,3 +385,296 @@ func TestKrakenOrdersManagerPostgres_GetUserOrders(t *testing.T) {
 		})
 	}
 }
+
+func TestKrakenOrdersManagerPostgres_FindUserOrders(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewKrakenOrdersManagerPostgres(sqlxDB)
+
+	from := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns := []string{"order_id", "user_id", "cli_order_id", "type", "symbol", "quantity",
+		"side", "filled", "timestamp", "last_update_timestamp", "price", "exchange"}
+
+	tests := []struct {
+		name    string
+		filter  models.OrderFilter
+		mock    func()
+		want    []models.Order
+		wantErr bool
+	}{
+		{
+			name: "All filters",
+			filter: models.OrderFilter{UserID: 1, Symbol: "symbol", Side: "buy", Type: "type", From: from,
+				After: &models.OrderCursor{Time: from, OrderID: "1"}, Limit: 10},
+			mock: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow("2", 1, "", "type", "symbol", 10, "buy", 10, "2022-01-02T03:04:05.000Z", "", 100, "kraken_futures")
+				mock.ExpectQuery(`SELECT (.+) FROM orders WHERE user_id = \$1 AND symbol = \$2 AND side = \$3 AND type = \$4 `+
+					`AND COALESCE\(timestamp, 'epoch'::timestamptz\) >= \$5 `+
+					`AND \(COALESCE\(timestamp, 'epoch'::timestamptz\), order_id\) > \(\$6, \$7\) `+
+					`ORDER BY COALESCE\(timestamp, 'epoch'::timestamptz\) ASC, order_id ASC LIMIT \$8`).
+					WithArgs(1, "symbol", "buy", "type", from, from, "1", 10).
+					WillReturnRows(rows)
+			},
+			want: []models.Order{{ID: "2", UserID: 1, Type: "type", Symbol: "symbol", Quantity: decimal.NewFromInt(10),
+				Side: "buy", Filled: decimal.NewFromInt(10), Timestamp: "2022-01-02T03:04:05.000Z",
+				Price: decimal.NewFromInt(100), Exchange: "kraken_futures"}},
+		},
+		{
+			name:   "Descending with default limit",
+			filter: models.OrderFilter{UserID: 1, Sort: models.SortDesc, After: &models.OrderCursor{Time: from, OrderID: "1"}},
+			mock: func() {
+				mock.ExpectQuery(`SELECT (.+) FROM orders WHERE user_id = \$1 `+
+					`AND \(COALESCE\(timestamp, 'epoch'::timestamptz\), order_id\) < \(\$2, \$3\) `+
+					`ORDER BY COALESCE\(timestamp, 'epoch'::timestamptz\) DESC, order_id DESC LIMIT \$4`).
+					WithArgs(1, from, "1", defaultOrdersLimit).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			want: []models.Order{},
+		},
+		{
+			name:   "Query error",
+			filter: models.OrderFilter{UserID: 1},
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			orders, err := r.FindUserOrders(test.filter)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, orders)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestKrakenOrdersManagerPostgres_GetOrders(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewKrakenOrdersManagerPostgres(sqlxDB)
+
+	columns := []string{"order_id", "user_id", "cli_order_id", "type", "symbol", "quantity",
+		"side", "filled", "timestamp", "last_update_timestamp", "price", "exchange"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []models.Order
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow("1", 1, "", "PLACE", "PI_XBTUSD", "2", "buy", "0", "", "", "100", "kraken_futures").
+					AddRow("2", 2, "", "EXECUTION", "PI_XBTUSD", "1", "sell", "1", "", "", "101", "paper")
+				mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnRows(rows)
+			},
+			want: []models.Order{
+				{ID: "1", UserID: 1, Type: "PLACE", Symbol: "PI_XBTUSD", Quantity: decimal.NewFromInt(2), Side: "buy",
+					Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(100), Exchange: "kraken_futures"},
+				{ID: "2", UserID: 2, Type: "EXECUTION", Symbol: "PI_XBTUSD", Quantity: decimal.NewFromInt(1), Side: "sell",
+					Filled: decimal.NewFromInt(1), Price: decimal.NewFromInt(101), Exchange: "paper"},
+			},
+		},
+		{
+			name: "Query error",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM orders").WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			orders, err := r.GetOrders()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, orders)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestKrakenOrdersManagerPostgres_CreateOrderSubmission(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewKrakenOrdersManagerPostgres(sqlxDB)
+
+	createdAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	submissionColumns := []string{"id", "user_id", "idempotency_key", "cli_order_id", "order_id", "failed", "created_at"}
+
+	tests := []struct {
+		name        string
+		input       models.OrderSubmission
+		mock        func(s models.OrderSubmission)
+		want        models.OrderSubmission
+		wantCreated bool
+		wantErr     bool
+	}{
+		{
+			name:  "Created",
+			input: models.OrderSubmission{UserID: 1, IdempotencyKey: "key", CliOrderID: "cli"},
+			mock: func(s models.OrderSubmission) {
+				mock.ExpectQuery("INSERT INTO order_submissions").
+					WithArgs(s.UserID, s.IdempotencyKey, s.CliOrderID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, createdAt))
+			},
+			want:        models.OrderSubmission{ID: 7, UserID: 1, IdempotencyKey: "key", CliOrderID: "cli", CreatedAt: createdAt},
+			wantCreated: true,
+		},
+		{
+			name:  "Existing idempotency key",
+			input: models.OrderSubmission{UserID: 1, IdempotencyKey: "key", CliOrderID: "new cli"},
+			mock: func(s models.OrderSubmission) {
+				mock.ExpectQuery("INSERT INTO order_submissions").
+					WithArgs(s.UserID, s.IdempotencyKey, s.CliOrderID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+				mock.ExpectQuery("SELECT (.+) FROM order_submissions").
+					WithArgs(s.UserID, s.IdempotencyKey).
+					WillReturnRows(sqlmock.NewRows(submissionColumns).AddRow(7, 1, "key", "cli", "order", false, createdAt))
+			},
+			want: models.OrderSubmission{ID: 7, UserID: 1, IdempotencyKey: "key", CliOrderID: "cli", OrderID: "order",
+				CreatedAt: createdAt},
+		},
+		{
+			name:  "Duplicate client order id",
+			input: models.OrderSubmission{UserID: 1, CliOrderID: "cli"},
+			mock: func(s models.OrderSubmission) {
+				mock.ExpectQuery("INSERT INTO order_submissions").
+					WithArgs(s.UserID, s.IdempotencyKey, s.CliOrderID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Insert error",
+			input: models.OrderSubmission{UserID: 1, CliOrderID: "cli"},
+			mock: func(s models.OrderSubmission) {
+				mock.ExpectQuery("INSERT INTO order_submissions").
+					WithArgs(s.UserID, s.IdempotencyKey, s.CliOrderID).
+					WillReturnError(errors.New("insert error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.input)
+
+			got, created, err := r.CreateOrderSubmission(test.input)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+				assert.Equal(t, test.wantCreated, created)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestKrakenOrdersManagerPostgres_RetryOrderSubmission(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewKrakenOrdersManagerPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "Failed submission is claimed",
+			mock: func() {
+				mock.ExpectExec("UPDATE order_submissions").WithArgs("cli").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: true,
+		},
+		{
+			name: "Submission is not failed",
+			mock: func() {
+				mock.ExpectExec("UPDATE order_submissions").WithArgs("cli").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "Update error",
+			mock: func() {
+				mock.ExpectExec("UPDATE order_submissions").WithArgs("cli").WillReturnError(errors.New("update error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			got, err := r.RetryOrderSubmission("cli")
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestKrakenOrdersManagerPostgres_GetOrderSubmission(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewKrakenOrdersManagerPostgres(sqlxDB)
+
+	mock.ExpectQuery("SELECT (.+) FROM order_submissions").WithArgs("cli").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "idempotency_key", "cli_order_id", "order_id", "failed", "created_at"}))
+
+	_, err = r.GetOrderSubmission("cli")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
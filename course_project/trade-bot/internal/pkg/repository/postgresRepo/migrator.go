@@ -0,0 +1,279 @@
+package postgresRepo
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrLoadMigrations   = errors.New("load migrations")
+	ErrMigrateUp        = errors.New("migrate up")
+	ErrMigrateDown      = errors.New("migrate down")
+	ErrMigrationsStatus = errors.New("migrations status")
+	ErrDirtyDatabase    = errors.New("database is dirty, fix failed migration by hand and set its version")
+	ErrUnknownVersion   = errors.New("database version has no migration")
+	ErrNoDownMigration  = errors.New("migration can't be reverted, it has no down file")
+)
+
+// migrationsLockID is key of advisory lock held while migration is applied,
+// so servers starting together do not apply the same migration twice
+const migrationsLockID = 2022_0412
+
+const (
+	createMigrationsTableQuery = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (version bigint not null primary key, dirty boolean not null)`
+	lockMigrationsQuery          = `SELECT pg_advisory_xact_lock($1)`
+	getMigrationsVersionQuery    = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	deleteMigrationsVersionQuery = `DELETE FROM schema_migrations`
+	setMigrationsVersionQuery    = `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration changes schema from the previous version to Version by Up and back by Down
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether migration is applied to database
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// Migrator applies migrations in order of versions and keeps the current version in schema_migrations
+// table the same way golang-migrate does, so databases migrated by its cli are continued from their version.
+// Every migration is applied in its own transaction together with version change
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// NewMigrator loads migrations from files of directory named {version}_{name}.up.sql and {version}_{name}.down.sql
+func NewMigrator(db *sqlx.DB, files fs.FS) (*Migrator, error) {
+	migrations, err := loadMigrations(files)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrLoadMigrations, err)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("version %d has migrations %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Version returns version of the last applied migration, 0 if none is applied
+func (m *Migrator) Version() (int64, error) {
+	if _, err := m.db.Exec(createMigrationsTableQuery); err != nil {
+		return 0, err
+	}
+	return currentVersion(m.db)
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func currentVersion(q queryRower) (int64, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := q.QueryRow(getMigrationsVersionQuery).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w: %d", ErrDirtyDatabase, version)
+	}
+	return version, nil
+}
+
+// Up applies every migration newer than database version and returns how many were applied
+func (m *Migrator) Up() (int, error) {
+	applied := 0
+	for {
+		version, err := m.Version()
+		if err != nil {
+			return applied, fmt.Errorf("%s: %w", ErrMigrateUp, err)
+		}
+
+		next := m.next(version)
+		if next == nil {
+			return applied, nil
+		}
+		if err := m.apply(version, next.Version, next.Up); err != nil {
+			return applied, fmt.Errorf("%s: %d_%s: %w", ErrMigrateUp, next.Version, next.Name, err)
+		}
+		applied++
+	}
+}
+
+// Down reverts up to steps last applied migrations and returns how many were reverted
+func (m *Migrator) Down(steps int) (int, error) {
+	reverted := 0
+	for reverted < steps {
+		version, err := m.Version()
+		if err != nil {
+			return reverted, fmt.Errorf("%s: %w", ErrMigrateDown, err)
+		}
+		if version == 0 {
+			return reverted, nil
+		}
+
+		i := m.index(version)
+		if i < 0 {
+			return reverted, fmt.Errorf("%s: %w: %d", ErrMigrateDown, ErrUnknownVersion, version)
+		}
+		var previous int64
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+
+		current := m.migrations[i]
+		if current.Down == "" {
+			return reverted, fmt.Errorf("%s: %d_%s: %w", ErrMigrateDown, current.Version, current.Name, ErrNoDownMigration)
+		}
+		if err := m.apply(version, previous, current.Down); err != nil {
+			return reverted, fmt.Errorf("%s: %d_%s: %w", ErrMigrateDown, current.Version, current.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Status lists known migrations with flag whether each of them is applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	version, err := m.Version()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrMigrationsStatus, err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= version,
+		})
+	}
+	return statuses, nil
+}
+
+// apply runs migration script and moves version from one to another under advisory lock,
+// nothing is done if other server has changed version meanwhile
+func (m *Migrator) apply(from, to int64, script string) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		if _, err := tx.Exec(lockMigrationsQuery, migrationsLockID); err != nil {
+			return err
+		}
+		version, err := currentVersion(tx)
+		if err != nil || version != from {
+			return err
+		}
+
+		if _, err := tx.Exec(script); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(deleteMigrationsVersionQuery); err != nil {
+			return err
+		}
+		if to == 0 {
+			return nil
+		}
+		_, err = tx.Exec(setMigrationsVersionQuery, to)
+		return err
+	}()
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) next(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version > version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) index(version int64) int {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,144 @@
+package postgresRepo
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/schema"
+)
+
+var testMigrations = fstest.MapFS{
+	"000001_init.up.sql":      {Data: []byte("CREATE TABLE users")},
+	"000001_init.down.sql":    {Data: []byte("DROP TABLE users")},
+	"000002_orders.up.sql":    {Data: []byte("CREATE TABLE orders")},
+	"000002_orders.down.sql":  {Data: []byte("DROP TABLE orders")},
+	"000010_indexes.up.sql":   {Data: []byte("CREATE INDEX orders_user_id_idx")},
+	"000010_indexes.down.sql": {Data: []byte("DROP INDEX orders_user_id_idx")},
+	"schema.go":               {Data: []byte("package schema")},
+}
+
+func TestNewMigrator(t *testing.T) {
+	m, err := NewMigrator(nil, testMigrations)
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE users", Down: "DROP TABLE users"},
+		{Version: 2, Name: "orders", Up: "CREATE TABLE orders", Down: "DROP TABLE orders"},
+		{Version: 10, Name: "indexes", Up: "CREATE INDEX orders_user_id_idx", Down: "DROP INDEX orders_user_id_idx"},
+	}, m.migrations)
+
+	_, err = NewMigrator(nil, fstest.MapFS{"000001_init.down.sql": {Data: []byte("DROP TABLE users")}})
+	assert.Error(t, err)
+
+	// embedded schema is loaded with every migration having both files
+	m, err = NewMigrator(nil, schema.Migrations)
+	require.NoError(t, err)
+	require.NotEmpty(t, m.migrations)
+	for _, migration := range m.migrations {
+		assert.NotEmpty(t, migration.Down, migration.Name)
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	m, err := NewMigrator(sqlxDB, testMigrations)
+	require.NoError(t, err)
+
+	expectVersion := func(version int64) {
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(version, false))
+	}
+	expectApply := func(from, to int64, script string) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(migrationsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(from, false))
+		mock.ExpectExec(script).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(to).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	// database migrated to version 1 by golang-migrate cli
+	expectVersion(1)
+	expectApply(1, 2, "CREATE TABLE orders")
+	expectVersion(2)
+	expectApply(2, 10, "CREATE INDEX orders_user_id_idx")
+	expectVersion(10)
+
+	applied, err := m.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpFailed(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	m, err := NewMigrator(sqlxDB, testMigrations)
+	require.NoError(t, err)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(migrationsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}))
+	mock.ExpectExec("CREATE TABLE users").WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+
+	applied, err := m.Up()
+	assert.Error(t, err)
+	assert.Equal(t, 0, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	m, err := NewMigrator(sqlxDB, testMigrations)
+	require.NoError(t, err)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Version: 1, Name: "init", Applied: true},
+		{Version: 2, Name: "orders", Applied: true},
+		{Version: 10, Name: "indexes", Applied: false},
+	}, statuses)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, true))
+
+	_, err = m.Status()
+	assert.True(t, errors.Is(err, ErrDirtyDatabase))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,83 @@
+package postgresRepo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateTradingSession = errors.New("create trading session")
+	ErrFinishTradingSession = errors.New("finish trading session")
+	ErrGetTrades            = errors.New("get trades")
+)
+
+type TradingSessionsPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTradingSessionsPostgres(db *sqlx.DB) *TradingSessionsPostgres {
+	return &TradingSessionsPostgres{db: db}
+}
+
+const createTradingSessionQuery = `
+	INSERT INTO trading_sessions (user_id, strategy, exchange, symbol, side, start_order_id, started_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id`
+
+func (r *TradingSessionsPostgres) CreateTradingSession(session models.TradingSession) (int, error) {
+	var id int
+	row := r.db.QueryRow(createTradingSessionQuery, session.UserID, session.Strategy, session.Exchange, session.Symbol,
+		session.Side, session.StartOrderID, session.StartedAt)
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrCreateTradingSession, err)
+	}
+	return id, nil
+}
+
+const finishTradingSessionQuery = `
+	UPDATE trading_sessions SET finish_order_id = $2, reason = $3, finished_at = $4 WHERE id = $1`
+
+func (r *TradingSessionsPostgres) FinishTradingSession(id int, finishOrderID, reason string, finishedAt time.Time) error {
+	if _, err := r.db.Exec(finishTradingSessionQuery, id, finishOrderID, reason, finishedAt); err != nil {
+		return fmt.Errorf("%s: %w", ErrFinishTradingSession, err)
+	}
+	return nil
+}
+
+const getTradesQuery = `
+	SELECT s.id, s.user_id, s.strategy, s.exchange, s.symbol, s.side, s.start_order_id, s.finish_order_id, s.reason,
+	       s.started_at, s.finished_at,
+	       COALESCE(entry_order.price, 0) AS entry_price, COALESCE(exit_order.price, 0) AS exit_price,
+	       entry_order.quantity, entry_order.filled
+	FROM trading_sessions s
+	         JOIN orders entry_order ON entry_order.order_id = s.start_order_id
+	         JOIN orders exit_order ON exit_order.order_id = s.finish_order_id
+	WHERE s.finished_at IS NOT NULL`
+
+// GetTrades returns sessions of user finished in the range of filter which closed position, in order they finished
+func (r *TradingSessionsPostgres) GetTrades(filter models.ReportFilter) ([]models.Trade, error) {
+	conditions := []string{"s.user_id = $1"}
+	args := []interface{}{filter.UserID}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("s.finished_at >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("s.finished_at < $%d", len(args)))
+	}
+
+	query := getTradesQuery + " AND " + strings.Join(conditions, " AND ") + " ORDER BY s.finished_at, s.id"
+
+	trades := []models.Trade{}
+	if err := r.db.Select(&trades, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetTrades, err)
+	}
+	return trades, nil
+}
@@ -0,0 +1,87 @@
+package postgresRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestTradingSessionsPostgres_CreateTradingSession(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	r := NewTradingSessionsPostgres(sqlx.NewDb(mockDB, "sqlmock"))
+
+	session := models.TradingSession{UserID: 1, Strategy: "stop_loss_take_profit", Exchange: "paper",
+		Symbol: "PI_XBTUSD", Side: "buy", StartOrderID: "entry", StartedAt: time.Now()}
+
+	mock.ExpectQuery("INSERT INTO trading_sessions").
+		WithArgs(session.UserID, session.Strategy, session.Exchange, session.Symbol, session.Side,
+			session.StartOrderID, session.StartedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	id, err := r.CreateTradingSession(session)
+	require.NoError(t, err)
+	assert.Equal(t, 7, id)
+
+	mock.ExpectQuery("INSERT INTO trading_sessions").WillReturnError(errors.New("some error"))
+	_, err = r.CreateTradingSession(session)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrCreateTradingSession.Error())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTradingSessionsPostgres_FinishTradingSession(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	r := NewTradingSessionsPostgres(sqlx.NewDb(mockDB, "sqlmock"))
+
+	finishedAt := time.Now()
+	mock.ExpectExec("UPDATE trading_sessions").WithArgs(7, "exit", "take profit", finishedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, r.FinishTradingSession(7, "exit", "take profit", finishedAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTradingSessionsPostgres_GetTrades(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	r := NewTradingSessionsPostgres(sqlx.NewDb(mockDB, "sqlmock"))
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	finishedAt := from.Add(time.Hour)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "strategy", "exchange", "symbol", "side", "start_order_id",
+		"finish_order_id", "reason", "started_at", "finished_at", "entry_price", "exit_price", "quantity", "filled"}).
+		AddRow(7, 1, "stop_loss_take_profit", "paper", "PI_XBTUSD", "buy", "entry", "exit", "take profit",
+			from, finishedAt, "100", "110", "2", "0")
+	mock.ExpectQuery(`FROM trading_sessions s .+ s\.user_id = \$1 AND s\.finished_at >= \$2 AND s\.finished_at < \$3 ORDER BY s\.finished_at, s\.id`).
+		WithArgs(1, from, to).WillReturnRows(rows)
+
+	trades, err := r.GetTrades(models.ReportFilter{UserID: 1, From: from, To: to})
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	assert.Equal(t, "exit", trades[0].FinishOrderID)
+	assert.True(t, trades[0].ExitPrice.Equal(decimal.NewFromInt(110)))
+	assert.True(t, trades[0].Profit().Equal(decimal.NewFromInt(20)))
+
+	mock.ExpectQuery("FROM trading_sessions").WithArgs(1).WillReturnError(errors.New("some error"))
+	_, err = r.GetTrades(models.ReportFilter{UserID: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrGetTrades.Error())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
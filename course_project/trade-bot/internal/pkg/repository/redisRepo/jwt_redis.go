@@ -2,14 +2,47 @@ package redisRepo
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
 
+	"trade-bot/internal/pkg/models"
 	"trade-bot/pkg/utils"
 )
 
+var (
+	ErrCreateSession  = errors.New("create session")
+	ErrRotateSession  = errors.New("rotate session")
+	ErrGetSessions    = errors.New("get sessions")
+	ErrDeleteSession  = errors.New("delete session")
+	ErrDeleteSessions = errors.New("delete user sessions")
+	ErrInvalidSession = errors.New("invalid session")
+)
+
+const (
+	sessionKeyPrefix      = "session:"
+	userSessionsKeyPrefix = "user_sessions:"
+)
+
+const (
+	sessionUserIDField      = "user_id"
+	sessionUserAgentField   = "user_agent"
+	sessionIPField          = "ip"
+	sessionCreatedAtField   = "created_at"
+	sessionRefreshedAtField = "refreshed_at"
+	sessionExpiresAtField   = "expires_at"
+	sessionAccessUUIDField  = "access_uuid"
+	sessionRefreshUUIDField = "refresh_uuid"
+)
+
+// rotateSessionAttempts limits retries of rotation when session is changed concurrently
+const rotateSessionAttempts = 2
+
+// JWTRedis stores access tokens under their uuid and sessions as hashes under session id,
+// ids of user sessions are kept in a set per user
 type JWTRedis struct {
 	client *redis.Client
 }
@@ -18,16 +51,12 @@ func NewJWTRedis(client *redis.Client) *JWTRedis {
 	return &JWTRedis{client: client}
 }
 
-func (r *JWTRedis) CreateJWT(userID int, td utils.TokenDetails) (string, error) {
-	at := time.Unix(td.AtExpires, 0)
-	now := time.Now()
-
-	errAccess := r.client.Set(context.Background(), td.AccessUUID, strconv.Itoa(userID), at.Sub(now)).Err()
-	if errAccess != nil {
-		return "", errAccess
-	}
+func sessionKey(sessionID string) string {
+	return sessionKeyPrefix + sessionID
+}
 
-	return td.AccessToken, nil
+func userSessionsKey(userID int) string {
+	return userSessionsKeyPrefix + strconv.Itoa(userID)
 }
 
 func (r *JWTRedis) GetJWTUserID(ad utils.AccessDetails) (int, error) {
@@ -46,3 +75,200 @@ func (r *JWTRedis) DeleteJWT(ad utils.AccessDetails) error {
 	_, err := r.client.Del(context.Background(), ad.AccessUUID).Result()
 	return err
 }
+
+// CreateSession stores access token and session of td, session expires together with refresh token
+func (r *JWTRedis) CreateSession(session models.Session, td utils.TokenDetails) error {
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, td.AccessUUID, strconv.Itoa(session.UserID), time.Unix(td.AtExpires, 0).Sub(now))
+		pipe.HSet(ctx, sessionKey(td.SessionID),
+			sessionUserIDField, session.UserID,
+			sessionUserAgentField, session.UserAgent,
+			sessionIPField, session.IP,
+			sessionCreatedAtField, now.Unix(),
+			sessionRefreshedAtField, now.Unix(),
+			sessionExpiresAtField, td.RtExpires,
+			sessionAccessUUIDField, td.AccessUUID,
+			sessionRefreshUUIDField, td.RefreshUUID,
+		)
+		pipe.ExpireAt(ctx, sessionKey(td.SessionID), time.Unix(td.RtExpires, 0))
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), td.SessionID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateSession, err)
+	}
+	return nil
+}
+
+// RotateSession replaces tokens of session by td if refresh token of rd is the latest one issued for it.
+// Both flags are false when there is no such session. Presenting older refresh token means it was stolen
+// or replayed, so the whole session is revoked and revoked flag is true
+func (r *JWTRedis) RotateSession(rd utils.RefreshDetails, td utils.TokenDetails) (rotated bool, revoked bool, err error) {
+	ctx := context.Background()
+	key := sessionKey(rd.SessionID)
+
+	for attempt := 0; attempt < rotateSessionAttempts; attempt++ {
+		err = r.client.Watch(ctx, func(tx *redis.Tx) error {
+			fields, err := tx.HGetAll(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			if len(fields) == 0 || fields[sessionUserIDField] != strconv.FormatInt(rd.UserID, 10) {
+				rotated, revoked = false, false
+				return nil
+			}
+
+			if fields[sessionRefreshUUIDField] != rd.RefreshUUID {
+				rotated, revoked = false, true
+				_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					pipe.Del(ctx, key, fields[sessionAccessUUIDField])
+					pipe.SRem(ctx, userSessionsKey(int(rd.UserID)), rd.SessionID)
+					return nil
+				})
+				return err
+			}
+
+			now := time.Now()
+			rotated, revoked = true, false
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, fields[sessionAccessUUIDField])
+				pipe.Set(ctx, td.AccessUUID, strconv.FormatInt(rd.UserID, 10), time.Unix(td.AtExpires, 0).Sub(now))
+				pipe.HSet(ctx, key,
+					sessionRefreshedAtField, now.Unix(),
+					sessionExpiresAtField, td.RtExpires,
+					sessionAccessUUIDField, td.AccessUUID,
+					sessionRefreshUUIDField, td.RefreshUUID,
+				)
+				pipe.ExpireAt(ctx, key, time.Unix(td.RtExpires, 0))
+				return nil
+			})
+			return err
+		}, key)
+
+		// session changed since it was read, next attempt sees the change
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return false, false, fmt.Errorf("%s: %w", ErrRotateSession, err)
+		}
+		return rotated, revoked, nil
+	}
+
+	return false, false, fmt.Errorf("%s: %w", ErrRotateSession, err)
+}
+
+// GetSessions returns alive sessions of user, ids of expired sessions are removed from the user set
+func (r *JWTRedis) GetSessions(userID int) ([]models.Session, error) {
+	ctx := context.Background()
+
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetSessions, err)
+	}
+
+	sessions := make([]models.Session, 0, len(ids))
+	for _, id := range ids {
+		fields, err := r.client.HGetAll(ctx, sessionKey(id)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrGetSessions, err)
+		}
+		if len(fields) == 0 {
+			if err := r.client.SRem(ctx, userSessionsKey(userID), id).Err(); err != nil {
+				return nil, fmt.Errorf("%s: %w", ErrGetSessions, err)
+			}
+			continue
+		}
+
+		session, err := parseSession(id, fields)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrGetSessions, err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession revokes session of user with its access token, false is returned if user has no such session
+func (r *JWTRedis) DeleteSession(userID int, sessionID string) (bool, error) {
+	ctx := context.Background()
+	key := sessionKey(sessionID)
+
+	fields, err := r.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteSession, err)
+	}
+	if len(fields) == 0 || fields[sessionUserIDField] != strconv.Itoa(userID) {
+		return false, nil
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key, fields[sessionAccessUUIDField])
+		pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteSession, err)
+	}
+	return true, nil
+}
+
+// DeleteUserSessions revokes every session of user with their access tokens and returns number of revoked sessions
+func (r *JWTRedis) DeleteUserSessions(userID int) (int, error) {
+	ctx := context.Background()
+
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrDeleteSessions, err)
+	}
+
+	keys := []string{userSessionsKey(userID)}
+	revoked := 0
+	for _, id := range ids {
+		accessUUID, err := r.client.HGet(ctx, sessionKey(id), sessionAccessUUIDField).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", ErrDeleteSessions, err)
+		}
+		keys = append(keys, sessionKey(id), accessUUID)
+		revoked++
+	}
+
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrDeleteSessions, err)
+	}
+	return revoked, nil
+}
+
+func parseSession(id string, fields map[string]string) (models.Session, error) {
+	userID, err := strconv.Atoi(fields[sessionUserIDField])
+	if err != nil {
+		return models.Session{}, fmt.Errorf("%s: %s: %w", ErrInvalidSession, id, err)
+	}
+
+	var times [3]int64
+	for i, field := range []string{sessionCreatedAtField, sessionRefreshedAtField, sessionExpiresAtField} {
+		times[i], err = strconv.ParseInt(fields[field], 10, 64)
+		if err != nil {
+			return models.Session{}, fmt.Errorf("%s: %s: %w", ErrInvalidSession, id, err)
+		}
+	}
+
+	return models.Session{
+		ID:     id,
+		UserID: userID,
+		Device: models.Device{
+			UserAgent: fields[sessionUserAgentField],
+			IP:        fields[sessionIPField],
+		},
+		CreatedAt:   time.Unix(times[0], 0).UTC(),
+		RefreshedAt: time.Unix(times[1], 0).UTC(),
+		ExpiresAt:   time.Unix(times[2], 0).UTC(),
+	}, nil
+}
@@ -3,67 +3,18 @@ package redisRepo
 import (
 	"strconv"
 	"testing"
+	"time"
+	"trade-bot/internal/pkg/models"
 	"trade-bot/pkg/utils"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 )
 
-func TestJWTRedis_CreateJWT(t *testing.T) {
-	mr, err := miniredis.Run()
-	if err != nil {
-		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer mr.Close()
-
-	c := redis.NewClient(&redis.Options{
-		Addr: mr.Addr(),
-	})
-
-	r := NewJWTRedis(c)
-
-	type args struct {
-		userID int
-		td     utils.TokenDetails
-	}
-
-	tests := []struct {
-		name    string
-		args    args
-		want    string
-		wantErr bool
-	}{
-		{
-			name: "OK",
-			args: args{
-				userID: 1,
-				td: utils.TokenDetails{
-					AccessToken: "token",
-					AccessUUID:  "accessUUID",
-					AtExpires:   1,
-				},
-			},
-			want: "token",
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			got, err := r.CreateJWT(test.args.userID, test.args.td)
-			if test.wantErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, test.want, got)
-			}
-			mr.FlushAll()
-		})
-	}
-}
-
 func TestJWTRedis_GetJWTUserID(t *testing.T) {
 	mr, err := miniredis.Run()
 	if err != nil {
@@ -184,3 +135,143 @@ func TestJWTRedis_DeleteJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestJWTRedis_Sessions(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mr.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	r := NewJWTRedis(c)
+
+	expires := time.Now().Add(time.Hour).Unix()
+	first := utils.TokenDetails{AccessUUID: "access1", AtExpires: expires, RefreshUUID: "refresh1",
+		RtExpires: expires, SessionID: "session"}
+	device := models.Device{UserAgent: "curl", IP: "127.0.0.1"}
+
+	require.NoError(t, r.CreateSession(models.Session{UserID: 1, Device: device}, first))
+
+	userID, err := r.GetJWTUserID(utils.AccessDetails{AccessUUID: "access1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	sessions, err := r.GetSessions(1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "session", sessions[0].ID)
+	assert.Equal(t, device, sessions[0].Device)
+	assert.Equal(t, expires, sessions[0].ExpiresAt.Unix())
+
+	// rotation replaces access token
+	second := utils.TokenDetails{AccessUUID: "access2", AtExpires: expires, RefreshUUID: "refresh2",
+		RtExpires: expires, SessionID: "session"}
+	rotated, revoked, err := r.RotateSession(utils.RefreshDetails{RefreshUUID: "refresh1", SessionID: "session", UserID: 1}, second)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	assert.False(t, revoked)
+
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "access1"})
+	assert.Error(t, err)
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "access2"})
+	assert.NoError(t, err)
+
+	// another user can't rotate the session
+	rotated, revoked, err = r.RotateSession(utils.RefreshDetails{RefreshUUID: "refresh2", SessionID: "session", UserID: 2}, first)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	assert.False(t, revoked)
+
+	// reuse of rotated refresh token revokes the session
+	rotated, revoked, err = r.RotateSession(utils.RefreshDetails{RefreshUUID: "refresh1", SessionID: "session", UserID: 1}, first)
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	assert.True(t, revoked)
+
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "access2"})
+	assert.Error(t, err)
+	sessions, err = r.GetSessions(1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	mr.FlushAll()
+}
+
+func TestJWTRedis_DeleteSession(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mr.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	r := NewJWTRedis(c)
+
+	expires := time.Now().Add(time.Hour).Unix()
+	for i, sessionID := range []string{"phone", "laptop"} {
+		td := utils.TokenDetails{AccessUUID: "access" + strconv.Itoa(i), AtExpires: expires,
+			RefreshUUID: "refresh" + strconv.Itoa(i), RtExpires: expires, SessionID: sessionID}
+		require.NoError(t, r.CreateSession(models.Session{UserID: 1}, td))
+	}
+
+	deleted, err := r.DeleteSession(2, "phone")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = r.DeleteSession(1, "phone")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "access0"})
+	assert.Error(t, err)
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "access1"})
+	assert.NoError(t, err)
+
+	sessions, err := r.GetSessions(1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "laptop", sessions[0].ID)
+}
+
+func TestJWTRedis_DeleteUserSessions(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mr.Close()
+
+	c := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	r := NewJWTRedis(c)
+
+	expires := time.Now().Add(time.Hour).Unix()
+	for i, userID := range []int{1, 1, 2} {
+		td := utils.TokenDetails{AccessUUID: "access" + strconv.Itoa(i), AtExpires: expires,
+			RefreshUUID: "refresh" + strconv.Itoa(i), RtExpires: expires, SessionID: "session" + strconv.Itoa(i)}
+		require.NoError(t, r.CreateSession(models.Session{UserID: userID}, td))
+	}
+
+	revoked, err := r.DeleteUserSessions(1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+
+	for _, accessUUID := range []string{"access0", "access1"} {
+		_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: accessUUID})
+		assert.Error(t, err)
+	}
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "access2"})
+	assert.NoError(t, err)
+
+	sessions, err := r.GetSessions(1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
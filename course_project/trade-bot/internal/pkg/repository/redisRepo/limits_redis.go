@@ -0,0 +1,104 @@
+package redisRepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrIncrementCounter = errors.New("increment counter")
+	ErrResetCounters    = errors.New("reset counters")
+	ErrBlock            = errors.New("block")
+	ErrBlockedFor       = errors.New("blocked for")
+)
+
+const (
+	counterKeyPrefix = "counter:"
+	blockKeyPrefix   = "block:"
+)
+
+// LimitsRedis keeps counters of events in fixed windows and temporary blocks, both expire by themselves
+type LimitsRedis struct {
+	client *redis.Client
+}
+
+func NewLimitsRedis(client *redis.Client) *LimitsRedis {
+	return &LimitsRedis{client: client}
+}
+
+// IncrementCounter counts event in window started by the first event and returns count and time left in window
+func (l *LimitsRedis) IncrementCounter(key string, window time.Duration) (int, time.Duration, error) {
+	ctx := context.Background()
+	key = counterKeyPrefix + key
+
+	var (
+		incr *redis.IntCmd
+		ttl  *redis.DurationCmd
+	)
+	_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", ErrIncrementCounter, err)
+	}
+
+	left := ttl.Val()
+	// window starts with the first event, counter without expiry is left by a failed earlier call
+	if left < 0 {
+		if err := l.client.PExpire(ctx, key, window).Err(); err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", ErrIncrementCounter, err)
+		}
+		left = window
+	}
+
+	return int(incr.Val()), left, nil
+}
+
+func (l *LimitsRedis) ResetCounters(keys ...string) error {
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, counterKeyPrefix+key)
+	}
+
+	if err := l.client.Del(context.Background(), prefixed...).Err(); err != nil {
+		return fmt.Errorf("%s: %w", ErrResetCounters, err)
+	}
+	return nil
+}
+
+// Block blocks key for d, longer existing block is kept
+func (l *LimitsRedis) Block(key string, d time.Duration) error {
+	ctx := context.Background()
+	key = blockKeyPrefix + key
+
+	left, err := l.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrBlock, err)
+	}
+	if left >= d {
+		return nil
+	}
+
+	if err := l.client.Set(ctx, key, 1, d).Err(); err != nil {
+		return fmt.Errorf("%s: %w", ErrBlock, err)
+	}
+	return nil
+}
+
+// BlockedFor returns time left until block of key expires, 0 if key is not blocked
+func (l *LimitsRedis) BlockedFor(key string) (time.Duration, error) {
+	left, err := l.client.PTTL(context.Background(), blockKeyPrefix+key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrBlockedFor, err)
+	}
+	if left < 0 {
+		return 0, nil
+	}
+	return left, nil
+}
@@ -0,0 +1,62 @@
+package redisRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsRedis_IncrementCounter(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	l := NewLimitsRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	for i := 1; i <= 3; i++ {
+		count, left, err := l.IncrementCounter("login:ip:127.0.0.1", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, count)
+		assert.True(t, left > 0 && left <= time.Minute)
+	}
+
+	// window is not prolonged by later events
+	mr.FastForward(time.Minute)
+	count, _, err := l.IncrementCounter("login:ip:127.0.0.1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, l.ResetCounters("login:ip:127.0.0.1", "unknown"))
+	count, _, err = l.IncrementCounter("login:ip:127.0.0.1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestLimitsRedis_Block(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	l := NewLimitsRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	left, err := l.BlockedFor("login:user:username")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), left)
+
+	require.NoError(t, l.Block("login:user:username", 15*time.Minute))
+	// shorter block does not shorten existing one
+	require.NoError(t, l.Block("login:user:username", time.Second))
+
+	left, err = l.BlockedFor("login:user:username")
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, left)
+
+	mr.FastForward(15 * time.Minute)
+	left, err = l.BlockedFor("login:user:username")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), left)
+}
@@ -1,37 +1,108 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/go-redis/redis/v8"
 	"github.com/jmoiron/sqlx"
 
 	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository/memoryRepo"
 	"trade-bot/internal/pkg/repository/postgresRepo"
 	"trade-bot/internal/pkg/repository/redisRepo"
+	"trade-bot/internal/pkg/repository/sqliteRepo"
 	"trade-bot/pkg/utils"
 )
 
 type Authorization interface {
 	CreateUser(models.User) (int, error)
 	GetUser(username string) (models.User, error)
+	GetUserByID(userID int) (models.User, error)
 	GetUserAPIKeys(userID int) (string, string, error)
+	SetTOTPSecret(userID int, secret string) error
+	EnableTOTP(userID int, recoveryCodeHashes []string) error
+	SetRecoveryCodes(userID int, recoveryCodeHashes []string) error
+	DisableTOTP(userID int) error
+	UseTOTPStep(userID int, step int64) (bool, error)
+	UseRecoveryCode(userID int, codeHash string) (bool, error)
+	UpdateUser(userID int, update models.ProfileUpdate) (bool, error)
+	SetPassword(userID int, passwordHash string) (bool, error)
+	DeleteUser(userID int) (bool, error)
 }
 
 type JWT interface {
-	CreateJWT(userID int, td utils.TokenDetails) (string, error)
 	GetJWTUserID(ad utils.AccessDetails) (int, error)
 	DeleteJWT(ad utils.AccessDetails) error
+	CreateSession(session models.Session, td utils.TokenDetails) error
+	RotateSession(rd utils.RefreshDetails, td utils.TokenDetails) (rotated bool, revoked bool, err error)
+	GetSessions(userID int) ([]models.Session, error)
+	DeleteSession(userID int, sessionID string) (bool, error)
+	DeleteUserSessions(userID int) (int, error)
 }
 
 type KrakenOrdersManager interface {
 	CreateOrder(userID int, order models.Order) error
+	CreateOrders(userID int, orders []models.Order) error
 	GetUserOrders(userID int) ([]models.Order, error)
+	FindUserOrders(filter models.OrderFilter) ([]models.Order, error)
 	GetOrder(orderID string) (models.Order, error)
+	GetOrders() ([]models.Order, error)
+	CreateOrderSubmission(submission models.OrderSubmission) (models.OrderSubmission, bool, error)
+	RetryOrderSubmission(cliOrderID string) (bool, error)
+	FailOrderSubmission(cliOrderID string) error
+	GetOrderSubmission(cliOrderID string) (models.OrderSubmission, error)
+}
+
+// TradingSessions records runs of strategies, finished runs with their orders are trades of reports
+type TradingSessions interface {
+	CreateTradingSession(session models.TradingSession) (int, error)
+	FinishTradingSession(id int, finishOrderID, reason string, finishedAt time.Time) error
+	GetTrades(filter models.ReportFilter) ([]models.Trade, error)
+}
+
+type Candles interface {
+	SaveCandles(candles []models.Candle) error
+	GetLastCandles(symbol, candlesType, interval string, n int) ([]models.Candle, error)
+	GetCandles(symbol, candlesType, interval string, from, to time.Time) ([]models.Candle, error)
+}
+
+type Audit interface {
+	CreateAuditEntry(entry models.AuditEntry) error
+	GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type APITokens interface {
+	CreateAPIToken(token models.APIToken) (models.APIToken, error)
+	GetAPITokens(userID int) ([]models.APIToken, error)
+	GetAPITokenByHash(tokenHash string) (models.APIToken, error)
+	TouchAPIToken(id int) error
+	DeleteAPIToken(userID, id int) (bool, error)
+}
+
+type Limits interface {
+	IncrementCounter(key string, window time.Duration) (int, time.Duration, error)
+	ResetCounters(keys ...string) error
+	Block(key string, d time.Duration) error
+	BlockedFor(key string) (time.Duration, error)
+}
+
+type Admin interface {
+	GetUsers() ([]models.UserSummary, error)
+	SetUserRole(userID int, role string) (bool, error)
+	SetUserDisabled(userID int, disabled bool) (bool, error)
+	GetRiskUsage() ([]models.RiskUsage, error)
 }
 
 type Repository struct {
 	Authorization
 	JWT
 	KrakenOrdersManager
+	TradingSessions
+	Candles
+	Audit
+	Admin
+	APITokens
+	Limits
 }
 
 func NewRepository(db *sqlx.DB, jwtDB *redis.Client) *Repository {
@@ -39,5 +110,41 @@ func NewRepository(db *sqlx.DB, jwtDB *redis.Client) *Repository {
 		Authorization:       postgresRepo.NewAuthPostgres(db),
 		JWT:                 redisRepo.NewJWTRedis(jwtDB),
 		KrakenOrdersManager: postgresRepo.NewKrakenOrdersManagerPostgres(db),
+		TradingSessions:     postgresRepo.NewTradingSessionsPostgres(db),
+		Candles:             postgresRepo.NewCandlesPostgres(db),
+		Audit:               postgresRepo.NewAuditPostgres(db),
+		Admin:               postgresRepo.NewAdminPostgres(db),
+		APITokens:           postgresRepo.NewAPITokensPostgres(db),
+		Limits:              redisRepo.NewLimitsRedis(jwtDB),
+	}
+}
+
+// NewSQLiteRepository keeps everything in one sqlite database, including sessions and limits kept in redis otherwise
+func NewSQLiteRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		Authorization:       sqliteRepo.NewAuthSQLite(db),
+		JWT:                 sqliteRepo.NewJWTSQLite(db),
+		KrakenOrdersManager: sqliteRepo.NewKrakenOrdersManagerSQLite(db),
+		TradingSessions:     sqliteRepo.NewTradingSessionsSQLite(db),
+		Candles:             sqliteRepo.NewCandlesSQLite(db),
+		Audit:               sqliteRepo.NewAuditSQLite(db),
+		Admin:               sqliteRepo.NewAdminSQLite(db),
+		APITokens:           sqliteRepo.NewAPITokensSQLite(db),
+		Limits:              sqliteRepo.NewLimitsSQLite(db),
+	}
+}
+
+// NewMemoryRepository keeps everything in memory of db, data is lost when process stops
+func NewMemoryRepository(db *memoryRepo.DB) *Repository {
+	return &Repository{
+		Authorization:       memoryRepo.NewAuthMemory(db),
+		JWT:                 memoryRepo.NewJWTMemory(db),
+		KrakenOrdersManager: memoryRepo.NewKrakenOrdersManagerMemory(db),
+		TradingSessions:     memoryRepo.NewTradingSessionsMemory(db),
+		Candles:             memoryRepo.NewCandlesMemory(db),
+		Audit:               memoryRepo.NewAuditMemory(db),
+		Admin:               memoryRepo.NewAdminMemory(db),
+		APITokens:           memoryRepo.NewAPITokensMemory(db),
+		Limits:              memoryRepo.NewLimitsMemory(db),
 	}
 }
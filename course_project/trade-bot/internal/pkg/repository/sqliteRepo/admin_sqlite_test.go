@@ -0,0 +1,50 @@
+package sqliteRepo
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestAdminSQLite(t *testing.T) {
+	db := newTestDB(t)
+	a := NewAdminSQLite(db)
+	orders := NewKrakenOrdersManagerSQLite(db)
+	userID := createTestUser(t, db, "user")
+	createTestUser(t, db, "other")
+
+	updated, err := a.SetUserRole(userID, "admin")
+	require.NoError(t, err)
+	assert.True(t, updated)
+	_, err = a.SetUserRole(userID, "unknown")
+	assert.Error(t, err)
+
+	updated, err = a.SetUserDisabled(userID, true)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	users, err := a.GetUsers()
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, models.UserSummary{ID: userID, Name: "Name", Username: "user", Role: "admin", Disabled: true}, users[0])
+
+	require.NoError(t, orders.CreateOrders(userID, []models.Order{
+		{ID: "1", UserID: userID, Type: models.PlaceOrder, Quantity: decimal.NewFromInt(10), Filled: decimal.NewFromInt(9),
+			Price: decimal.NewFromInt(100)},
+		{ID: "2", UserID: userID, Type: models.ExecutionOrder, Quantity: decimal.NewFromInt(2), Filled: decimal.NewFromInt(2),
+			Price: decimal.NewFromInt(50)},
+	}))
+
+	usage, err := a.GetRiskUsage()
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.Equal(t, 2, usage[0].Orders)
+	assert.Equal(t, 1, usage[0].OpenOrders)
+	assert.True(t, usage[0].OpenNotional.Equal(decimal.NewFromInt(100)))
+	assert.True(t, usage[0].TradedNotional.Equal(decimal.NewFromInt(100)))
+	assert.Zero(t, usage[1].Orders)
+}
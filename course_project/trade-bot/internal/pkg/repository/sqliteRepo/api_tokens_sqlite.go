@@ -0,0 +1,95 @@
+package sqliteRepo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateAPIToken = errors.New("create api token")
+	ErrGetAPITokens   = errors.New("get api tokens")
+	ErrGetAPIToken    = errors.New("get api token")
+	ErrTouchAPIToken  = errors.New("touch api token")
+	ErrDeleteAPIToken = errors.New("delete api token")
+)
+
+type APITokensSQLite struct {
+	db *sqlx.DB
+}
+
+func NewAPITokensSQLite(db *sqlx.DB) *APITokensSQLite {
+	return &APITokensSQLite{db: db}
+}
+
+const insertAPITokenQuery = `
+	INSERT INTO api_tokens (user_id, name, prefix, token_hash, scopes, allowed_ips, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+// CreateAPIToken stores token and returns it with id and creation time
+func (r *APITokensSQLite) CreateAPIToken(token models.APIToken) (models.APIToken, error) {
+	token.CreatedAt = time.Now().UTC()
+	var expiresAt *time.Time
+	if token.ExpiresAt != nil {
+		utc := token.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	result, err := r.db.Exec(insertAPITokenQuery, token.UserID, token.Name, token.Prefix, token.TokenHash,
+		token.Scopes, token.AllowedIPs, token.CreatedAt, expiresAt)
+	if err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrCreateAPIToken, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrCreateAPIToken, err)
+	}
+	token.ID = int(id)
+	return token, nil
+}
+
+const getAPITokensQuery = `SELECT * FROM api_tokens WHERE user_id = ? ORDER BY id`
+
+func (r *APITokensSQLite) GetAPITokens(userID int) ([]models.APIToken, error) {
+	tokens := []models.APIToken{}
+	if err := r.db.Select(&tokens, getAPITokensQuery, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetAPITokens, err)
+	}
+	return tokens, nil
+}
+
+const getAPITokenByHashQuery = `SELECT * FROM api_tokens WHERE token_hash = ?`
+
+func (r *APITokensSQLite) GetAPITokenByHash(tokenHash string) (models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.Get(&token, getAPITokenByHashQuery, tokenHash); err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrGetAPIToken, err)
+	}
+	return token, nil
+}
+
+const touchAPITokenQuery = `UPDATE api_tokens SET last_used_at = ? WHERE id = ?`
+
+// TouchAPIToken remembers when token was used the last time
+func (r *APITokensSQLite) TouchAPIToken(id int) error {
+	if _, err := r.db.Exec(touchAPITokenQuery, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("%s: %w", ErrTouchAPIToken, err)
+	}
+	return nil
+}
+
+const deleteAPITokenQuery = `DELETE FROM api_tokens WHERE id = ? AND user_id = ?`
+
+// DeleteAPIToken revokes token of user, false is returned if user has no such token
+func (r *APITokensSQLite) DeleteAPIToken(userID, id int) (bool, error) {
+	affected, err := execAffected(r.db, deleteAPITokenQuery, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteAPIToken, err)
+	}
+	return affected == 1, nil
+}
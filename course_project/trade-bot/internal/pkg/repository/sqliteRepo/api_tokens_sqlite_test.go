@@ -0,0 +1,55 @@
+package sqliteRepo
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestAPITokensSQLite(t *testing.T) {
+	db := newTestDB(t)
+	r := NewAPITokensSQLite(db)
+	userID := createTestUser(t, db, "user")
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	token, err := r.CreateAPIToken(models.APIToken{
+		UserID:     userID,
+		Name:       "script",
+		Prefix:     "tbt_abcd",
+		TokenHash:  "hash",
+		Scopes:     models.StringList{models.ScopeReadOrders, models.ScopeTrade},
+		AllowedIPs: models.StringList{"127.0.0.1/32"},
+		ExpiresAt:  &expiresAt,
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, token.ID)
+
+	stored, err := r.GetAPITokenByHash("hash")
+	require.NoError(t, err)
+	assert.Equal(t, token.Scopes, stored.Scopes)
+	assert.Equal(t, token.AllowedIPs, stored.AllowedIPs)
+	require.NotNil(t, stored.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*stored.ExpiresAt))
+	assert.Nil(t, stored.LastUsedAt)
+
+	require.NoError(t, r.TouchAPIToken(token.ID))
+	tokens, err := r.GetAPITokens(userID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.NotNil(t, tokens[0].LastUsedAt)
+
+	deleted, err := r.DeleteAPIToken(userID+1, token.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	deleted, err = r.DeleteAPIToken(userID, token.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = r.GetAPITokenByHash("hash")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,94 @@
+package sqliteRepo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateAuditEntry = errors.New("create audit entry")
+	ErrGetAuditEntries  = errors.New("get audit entries")
+)
+
+const defaultAuditEntriesLimit = 100
+
+// AuditSQLite writes audit log which triggers keep append-only
+type AuditSQLite struct {
+	db *sqlx.DB
+}
+
+func NewAuditSQLite(db *sqlx.DB) *AuditSQLite {
+	return &AuditSQLite{db: db}
+}
+
+const createAuditEntryQuery = `
+	INSERT INTO audit_log(user_id, action, entity_id, details, correlation_id, data, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+func (a *AuditSQLite) CreateAuditEntry(entry models.AuditEntry) error {
+	_, err := a.db.Exec(createAuditEntryQuery, entry.UserID, entry.Action, entry.EntityID, entry.Details,
+		entry.CorrelationID, entry.Data, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateAuditEntry, err)
+	}
+	return nil
+}
+
+const getAuditEntriesQuery = `
+	SELECT id, user_id, action, entity_id, details, correlation_id, data, created_at FROM audit_log`
+
+// GetAuditEntries returns entries matching filter in order they were written
+func (a *AuditSQLite) GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition)
+	}
+
+	if filter.UserID != 0 {
+		where("user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+	if filter.EntityID != "" {
+		where("entity_id = ?", filter.EntityID)
+	}
+	if filter.CorrelationID != "" {
+		where("correlation_id = ?", filter.CorrelationID)
+	}
+	// times are stored in UTC as text, so they are compared in UTC too
+	if !filter.From.IsZero() {
+		where("created_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		where("created_at < ?", filter.To.UTC())
+	}
+
+	query := getAuditEntriesQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditEntriesLimit
+	}
+	args = append(args, limit, filter.Offset)
+	query += " ORDER BY id LIMIT ? OFFSET ?"
+
+	entries := []models.AuditEntry{}
+	if err := a.db.Select(&entries, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetAuditEntries, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,46 @@
+package sqliteRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestAuditSQLite(t *testing.T) {
+	db := newTestDB(t)
+	a := NewAuditSQLite(db)
+
+	require.NoError(t, a.CreateAuditEntry(models.AuditEntry{UserID: 1, Action: models.AuditLogin,
+		Data: models.AuditData(`{"ip":"127.0.0.1"}`)}))
+	require.NoError(t, a.CreateAuditEntry(models.AuditEntry{UserID: 2, Action: models.AuditLogin}))
+	require.NoError(t, a.CreateAuditEntry(models.AuditEntry{UserID: 1, Action: models.AuditLogout,
+		CorrelationID: "correlation"}))
+
+	entries, err := a.GetAuditEntries(models.AuditFilter{UserID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditLogin, entries[0].Action)
+	assert.JSONEq(t, `{"ip":"127.0.0.1"}`, string(entries[0].Data))
+	assert.WithinDuration(t, time.Now(), entries[0].CreatedAt, time.Minute)
+
+	entries, err = a.GetAuditEntries(models.AuditFilter{CorrelationID: "correlation",
+		From: time.Now().Add(-time.Hour).In(time.FixedZone("UTC+3", 3*60*60)), To: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditLogout, entries[0].Action)
+
+	entries, err = a.GetAuditEntries(models.AuditFilter{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].UserID)
+
+	// audit log is append-only
+	_, err = db.Exec(`UPDATE audit_log SET action = 'changed'`)
+	assert.Error(t, err)
+	_, err = db.Exec(`DELETE FROM audit_log`)
+	assert.Error(t, err)
+}
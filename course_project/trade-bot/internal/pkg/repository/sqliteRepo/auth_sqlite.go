@@ -0,0 +1,271 @@
+package sqliteRepo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateUser       = errors.New("create user")
+	ErrSetTOTPSecret    = errors.New("set totp secret")
+	ErrEnableTOTP       = errors.New("enable totp")
+	ErrDisableTOTP      = errors.New("disable totp")
+	ErrUseTOTPStep      = errors.New("use totp step")
+	ErrUseRecoveryCode  = errors.New("use recovery code")
+	ErrSetRecoveryCodes = errors.New("set recovery codes")
+	ErrUpdateUser       = errors.New("update user")
+	ErrSetPassword      = errors.New("set password")
+	ErrDeleteUser       = errors.New("delete user")
+)
+
+type AuthSQLite struct {
+	db *sqlx.DB
+}
+
+func NewAuthSQLite(db *sqlx.DB) *AuthSQLite {
+	return &AuthSQLite{db: db}
+}
+
+const insertUserQuery = `
+	INSERT INTO users (name, username, password_hash, public_api_key, private_api_key) VALUES (?, ?, ?, ?, ?)`
+
+func (r *AuthSQLite) CreateUser(user models.User) (int, error) {
+	result, err := r.db.Exec(insertUserQuery, user.Name, user.Username, user.Password, user.PublicAPIKey, user.PrivateAPIKey)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrCreateUser, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrCreateUser, err)
+	}
+	return int(id), nil
+}
+
+const getUserQuery = "SELECT * FROM users WHERE username = ?"
+
+func (r *AuthSQLite) GetUser(username string) (models.User, error) {
+	var user models.User
+	err := r.db.Get(&user, getUserQuery, username)
+	return user, err
+}
+
+const getUserByIDQuery = "SELECT * FROM users WHERE id = ?"
+
+func (r *AuthSQLite) GetUserByID(userID int) (models.User, error) {
+	var user models.User
+	err := r.db.Get(&user, getUserByIDQuery, userID)
+	return user, err
+}
+
+func (r *AuthSQLite) GetUserAPIKeys(userID int) (string, string, error) {
+	user, err := r.GetUserByID(userID)
+	return user.PublicAPIKey, user.PrivateAPIKey, err
+}
+
+const setTOTPSecretQuery = `
+	UPDATE users SET totp_secret = ?, totp_enabled = false, totp_last_step = 0 WHERE id = ?`
+
+// SetTOTPSecret stores secret of enrolment which is not enabled until it is confirmed by code
+func (r *AuthSQLite) SetTOTPSecret(userID int, secret string) error {
+	if _, err := r.db.Exec(setTOTPSecretQuery, secret, userID); err != nil {
+		return fmt.Errorf("%s: %w", ErrSetTOTPSecret, err)
+	}
+	return nil
+}
+
+const enableTOTPQuery = `UPDATE users SET totp_enabled = true WHERE id = ?`
+
+// EnableTOTP turns two-factor authentication on and replaces recovery codes in one transaction
+func (r *AuthSQLite) EnableTOTP(userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+
+	if _, err := tx.Exec(enableTOTPQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrEnableTOTP, err)
+	}
+	return nil
+}
+
+// SetRecoveryCodes replaces unused and used recovery codes of user
+func (r *AuthSQLite) SetRecoveryCodes(userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrSetRecoveryCodes, err)
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrSetRecoveryCodes, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrSetRecoveryCodes, err)
+	}
+	return nil
+}
+
+const (
+	deleteRecoveryCodesQuery = `DELETE FROM recovery_codes WHERE user_id = ?`
+	insertRecoveryCodeQuery  = `INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)`
+)
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int, hashes []string) error {
+	if _, err := tx.Exec(deleteRecoveryCodesQuery, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec(insertRecoveryCodeQuery, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const disableTOTPQuery = `
+	UPDATE users SET totp_secret = '', totp_enabled = false, totp_last_step = 0 WHERE id = ?`
+
+func (r *AuthSQLite) DisableTOTP(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+
+	if _, err := tx.Exec(disableTOTPQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+	if _, err := tx.Exec(deleteRecoveryCodesQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+	return nil
+}
+
+const useTOTPStepQuery = `UPDATE users SET totp_last_step = ?1 WHERE id = ?2 AND totp_last_step < ?1`
+
+// UseTOTPStep remembers step of accepted code, false is returned if code of this or later step was already used
+func (r *AuthSQLite) UseTOTPStep(userID int, step int64) (bool, error) {
+	affected, err := execAffected(r.db, useTOTPStepQuery, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUseTOTPStep, err)
+	}
+	return affected == 1, nil
+}
+
+const useRecoveryCodeQuery = `
+	UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`
+
+// UseRecoveryCode marks recovery code as used, false is returned if there is no such unused code
+func (r *AuthSQLite) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	affected, err := execAffected(r.db, useRecoveryCodeQuery, time.Now().UTC(), userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUseRecoveryCode, err)
+	}
+	return affected == 1, nil
+}
+
+const updateUserQuery = `
+	UPDATE users SET name = COALESCE(?, name),
+	                 public_api_key = COALESCE(?, public_api_key),
+	                 private_api_key = COALESCE(?, private_api_key)
+	WHERE id = ?`
+
+// UpdateUser changes fields of update which are not nil, false is returned if there is no such user
+func (r *AuthSQLite) UpdateUser(userID int, update models.ProfileUpdate) (bool, error) {
+	affected, err := execAffected(r.db, updateUserQuery, update.Name, update.PublicAPIKey, update.PrivateAPIKey, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrUpdateUser, err)
+	}
+	return affected == 1, nil
+}
+
+const setPasswordQuery = `UPDATE users SET password_hash = ? WHERE id = ?`
+
+// SetPassword replaces password hash of user, false is returned if there is no such user
+func (r *AuthSQLite) SetPassword(userID int, passwordHash string) (bool, error) {
+	affected, err := execAffected(r.db, setPasswordQuery, passwordHash, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrSetPassword, err)
+	}
+	return affected == 1, nil
+}
+
+const (
+	deleteUserOrdersQuery = `
+	DELETE FROM orders WHERE order_id IN (SELECT order_id FROM users_orders WHERE user_id = ?)`
+	deleteUserQuery = `DELETE FROM users WHERE id = ?`
+)
+
+// DeleteUser deletes orders of user and user in one transaction, links in users_orders, order submissions,
+// recovery codes and api tokens are deleted by cascade. False is returned if there is no such user
+func (r *AuthSQLite) DeleteUser(userID int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+
+	if _, err := tx.Exec(deleteUserOrdersQuery, userID); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, ErrCouldNotRollbackTransaction
+		}
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+
+	affected, err := execAffected(tx, deleteUserQuery, userID)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, ErrCouldNotRollbackTransaction
+		}
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteUser, err)
+	}
+	return affected == 1, nil
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// execAffected executes query and returns number of rows it changed
+func execAffected(e execer, query string, args ...interface{}) (int64, error) {
+	result, err := e.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
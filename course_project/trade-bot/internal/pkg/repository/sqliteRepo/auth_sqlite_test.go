@@ -0,0 +1,129 @@
+package sqliteRepo
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestAuthSQLite_CreateUser(t *testing.T) {
+	db := newTestDB(t)
+	r := NewAuthSQLite(db)
+
+	id := createTestUser(t, db, "user")
+
+	user, err := r.GetUser("user")
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "trader", user.Role)
+
+	public, private, err := r.GetUserAPIKeys(id)
+	require.NoError(t, err)
+	assert.Equal(t, "public", public)
+	assert.Equal(t, "private", private)
+
+	_, err = r.CreateUser(models.User{Name: "Name", Username: "user", Password: "hash"})
+	assert.Error(t, err)
+
+	_, err = r.GetUserByID(id + 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAuthSQLite_TOTP(t *testing.T) {
+	db := newTestDB(t)
+	r := NewAuthSQLite(db)
+	id := createTestUser(t, db, "user")
+
+	require.NoError(t, r.SetTOTPSecret(id, "secret"))
+	require.NoError(t, r.EnableTOTP(id, []string{"a", "b"}))
+
+	user, err := r.GetUserByID(id)
+	require.NoError(t, err)
+	assert.True(t, user.TOTPEnabled)
+	assert.Equal(t, "secret", user.TOTPSecret)
+
+	used, err := r.UseTOTPStep(id, 10)
+	require.NoError(t, err)
+	assert.True(t, used)
+	used, err = r.UseTOTPStep(id, 10)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	used, err = r.UseRecoveryCode(id, "a")
+	require.NoError(t, err)
+	assert.True(t, used)
+	used, err = r.UseRecoveryCode(id, "a")
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	require.NoError(t, r.SetRecoveryCodes(id, []string{"a"}))
+	used, err = r.UseRecoveryCode(id, "a")
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	require.NoError(t, r.DisableTOTP(id))
+	user, err = r.GetUserByID(id)
+	require.NoError(t, err)
+	assert.False(t, user.TOTPEnabled)
+	assert.Empty(t, user.TOTPSecret)
+	used, err = r.UseRecoveryCode(id, "b")
+	require.NoError(t, err)
+	assert.False(t, used)
+}
+
+func TestAuthSQLite_UpdateUser(t *testing.T) {
+	db := newTestDB(t)
+	r := NewAuthSQLite(db)
+	id := createTestUser(t, db, "user")
+
+	name := "New name"
+	updated, err := r.UpdateUser(id, models.ProfileUpdate{Name: &name})
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	user, err := r.GetUserByID(id)
+	require.NoError(t, err)
+	assert.Equal(t, "New name", user.Name)
+	assert.Equal(t, "public", user.PublicAPIKey)
+
+	updated, err = r.SetPassword(id, "new hash")
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	updated, err = r.SetPassword(id+1, "new hash")
+	require.NoError(t, err)
+	assert.False(t, updated)
+}
+
+func TestAuthSQLite_DeleteUser(t *testing.T) {
+	db := newTestDB(t)
+	r := NewAuthSQLite(db)
+	orders := NewKrakenOrdersManagerSQLite(db)
+	id := createTestUser(t, db, "user")
+	otherID := createTestUser(t, db, "other")
+
+	require.NoError(t, orders.CreateOrder(id, models.Order{ID: "1", UserID: id}))
+	require.NoError(t, orders.CreateOrder(otherID, models.Order{ID: "2", UserID: otherID}))
+	_, _, err := orders.CreateOrderSubmission(models.OrderSubmission{UserID: id, CliOrderID: "cli"})
+	require.NoError(t, err)
+
+	deleted, err := r.DeleteUser(id)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	all, err := orders.GetOrders()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "2", all[0].ID)
+
+	_, err = orders.GetOrderSubmission("cli")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleted, err = r.DeleteUser(id)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
@@ -0,0 +1,77 @@
+package sqliteRepo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrSaveCandles    = errors.New("save candles")
+	ErrGetLastCandles = errors.New("get last candles")
+	ErrGetCandles     = errors.New("get candles")
+)
+
+type CandlesSQLite struct {
+	db *sqlx.DB
+}
+
+func NewCandlesSQLite(db *sqlx.DB) *CandlesSQLite {
+	return &CandlesSQLite{db: db}
+}
+
+const saveCandleQuery = `
+	INSERT INTO candles(symbol, candles_type, timeframe, time, open, high, low, close, volume)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (symbol, candles_type, timeframe, time) DO UPDATE
+	SET open=excluded.open, high=excluded.high, low=excluded.low, close=excluded.close, volume=excluded.volume`
+
+// SaveCandles inserts candles, already stored ones are updated
+func (r *CandlesSQLite) SaveCandles(candles []models.Candle) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrSaveCandles, err)
+	}
+
+	for _, c := range candles {
+		_, err := tx.Exec(saveCandleQuery, c.Symbol, c.Type, c.Interval, c.Time.UTC(), c.Open, c.High, c.Low, c.Close, c.Volume)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return ErrCouldNotRollbackTransaction
+			}
+			return fmt.Errorf("%s: %w", ErrSaveCandles, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+const getLastCandlesQuery = `
+	SELECT * FROM (
+		SELECT * FROM candles WHERE symbol=? AND candles_type=? AND timeframe=? ORDER BY time DESC LIMIT ?
+	) AS last_candles ORDER BY time`
+
+// GetLastCandles returns n latest candles sorted by time
+func (r *CandlesSQLite) GetLastCandles(symbol, candlesType, interval string, n int) ([]models.Candle, error) {
+	var candles []models.Candle
+	if err := r.db.Select(&candles, getLastCandlesQuery, symbol, candlesType, interval, n); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetLastCandles, err)
+	}
+	return candles, nil
+}
+
+const getCandlesQuery = `
+	SELECT * FROM candles WHERE symbol=? AND candles_type=? AND timeframe=? AND time >= ? AND time < ? ORDER BY time`
+
+// GetCandles returns candles started in [from, to) sorted by time
+func (r *CandlesSQLite) GetCandles(symbol, candlesType, interval string, from, to time.Time) ([]models.Candle, error) {
+	var candles []models.Candle
+	if err := r.db.Select(&candles, getCandlesQuery, symbol, candlesType, interval, from.UTC(), to.UTC()); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetCandles, err)
+	}
+	return candles, nil
+}
@@ -0,0 +1,40 @@
+package sqliteRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestCandlesSQLite(t *testing.T) {
+	r := NewCandlesSQLite(newTestDB(t))
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []models.Candle
+	for i := 0; i < 3; i++ {
+		candles = append(candles, models.Candle{Symbol: "PI_XBTUSD", Type: "trade", Interval: "1m",
+			Time: start.Add(time.Duration(i) * time.Minute), Open: decimal.NewFromInt(int64(i)),
+			Close: decimal.RequireFromString("0.1")})
+	}
+	require.NoError(t, r.SaveCandles(candles))
+
+	candles[2].Close = decimal.RequireFromString("0.2")
+	require.NoError(t, r.SaveCandles(candles[2:]))
+
+	last, err := r.GetLastCandles("PI_XBTUSD", "trade", "1m", 2)
+	require.NoError(t, err)
+	require.Len(t, last, 2)
+	assert.True(t, start.Add(time.Minute).Equal(last[0].Time))
+	assert.Equal(t, "0.2", last[1].Close.String())
+
+	got, err := r.GetCandles("PI_XBTUSD", "trade", "1m", start.In(time.FixedZone("UTC+3", 3*60*60)),
+		start.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.True(t, start.Equal(got[0].Time))
+}
@@ -0,0 +1,269 @@
+package sqliteRepo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/pkg/utils"
+)
+
+var (
+	ErrGetJWTUserID   = errors.New("get jwt user id")
+	ErrDeleteJWT      = errors.New("delete jwt")
+	ErrCreateSession  = errors.New("create session")
+	ErrRotateSession  = errors.New("rotate session")
+	ErrGetSessions    = errors.New("get sessions")
+	ErrDeleteSession  = errors.New("delete session")
+	ErrDeleteSessions = errors.New("delete user sessions")
+)
+
+// JWTSQLite stores access tokens and sessions like redis does, rows expire at expires_at.
+// Expired rows are never returned and are purged when new session is created
+type JWTSQLite struct {
+	db *sqlx.DB
+}
+
+func NewJWTSQLite(db *sqlx.DB) *JWTSQLite {
+	return &JWTSQLite{db: db}
+}
+
+type sessionRow struct {
+	ID          string `db:"id"`
+	UserID      int    `db:"user_id"`
+	UserAgent   string `db:"user_agent"`
+	IP          string `db:"ip"`
+	CreatedAt   int64  `db:"created_at"`
+	RefreshedAt int64  `db:"refreshed_at"`
+	ExpiresAt   int64  `db:"expires_at"`
+	AccessUUID  string `db:"access_uuid"`
+	RefreshUUID string `db:"refresh_uuid"`
+}
+
+func (s sessionRow) session() models.Session {
+	return models.Session{
+		ID:     s.ID,
+		UserID: s.UserID,
+		Device: models.Device{
+			UserAgent: s.UserAgent,
+			IP:        s.IP,
+		},
+		CreatedAt:   time.Unix(s.CreatedAt, 0).UTC(),
+		RefreshedAt: time.Unix(s.RefreshedAt, 0).UTC(),
+		ExpiresAt:   time.Unix(s.ExpiresAt, 0).UTC(),
+	}
+}
+
+const getJWTUserIDQuery = `SELECT user_id FROM access_tokens WHERE uuid = ? AND expires_at > ?`
+
+// GetJWTUserID returns user of alive access token, sql.ErrNoRows is wrapped when token is unknown or expired
+func (r *JWTSQLite) GetJWTUserID(ad utils.AccessDetails) (int, error) {
+	var userID int
+	if err := r.db.Get(&userID, getJWTUserIDQuery, ad.AccessUUID, time.Now().Unix()); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrGetJWTUserID, err)
+	}
+	return userID, nil
+}
+
+const deleteAccessTokenQuery = `DELETE FROM access_tokens WHERE uuid = ?`
+
+func (r *JWTSQLite) DeleteJWT(ad utils.AccessDetails) error {
+	if _, err := r.db.Exec(deleteAccessTokenQuery, ad.AccessUUID); err != nil {
+		return fmt.Errorf("%s: %w", ErrDeleteJWT, err)
+	}
+	return nil
+}
+
+const (
+	purgeAccessTokensQuery = `DELETE FROM access_tokens WHERE expires_at <= ?`
+	purgeSessionsQuery     = `DELETE FROM sessions WHERE expires_at <= ?`
+	insertAccessTokenQuery = `INSERT INTO access_tokens (uuid, user_id, expires_at) VALUES (?, ?, ?)`
+	insertSessionQuery     = `
+	INSERT INTO sessions (id, user_id, user_agent, ip, created_at, refreshed_at, expires_at, access_uuid, refresh_uuid)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+)
+
+// CreateSession stores access token and session of td, session expires together with refresh token
+func (r *JWTSQLite) CreateSession(session models.Session, td utils.TokenDetails) error {
+	now := time.Now().Unix()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateSession, err)
+	}
+
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{purgeAccessTokensQuery, []interface{}{now}},
+		{purgeSessionsQuery, []interface{}{now}},
+		{insertAccessTokenQuery, []interface{}{td.AccessUUID, session.UserID, td.AtExpires}},
+		{insertSessionQuery, []interface{}{td.SessionID, session.UserID, session.UserAgent, session.IP, now, now,
+			td.RtExpires, td.AccessUUID, td.RefreshUUID}},
+	} {
+		if _, err := tx.Exec(q.query, q.args...); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return ErrCouldNotRollbackTransaction
+			}
+			return fmt.Errorf("%s: %w", ErrCreateSession, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateSession, err)
+	}
+	return nil
+}
+
+const (
+	getSessionQuery = `
+	SELECT id, user_id, user_agent, ip, created_at, refreshed_at, expires_at, access_uuid, refresh_uuid
+	FROM sessions WHERE id = ? AND expires_at > ?`
+	deleteSessionQuery       = `DELETE FROM sessions WHERE id = ?`
+	updateSessionTokensQuery = `
+	UPDATE sessions SET refreshed_at = ?, expires_at = ?, access_uuid = ?, refresh_uuid = ? WHERE id = ?`
+)
+
+// RotateSession replaces tokens of session by td if refresh token of rd is the latest one issued for it.
+// Both flags are false when there is no such session. Presenting older refresh token means it was stolen
+// or replayed, so the whole session is revoked and revoked flag is true
+func (r *JWTSQLite) RotateSession(rd utils.RefreshDetails, td utils.TokenDetails) (rotated bool, revoked bool, err error) {
+	now := time.Now().Unix()
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", ErrRotateSession, err)
+	}
+
+	var session sessionRow
+	err = tx.Get(&session, getSessionQuery, rd.SessionID, now)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && int64(session.UserID) != rd.UserID {
+		if err := tx.Rollback(); err != nil {
+			return false, false, ErrCouldNotRollbackTransaction
+		}
+		return false, false, nil
+	}
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, false, ErrCouldNotRollbackTransaction
+		}
+		return false, false, fmt.Errorf("%s: %w", ErrRotateSession, err)
+	}
+
+	if session.RefreshUUID != rd.RefreshUUID {
+		rotated, revoked = false, true
+		_, err = tx.Exec(deleteSessionQuery, session.ID)
+		if err == nil {
+			_, err = tx.Exec(deleteAccessTokenQuery, session.AccessUUID)
+		}
+	} else {
+		rotated, revoked = true, false
+		_, err = tx.Exec(deleteAccessTokenQuery, session.AccessUUID)
+		if err == nil {
+			_, err = tx.Exec(insertAccessTokenQuery, td.AccessUUID, session.UserID, td.AtExpires)
+		}
+		if err == nil {
+			_, err = tx.Exec(updateSessionTokensQuery, now, td.RtExpires, td.AccessUUID, td.RefreshUUID, session.ID)
+		}
+	}
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, false, ErrCouldNotRollbackTransaction
+		}
+		return false, false, fmt.Errorf("%s: %w", ErrRotateSession, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, false, fmt.Errorf("%s: %w", ErrRotateSession, err)
+	}
+	return rotated, revoked, nil
+}
+
+const getUserSessionsQuery = `
+	SELECT id, user_id, user_agent, ip, created_at, refreshed_at, expires_at, access_uuid, refresh_uuid
+	FROM sessions WHERE user_id = ? AND expires_at > ? ORDER BY created_at, id`
+
+// GetSessions returns alive sessions of user
+func (r *JWTSQLite) GetSessions(userID int) ([]models.Session, error) {
+	var rows []sessionRow
+	if err := r.db.Select(&rows, getUserSessionsQuery, userID, time.Now().Unix()); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetSessions, err)
+	}
+
+	sessions := make([]models.Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, row.session())
+	}
+	return sessions, nil
+}
+
+const (
+	deleteSessionAccessTokenQuery = `
+	DELETE FROM access_tokens WHERE uuid = (SELECT access_uuid FROM sessions WHERE id = ? AND user_id = ?)`
+	deleteUserSessionQuery = `DELETE FROM sessions WHERE id = ? AND user_id = ? AND expires_at > ?`
+)
+
+// DeleteSession revokes session of user with its access token, false is returned if user has no such session
+func (r *JWTSQLite) DeleteSession(userID int, sessionID string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteSession, err)
+	}
+
+	var affected int64
+	_, err = tx.Exec(deleteSessionAccessTokenQuery, sessionID, userID)
+	if err == nil {
+		affected, err = execAffected(tx, deleteUserSessionQuery, sessionID, userID, time.Now().Unix())
+	}
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return false, ErrCouldNotRollbackTransaction
+		}
+		return false, fmt.Errorf("%s: %w", ErrDeleteSession, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", ErrDeleteSession, err)
+	}
+	return affected == 1, nil
+}
+
+const (
+	deleteUserAccessTokensQuery = `
+	DELETE FROM access_tokens WHERE uuid IN (SELECT access_uuid FROM sessions WHERE user_id = ?)`
+	deleteUserSessionsQuery = `DELETE FROM sessions WHERE user_id = ?`
+	countUserSessionsQuery  = `SELECT COUNT(*) FROM sessions WHERE user_id = ? AND expires_at > ?`
+)
+
+// DeleteUserSessions revokes every session of user with their access tokens and returns number of revoked sessions
+func (r *JWTSQLite) DeleteUserSessions(userID int) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrDeleteSessions, err)
+	}
+
+	var revoked int
+	err = tx.Get(&revoked, countUserSessionsQuery, userID, time.Now().Unix())
+	if err == nil {
+		_, err = tx.Exec(deleteUserAccessTokensQuery, userID)
+	}
+	if err == nil {
+		_, err = tx.Exec(deleteUserSessionsQuery, userID)
+	}
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, ErrCouldNotRollbackTransaction
+		}
+		return 0, fmt.Errorf("%s: %w", ErrDeleteSessions, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrDeleteSessions, err)
+	}
+	return revoked, nil
+}
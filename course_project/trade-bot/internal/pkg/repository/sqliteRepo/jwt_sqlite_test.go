@@ -0,0 +1,152 @@
+package sqliteRepo
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/pkg/utils"
+)
+
+func testTokenDetails(n string, ttl time.Duration) utils.TokenDetails {
+	now := time.Now()
+	return utils.TokenDetails{
+		AccessUUID:  "access" + n,
+		AtExpires:   now.Add(ttl).Unix(),
+		RefreshUUID: "refresh" + n,
+		RtExpires:   now.Add(ttl).Unix(),
+		SessionID:   "session",
+	}
+}
+
+func TestJWTSQLite_CreateSession(t *testing.T) {
+	r := NewJWTSQLite(newTestDB(t))
+
+	td := testTokenDetails("1", time.Hour)
+	session := models.Session{UserID: 1, Device: models.Device{UserAgent: "agent", IP: "127.0.0.1"}}
+	require.NoError(t, r.CreateSession(session, td))
+
+	userID, err := r.GetJWTUserID(utils.AccessDetails{AccessUUID: td.AccessUUID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	sessions, err := r.GetSessions(1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "session", sessions[0].ID)
+	assert.Equal(t, "agent", sessions[0].UserAgent)
+	assert.Equal(t, time.Unix(td.RtExpires, 0).UTC(), sessions[0].ExpiresAt)
+
+	require.NoError(t, r.DeleteJWT(utils.AccessDetails{AccessUUID: td.AccessUUID}))
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: td.AccessUUID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestJWTSQLite_Expiry(t *testing.T) {
+	db := newTestDB(t)
+	r := NewJWTSQLite(db)
+
+	expired := testTokenDetails("1", -time.Second)
+	require.NoError(t, r.CreateSession(models.Session{UserID: 1}, expired))
+
+	_, err := r.GetJWTUserID(utils.AccessDetails{AccessUUID: expired.AccessUUID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	sessions, err := r.GetSessions(1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	rotated, revoked, err := r.RotateSession(utils.RefreshDetails{RefreshUUID: expired.RefreshUUID, SessionID: "session",
+		UserID: 1}, testTokenDetails("2", time.Hour))
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	assert.False(t, revoked)
+
+	// expired rows are purged when the next session is created
+	td := testTokenDetails("3", time.Hour)
+	td.SessionID = "other"
+	require.NoError(t, r.CreateSession(models.Session{UserID: 1}, td))
+
+	var rows int
+	require.NoError(t, db.Get(&rows, `SELECT COUNT(*) FROM access_tokens`))
+	assert.Equal(t, 1, rows)
+	require.NoError(t, db.Get(&rows, `SELECT COUNT(*) FROM sessions`))
+	assert.Equal(t, 1, rows)
+}
+
+func TestJWTSQLite_RotateSession(t *testing.T) {
+	r := NewJWTSQLite(newTestDB(t))
+
+	first := testTokenDetails("1", time.Hour)
+	require.NoError(t, r.CreateSession(models.Session{UserID: 1}, first))
+
+	second := testTokenDetails("2", time.Hour)
+	rotated, revoked, err := r.RotateSession(utils.RefreshDetails{RefreshUUID: first.RefreshUUID, SessionID: "session",
+		UserID: 1}, second)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+	assert.False(t, revoked)
+
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: first.AccessUUID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	userID, err := r.GetJWTUserID(utils.AccessDetails{AccessUUID: second.AccessUUID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	// session of another user is not rotated
+	rotated, revoked, err = r.RotateSession(utils.RefreshDetails{RefreshUUID: second.RefreshUUID, SessionID: "session",
+		UserID: 2}, testTokenDetails("3", time.Hour))
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	assert.False(t, revoked)
+
+	// reused refresh token revokes session
+	rotated, revoked, err = r.RotateSession(utils.RefreshDetails{RefreshUUID: first.RefreshUUID, SessionID: "session",
+		UserID: 1}, testTokenDetails("3", time.Hour))
+	require.NoError(t, err)
+	assert.False(t, rotated)
+	assert.True(t, revoked)
+
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: second.AccessUUID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	sessions, err := r.GetSessions(1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestJWTSQLite_DeleteSessions(t *testing.T) {
+	r := NewJWTSQLite(newTestDB(t))
+
+	for i, id := range []string{"a", "b", "c"} {
+		td := testTokenDetails(id, time.Hour)
+		td.SessionID = id
+		userID := 1
+		if i == 2 {
+			userID = 2
+		}
+		require.NoError(t, r.CreateSession(models.Session{UserID: userID}, td))
+	}
+
+	deleted, err := r.DeleteSession(2, "a")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = r.DeleteSession(1, "a")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "accessa"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	revoked, err := r.DeleteUserSessions(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	_, err = r.GetJWTUserID(utils.AccessDetails{AccessUUID: "accessb"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	userID, err := r.GetJWTUserID(utils.AccessDetails{AccessUUID: "accessc"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, userID)
+}
@@ -0,0 +1,286 @@
+package sqliteRepo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateOrder           = errors.New("create order")
+	ErrGetUsersOrder         = errors.New("get user orders")
+	ErrFindUserOrders        = errors.New("find user orders")
+	ErrGetOrder              = errors.New("get order")
+	ErrCreateOrders          = errors.New("create orders")
+	ErrGetOrders             = errors.New("get orders")
+	ErrCreateOrderSubmission = errors.New("create order submission")
+	ErrDuplicateCliOrderID   = errors.New("client order id is already used")
+	ErrRetryOrderSubmission  = errors.New("retry order submission")
+	ErrFailOrderSubmission   = errors.New("fail order submission")
+	ErrGetOrderSubmission    = errors.New("get order submission")
+)
+
+type KrakenOrdersManagerSQLite struct {
+	db *sqlx.DB
+}
+
+func NewKrakenOrdersManagerSQLite(db *sqlx.DB) *KrakenOrdersManagerSQLite {
+	return &KrakenOrdersManagerSQLite{db: db}
+}
+
+const orderColumns = `
+	order_id, user_id, cli_order_id, type, symbol, quantity, side, filled, timestamp, last_update_timestamp, price,
+	exchange`
+
+const (
+	createOrderQuery = `
+	INSERT INTO orders(order_id, user_id, cli_order_id, type, symbol, quantity, side, filled,
+	                   timestamp, last_update_timestamp, price, exchange)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	createUsersOrdersQuery = `INSERT INTO users_orders(user_id, order_id) VALUES (?, ?)`
+)
+
+func (k *KrakenOrdersManagerSQLite) CreateOrder(userID int, order models.Order) error {
+	tx, err := k.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateOrder, err)
+	}
+
+	_, err = tx.Exec(createOrderQuery, order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
+		order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange)
+	if err == nil {
+		_, err = tx.Exec(createUsersOrdersQuery, userID, order.ID)
+	}
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return ErrCouldNotRollbackTransaction
+		}
+		return fmt.Errorf("%s: %w", ErrCreateOrder, err)
+	}
+
+	return tx.Commit()
+}
+
+const (
+	upsertOrderQuery = `
+	INSERT INTO orders(order_id, user_id, cli_order_id, type, symbol, quantity, side, filled,
+	                   timestamp, last_update_timestamp, price, exchange)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (order_id) DO UPDATE SET type = excluded.type, quantity = excluded.quantity, filled = excluded.filled,
+	                   last_update_timestamp = excluded.last_update_timestamp, price = excluded.price`
+	createMissingUsersOrdersQuery = `
+	INSERT INTO users_orders(user_id, order_id)
+	SELECT ?1, ?2 WHERE NOT EXISTS (SELECT 1 FROM users_orders WHERE order_id = ?2)`
+	completeOrderSubmissionQuery = `
+	UPDATE order_submissions SET order_id = ?1, failed = false WHERE cli_order_id = ?2 AND ?2 <> ''`
+)
+
+// CreateOrders saves all orders in one transaction, orders already stored are updated.
+// Submissions of orders are completed by their client order id
+func (k *KrakenOrdersManagerSQLite) CreateOrders(userID int, orders []models.Order) error {
+	tx, err := k.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCreateOrders, err)
+	}
+
+	for _, order := range orders {
+		_, err = tx.Exec(upsertOrderQuery, order.ID, order.UserID, order.ClientOrderID, order.Type, order.Symbol, order.Quantity,
+			order.Side, order.Filled, order.Timestamp, order.LastUpdateTimestamp, order.Price, order.Exchange)
+		if err == nil {
+			_, err = tx.Exec(createMissingUsersOrdersQuery, userID, order.ID)
+		}
+		if err == nil {
+			_, err = tx.Exec(completeOrderSubmissionQuery, order.ID, order.ClientOrderID)
+		}
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return ErrCouldNotRollbackTransaction
+			}
+			return fmt.Errorf("%s: %w", ErrCreateOrders, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+const getOrderByIDQuery = `SELECT ` + orderColumns + ` FROM orders WHERE order_id = ?`
+
+// GetOrder returns order by id, sql.ErrNoRows is wrapped when there is no such order
+func (k *KrakenOrdersManagerSQLite) GetOrder(orderID string) (models.Order, error) {
+	var order models.Order
+	if err := k.db.Get(&order, getOrderByIDQuery, orderID); err != nil {
+		return models.Order{}, fmt.Errorf("%s: %w", ErrGetOrder, err)
+	}
+	return order, nil
+}
+
+const getUserOrdersQuery = `SELECT ` + orderColumns + ` FROM orders WHERE user_id = ? ORDER BY rowid`
+
+func (k *KrakenOrdersManagerSQLite) GetUserOrders(userID int) ([]models.Order, error) {
+	var orders []models.Order
+	if err := k.db.Select(&orders, getUserOrdersQuery, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetUsersOrder, err)
+	}
+	return orders, nil
+}
+
+// orderTimeColumn is time of order in UTC with milliseconds, so text of times is compared in time order.
+// Orders placed without timestamp go first like models.Order.Time does, orders_user_id_time_idx is built on it
+const orderTimeColumn = `COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', NULLIF(timestamp, '')), '1970-01-01T00:00:00.000Z')`
+
+// orderTimeLayout formats times the way orderTimeColumn does
+const orderTimeLayout = "2006-01-02T15:04:05.000Z"
+
+const findUserOrdersQuery = `SELECT ` + orderColumns + ` FROM orders`
+
+const defaultOrdersLimit = 100
+
+// FindUserOrders returns orders matching filter sorted by time and id, starting after filter.After
+func (k *KrakenOrdersManagerSQLite) FindUserOrders(filter models.OrderFilter) ([]models.Order, error) {
+	conditions := []string{"user_id = ?"}
+	args := []interface{}{filter.UserID}
+	where := func(condition string, arg interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if filter.Symbol != "" {
+		where("symbol = ?", filter.Symbol)
+	}
+	if filter.Side != "" {
+		where("side = ?", filter.Side)
+	}
+	if filter.Type != "" {
+		where("type = ?", filter.Type)
+	}
+	if !filter.From.IsZero() {
+		where(orderTimeColumn+" >= ?", filter.From.UTC().Format(orderTimeLayout))
+	}
+	if !filter.To.IsZero() {
+		where(orderTimeColumn+" < ?", filter.To.UTC().Format(orderTimeLayout))
+	}
+
+	order, compare := "ASC", ">"
+	if filter.Descending() {
+		order, compare = "DESC", "<"
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, order_id) %s (?, ?)", orderTimeColumn, compare))
+		args = append(args, filter.After.Time.UTC().Format(orderTimeLayout), filter.After.OrderID)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultOrdersLimit
+	}
+	args = append(args, limit)
+
+	query := findUserOrdersQuery + " WHERE " + strings.Join(conditions, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, order_id %s LIMIT ?", orderTimeColumn, order, order)
+
+	orders := []models.Order{}
+	if err := k.db.Select(&orders, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrFindUserOrders, err)
+	}
+	return orders, nil
+}
+
+const getOrdersQuery = `SELECT ` + orderColumns + ` FROM orders ORDER BY rowid`
+
+// GetOrders returns orders of all users
+func (k *KrakenOrdersManagerSQLite) GetOrders() ([]models.Order, error) {
+	var orders []models.Order
+	if err := k.db.Select(&orders, getOrdersQuery); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetOrders, err)
+	}
+	return orders, nil
+}
+
+const (
+	createOrderSubmissionQuery = `
+	INSERT INTO order_submissions(user_id, idempotency_key, cli_order_id, created_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT DO NOTHING`
+	getOrderSubmissionByIdempotencyKeyQuery = `
+	SELECT id, user_id, idempotency_key, cli_order_id, order_id, failed, created_at
+	FROM order_submissions WHERE user_id = ? AND idempotency_key = ?`
+)
+
+// CreateOrderSubmission stores submission and returns true. When submission with the same
+// idempotency key is already stored it is returned instead with false
+func (k *KrakenOrdersManagerSQLite) CreateOrderSubmission(submission models.OrderSubmission) (models.OrderSubmission, bool, error) {
+	submission.CreatedAt = time.Now().UTC()
+	result, err := k.db.Exec(createOrderSubmissionQuery, submission.UserID, submission.IdempotencyKey,
+		submission.CliOrderID, submission.CreatedAt)
+	if err != nil {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %w", ErrCreateOrderSubmission, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %w", ErrCreateOrderSubmission, err)
+	}
+	if affected == 1 {
+		id, err := result.LastInsertId()
+		if err != nil {
+			return models.OrderSubmission{}, false, fmt.Errorf("%s: %w", ErrCreateOrderSubmission, err)
+		}
+		submission.ID = int(id)
+		return submission, true, nil
+	}
+	if submission.IdempotencyKey == "" {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %s: %s", ErrCreateOrderSubmission, ErrDuplicateCliOrderID, submission.CliOrderID)
+	}
+
+	var existing models.OrderSubmission
+	err = k.db.Get(&existing, getOrderSubmissionByIdempotencyKeyQuery, submission.UserID, submission.IdempotencyKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %s: %s", ErrCreateOrderSubmission, ErrDuplicateCliOrderID, submission.CliOrderID)
+	}
+	if err != nil {
+		return models.OrderSubmission{}, false, fmt.Errorf("%s: %w", ErrCreateOrderSubmission, err)
+	}
+
+	return existing, false, nil
+}
+
+const retryOrderSubmissionQuery = `
+	UPDATE order_submissions SET failed = false WHERE cli_order_id = ? AND failed`
+
+// RetryOrderSubmission marks failed submission as pending again,
+// false is returned when submission is not failed, e.g. it is being retried concurrently
+func (k *KrakenOrdersManagerSQLite) RetryOrderSubmission(cliOrderID string) (bool, error) {
+	affected, err := execAffected(k.db, retryOrderSubmissionQuery, cliOrderID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", ErrRetryOrderSubmission, err)
+	}
+	return affected == 1, nil
+}
+
+const failOrderSubmissionQuery = `
+	UPDATE order_submissions SET failed = true WHERE cli_order_id = ? AND order_id = ''`
+
+func (k *KrakenOrdersManagerSQLite) FailOrderSubmission(cliOrderID string) error {
+	if _, err := k.db.Exec(failOrderSubmissionQuery, cliOrderID); err != nil {
+		return fmt.Errorf("%s: %w", ErrFailOrderSubmission, err)
+	}
+	return nil
+}
+
+const getOrderSubmissionQuery = `
+	SELECT id, user_id, idempotency_key, cli_order_id, order_id, failed, created_at
+	FROM order_submissions WHERE cli_order_id = ?`
+
+// GetOrderSubmission returns submission by client order id, sql.ErrNoRows is wrapped when there is no such submission
+func (k *KrakenOrdersManagerSQLite) GetOrderSubmission(cliOrderID string) (models.OrderSubmission, error) {
+	var submission models.OrderSubmission
+	if err := k.db.Get(&submission, getOrderSubmissionQuery, cliOrderID); err != nil {
+		return models.OrderSubmission{}, fmt.Errorf("%s: %w", ErrGetOrderSubmission, err)
+	}
+	return submission, nil
+}
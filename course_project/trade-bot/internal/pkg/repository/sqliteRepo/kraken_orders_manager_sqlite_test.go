@@ -0,0 +1,201 @@
+package sqliteRepo
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestKrakenOrdersManagerSQLite_CreateOrder(t *testing.T) {
+	db := newTestDB(t)
+	k := NewKrakenOrdersManagerSQLite(db)
+	userID := createTestUser(t, db, "user")
+
+	order := models.Order{
+		ID:            "order",
+		UserID:        userID,
+		ClientOrderID: "cli",
+		Type:          models.PlaceOrder,
+		Symbol:        "pi_xbtusd",
+		Quantity:      decimal.RequireFromString("0.1"),
+		Side:          "buy",
+		Filled:        decimal.Zero,
+		Timestamp:     "2022-01-01T00:00:00.000Z",
+		Price:         decimal.RequireFromString("41234.5"),
+		Exchange:      "paper",
+	}
+	require.NoError(t, k.CreateOrder(userID, order))
+	assert.Error(t, k.CreateOrder(userID, order))
+
+	stored, err := k.GetOrder("order")
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", stored.Quantity.String())
+	assert.Equal(t, "41234.5", stored.Price.String())
+	assert.Equal(t, order.Timestamp, stored.Timestamp)
+	assert.Equal(t, "paper", stored.Exchange)
+
+	orders, err := k.GetUserOrders(userID)
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	_, err = k.GetOrder("unknown")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestKrakenOrdersManagerSQLite_CreateOrders(t *testing.T) {
+	db := newTestDB(t)
+	k := NewKrakenOrdersManagerSQLite(db)
+	userID := createTestUser(t, db, "user")
+
+	submission, created, err := k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, CliOrderID: "cli"})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	order := models.Order{ID: "order", UserID: userID, ClientOrderID: "cli", Type: models.PlaceOrder,
+		Quantity: decimal.NewFromInt(2)}
+	require.NoError(t, k.CreateOrders(userID, []models.Order{order}))
+
+	order.Type = models.ExecutionOrder
+	order.Filled = decimal.NewFromInt(2)
+	require.NoError(t, k.CreateOrders(userID, []models.Order{order}))
+
+	orders, err := k.GetOrders()
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, models.ExecutionOrder, orders[0].Type)
+	assert.True(t, orders[0].Filled.Equal(decimal.NewFromInt(2)))
+
+	stored, err := k.GetOrderSubmission("cli")
+	require.NoError(t, err)
+	assert.Equal(t, submission.ID, stored.ID)
+	assert.Equal(t, "order", stored.OrderID)
+}
+
+func TestKrakenOrdersManagerSQLite_OrderSubmission(t *testing.T) {
+	db := newTestDB(t)
+	k := NewKrakenOrdersManagerSQLite(db)
+	userID := createTestUser(t, db, "user")
+
+	first, created, err := k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, IdempotencyKey: "key",
+		CliOrderID: "cli1"})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	existing, created, err := k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, IdempotencyKey: "key",
+		CliOrderID: "cli2"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first.ID, existing.ID)
+	assert.Equal(t, "cli1", existing.CliOrderID)
+
+	_, _, err = k.CreateOrderSubmission(models.OrderSubmission{UserID: userID, CliOrderID: "cli1"})
+	assert.Error(t, err)
+
+	retried, err := k.RetryOrderSubmission("cli1")
+	require.NoError(t, err)
+	assert.False(t, retried)
+
+	require.NoError(t, k.FailOrderSubmission("cli1"))
+	stored, err := k.GetOrderSubmission("cli1")
+	require.NoError(t, err)
+	assert.True(t, stored.Failed)
+
+	retried, err = k.RetryOrderSubmission("cli1")
+	require.NoError(t, err)
+	assert.True(t, retried)
+}
+
+func TestKrakenOrdersManagerSQLite_FindUserOrders(t *testing.T) {
+	db := newTestDB(t)
+	k := NewKrakenOrdersManagerSQLite(db)
+	userID := createTestUser(t, db, "user")
+	otherID := createTestUser(t, db, "other")
+
+	orders := []models.Order{
+		{ID: "a", UserID: userID, Type: models.ExecutionOrder, Symbol: "pi_xbtusd", Side: "buy", Timestamp: "2022-01-02T03:00:00+03:00"},
+		{ID: "b", UserID: userID, Type: models.ExecutionOrder, Symbol: "pi_ethusd", Side: "sell", Timestamp: "2022-01-01T01:00:00.500Z"},
+		{ID: "c", UserID: userID, Type: models.PlaceOrder, Symbol: "pi_xbtusd", Side: "sell", Timestamp: "2022-01-01T01:00:00.500Z"},
+		{ID: "d", UserID: userID, Type: models.ExecutionOrder, Symbol: "pi_xbtusd", Side: "buy"},
+		{ID: "e", UserID: otherID, Type: models.ExecutionOrder, Symbol: "pi_xbtusd", Side: "buy", Timestamp: "2022-01-01T00:00:00Z"},
+	}
+	for _, order := range orders {
+		require.NoError(t, k.CreateOrder(order.UserID, order))
+	}
+
+	ids := func(orders []models.Order) []string {
+		ids := []string{}
+		for _, order := range orders {
+			ids = append(ids, order.ID)
+		}
+		return ids
+	}
+	after := func(id string) *models.OrderCursor {
+		order, err := k.GetOrder(id)
+		require.NoError(t, err)
+		cursor := models.NewOrderCursor(order)
+		return &cursor
+	}
+
+	tests := []struct {
+		name   string
+		filter models.OrderFilter
+		want   []string
+	}{
+		{
+			name:   "All orders sorted by time in UTC",
+			filter: models.OrderFilter{UserID: userID},
+			want:   []string{"d", "b", "c", "a"},
+		},
+		{
+			name:   "Descending",
+			filter: models.OrderFilter{UserID: userID, Sort: models.SortDesc},
+			want:   []string{"a", "c", "b", "d"},
+		},
+		{
+			name:   "Filters",
+			filter: models.OrderFilter{UserID: userID, Symbol: "pi_xbtusd", Side: "sell", Type: models.PlaceOrder},
+			want:   []string{"c"},
+		},
+		{
+			name: "Time range excluding its end",
+			filter: models.OrderFilter{UserID: userID, From: time.Date(2022, 1, 1, 1, 0, 0, 500e6, time.UTC),
+				To: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+			want: []string{"b", "c"},
+		},
+		{
+			name:   "Page after order with the same time",
+			filter: models.OrderFilter{UserID: userID, After: after("b"), Limit: 1},
+			want:   []string{"c"},
+		},
+		{
+			name:   "Descending page",
+			filter: models.OrderFilter{UserID: userID, Sort: models.SortDesc, After: after("b")},
+			want:   []string{"d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, err := k.FindUserOrders(test.filter)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, ids(found))
+		})
+	}
+
+	var plan []struct {
+		ID      int    `db:"id"`
+		Parent  int    `db:"parent"`
+		NotUsed int    `db:"notused"`
+		Detail  string `db:"detail"`
+	}
+	require.NoError(t, db.Select(&plan, `EXPLAIN QUERY PLAN SELECT order_id FROM orders WHERE user_id = 1 ORDER BY `+
+		orderTimeColumn+`, order_id`))
+	require.NotEmpty(t, plan)
+	assert.Contains(t, plan[0].Detail, "orders_user_id_time_idx")
+}
@@ -0,0 +1,118 @@
+package sqliteRepo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrIncrementCounter = errors.New("increment counter")
+	ErrResetCounters    = errors.New("reset counters")
+	ErrBlock            = errors.New("block")
+	ErrBlockedFor       = errors.New("blocked for")
+)
+
+// LimitsSQLite keeps counters of events in fixed windows and temporary blocks, rows are alive until expires_at
+type LimitsSQLite struct {
+	db *sqlx.DB
+}
+
+func NewLimitsSQLite(db *sqlx.DB) *LimitsSQLite {
+	return &LimitsSQLite{db: db}
+}
+
+const (
+	deleteExpiredCounterQuery = `DELETE FROM limit_counters WHERE key = ? AND expires_at <= ?`
+	incrementCounterQuery     = `
+	INSERT INTO limit_counters (key, count, expires_at) VALUES (?, 1, ?)
+	ON CONFLICT (key) DO UPDATE SET count = count + 1`
+	getCounterQuery = `SELECT count, expires_at FROM limit_counters WHERE key = ?`
+)
+
+// IncrementCounter counts event in window started by the first event and returns count and time left in window
+func (l *LimitsSQLite) IncrementCounter(key string, window time.Duration) (int, time.Duration, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	tx, err := l.db.Beginx()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", ErrIncrementCounter, err)
+	}
+
+	var counter struct {
+		Count     int   `db:"count"`
+		ExpiresAt int64 `db:"expires_at"`
+	}
+	_, err = tx.Exec(deleteExpiredCounterQuery, key, now)
+	if err == nil {
+		_, err = tx.Exec(incrementCounterQuery, key, now+window.Milliseconds())
+	}
+	if err == nil {
+		err = tx.Get(&counter, getCounterQuery, key)
+	}
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, 0, ErrCouldNotRollbackTransaction
+		}
+		return 0, 0, fmt.Errorf("%s: %w", ErrIncrementCounter, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", ErrIncrementCounter, err)
+	}
+	return counter.Count, time.Duration(counter.ExpiresAt-now) * time.Millisecond, nil
+}
+
+const resetCounterQuery = `DELETE FROM limit_counters WHERE key = ?`
+
+func (l *LimitsSQLite) ResetCounters(keys ...string) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrResetCounters, err)
+	}
+
+	for _, key := range keys {
+		if _, err := tx.Exec(resetCounterQuery, key); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return ErrCouldNotRollbackTransaction
+			}
+			return fmt.Errorf("%s: %w", ErrResetCounters, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrResetCounters, err)
+	}
+	return nil
+}
+
+const blockQuery = `
+	INSERT INTO limit_blocks (key, expires_at) VALUES (?, ?)
+	ON CONFLICT (key) DO UPDATE SET expires_at = MAX(expires_at, excluded.expires_at)`
+
+// Block blocks key for d, longer existing block is kept
+func (l *LimitsSQLite) Block(key string, d time.Duration) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if _, err := l.db.Exec(blockQuery, key, now+d.Milliseconds()); err != nil {
+		return fmt.Errorf("%s: %w", ErrBlock, err)
+	}
+	return nil
+}
+
+const blockedForQuery = `SELECT COALESCE(MAX(expires_at), 0) FROM limit_blocks WHERE key = ?`
+
+// BlockedFor returns time left until block of key expires, 0 if key is not blocked
+func (l *LimitsSQLite) BlockedFor(key string) (time.Duration, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	var expiresAt int64
+	if err := l.db.Get(&expiresAt, blockedForQuery, key); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrBlockedFor, err)
+	}
+	if expiresAt <= now {
+		return 0, nil
+	}
+	return time.Duration(expiresAt-now) * time.Millisecond, nil
+}
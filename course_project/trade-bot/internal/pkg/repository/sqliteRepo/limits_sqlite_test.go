@@ -0,0 +1,56 @@
+package sqliteRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsSQLite_IncrementCounter(t *testing.T) {
+	l := NewLimitsSQLite(newTestDB(t))
+
+	for i := 1; i <= 3; i++ {
+		count, left, err := l.IncrementCounter("login:ip:127.0.0.1", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, count)
+		assert.True(t, left > 0 && left <= time.Minute)
+	}
+
+	// window is not prolonged by later events and starts again when it is over
+	count, _, err := l.IncrementCounter("short", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	time.Sleep(5 * time.Millisecond)
+	count, _, err = l.IncrementCounter("short", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, l.ResetCounters("login:ip:127.0.0.1", "unknown"))
+	count, _, err = l.IncrementCounter("login:ip:127.0.0.1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestLimitsSQLite_Block(t *testing.T) {
+	l := NewLimitsSQLite(newTestDB(t))
+
+	left, err := l.BlockedFor("user")
+	require.NoError(t, err)
+	assert.Zero(t, left)
+
+	require.NoError(t, l.Block("user", time.Hour))
+	// shorter block does not shorten existing one
+	require.NoError(t, l.Block("user", time.Minute))
+
+	left, err = l.BlockedFor("user")
+	require.NoError(t, err)
+	assert.True(t, left > time.Minute && left <= time.Hour)
+
+	require.NoError(t, l.Block("short", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	left, err = l.BlockedFor("short")
+	require.NoError(t, err)
+	assert.Zero(t, left)
+}
@@ -0,0 +1,185 @@
+CREATE TABLE IF NOT EXISTS users
+(
+    id              integer primary key autoincrement,
+    name            text    not null,
+    username        text    not null unique,
+    password_hash   text    not null,
+    public_api_key  text    not null,
+    private_api_key text    not null,
+    role            text    not null default 'trader' CHECK (role IN ('trader', 'viewer', 'admin')),
+    disabled        boolean not null default false,
+    totp_secret     text    not null default '',
+    totp_enabled    boolean not null default false,
+    totp_last_step  integer not null default 0
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id        integer primary key autoincrement,
+    user_id   integer not null references users (id) on delete cascade,
+    code_hash text    not null,
+    used_at   timestamp
+);
+
+CREATE INDEX IF NOT EXISTS recovery_codes_user_id_idx ON recovery_codes (user_id);
+
+-- decimals are kept as text, numeric affinity would turn them into floats
+CREATE TABLE IF NOT EXISTS orders
+(
+    order_id              text    not null unique,
+    user_id               integer not null,
+    cli_order_id          text    not null,
+    type                  text    not null,
+    symbol                text    not null,
+    quantity              text    not null,
+    side                  text    not null,
+    filled                text    not null,
+    timestamp             text    not null default '',
+    last_update_timestamp text    not null default '',
+    price                 text,
+    exchange              text    not null default 'kraken_futures'
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_idx ON orders (user_id);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_time_idx ON orders
+    (user_id, COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', NULLIF(timestamp, '')), '1970-01-01T00:00:00.000Z'), order_id);
+
+CREATE TABLE IF NOT EXISTS users_orders
+(
+    user_id  integer not null references users (id) on delete cascade,
+    order_id text    not null references orders (order_id) on delete cascade,
+    primary key (user_id, order_id)
+);
+
+CREATE TABLE IF NOT EXISTS order_submissions
+(
+    id              integer primary key autoincrement,
+    user_id         integer   not null references users (id) on delete cascade,
+    idempotency_key text      not null default '',
+    cli_order_id    text      not null unique,
+    order_id        text      not null default '',
+    failed          boolean   not null default false,
+    created_at      timestamp not null
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS order_submissions_user_id_idempotency_key_idx
+    ON order_submissions (user_id, idempotency_key) WHERE idempotency_key <> '';
+
+-- keys stored before they were prefixed by their source, batches used client order ids as keys
+UPDATE order_submissions
+SET idempotency_key = CASE WHEN idempotency_key = cli_order_id THEN 'batch:' ELSE 'request:' END || idempotency_key
+WHERE idempotency_key <> '' AND idempotency_key NOT LIKE 'request:%' AND idempotency_key NOT LIKE 'batch:%';
+
+CREATE TABLE IF NOT EXISTS candles
+(
+    symbol       text      not null,
+    candles_type text      not null,
+    timeframe    text      not null,
+    time         timestamp not null,
+    open         text      not null,
+    high         text      not null,
+    low          text      not null,
+    close        text      not null,
+    volume       text      not null,
+    primary key (symbol, candles_type, timeframe, time)
+);
+
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id             integer primary key autoincrement,
+    user_id        integer   not null default 0,
+    action         text      not null,
+    entity_id      text      not null default '',
+    details        text      not null default '',
+    correlation_id text      not null default '',
+    data           text      not null default '{}',
+    created_at     timestamp not null
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_user_id_created_at_idx ON audit_log (user_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_log_correlation_id_idx ON audit_log (correlation_id);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_append_only_update
+    BEFORE UPDATE
+    ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_append_only_delete
+    BEFORE DELETE
+    ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TABLE IF NOT EXISTS api_tokens
+(
+    id           integer primary key autoincrement,
+    user_id      integer   not null references users (id) on delete cascade,
+    name         text      not null,
+    prefix       text      not null,
+    token_hash   text      not null unique,
+    scopes       text      not null,
+    allowed_ips  text      not null default '',
+    created_at   timestamp not null,
+    expires_at   timestamp,
+    last_used_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS api_tokens_user_id_idx ON api_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS trading_sessions
+(
+    id              integer primary key autoincrement,
+    user_id         integer   not null references users (id) on delete cascade,
+    strategy        text      not null,
+    exchange        text      not null default '',
+    symbol          text      not null,
+    side            text      not null,
+    start_order_id  text      not null,
+    finish_order_id text      not null default '',
+    reason          text      not null default '',
+    started_at      timestamp not null,
+    finished_at     timestamp
+);
+
+CREATE INDEX IF NOT EXISTS trading_sessions_user_id_finished_at_idx ON trading_sessions (user_id, finished_at);
+
+-- access tokens and sessions replace redis keys, rows are alive until expires_at in unix seconds
+CREATE TABLE IF NOT EXISTS access_tokens
+(
+    uuid       text    primary key,
+    user_id    integer not null,
+    expires_at integer not null
+);
+
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id           text    primary key,
+    user_id      integer not null,
+    user_agent   text    not null default '',
+    ip           text    not null default '',
+    created_at   integer not null,
+    refreshed_at integer not null,
+    expires_at   integer not null,
+    access_uuid  text    not null,
+    refresh_uuid text    not null
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+
+-- counters and blocks of limits, expires_at is in unix milliseconds
+CREATE TABLE IF NOT EXISTS limit_counters
+(
+    key        text    primary key,
+    count      integer not null,
+    expires_at integer not null
+);
+
+CREATE TABLE IF NOT EXISTS limit_blocks
+(
+    key        text    primary key,
+    expires_at integer not null
+);
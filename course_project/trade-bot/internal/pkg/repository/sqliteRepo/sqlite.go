@@ -0,0 +1,84 @@
+package sqliteRepo
+
+import (
+	_ "embed" // schema of database
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // driver for sqlx
+	"github.com/pkg/errors"
+
+	"trade-bot/configs"
+)
+
+var (
+	ErrNewSQLiteDB                 = errors.New("new sqlite db")
+	ErrCreateSchema                = errors.New("create schema")
+	ErrCouldNotRollbackTransaction = errors.New("could not rollback transaction")
+)
+
+const defaultPath = "trade-bot.db"
+
+// schema is applied on every start, all statements are idempotent
+//
+//go:embed schema.sql
+var schema string
+
+// addedColumns are added to tables created by older schema, since sqlite can't add column if it doesn't exist
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{table: "orders", column: "exchange", definition: "text not null default 'kraken_futures'"},
+}
+
+// NewSQLiteDB opens database file and creates missing tables. One connection is used,
+// so transactions don't wait for each other and in-memory database is shared by all queries
+func NewSQLiteDB(cfg configs.SQLiteDatabaseConfiguration) (*sqlx.DB, error) {
+	path := cfg.Path
+	if path == "" {
+		path = defaultPath
+	}
+
+	db, err := sqlx.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=1&_busy_timeout=5000", path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrNewSQLiteDB, err)
+	}
+	db.SetMaxOpenConns(1)
+	// in-memory database is gone with its connection
+	db.SetConnMaxLifetime(0)
+	db.SetMaxIdleConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		if err := db.Close(); err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrNewSQLiteDB, err)
+		}
+		return nil, fmt.Errorf("%s: %s: %w", ErrNewSQLiteDB, ErrCreateSchema, err)
+	}
+
+	if err := addColumns(db); err != nil {
+		if err := db.Close(); err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrNewSQLiteDB, err)
+		}
+		return nil, fmt.Errorf("%s: %s: %w", ErrNewSQLiteDB, ErrCreateSchema, err)
+	}
+
+	return db, nil
+}
+
+func addColumns(db *sqlx.DB) error {
+	for _, c := range addedColumns {
+		var exists bool
+		err := db.Get(&exists, `SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
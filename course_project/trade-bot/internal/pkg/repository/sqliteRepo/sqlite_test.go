@@ -0,0 +1,110 @@
+package sqliteRepo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+)
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	db, err := NewSQLiteDB(configs.SQLiteDatabaseConfiguration{Path: ":memory:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	return db
+}
+
+func createTestUser(t *testing.T, db *sqlx.DB, username string) int {
+	id, err := NewAuthSQLite(db).CreateUser(models.User{
+		Name:          "Name",
+		Username:      username,
+		Password:      "hash",
+		PublicAPIKey:  "public",
+		PrivateAPIKey: "private",
+	})
+	require.NoError(t, err)
+	return id
+}
+
+func TestNewSQLiteDB(t *testing.T) {
+	cfg := configs.SQLiteDatabaseConfiguration{Path: filepath.Join(t.TempDir(), "trade-bot.db")}
+
+	db, err := NewSQLiteDB(cfg)
+	require.NoError(t, err)
+	createTestUser(t, db, "user")
+	require.NoError(t, db.Close())
+
+	// schema is applied again on every start and data is kept
+	db, err = NewSQLiteDB(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = NewAuthSQLite(db).GetUser("user")
+	require.NoError(t, err)
+}
+
+func TestNewSQLiteDB_AddsColumnsToOldTables(t *testing.T) {
+	cfg := configs.SQLiteDatabaseConfiguration{Path: filepath.Join(t.TempDir(), "trade-bot.db")}
+
+	old, err := sqlx.Open("sqlite3", cfg.Path)
+	require.NoError(t, err)
+	_, err = old.Exec(`
+	CREATE TABLE orders
+	(
+	    order_id              text    not null unique,
+	    user_id               integer not null,
+	    cli_order_id          text    not null,
+	    type                  text    not null,
+	    symbol                text    not null,
+	    quantity              text    not null,
+	    side                  text    not null,
+	    filled                text    not null,
+	    timestamp             text    not null default '',
+	    last_update_timestamp text    not null default '',
+	    price                 text
+	);
+	INSERT INTO orders VALUES ('id', 1, '', 'PLACE', 'PI_XBTUSD', '1', 'buy', '0', '', '', '100')`)
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	db, err := NewSQLiteDB(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	order, err := NewKrakenOrdersManagerSQLite(db).GetOrder("id")
+	require.NoError(t, err)
+	require.Equal(t, "kraken_futures", order.Exchange)
+}
+
+func TestNewSQLiteDB_PrefixesOldIdempotencyKeys(t *testing.T) {
+	cfg := configs.SQLiteDatabaseConfiguration{Path: filepath.Join(t.TempDir(), "trade-bot.db")}
+
+	db, err := NewSQLiteDB(cfg)
+	require.NoError(t, err)
+	userID := createTestUser(t, db, "user")
+	_, err = db.Exec(`
+	INSERT INTO order_submissions(user_id, idempotency_key, cli_order_id, created_at)
+	VALUES (?1, 'header', 'generated', CURRENT_TIMESTAMP), (?1, 'batch-cli', 'batch-cli', CURRENT_TIMESTAMP),
+	       (?1, 'request:new', 'new', CURRENT_TIMESTAMP)`, userID)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = NewSQLiteDB(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	k := NewKrakenOrdersManagerSQLite(db)
+	for cliOrderID, key := range map[string]string{
+		"generated": "request:header",
+		"batch-cli": "batch:batch-cli",
+		"new":       "request:new",
+	} {
+		submission, err := k.GetOrderSubmission(cliOrderID)
+		require.NoError(t, err)
+		require.Equal(t, key, submission.IdempotencyKey)
+	}
+}
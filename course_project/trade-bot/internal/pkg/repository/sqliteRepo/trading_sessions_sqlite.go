@@ -0,0 +1,84 @@
+package sqliteRepo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrCreateTradingSession = errors.New("create trading session")
+	ErrFinishTradingSession = errors.New("finish trading session")
+	ErrGetTrades            = errors.New("get trades")
+)
+
+type TradingSessionsSQLite struct {
+	db *sqlx.DB
+}
+
+func NewTradingSessionsSQLite(db *sqlx.DB) *TradingSessionsSQLite {
+	return &TradingSessionsSQLite{db: db}
+}
+
+const createTradingSessionQuery = `
+	INSERT INTO trading_sessions (user_id, strategy, exchange, symbol, side, start_order_id, started_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+func (r *TradingSessionsSQLite) CreateTradingSession(session models.TradingSession) (int, error) {
+	result, err := r.db.Exec(createTradingSessionQuery, session.UserID, session.Strategy, session.Exchange, session.Symbol,
+		session.Side, session.StartOrderID, session.StartedAt.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrCreateTradingSession, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrCreateTradingSession, err)
+	}
+	return int(id), nil
+}
+
+const finishTradingSessionQuery = `
+	UPDATE trading_sessions SET finish_order_id = ?, reason = ?, finished_at = ? WHERE id = ?`
+
+func (r *TradingSessionsSQLite) FinishTradingSession(id int, finishOrderID, reason string, finishedAt time.Time) error {
+	if _, err := r.db.Exec(finishTradingSessionQuery, finishOrderID, reason, finishedAt.UTC(), id); err != nil {
+		return fmt.Errorf("%s: %w", ErrFinishTradingSession, err)
+	}
+	return nil
+}
+
+const getTradesQuery = `
+	SELECT s.id, s.user_id, s.strategy, s.exchange, s.symbol, s.side, s.start_order_id, s.finish_order_id, s.reason,
+	       s.started_at, s.finished_at,
+	       COALESCE(entry_order.price, '0') AS entry_price, COALESCE(exit_order.price, '0') AS exit_price,
+	       entry_order.quantity, entry_order.filled
+	FROM trading_sessions s
+	         JOIN orders entry_order ON entry_order.order_id = s.start_order_id
+	         JOIN orders exit_order ON exit_order.order_id = s.finish_order_id
+	WHERE s.finished_at IS NOT NULL AND s.user_id = ?`
+
+// GetTrades returns sessions of user finished in the range of filter which closed position, in order they finished
+func (r *TradingSessionsSQLite) GetTrades(filter models.ReportFilter) ([]models.Trade, error) {
+	query := getTradesQuery
+	args := []interface{}{filter.UserID}
+	if !filter.From.IsZero() {
+		query += " AND s.finished_at >= ?"
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query += " AND s.finished_at < ?"
+		args = append(args, filter.To.UTC())
+	}
+	query += " ORDER BY s.finished_at, s.id"
+
+	trades := []models.Trade{}
+	if err := r.db.Select(&trades, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetTrades, err)
+	}
+	return trades, nil
+}
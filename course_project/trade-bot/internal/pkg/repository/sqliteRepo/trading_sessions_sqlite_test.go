@@ -0,0 +1,68 @@
+package sqliteRepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func TestTradingSessionsSQLite_GetTrades(t *testing.T) {
+	db := newTestDB(t)
+	k := NewKrakenOrdersManagerSQLite(db)
+	r := NewTradingSessionsSQLite(db)
+	userID := createTestUser(t, db, "user")
+	otherID := createTestUser(t, db, "other")
+
+	orders := []models.Order{
+		{ID: "entry", UserID: userID, Side: "buy", Quantity: decimal.NewFromInt(2), Filled: decimal.NewFromInt(1),
+			Price: decimal.RequireFromString("100.5")},
+		{ID: "exit", UserID: userID, Side: "sell", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(110)},
+	}
+	require.NoError(t, k.CreateOrders(userID, orders))
+
+	start := time.Date(2022, 1, 1, 10, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	session := models.TradingSession{UserID: userID, Strategy: "stop_loss_take_profit", Exchange: "paper",
+		Symbol: "PI_XBTUSD", Side: "buy", StartOrderID: "entry", StartedAt: start}
+
+	finished, err := r.CreateTradingSession(session)
+	require.NoError(t, err)
+	require.NoError(t, r.FinishTradingSession(finished, "exit", "take profit", start.Add(time.Hour)))
+
+	// running and stopped sessions and sessions of other users are not trades
+	_, err = r.CreateTradingSession(session)
+	require.NoError(t, err)
+	stopped, err := r.CreateTradingSession(session)
+	require.NoError(t, err)
+	require.NoError(t, r.FinishTradingSession(stopped, "", models.TradingSessionStopped, start.Add(time.Hour)))
+	session.UserID = otherID
+	other, err := r.CreateTradingSession(session)
+	require.NoError(t, err)
+	require.NoError(t, r.FinishTradingSession(other, "exit", "take profit", start.Add(time.Hour)))
+
+	trades, err := r.GetTrades(models.ReportFilter{UserID: userID})
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	trade := trades[0]
+	assert.Equal(t, finished, trade.ID)
+	assert.Equal(t, "take profit", trade.Reason)
+	assert.True(t, trade.StartedAt.Equal(start))
+	require.NotNil(t, trade.FinishedAt)
+	assert.True(t, trade.FinishedAt.Equal(start.Add(time.Hour)))
+	assert.Equal(t, "100.5", trade.EntryPrice.String())
+	assert.Equal(t, "110", trade.ExitPrice.String())
+	assert.Equal(t, "2", trade.Quantity.String())
+	assert.Equal(t, "1", trade.Filled.String())
+
+	// range is compared in UTC, To is exclusive
+	trades, err = r.GetTrades(models.ReportFilter{UserID: userID, From: start.Add(time.Hour).UTC()})
+	require.NoError(t, err)
+	assert.Len(t, trades, 1)
+	trades, err = r.GetTrades(models.ReportFilter{UserID: userID, To: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, trades)
+}
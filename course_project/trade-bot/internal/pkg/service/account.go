@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+)
+
+var (
+	ErrGetProfile         = errors.New("get profile")
+	ErrUpdateProfile      = errors.New("update profile")
+	ErrChangePassword     = errors.New("change password")
+	ErrDeleteAccount      = errors.New("delete account")
+	ErrEmptyProfileUpdate = errors.New("nothing to update")
+	ErrSamePassword       = errors.New("new password must differ from the current one")
+)
+
+// maskedAPIKeyVisibleLen is number of trailing characters of private api key shown in profile
+const maskedAPIKeyVisibleLen = 4
+
+// AccountService lets users manage their own accounts
+type AccountService struct {
+	repo    repository.Authorization
+	jwtRepo repository.JWT
+	journal Journal
+}
+
+func NewAccountService(repo repository.Authorization, jwtRepo repository.JWT, journal Journal) *AccountService {
+	return &AccountService{repo: repo, jwtRepo: jwtRepo, journal: journal}
+}
+
+type profileEvent struct {
+	Fields []string `json:"fields"`
+}
+
+type passwordEvent struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+func (a *AccountService) GetProfile(userID int) (models.Profile, error) {
+	user, err := a.repo.GetUserByID(userID)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", ErrGetProfile, err)
+	}
+	return newProfile(user), nil
+}
+
+// UpdateProfile changes name and api keys of user, values of api keys are not written to audit
+func (a *AccountService) UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (models.Profile, error) {
+	var fields []string
+	if update.Name != nil {
+		fields = append(fields, "name")
+	}
+	if update.PublicAPIKey != nil {
+		fields = append(fields, "public_api_key")
+	}
+	if update.PrivateAPIKey != nil {
+		fields = append(fields, "private_api_key")
+	}
+	if len(fields) == 0 {
+		return models.Profile{}, fmt.Errorf("%s: %w", ErrUpdateProfile, ErrEmptyProfileUpdate)
+	}
+
+	found, err := a.repo.UpdateUser(userID, update)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", ErrUpdateProfile, err)
+	}
+	if !found {
+		return models.Profile{}, fmt.Errorf("%s: %w", ErrUpdateProfile, ErrUserNotFound)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditProfileUpdated}, profileEvent{Fields: fields})
+
+	user, err := a.repo.GetUserByID(userID)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", ErrUpdateProfile, err)
+	}
+	return newProfile(user), nil
+}
+
+// ChangePassword replaces password after checking the current one and revokes every session of user,
+// devices have to sign in again with the new password
+func (a *AccountService) ChangePassword(ctx context.Context, userID int, password, newPassword string) error {
+	user, err := a.repo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrChangePassword, err)
+	}
+	if !user.ComparePassword(password) {
+		return fmt.Errorf("%s: %w", ErrChangePassword, ErrMismatchedPassword)
+	}
+	if password == newPassword {
+		return fmt.Errorf("%s: %w", ErrChangePassword, ErrSamePassword)
+	}
+
+	if err := user.GeneratePasswordHash(newPassword); err != nil {
+		return fmt.Errorf("%s: %w", ErrChangePassword, err)
+	}
+	found, err := a.repo.SetPassword(userID, user.Password)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrChangePassword, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", ErrChangePassword, ErrUserNotFound)
+	}
+
+	revoked, err := a.jwtRepo.DeleteUserSessions(userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrChangePassword, err)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditPasswordChanged},
+		passwordEvent{RevokedSessions: revoked})
+	return nil
+}
+
+// DeleteAccount deletes user with orders after checking password, sessions are revoked first
+// so no request of user is served while account is deleted
+func (a *AccountService) DeleteAccount(ctx context.Context, userID int, password string) error {
+	user, err := a.repo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrDeleteAccount, err)
+	}
+	if !user.ComparePassword(password) {
+		return fmt.Errorf("%s: %w", ErrDeleteAccount, ErrMismatchedPassword)
+	}
+
+	if _, err := a.jwtRepo.DeleteUserSessions(userID); err != nil {
+		return fmt.Errorf("%s: %w", ErrDeleteAccount, err)
+	}
+
+	found, err := a.repo.DeleteUser(userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrDeleteAccount, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", ErrDeleteAccount, ErrUserNotFound)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditAccountDeleted, EntityID: user.Username}, nil)
+	return nil
+}
+
+func newProfile(user models.User) models.Profile {
+	return models.Profile{
+		ID:            user.ID,
+		Name:          user.Name,
+		Username:      user.Username,
+		Role:          user.Role,
+		PublicAPIKey:  user.PublicAPIKey,
+		PrivateAPIKey: maskAPIKey(user.PrivateAPIKey),
+		TOTPEnabled:   user.TOTPEnabled,
+	}
+}
+
+// maskAPIKey hides all but the last characters of key
+func maskAPIKey(key string) string {
+	if len(key) <= maskedAPIKeyVisibleLen {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", len(key)-maskedAPIKeyVisibleLen) + key[len(key)-maskedAPIKeyVisibleLen:]
+}
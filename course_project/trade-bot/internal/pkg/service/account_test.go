@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+func (r *authRepoStub) SetPassword(userID int, passwordHash string) (bool, error) {
+	r.user.Password = passwordHash
+	return true, nil
+}
+
+func (j *jwtRepoStub) DeleteUserSessions(userID int) (int, error) {
+	revoked := j.sessions
+	j.sessions = 0
+	return revoked, nil
+}
+
+func TestAccountService_ChangePassword(t *testing.T) {
+	user := models.User{ID: 1, Username: "username"}
+	require.NoError(t, user.GeneratePasswordHash("qwerty"))
+
+	repo := &authRepoStub{user: user}
+	jwtRepo := &jwtRepoStub{sessions: 2}
+	audit := &auditRepoStub{}
+	a := NewAccountService(repo, jwtRepo, NewJournalService(audit))
+
+	err := a.ChangePassword(context.Background(), 1, "wrong", "new password")
+	assert.True(t, errors.Is(err, ErrMismatchedPassword))
+	err = a.ChangePassword(context.Background(), 1, "qwerty", "qwerty")
+	assert.True(t, errors.Is(err, ErrSamePassword))
+	assert.Equal(t, 2, jwtRepo.sessions)
+
+	require.NoError(t, a.ChangePassword(context.Background(), 1, "qwerty", "new password"))
+	assert.True(t, repo.user.ComparePassword("new password"))
+	assert.Equal(t, 0, jwtRepo.sessions)
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, models.AuditPasswordChanged, audit.entries[0].Action)
+	assert.JSONEq(t, `{"revoked_sessions":2}`, string(audit.entries[0].Data))
+}
+
+func TestAccountService_GetProfile(t *testing.T) {
+	repo := &authRepoStub{user: models.User{ID: 1, Name: "name", Username: "username", Role: models.RoleTrader,
+		PublicAPIKey: "public", PrivateAPIKey: "private-key"}}
+	a := NewAccountService(repo, &jwtRepoStub{}, NewJournalService(&auditRepoStub{}))
+
+	profile, err := a.GetProfile(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.Profile{ID: 1, Name: "name", Username: "username", Role: models.RoleTrader,
+		PublicAPIKey: "public", PrivateAPIKey: "*******-key"}, profile)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+)
+
+var (
+	ErrGetUsers       = errors.New("get users")
+	ErrSetUserRole    = errors.New("set user role")
+	ErrDisableUser    = errors.New("disable user")
+	ErrEnableUser     = errors.New("enable user")
+	ErrForceLogout    = errors.New("force logout")
+	ErrGetAllSessions = errors.New("get all sessions")
+	ErrGetRiskUsage   = errors.New("get risk usage")
+	ErrUnknownRole    = errors.New("unknown role")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrModifySelf     = errors.New("admin can't change role of or disable own account")
+)
+
+// AdminService lets admins manage accounts of other users
+type AdminService struct {
+	repo    repository.Admin
+	jwtRepo repository.JWT
+	journal Journal
+}
+
+func NewAdminService(repo repository.Admin, jwtRepo repository.JWT, journal Journal) *AdminService {
+	return &AdminService{repo: repo, jwtRepo: jwtRepo, journal: journal}
+}
+
+type adminEvent struct {
+	AdminID int    `json:"admin_id"`
+	Role    string `json:"role,omitempty"`
+}
+
+func (a *AdminService) GetUsers() ([]models.UserSummary, error) {
+	users, err := a.repo.GetUsers()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetUsers, err)
+	}
+	return users, nil
+}
+
+func (a *AdminService) SetUserRole(ctx context.Context, adminID, userID int, role string) error {
+	if !models.IsRole(role) {
+		return fmt.Errorf("%s: %w: %s", ErrSetUserRole, ErrUnknownRole, role)
+	}
+	if adminID == userID {
+		return fmt.Errorf("%s: %w", ErrSetUserRole, ErrModifySelf)
+	}
+
+	found, err := a.repo.SetUserRole(userID, role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrSetUserRole, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", ErrSetUserRole, ErrUserNotFound)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditRoleChanged},
+		adminEvent{AdminID: adminID, Role: role})
+	return nil
+}
+
+// DisableUser blocks sign in of user and revokes all sessions of user
+func (a *AdminService) DisableUser(ctx context.Context, adminID, userID int) error {
+	if adminID == userID {
+		return fmt.Errorf("%s: %w", ErrDisableUser, ErrModifySelf)
+	}
+
+	found, err := a.repo.SetUserDisabled(userID, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrDisableUser, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", ErrDisableUser, ErrUserNotFound)
+	}
+	if _, err := a.jwtRepo.DeleteUserSessions(userID); err != nil {
+		return fmt.Errorf("%s: %w", ErrDisableUser, err)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditUserDisabled}, adminEvent{AdminID: adminID})
+	return nil
+}
+
+func (a *AdminService) EnableUser(ctx context.Context, adminID, userID int) error {
+	found, err := a.repo.SetUserDisabled(userID, false)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrEnableUser, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", ErrEnableUser, ErrUserNotFound)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditUserEnabled}, adminEvent{AdminID: adminID})
+	return nil
+}
+
+// ForceLogout revokes every session of user and returns number of revoked sessions
+func (a *AdminService) ForceLogout(ctx context.Context, adminID, userID int) (int, error) {
+	revoked, err := a.jwtRepo.DeleteUserSessions(userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrForceLogout, err)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditForcedLogout}, adminEvent{AdminID: adminID})
+	return revoked, nil
+}
+
+// GetAllSessions returns sessions of every user ordered by user and creation time
+func (a *AdminService) GetAllSessions() ([]models.Session, error) {
+	users, err := a.repo.GetUsers()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetAllSessions, err)
+	}
+
+	sessions := []models.Session{}
+	for _, user := range users {
+		userSessions, err := a.jwtRepo.GetSessions(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrGetAllSessions, err)
+		}
+		sort.Slice(userSessions, func(i, j int) bool {
+			return userSessions[i].CreatedAt.Before(userSessions[j].CreatedAt)
+		})
+		sessions = append(sessions, userSessions...)
+	}
+	return sessions, nil
+}
+
+func (a *AdminService) GetRiskUsage() ([]models.RiskUsage, error) {
+	usage, err := a.repo.GetRiskUsage()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetRiskUsage, err)
+	}
+	return usage, nil
+}
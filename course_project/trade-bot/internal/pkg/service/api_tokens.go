@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/pkg/utils"
+)
+
+var (
+	ErrCreateAPIToken         = errors.New("create api token")
+	ErrGetAPITokens           = errors.New("get api tokens")
+	ErrRevokeAPIToken         = errors.New("revoke api token")
+	ErrAuthenticateAPIToken   = errors.New("authenticate api token")
+	ErrAPITokenNotFound       = errors.New("api token not found")
+	ErrInvalidAPIToken        = errors.New("invalid api token")
+	ErrAPITokenExpired        = errors.New("api token is expired")
+	ErrAPITokenIPNotAllowed   = errors.New("ip is not allowed for api token")
+	ErrUnknownScope           = errors.New("unknown scope")
+	ErrScopeNotPermitted      = errors.New("role of user does not permit scope")
+	ErrInvalidIPAllowlistItem = errors.New("invalid ip allowlist item")
+)
+
+// APITokenService manages long-lived api tokens of users, tokens are shown once and stored hashed
+type APITokenService struct {
+	repo     repository.APITokens
+	authRepo repository.Authorization
+	journal  Journal
+}
+
+func NewAPITokenService(repo repository.APITokens, authRepo repository.Authorization, journal Journal) *APITokenService {
+	return &APITokenService{repo: repo, authRepo: authRepo, journal: journal}
+}
+
+type apiTokenEvent struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// CreateAPIToken issues token with scopes allowed by role of user, plain token is returned only here
+func (a *APITokenService) CreateAPIToken(ctx context.Context, userID int, input models.APITokenInput) (models.NewAPIToken, error) {
+	user, err := a.authRepo.GetUserByID(userID)
+	if err != nil {
+		return models.NewAPIToken{}, fmt.Errorf("%s: %w", ErrCreateAPIToken, err)
+	}
+
+	scopes := make([]string, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		if !models.IsScope(scope) {
+			return models.NewAPIToken{}, fmt.Errorf("%s: %w: %s", ErrCreateAPIToken, ErrUnknownScope, scope)
+		}
+		if !models.HasPermission(user.Role, models.ScopePermission(scope)) {
+			return models.NewAPIToken{}, fmt.Errorf("%s: %w: %s", ErrCreateAPIToken, ErrScopeNotPermitted, scope)
+		}
+		if !contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	allowedIPs, err := normalizeIPAllowlist(input.AllowedIPs)
+	if err != nil {
+		return models.NewAPIToken{}, fmt.Errorf("%s: %w", ErrCreateAPIToken, err)
+	}
+
+	plain, prefix, err := utils.GenerateAPIToken()
+	if err != nil {
+		return models.NewAPIToken{}, fmt.Errorf("%s: %w", ErrCreateAPIToken, err)
+	}
+
+	token := models.APIToken{
+		UserID:     userID,
+		Name:       input.Name,
+		Prefix:     prefix,
+		TokenHash:  utils.HashToken(plain),
+		Scopes:     scopes,
+		AllowedIPs: allowedIPs,
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().UTC().AddDate(0, 0, input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	token, err = a.repo.CreateAPIToken(token)
+	if err != nil {
+		return models.NewAPIToken{}, fmt.Errorf("%s: %w", ErrCreateAPIToken, err)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditAPITokenCreated,
+		EntityID: token.Prefix}, apiTokenEvent{Name: token.Name, Scopes: scopes})
+	return models.NewAPIToken{Token: plain, APIToken: token}, nil
+}
+
+func (a *APITokenService) GetAPITokens(userID int) ([]models.APIToken, error) {
+	tokens, err := a.repo.GetAPITokens(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetAPITokens, err)
+	}
+	return tokens, nil
+}
+
+func (a *APITokenService) RevokeAPIToken(ctx context.Context, userID, id int) error {
+	deleted, err := a.repo.DeleteAPIToken(userID, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrRevokeAPIToken, err)
+	}
+	if !deleted {
+		return fmt.Errorf("%s: %w", ErrRevokeAPIToken, ErrAPITokenNotFound)
+	}
+
+	a.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditAPITokenRevoked,
+		EntityID: fmt.Sprint(id)}, nil)
+	return nil
+}
+
+// AuthenticateAPIToken returns stored token if it is not expired, ip is in its allowlist
+// and its user is not disabled
+func (a *APITokenService) AuthenticateAPIToken(token, ip string) (models.APIToken, error) {
+	stored, err := a.repo.GetAPITokenByHash(utils.HashToken(token))
+	if err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrAuthenticateAPIToken, ErrInvalidAPIToken)
+	}
+	if stored.ExpiresAt != nil && time.Now().After(*stored.ExpiresAt) {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrAuthenticateAPIToken, ErrAPITokenExpired)
+	}
+	if !ipAllowed(stored.AllowedIPs, ip) {
+		return models.APIToken{}, fmt.Errorf("%s: %w: %s", ErrAuthenticateAPIToken, ErrAPITokenIPNotAllowed, ip)
+	}
+
+	user, err := a.authRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrAuthenticateAPIToken, err)
+	}
+	if user.Disabled {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrAuthenticateAPIToken, ErrUserDisabled)
+	}
+
+	if err := a.repo.TouchAPIToken(stored.ID); err != nil {
+		return models.APIToken{}, fmt.Errorf("%s: %w", ErrAuthenticateAPIToken, err)
+	}
+	return stored, nil
+}
+
+// normalizeIPAllowlist turns every ip and CIDR range into CIDR range
+func normalizeIPAllowlist(items []string) ([]string, error) {
+	networks := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+
+		if ip := net.ParseIP(item); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String())
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidIPAllowlistItem, item)
+		}
+		networks = append(networks, network.String())
+	}
+	return networks, nil
+}
+
+func ipAllowed(networks []string, ip string) bool {
+	if len(networks) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, cidr := range networks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+)
+
+type apiTokensRepoStub struct {
+	repository.APITokens
+	tokens map[string]models.APIToken
+}
+
+func (r *apiTokensRepoStub) CreateAPIToken(token models.APIToken) (models.APIToken, error) {
+	token.ID = len(r.tokens) + 1
+	r.tokens[token.TokenHash] = token
+	return token, nil
+}
+
+func (r *apiTokensRepoStub) GetAPITokenByHash(tokenHash string) (models.APIToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return models.APIToken{}, errors.New("no rows")
+	}
+	return token, nil
+}
+
+func (r *apiTokensRepoStub) TouchAPIToken(id int) error {
+	return nil
+}
+
+func TestAPITokenService_CreateAPIToken(t *testing.T) {
+	repo := &apiTokensRepoStub{tokens: map[string]models.APIToken{}}
+	authRepo := &authRepoStub{user: models.User{ID: 1, Role: models.RoleTrader}}
+	a := NewAPITokenService(repo, authRepo, NewJournalService(&auditRepoStub{}))
+
+	tests := []struct {
+		name    string
+		input   models.APITokenInput
+		wantErr error
+	}{
+		{
+			name:    "Unknown scope",
+			input:   models.APITokenInput{Name: "ci", Scopes: []string{"write-everything"}},
+			wantErr: ErrUnknownScope,
+		},
+		{
+			name:    "Scope above role",
+			input:   models.APITokenInput{Name: "ci", Scopes: []string{models.ScopeAdmin}},
+			wantErr: ErrScopeNotPermitted,
+		},
+		{
+			name:    "Invalid allowlist",
+			input:   models.APITokenInput{Name: "ci", Scopes: []string{models.ScopeTrade}, AllowedIPs: []string{"10.0.0"}},
+			wantErr: ErrInvalidIPAllowlistItem,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := a.CreateAPIToken(context.Background(), 1, test.input)
+			assert.True(t, errors.Is(err, test.wantErr))
+		})
+	}
+
+	token, err := a.CreateAPIToken(context.Background(), 1, models.APITokenInput{Name: "ci",
+		Scopes:     []string{models.ScopeTrade, models.ScopeReadOrders, models.ScopeTrade},
+		AllowedIPs: []string{"192.0.2.1", " 10.1.2.3/8 ", "2001:db8::1"}, ExpiresInDays: 30})
+	require.NoError(t, err)
+
+	assert.Equal(t, token.Prefix, token.Token[:len(token.Prefix)])
+	assert.NotContains(t, token.TokenHash, token.Token)
+	assert.Equal(t, models.StringList{models.ScopeTrade, models.ScopeReadOrders}, token.Scopes)
+	assert.Equal(t, models.StringList{"192.0.2.1/32", "10.0.0.0/8", "2001:db8::1/128"}, token.AllowedIPs)
+	require.NotNil(t, token.ExpiresAt)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *token.ExpiresAt, time.Minute)
+}
+
+func TestAPITokenService_AuthenticateAPIToken(t *testing.T) {
+	repo := &apiTokensRepoStub{tokens: map[string]models.APIToken{}}
+	authRepo := &authRepoStub{user: models.User{ID: 1, Role: models.RoleTrader}}
+	a := NewAPITokenService(repo, authRepo, NewJournalService(&auditRepoStub{}))
+
+	limited, err := a.CreateAPIToken(context.Background(), 1, models.APITokenInput{Name: "ci",
+		Scopes: []string{models.ScopeTrade}, AllowedIPs: []string{"10.0.0.0/8"}})
+	require.NoError(t, err)
+
+	_, err = a.AuthenticateAPIToken(limited.Token, "10.20.30.40")
+	assert.NoError(t, err)
+	_, err = a.AuthenticateAPIToken(limited.Token, "192.0.2.1")
+	assert.True(t, errors.Is(err, ErrAPITokenIPNotAllowed))
+	_, err = a.AuthenticateAPIToken(limited.Token+"0", "10.20.30.40")
+	assert.True(t, errors.Is(err, ErrInvalidAPIToken))
+
+	expired := repo.tokens[limited.TokenHash]
+	expiresAt := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &expiresAt
+	repo.tokens[limited.TokenHash] = expired
+	_, err = a.AuthenticateAPIToken(limited.Token, "10.20.30.40")
+	assert.True(t, errors.Is(err, ErrAPITokenExpired))
+
+	unlimited, err := a.CreateAPIToken(context.Background(), 1, models.APITokenInput{Name: "any",
+		Scopes: []string{models.ScopeReadOrders}})
+	require.NoError(t, err)
+	_, err = a.AuthenticateAPIToken(unlimited.Token, "192.0.2.1")
+	assert.NoError(t, err)
+
+	authRepo.user.Disabled = true
+	_, err = a.AuthenticateAPIToken(unlimited.Token, "192.0.2.1")
+	assert.True(t, errors.Is(err, ErrUserDisabled))
+}
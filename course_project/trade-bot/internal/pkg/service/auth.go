@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"trade-bot/configs"
 	"trade-bot/internal/pkg/models"
 	"trade-bot/internal/pkg/repository"
 	"trade-bot/pkg/utils"
@@ -18,15 +22,54 @@ var (
 	ErrLogoutUser         = errors.New("logout user")
 	ErrGetUserAPIKeys     = errors.New("get user api keys")
 	ErrMismatchedPassword = errors.New("mismatched password")
+	ErrRefreshJWT         = errors.New("refresh jwt")
+	ErrGetSessions        = errors.New("get sessions")
+	ErrRevokeSession      = errors.New("revoke session")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrRefreshTokenReused = errors.New("refresh token is already used, session is revoked")
+	ErrGetUserRole        = errors.New("get user role")
+	ErrUserDisabled       = errors.New("user is disabled")
+
+	ErrEnrollTOTP              = errors.New("enroll totp")
+	ErrConfirmTOTP             = errors.New("confirm totp")
+	ErrDisableTOTP             = errors.New("disable totp")
+	ErrRegenerateRecoveryCodes = errors.New("regenerate recovery codes")
+	ErrVerifySecondFactor      = errors.New("verify second factor")
+	ErrTOTPRequired            = errors.New("totp code is required")
+	ErrInvalidTOTPCode         = errors.New("invalid totp code")
+	ErrTOTPAlreadyEnabled      = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled         = errors.New("two-factor authentication is not enrolled")
+	ErrTOTPNotEnabled          = errors.New("two-factor authentication is not enabled")
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+
+	totpIssuer         = "GoTrader"
+	recoveryCodesCount = 10
 )
 
 type AuthService struct {
 	repo    repository.Authorization
 	jwtRepo repository.JWT
+	journal Journal
+	guard   *loginGuard
 }
 
-func NewAuthService(repo repository.Authorization, jwtRepo repository.JWT) *AuthService {
-	return &AuthService{repo: repo, jwtRepo: jwtRepo}
+func NewAuthService(repo repository.Authorization, jwtRepo repository.JWT, limits repository.Limits, journal Journal,
+	loginProtection configs.LoginProtectionConfiguration) *AuthService {
+	return &AuthService{
+		repo:    repo,
+		jwtRepo: jwtRepo,
+		journal: journal,
+		guard:   newLoginGuard(limits, journal, loginProtection),
+	}
+}
+
+type loginEvent struct {
+	Username string `json:"username"`
+	Reason   string `json:"reason,omitempty"`
 }
 
 func (s *AuthService) CreateUser(user models.User) (int, error) {
@@ -41,24 +84,95 @@ func (s *AuthService) CreateUser(user models.User) (int, error) {
 	return userID, nil
 }
 
-func (s *AuthService) GenerateJWT(username string, password string) (string, error) {
+// GenerateJWT opens new session of device and issues its access and refresh tokens,
+// TOTP code is checked when user has two-factor authentication enabled.
+// Every failure delays next attempt for the username and ip, too many failures lock them out
+func (s *AuthService) GenerateJWT(ctx context.Context, credentials models.Credentials,
+	device models.Device) (models.Tokens, error) {
+	username, password := credentials.Username, credentials.Password
+
+	if err := s.guard.check(username, device.IP); err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrGenerateJWT, err)
+	}
+
 	user, err := s.repo.GetUser(username)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", ErrGenerateJWT, err)
+		return models.Tokens{}, s.loginFailed(ctx, 0, username, device.IP, err)
 	}
 	if ok := user.ComparePassword(password); !ok {
-		return "", fmt.Errorf("%s: %w", ErrGenerateJWT, ErrMismatchedPassword)
+		return models.Tokens{}, s.loginFailed(ctx, user.ID, username, device.IP, ErrMismatchedPassword)
+	}
+	if user.Disabled {
+		return models.Tokens{}, s.loginFailed(ctx, user.ID, username, device.IP, ErrUserDisabled)
+	}
+	if user.TOTPEnabled {
+		if err := s.checkSecondFactor(ctx, user, credentials.TOTPCode); err != nil {
+			return models.Tokens{}, s.loginFailed(ctx, user.ID, username, device.IP, err)
+		}
+	}
+
+	if err := s.guard.succeed(username); err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrGenerateJWT, err)
+	}
+
+	td, err := utils.GenerateTokenPair(user.ID, "", accessTokenTTL, refreshTokenTTL)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrGenerateJWT, err)
 	}
+	if err := s.jwtRepo.CreateSession(models.Session{UserID: user.ID, Device: device}, td); err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrGenerateJWT, err)
+	}
+
+	s.journal.Record(ctx, models.AuditEntry{UserID: user.ID, Action: models.AuditLogin, EntityID: td.SessionID},
+		loginEvent{Username: username})
+	return newTokens(td), nil
+}
 
-	td, err := utils.GenerateJWTToken(user.ID, time.Hour*12)
+// loginFailed records failed sign in and returns reason wrapped by ErrGenerateJWT, userID is 0 for unknown username
+func (s *AuthService) loginFailed(ctx context.Context, userID int, username, ip string, reason error) error {
+	s.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditLoginFailed},
+		loginEvent{Username: username, Reason: reason.Error()})
+
+	if err := s.guard.fail(ctx, userID, username, ip); err != nil {
+		return fmt.Errorf("%s: %w", ErrGenerateJWT, err)
+	}
+	return fmt.Errorf("%s: %w", ErrGenerateJWT, reason)
+}
+
+// RefreshJWT rotates tokens of session refresh token belongs to, every refresh token can be used once
+func (s *AuthService) RefreshJWT(ctx context.Context, refreshToken string) (models.Tokens, error) {
+	rd, err := utils.ExtractRefreshTokenMetadata(refreshToken)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", ErrGenerateJWT, err)
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrRefreshJWT, err)
 	}
-	token, err := s.jwtRepo.CreateJWT(user.ID, td)
+
+	td, err := utils.GenerateTokenPair(int(rd.UserID), rd.SessionID, accessTokenTTL, refreshTokenTTL)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", ErrGenerateJWT, err)
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrRefreshJWT, err)
+	}
+
+	rotated, revoked, err := s.jwtRepo.RotateSession(rd, td)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrRefreshJWT, err)
+	}
+	if revoked {
+		s.journal.Record(ctx, models.AuditEntry{UserID: int(rd.UserID), Action: models.AuditRefreshTokenReused,
+			EntityID: rd.SessionID}, nil)
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrRefreshJWT, ErrRefreshTokenReused)
+	}
+	if !rotated {
+		return models.Tokens{}, fmt.Errorf("%s: %w", ErrRefreshJWT, ErrSessionNotFound)
+	}
+
+	return newTokens(td), nil
+}
+
+func newTokens(td utils.TokenDetails) models.Tokens {
+	return models.Tokens{
+		AccessToken:  td.AccessToken,
+		RefreshToken: td.RefreshToken,
+		ExpiresIn:    int64(accessTokenTTL / time.Second),
 	}
-	return token, nil
 }
 
 func (s *AuthService) GetUserIDByJWT(token string) (int, error) {
@@ -73,17 +187,73 @@ func (s *AuthService) GetUserIDByJWT(token string) (int, error) {
 	return userID, nil
 }
 
-func (s *AuthService) LogoutUser(token string) error {
+// LogoutUser revokes session of token, tokens issued without session are deleted alone
+func (s *AuthService) LogoutUser(ctx context.Context, token string) error {
 	ad, err := utils.ExtractTokenMetadata(token)
 	if err != nil {
 		return fmt.Errorf("%s: %w", ErrLogoutUser, err)
 	}
-	if err := s.jwtRepo.DeleteJWT(ad); err != nil {
+
+	if ad.SessionID == "" {
+		err = s.jwtRepo.DeleteJWT(ad)
+	} else {
+		_, err = s.jwtRepo.DeleteSession(int(ad.UserID), ad.SessionID)
+	}
+	if err != nil {
 		return fmt.Errorf("%s: %w", ErrLogoutUser, err)
 	}
+
+	s.journal.Record(ctx, models.AuditEntry{UserID: int(ad.UserID), Action: models.AuditLogout, EntityID: ad.AccessUUID}, nil)
+	return nil
+}
+
+// GetSessions returns logged-in devices of user, session of token is marked as current
+func (s *AuthService) GetSessions(userID int, token string) ([]models.Session, error) {
+	sessions, err := s.jwtRepo.GetSessions(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetSessions, err)
+	}
+
+	ad, err := utils.ExtractTokenMetadata(token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetSessions, err)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == ad.SessionID
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession logs device out, its access token stops working at once and refresh token can't be used
+func (s *AuthService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	deleted, err := s.jwtRepo.DeleteSession(userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrRevokeSession, err)
+	}
+	if !deleted {
+		return fmt.Errorf("%s: %w", ErrRevokeSession, ErrSessionNotFound)
+	}
+
+	s.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditSessionRevoked, EntityID: sessionID}, nil)
 	return nil
 }
 
+// GetUserRole returns role of user, ErrUserDisabled is wrapped when account is disabled
+func (s *AuthService) GetUserRole(userID int) (string, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ErrGetUserRole, err)
+	}
+	if user.Disabled {
+		return "", fmt.Errorf("%s: %w", ErrGetUserRole, ErrUserDisabled)
+	}
+	return user.Role, nil
+}
+
 func (s *AuthService) GetUserAPIKeys(userID int) (string, string, error) {
 	public, private, err := s.repo.GetUserAPIKeys(userID)
 	if err != nil {
@@ -91,3 +261,173 @@ func (s *AuthService) GetUserAPIKeys(userID int) (string, string, error) {
 	}
 	return public, private, nil
 }
+
+// EnrollTOTP generates secret of authenticator app, two-factor authentication is enabled after ConfirmTOTP
+func (s *AuthService) EnrollTOTP(userID int) (models.TOTPEnrollment, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", ErrEnrollTOTP, err)
+	}
+	if user.TOTPEnabled {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", ErrEnrollTOTP, ErrTOTPAlreadyEnabled)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", ErrEnrollTOTP, err)
+	}
+	if err := s.repo.SetTOTPSecret(userID, secret); err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", ErrEnrollTOTP, err)
+	}
+
+	return models.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once user proves authenticator app works
+// and returns recovery codes, they are shown only once
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfirmTOTP, err)
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("%s: %w", ErrConfirmTOTP, ErrTOTPAlreadyEnabled)
+	}
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("%s: %w", ErrConfirmTOTP, ErrTOTPNotEnrolled)
+	}
+	if err := s.checkTOTPCode(user, code); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfirmTOTP, err)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfirmTOTP, err)
+	}
+	if err := s.repo.EnableTOTP(userID, hashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrConfirmTOTP, err)
+	}
+
+	s.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditTOTPEnabled}, nil)
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off, caller has to verify second factor first
+func (s *AuthService) DisableTOTP(ctx context.Context, userID int) error {
+	if err := s.repo.DisableTOTP(userID); err != nil {
+		return fmt.Errorf("%s: %w", ErrDisableTOTP, err)
+	}
+
+	s.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditTOTPDisabled}, nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces recovery codes of user, caller has to verify second factor first
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrRegenerateRecoveryCodes, err)
+	}
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("%s: %w", ErrRegenerateRecoveryCodes, ErrTOTPNotEnabled)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrRegenerateRecoveryCodes, err)
+	}
+	if err := s.repo.SetRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrRegenerateRecoveryCodes, err)
+	}
+
+	s.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditRecoveryCodesRegenerated}, nil)
+	return codes, nil
+}
+
+// VerifySecondFactor checks fresh TOTP or recovery code before sensitive actions,
+// users without two-factor authentication pass without code. Invalid codes are counted
+// like failed sign ins of the user and ip, so codes can't be guessed by trying them one by one
+func (s *AuthService) VerifySecondFactor(ctx context.Context, userID int, ip, code string) error {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrVerifySecondFactor, err)
+	}
+	if !user.TOTPEnabled {
+		return nil
+	}
+
+	if err := s.guard.check(user.Username, ip); err != nil {
+		return fmt.Errorf("%s: %w", ErrVerifySecondFactor, err)
+	}
+
+	err = s.checkSecondFactor(ctx, user, code)
+	if errors.Is(err, ErrInvalidTOTPCode) {
+		if err := s.guard.fail(ctx, user.ID, user.Username, ip); err != nil {
+			return fmt.Errorf("%s: %w", ErrVerifySecondFactor, err)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrVerifySecondFactor, err)
+	}
+
+	if err := s.guard.succeed(user.Username); err != nil {
+		return fmt.Errorf("%s: %w", ErrVerifySecondFactor, err)
+	}
+	return nil
+}
+
+// checkSecondFactor accepts TOTP code or unused recovery code of user
+func (s *AuthService) checkSecondFactor(ctx context.Context, user models.User, code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return ErrTOTPRequired
+	}
+
+	if err := s.checkTOTPCode(user, code); err == nil || !errors.Is(err, ErrInvalidTOTPCode) {
+		return err
+	}
+
+	used, err := s.repo.UseRecoveryCode(user.ID, utils.HashToken(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTOTPCode
+	}
+
+	s.journal.Record(ctx, models.AuditEntry{UserID: user.ID, Action: models.AuditRecoveryCodeUsed}, nil)
+	return nil
+}
+
+// checkTOTPCode accepts every code once, so code seen by someone else can't be replayed
+func (s *AuthService) checkTOTPCode(user models.User, code string) error {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	fresh, err := s.repo.UseTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashToken(code))
+	}
+	return codes, hashes, nil
+}
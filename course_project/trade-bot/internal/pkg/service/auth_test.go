@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/pkg/utils"
+)
+
+type authRepoStub struct {
+	repository.Authorization
+	user          models.User
+	recoveryCodes map[string]bool
+}
+
+func (r *authRepoStub) GetUser(username string) (models.User, error) {
+	if username != r.user.Username {
+		return models.User{}, errors.New("no rows")
+	}
+	return r.user, nil
+}
+
+func (r *authRepoStub) GetUserByID(userID int) (models.User, error) {
+	return r.user, nil
+}
+
+func (r *authRepoStub) UseTOTPStep(userID int, step int64) (bool, error) {
+	if step <= r.user.TOTPLastStep {
+		return false, nil
+	}
+	r.user.TOTPLastStep = step
+	return true, nil
+}
+
+func (r *authRepoStub) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	if !r.recoveryCodes[codeHash] {
+		return false, nil
+	}
+	r.recoveryCodes[codeHash] = false
+	return true, nil
+}
+
+type jwtRepoStub struct {
+	repository.JWT
+	sessions int
+}
+
+func (j *jwtRepoStub) CreateSession(session models.Session, td utils.TokenDetails) error {
+	j.sessions++
+	return nil
+}
+
+// limitsStub keeps counters and blocks until they are reset, time does not pass
+type limitsStub struct {
+	counters map[string]int
+	blocks   map[string]time.Duration
+}
+
+func newLimitsStub() *limitsStub {
+	return &limitsStub{counters: map[string]int{}, blocks: map[string]time.Duration{}}
+}
+
+func (l *limitsStub) IncrementCounter(key string, window time.Duration) (int, time.Duration, error) {
+	l.counters[key]++
+	return l.counters[key], window, nil
+}
+
+func (l *limitsStub) ResetCounters(keys ...string) error {
+	for _, key := range keys {
+		delete(l.counters, key)
+	}
+	return nil
+}
+
+func (l *limitsStub) Block(key string, d time.Duration) error {
+	if l.blocks[key] < d {
+		l.blocks[key] = d
+	}
+	return nil
+}
+
+func (l *limitsStub) BlockedFor(key string) (time.Duration, error) {
+	return l.blocks[key], nil
+}
+
+func TestAuthService_GenerateJWTLockout(t *testing.T) {
+	user := models.User{ID: 1, Username: "username"}
+	require.NoError(t, user.GeneratePasswordHash("qwerty"))
+
+	limits := newLimitsStub()
+	audit := &auditRepoStub{}
+	s := NewAuthService(&authRepoStub{user: user}, &jwtRepoStub{}, limits, NewJournalService(audit),
+		configs.LoginProtectionConfiguration{MaxFailures: 3, BaseDelayInMilliseconds: 100, MaxDelayInSeconds: 1})
+
+	signIn := func(password, ip string) error {
+		_, err := s.GenerateJWT(context.Background(),
+			models.Credentials{Username: "username", Password: password}, models.Device{IP: ip})
+		return err
+	}
+
+	assert.True(t, errors.Is(signIn("wrong", "10.0.0.1"), ErrMismatchedPassword))
+	assert.Equal(t, 100*time.Millisecond, limits.blocks["login:user:username"])
+	assert.True(t, errors.Is(signIn("qwerty", "10.0.0.1"), ErrTooManyLoginAttempts))
+
+	// delays grow exponentially until they reach the maximum
+	limits.blocks = map[string]time.Duration{}
+	assert.True(t, errors.Is(signIn("wrong", "10.0.0.2"), ErrMismatchedPassword))
+	assert.Equal(t, 200*time.Millisecond, limits.blocks["login:user:username"])
+	assert.Equal(t, 100*time.Millisecond, limits.blocks["login:ip:10.0.0.2"])
+
+	limits.blocks = map[string]time.Duration{}
+	assert.True(t, errors.Is(signIn("wrong", "10.0.0.3"), ErrMismatchedPassword))
+	assert.Equal(t, 15*time.Minute, limits.blocks["login:user:username"])
+	assert.Equal(t, 100*time.Millisecond, limits.blocks["login:ip:10.0.0.3"])
+
+	locked := 0
+	for _, entry := range audit.entries {
+		if entry.Action == models.AuditLoginLocked {
+			locked++
+			assert.Equal(t, "login:user:username", entry.EntityID)
+		}
+	}
+	assert.Equal(t, 1, locked)
+
+	// successful sign in after lockout forgets failures of username
+	limits.blocks = map[string]time.Duration{}
+	assert.NoError(t, signIn("qwerty", "10.0.0.3"))
+	assert.Equal(t, 0, limits.counters["login:user:username"])
+	assert.Equal(t, 1, limits.counters["login:ip:10.0.0.3"])
+}
+
+func TestAuthService_GenerateJWTWithTOTP(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	user := models.User{ID: 1, Username: "username", TOTPSecret: secret, TOTPEnabled: true}
+	require.NoError(t, user.GeneratePasswordHash("qwerty"))
+
+	repo := &authRepoStub{user: user, recoveryCodes: map[string]bool{utils.HashToken("ABCDE-FGHIJ"): true}}
+	jwtRepo := &jwtRepoStub{}
+	limits := newLimitsStub()
+	s := NewAuthService(repo, jwtRepo, limits, NewJournalService(&auditRepoStub{}),
+		configs.LoginProtectionConfiguration{})
+
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	require.NoError(t, err)
+
+	signIn := func(totpCode string) error {
+		// delay after previous failure is over
+		limits.blocks = map[string]time.Duration{}
+		_, err := s.GenerateJWT(context.Background(),
+			models.Credentials{Username: "username", Password: "qwerty", TOTPCode: totpCode}, models.Device{})
+		return err
+	}
+
+	assert.True(t, errors.Is(signIn(""), ErrTOTPRequired))
+
+	assert.NoError(t, signIn(code))
+	// the same code can't be replayed
+	assert.True(t, errors.Is(signIn(code), ErrInvalidTOTPCode))
+
+	// recovery codes are accepted once, case and spaces do not matter
+	assert.NoError(t, signIn(" abcde-fghij "))
+	assert.True(t, errors.Is(signIn("ABCDE-FGHIJ"), ErrInvalidTOTPCode))
+
+	assert.Equal(t, 2, jwtRepo.sessions)
+}
+
+func TestAuthService_VerifySecondFactorLockout(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	repo := &authRepoStub{user: models.User{ID: 1, Username: "username", TOTPSecret: secret, TOTPEnabled: true}}
+	limits := newLimitsStub()
+	s := NewAuthService(repo, &jwtRepoStub{}, limits, NewJournalService(&auditRepoStub{}),
+		configs.LoginProtectionConfiguration{MaxFailures: 3, BaseDelayInMilliseconds: 100, MaxDelayInSeconds: 1})
+
+	verify := func(code string) error {
+		return s.VerifySecondFactor(context.Background(), 1, "10.0.0.1", code)
+	}
+
+	// missing code is not a guess
+	assert.True(t, errors.Is(verify(""), ErrTOTPRequired))
+	assert.Empty(t, limits.counters)
+
+	assert.True(t, errors.Is(verify("000000"), ErrInvalidTOTPCode))
+	assert.Equal(t, 100*time.Millisecond, limits.blocks["login:user:username"])
+	assert.Equal(t, 100*time.Millisecond, limits.blocks["login:ip:10.0.0.1"])
+	// next code waits for the delay
+	assert.True(t, errors.Is(verify("000000"), ErrTooManyLoginAttempts))
+
+	for i := 0; i < 2; i++ {
+		limits.blocks = map[string]time.Duration{}
+		assert.True(t, errors.Is(verify("000000"), ErrInvalidTOTPCode))
+	}
+	assert.Equal(t, 15*time.Minute, limits.blocks["login:user:username"])
+
+	// valid code is rejected while user is locked out
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	assert.True(t, errors.Is(verify(code), ErrTooManyLoginAttempts))
+
+	limits.blocks = map[string]time.Duration{}
+	assert.NoError(t, verify(code))
+	assert.Equal(t, 0, limits.counters["login:user:username"])
+}
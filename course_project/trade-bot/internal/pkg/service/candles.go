@@ -0,0 +1,51 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/internal/pkg/web"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+var (
+	ErrBackfillCandles = errors.New("backfill candles")
+	ErrCandlesHistory  = errors.New("candles history")
+)
+
+type CandlesService struct {
+	repo   repository.Candles
+	charts web.KrakenCharts
+}
+
+func NewCandlesService(repo repository.Candles, charts web.KrakenCharts) *CandlesService {
+	return &CandlesService{repo: repo, charts: charts}
+}
+
+// Backfill loads candles of [from, to) from kraken charts and stores them, returns number of stored candles
+func (c *CandlesService) Backfill(candlesType krakenFuturesWSSDK.CandlesType, symbol string,
+	interval krakenFuturesWSSDK.CandlesInterval, from, to time.Time) (int, error) {
+	candles, err := c.charts.GetCandles(candlesType, symbol, interval, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrBackfillCandles, err)
+	}
+
+	if err := c.repo.SaveCandles(candles); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrBackfillCandles, err)
+	}
+
+	return len(candles), nil
+}
+
+func (c *CandlesService) GetCandles(candlesType krakenFuturesWSSDK.CandlesType, symbol string,
+	interval krakenFuturesWSSDK.CandlesInterval, from, to time.Time) ([]models.Candle, error) {
+	candles, err := c.repo.GetCandles(symbol, string(candlesType), string(interval), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrCandlesHistory, err)
+	}
+	return candles, nil
+}
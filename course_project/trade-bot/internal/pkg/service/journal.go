@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/pkg/utils"
+)
+
+var (
+	ErrRecordAuditEntry   = errors.New("record audit entry")
+	ErrGetAuditEntries    = errors.New("get audit entries")
+	ErrExportAuditEntries = errors.New("export audit entries")
+)
+
+// exportPageSize is the number of entries read from repository at once during export
+const exportPageSize = 500
+
+// JournalService writes events of users to append-only audit log
+type JournalService struct {
+	repo repository.Audit
+}
+
+func NewJournalService(repo repository.Audit) *JournalService {
+	return &JournalService{repo: repo}
+}
+
+// Record writes entry with correlation id of ctx and data encoded to JSON.
+// Failures are logged only, so that audit never stops trading
+func (j *JournalService) Record(ctx context.Context, entry models.AuditEntry, data interface{}) {
+	entry.CorrelationID = utils.CorrelationID(ctx)
+
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			log.Errorf("%s: %s: %s", ErrRecordAuditEntry, entry.Action, err)
+			return
+		}
+		entry.Data = encoded
+	}
+
+	if err := j.repo.CreateAuditEntry(entry); err != nil {
+		log.Errorf("%s: %s: %s", ErrRecordAuditEntry, entry.Action, err)
+	}
+}
+
+func (j *JournalService) GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	entries, err := j.repo.GetAuditEntries(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetAuditEntries, err)
+	}
+	return entries, nil
+}
+
+// ExportAuditEntries writes all entries matching filter to w as JSON lines, limit and offset of filter are ignored
+func (j *JournalService) ExportAuditEntries(filter models.AuditFilter, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	filter.Limit, filter.Offset = exportPageSize, 0
+
+	for {
+		entries, err := j.repo.GetAuditEntries(filter)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrExportAuditEntries, err)
+		}
+
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return fmt.Errorf("%s: %w", ErrExportAuditEntries, err)
+			}
+		}
+
+		if len(entries) < exportPageSize {
+			return nil
+		}
+		filter.Offset += exportPageSize
+	}
+}
@@ -6,59 +6,159 @@ import (
 	"time"
 	"trade-bot/internal/pkg/models"
 
+	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 
 	"trade-bot/internal/pkg/repository"
 	"trade-bot/internal/pkg/tradeAlgorithm"
 	"trade-bot/internal/pkg/tradeAlgorithm/types"
 	"trade-bot/internal/pkg/web"
-	"trade-bot/pkg/krakenFuturesSDK"
 )
 
 var (
 	ErrSendOrderServiceMethod    = errors.New("send order service method")
 	ErrStartTradingService       = errors.New("start trading service")
 	ErrUnableToParseBuyTimestamp = errors.New("unable to convert buy timestamp")
+	ErrBatchOrderService         = errors.New("batch order service")
+	ErrBatchNotSupported         = errors.New("exchange does not support batch orders")
+	ErrOrderSubmissionInProgress = errors.New("order with the same idempotency key is being sent")
+)
+
+// Idempotency keys of send order requests and client order ids of batches share one unique index of submissions,
+// so they are stored with prefix of their source and can't take each other's orders
+const (
+	requestIdempotencyKeyPrefix = "request:"
+	batchIdempotencyKeyPrefix   = "batch:"
 )
 
 type KrakenOrdersManagerService struct {
-	sdk    web.KrakenOrdersManager
-	repo   repository.KrakenOrdersManager
-	trader tradeAlgorithm.Trader
+	exchanges web.Exchanges
+	repo      repository.KrakenOrdersManager
+	sessions  repository.TradingSessions
+	trader    tradeAlgorithm.Trader
+	journal   Journal
+}
+
+func NewKrakenOrdersManagerService(exchanges web.Exchanges, repo repository.KrakenOrdersManager,
+	sessions repository.TradingSessions, trader tradeAlgorithm.Trader, journal Journal) *KrakenOrdersManagerService {
+	return &KrakenOrdersManagerService{exchanges: exchanges, repo: repo, sessions: sessions, trader: trader, journal: journal}
 }
 
-func NewKrakenOrdersManagerService(sdk web.KrakenOrdersManager, repo repository.KrakenOrdersManager,
-	trader tradeAlgorithm.Trader) *KrakenOrdersManagerService {
-	return &KrakenOrdersManagerService{sdk: sdk, repo: repo, trader: trader}
+type errorEvent struct {
+	Error string `json:"error"`
 }
 
-func (k *KrakenOrdersManagerService) SendOrder(userID int, args krakenFuturesSDK.SendOrderArguments) (models.Order, error) {
-	sendStatus, err := k.sdk.SendOrder(args)
+// SendOrder stores submission of order with client order id before sending it, generating the id when it is empty.
+// Order sent with the same non-empty idempotency key before is returned without sending request again
+func (k *KrakenOrdersManagerService) SendOrder(ctx context.Context, userID int, idempotencyKey string,
+	request models.OrderRequest) (models.Order, error) {
+	exchange, err := k.exchanges.Get(request.Exchange)
+	if err != nil {
+		return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrderServiceMethod, err)
+	}
+
+	if request.CliOrderID == "" {
+		if request.CliOrderID, err = newCliOrderID(); err != nil {
+			return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrderServiceMethod, err)
+		}
+	}
+
+	if idempotencyKey != "" {
+		idempotencyKey = requestIdempotencyKeyPrefix + idempotencyKey
+	}
+
+	submission, created, err := k.repo.CreateOrderSubmission(models.OrderSubmission{
+		UserID:         userID,
+		IdempotencyKey: idempotencyKey,
+		CliOrderID:     request.CliOrderID,
+	})
 	if err != nil {
 		return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrderServiceMethod, err)
 	}
 
-	order, err := k.sdk.ParseSendStatusToExecutedOrder(userID, sendStatus)
+	if !created {
+		if submission.Sent() {
+			observeOrders(request.Exchange, orderReplayed, 1)
+			return k.repo.GetOrder(submission.OrderID)
+		}
+
+		retried := false
+		if submission.Failed {
+			if retried, err = k.repo.RetryOrderSubmission(submission.CliOrderID); err != nil {
+				return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrderServiceMethod, err)
+			}
+		}
+		if !retried {
+			return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrderServiceMethod, ErrOrderSubmissionInProgress)
+		}
+		request.CliOrderID = submission.CliOrderID
+	}
+
+	k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditOrderRequest, EntityID: request.CliOrderID}, request)
+
+	order, err := exchange.SendOrder(userID, request)
 	if err != nil {
+		k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditOrderResponse, EntityID: request.CliOrderID},
+			errorEvent{Error: err.Error()})
+		k.failSubmissions(request.CliOrderID)
+		observeOrders(request.Exchange, orderFailed, 1)
 		return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrderServiceMethod, err)
 	}
+	observeOrders(request.Exchange, orderAccepted, 1)
+	k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditOrderResponse, EntityID: request.CliOrderID}, order)
+	if order.ClientOrderID == "" {
+		order.ClientOrderID = request.CliOrderID
+	}
 
-	if err := k.repo.CreateOrder(userID, order); err != nil {
+	if err := k.repo.CreateOrders(userID, []models.Order{order}); err != nil {
 		return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrderServiceMethod, err)
 	}
 
 	return order, nil
 }
 
+// failSubmissions marks submissions of orders exchange did not accept, so they can be retried
+func (k *KrakenOrdersManagerService) failSubmissions(cliOrderIDs ...string) {
+	for _, cliOrderID := range cliOrderIDs {
+		if err := k.repo.FailOrderSubmission(cliOrderID); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func newCliOrderID() (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// StartTrading opens position, waits for strategy to decide to close it and closes it.
+// Start, decision and stop of trading are written to audit log
 func (k *KrakenOrdersManagerService) StartTrading(ctx context.Context, userID int, details types.TradingDetails) (models.Order, error) {
-	sendArgs := krakenFuturesSDK.SendOrderArguments{
+	order, err := k.startTrading(ctx, userID, details)
+	if err != nil {
+		k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditTradingStopped, EntityID: details.Symbol},
+			errorEvent{Error: err.Error()})
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+func (k *KrakenOrdersManagerService) startTrading(ctx context.Context, userID int, details types.TradingDetails) (models.Order, error) {
+	k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditTradingStarted, EntityID: details.Symbol}, details)
+
+	request := models.OrderRequest{
+		Exchange:  details.Exchange,
 		OrderType: details.OrderType,
 		Symbol:    details.Symbol,
 		Side:      details.Side,
 		Size:      details.Size,
 	}
 
-	startOrder, err := k.SendOrder(userID, sendArgs)
+	startOrder, err := k.SendOrder(ctx, userID, "", request)
 	if err != nil {
 		return models.Order{}, fmt.Errorf("%s: %w", ErrStartTradingService, err)
 	}
@@ -69,21 +169,169 @@ func (k *KrakenOrdersManagerService) StartTrading(ctx context.Context, userID in
 		return models.Order{}, fmt.Errorf("%s: %w", ErrUnableToParseBuyTimestamp, err)
 	}
 
-	if err := k.trader.StartAnalyzing(ctx, buyTime, details); err != nil {
+	activeTradingSessions.Inc()
+	defer activeTradingSessions.Dec()
+
+	sessionID := k.createTradingSession(models.TradingSession{
+		UserID:       userID,
+		Strategy:     types.StopLossTakeProfitStrategy,
+		Exchange:     details.Exchange,
+		Symbol:       details.Symbol,
+		Side:         details.Side,
+		StartOrderID: startOrder.ID,
+		StartedAt:    buyTime,
+	})
+
+	decision, err := k.trader.StartAnalyzing(ctx, buyTime, details)
+	if err != nil {
+		k.finishTradingSession(sessionID, "", models.TradingSessionStopped, time.Now())
 		return models.Order{}, fmt.Errorf("%s: %w", ErrStartTradingService, err)
 	}
+	k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditStrategyDecision, EntityID: startOrder.ID,
+		Details: decision.Reason}, decision)
 
-	opositeArgs := sendArgs
-	opositeArgs.ChangeToOpositeOrderSide()
+	opposite := request.Opposite()
+	opposite.CliOrderID = ""
 
-	finishOrder, err := k.SendOrder(userID, opositeArgs)
+	finishOrder, err := k.SendOrder(ctx, userID, "", opposite)
 	if err != nil {
+		k.finishTradingSession(sessionID, "", models.TradingSessionStopped, time.Now())
 		return models.Order{}, fmt.Errorf("%s: %w", ErrStartTradingService, err)
 	}
+	k.finishTradingSession(sessionID, finishOrder.ID, decision.Reason, finishOrder.Time())
 
 	return finishOrder, nil
 }
 
-func (k *KrakenOrdersManagerService) GetUserOrders(userID int) ([]models.Order, error) {
-	return k.repo.GetUserOrders(userID)
+// createTradingSession stores session and returns its id, 0 when it is not stored.
+// Failures are logged only, so that reports never stop trading
+func (k *KrakenOrdersManagerService) createTradingSession(session models.TradingSession) int {
+	id, err := k.sessions.CreateTradingSession(session)
+	if err != nil {
+		log.Error(err)
+		return 0
+	}
+	return id
+}
+
+func (k *KrakenOrdersManagerService) finishTradingSession(id int, finishOrderID, reason string, finishedAt time.Time) {
+	if id == 0 {
+		return
+	}
+	if err := k.sessions.FinishTradingSession(id, finishOrderID, reason, finishedAt.UTC()); err != nil {
+		log.Error(err)
+	}
+}
+
+// BatchOrder executes instructions in one exchange request and stores resulting orders in one transaction.
+// Submissions of new orders are stored before request like in SendOrder, orders sent with the same client order id
+// before are returned without sending them again
+func (k *KrakenOrdersManagerService) BatchOrder(ctx context.Context, userID int, exchangeName string,
+	instructions []models.BatchInstruction) (models.BatchResult, error) {
+	exchange, err := k.exchanges.Get(exchangeName)
+	if err != nil {
+		return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, err)
+	}
+
+	batchExchange, ok := exchange.(web.BatchExchange)
+	if !ok {
+		return models.BatchResult{}, fmt.Errorf("%s: %s", ErrBatchOrderService, ErrBatchNotSupported)
+	}
+
+	var (
+		sent     []string
+		replayed []models.Order
+		batch    = make([]models.BatchInstruction, 0, len(instructions))
+	)
+	for i := range instructions {
+		if instructions[i].Order != models.BatchSend {
+			batch = append(batch, instructions[i])
+			continue
+		}
+		// client order id given by user identifies the order in retried batches
+		var idempotencyKey string
+		if instructions[i].CliOrderID != "" {
+			idempotencyKey = batchIdempotencyKeyPrefix + instructions[i].CliOrderID
+		}
+		if instructions[i].CliOrderID == "" {
+			if instructions[i].CliOrderID, err = newCliOrderID(); err != nil {
+				k.failSubmissions(sent...)
+				return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, err)
+			}
+		}
+
+		submission, created, err := k.repo.CreateOrderSubmission(models.OrderSubmission{
+			UserID:         userID,
+			IdempotencyKey: idempotencyKey,
+			CliOrderID:     instructions[i].CliOrderID,
+		})
+		if err != nil {
+			k.failSubmissions(sent...)
+			return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, err)
+		}
+
+		if !created {
+			if submission.Sent() {
+				order, err := k.repo.GetOrder(submission.OrderID)
+				if err != nil {
+					k.failSubmissions(sent...)
+					return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, err)
+				}
+				replayed = append(replayed, order)
+				continue
+			}
+
+			retried := false
+			if submission.Failed {
+				if retried, err = k.repo.RetryOrderSubmission(submission.CliOrderID); err != nil {
+					k.failSubmissions(sent...)
+					return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, err)
+				}
+			}
+			if !retried {
+				k.failSubmissions(sent...)
+				return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, ErrOrderSubmissionInProgress)
+			}
+		}
+		sent = append(sent, instructions[i].CliOrderID)
+		batch = append(batch, instructions[i])
+	}
+	observeOrders(exchangeName, orderReplayed, len(replayed))
+
+	if len(batch) == 0 {
+		return models.BatchResult{Orders: replayed}, nil
+	}
+
+	k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditBatchRequest}, batch)
+
+	result, err := batchExchange.BatchOrder(userID, batch)
+	if err != nil {
+		k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditBatchResponse}, errorEvent{Error: err.Error()})
+		k.failSubmissions(sent...)
+		observeOrders(exchangeName, orderFailed, len(sent))
+		return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, err)
+	}
+
+	k.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditBatchResponse}, result)
+
+	accepted := make(map[string]bool, len(result.Orders))
+	for _, order := range result.Orders {
+		accepted[order.ClientOrderID] = true
+	}
+	failed := 0
+	for _, cliOrderID := range sent {
+		if !accepted[cliOrderID] {
+			k.failSubmissions(cliOrderID)
+			failed++
+		}
+	}
+	observeOrders(exchangeName, orderAccepted, len(sent)-failed)
+	observeOrders(exchangeName, orderFailed, failed)
+
+	if err := k.repo.CreateOrders(userID, result.Orders); err != nil {
+		return models.BatchResult{}, fmt.Errorf("%s: %w", ErrBatchOrderService, err)
+	}
+	result.Orders = append(result.Orders, replayed...)
+
+	return result, nil
 }
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository/memoryRepo"
+	"trade-bot/internal/pkg/web"
+)
+
+type batchExchangeStub struct {
+	batches [][]models.BatchInstruction
+	sent    []models.OrderRequest
+	err     error
+}
+
+func (e *batchExchangeStub) SendOrder(userID int, request models.OrderRequest) (models.Order, error) {
+	e.sent = append(e.sent, request)
+	return models.Order{
+		ID:            fmt.Sprintf("sent-%d", len(e.sent)),
+		ClientOrderID: request.CliOrderID,
+		Type:          models.PlaceOrder,
+		Symbol:        request.Symbol,
+	}, nil
+}
+
+func (e *batchExchangeStub) LastPrice(symbol string) (decimal.Decimal, error) {
+	return decimal.Zero, nil
+}
+
+func (e *batchExchangeStub) Instruments() ([]models.Instrument, error) {
+	return nil, nil
+}
+
+func (e *batchExchangeStub) GetCandles(symbol string, interval string, from, to time.Time) ([]models.Candle, error) {
+	return nil, nil
+}
+
+func (e *batchExchangeStub) BatchOrder(userID int, instructions []models.BatchInstruction) (models.BatchResult, error) {
+	e.batches = append(e.batches, instructions)
+	if e.err != nil {
+		return models.BatchResult{}, e.err
+	}
+
+	var result models.BatchResult
+	for _, instruction := range instructions {
+		result.Orders = append(result.Orders, models.Order{
+			ID:            fmt.Sprintf("order-%d-%s", len(e.batches), instruction.CliOrderID),
+			ClientOrderID: instruction.CliOrderID,
+			Type:          models.PlaceOrder,
+			Symbol:        instruction.Symbol,
+		})
+	}
+	return result, nil
+}
+
+func TestKrakenOrdersManagerService_BatchOrder(t *testing.T) {
+	db := memoryRepo.NewDB()
+	exchange := &batchExchangeStub{}
+	k := NewKrakenOrdersManagerService(web.Exchanges{"stub": exchange}, memoryRepo.NewKrakenOrdersManagerMemory(db),
+		nil, nil, NewJournalService(memoryRepo.NewAuditMemory(db)))
+
+	batch := []models.BatchInstruction{
+		{Order: models.BatchSend, Symbol: "PI_XBTUSD", CliOrderID: "first"},
+		{Order: models.BatchSend, Symbol: "PI_ETHUSD", CliOrderID: "second"},
+	}
+
+	// failed batch is sent again on retry
+	exchange.err = errors.New("connection reset")
+	_, err := k.BatchOrder(context.Background(), 1, "stub", batch)
+	require.Error(t, err)
+
+	exchange.err = nil
+	result, err := k.BatchOrder(context.Background(), 1, "stub", batch)
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 2)
+	require.Len(t, exchange.batches, 2)
+
+	// orders accepted before are returned without sending them again, only new ones are sent
+	retried := append(batch, models.BatchInstruction{Order: models.BatchSend, Symbol: "PI_XBTUSD", CliOrderID: "third"})
+	result, err = k.BatchOrder(context.Background(), 1, "stub", retried)
+	require.NoError(t, err)
+	require.Len(t, exchange.batches, 3)
+	require.Len(t, exchange.batches[2], 1)
+	assert.Equal(t, "third", exchange.batches[2][0].CliOrderID)
+
+	ids := make([]string, 0, len(result.Orders))
+	for _, order := range result.Orders {
+		ids = append(ids, order.ID)
+	}
+	assert.ElementsMatch(t, []string{"order-2-first", "order-2-second", "order-3-third"}, ids)
+
+	// nothing is sent when the whole batch was accepted
+	result, err = k.BatchOrder(context.Background(), 1, "stub", batch)
+	require.NoError(t, err)
+	assert.Len(t, result.Orders, 2)
+	assert.Len(t, exchange.batches, 3)
+}
+
+func TestKrakenOrdersManagerService_BatchOrderInProgress(t *testing.T) {
+	db := memoryRepo.NewDB()
+	repo := memoryRepo.NewKrakenOrdersManagerMemory(db)
+	exchange := &batchExchangeStub{}
+	k := NewKrakenOrdersManagerService(web.Exchanges{"stub": exchange}, repo,
+		nil, nil, NewJournalService(memoryRepo.NewAuditMemory(db)))
+
+	// submission is stored, but request is not answered yet
+	_, _, err := repo.CreateOrderSubmission(models.OrderSubmission{UserID: 1,
+		IdempotencyKey: batchIdempotencyKeyPrefix + "pending", CliOrderID: "pending"})
+	require.NoError(t, err)
+
+	_, err = k.BatchOrder(context.Background(), 1, "stub", []models.BatchInstruction{
+		{Order: models.BatchSend, Symbol: "PI_XBTUSD", CliOrderID: "new"},
+		{Order: models.BatchSend, Symbol: "PI_XBTUSD", CliOrderID: "pending"},
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrOrderSubmissionInProgress))
+	assert.Empty(t, exchange.batches)
+
+	submission, err := repo.GetOrderSubmission("new")
+	require.NoError(t, err)
+	assert.True(t, submission.Failed)
+}
+
+func TestKrakenOrdersManagerService_IdempotencyKeySources(t *testing.T) {
+	db := memoryRepo.NewDB()
+	exchange := &batchExchangeStub{}
+	k := NewKrakenOrdersManagerService(web.Exchanges{"stub": exchange}, memoryRepo.NewKrakenOrdersManagerMemory(db),
+		nil, nil, NewJournalService(memoryRepo.NewAuditMemory(db)))
+
+	result, err := k.BatchOrder(context.Background(), 1, "stub", []models.BatchInstruction{
+		{Order: models.BatchSend, Symbol: "PI_XBTUSD", CliOrderID: "shared"},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 1)
+
+	// idempotency key equal to client order id of batch order is another key
+	request := models.OrderRequest{Exchange: "stub", Symbol: "PI_ETHUSD"}
+	order, err := k.SendOrder(context.Background(), 1, "shared", request)
+	require.NoError(t, err)
+	assert.Equal(t, "sent-1", order.ID)
+	require.Len(t, exchange.sent, 1)
+
+	// both are still replayed by their own source
+	order, err = k.SendOrder(context.Background(), 1, "shared", request)
+	require.NoError(t, err)
+	assert.Equal(t, "sent-1", order.ID)
+	assert.Len(t, exchange.sent, 1)
+
+	result, err = k.BatchOrder(context.Background(), 1, "stub", []models.BatchInstruction{
+		{Order: models.BatchSend, Symbol: "PI_XBTUSD", CliOrderID: "shared"},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 1)
+	assert.Equal(t, "order-1-shared", result.Orders[0].ID)
+	assert.Len(t, exchange.batches, 1)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+)
+
+var (
+	ErrTooManyLoginAttempts = errors.New("too many login attempts")
+)
+
+const (
+	defaultMaxLoginFailures             = 5
+	defaultLoginFailuresWindowInMinutes = 15
+	defaultLoginLockoutInMinutes        = 15
+	defaultLoginBaseDelayInMilliseconds = 500
+	defaultLoginMaxDelayInSeconds       = 30
+)
+
+func withDefaultLoginProtectionConfig(cfg configs.LoginProtectionConfiguration) configs.LoginProtectionConfiguration {
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = defaultMaxLoginFailures
+	}
+	if cfg.FailuresWindowInMinutes <= 0 {
+		cfg.FailuresWindowInMinutes = defaultLoginFailuresWindowInMinutes
+	}
+	if cfg.LockoutInMinutes <= 0 {
+		cfg.LockoutInMinutes = defaultLoginLockoutInMinutes
+	}
+	if cfg.BaseDelayInMilliseconds <= 0 {
+		cfg.BaseDelayInMilliseconds = defaultLoginBaseDelayInMilliseconds
+	}
+	if cfg.MaxDelayInSeconds <= 0 {
+		cfg.MaxDelayInSeconds = defaultLoginMaxDelayInSeconds
+	}
+	return cfg
+}
+
+// loginGuard counts failed sign ins and second factor checks per username and per ip. Every failure blocks
+// next attempt for exponentially growing delay, reaching max failures within window locks username or ip out
+type loginGuard struct {
+	limits  repository.Limits
+	journal Journal
+	config  configs.LoginProtectionConfiguration
+}
+
+func newLoginGuard(limits repository.Limits, journal Journal, config configs.LoginProtectionConfiguration) *loginGuard {
+	return &loginGuard{limits: limits, journal: journal, config: withDefaultLoginProtectionConfig(config)}
+}
+
+type lockoutEvent struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+	Failures int    `json:"failures"`
+}
+
+func loginKeys(username, ip string) []string {
+	keys := []string{"login:user:" + strings.ToLower(username)}
+	if ip != "" {
+		keys = append(keys, "login:ip:"+ip)
+	}
+	return keys
+}
+
+// check returns error wrapping ErrTooManyLoginAttempts while username or ip is blocked
+func (g *loginGuard) check(username, ip string) error {
+	var blockedFor time.Duration
+	for _, key := range loginKeys(username, ip) {
+		left, err := g.limits.BlockedFor(key)
+		if err != nil {
+			return err
+		}
+		if left > blockedFor {
+			blockedFor = left
+		}
+	}
+
+	if blockedFor > 0 {
+		retryIn := (blockedFor + time.Second - 1).Truncate(time.Second)
+		return fmt.Errorf("%w: retry in %s", ErrTooManyLoginAttempts, retryIn)
+	}
+	return nil
+}
+
+// fail registers failed sign in and blocks username and ip, userID is 0 for unknown username
+func (g *loginGuard) fail(ctx context.Context, userID int, username, ip string) error {
+	window := time.Duration(g.config.FailuresWindowInMinutes) * time.Minute
+
+	for _, key := range loginKeys(username, ip) {
+		failures, _, err := g.limits.IncrementCounter(key, window)
+		if err != nil {
+			return err
+		}
+
+		if failures >= g.config.MaxFailures {
+			if err := g.limits.Block(key, time.Duration(g.config.LockoutInMinutes)*time.Minute); err != nil {
+				return err
+			}
+			g.journal.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditLoginLocked, EntityID: key},
+				lockoutEvent{Username: username, IP: ip, Failures: failures})
+			continue
+		}
+
+		if err := g.limits.Block(key, g.delay(failures)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// succeed forgets failures of username, failures of ip are kept so one valid account does not hide guessing of others
+func (g *loginGuard) succeed(username string) error {
+	return g.limits.ResetCounters(loginKeys(username, "")...)
+}
+
+func (g *loginGuard) delay(failures int) time.Duration {
+	maxDelay := time.Duration(g.config.MaxDelayInSeconds) * time.Second
+
+	delay := time.Duration(g.config.BaseDelayInMilliseconds) * time.Millisecond
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"trade-bot/internal/pkg/web"
+)
+
+// Results of orders in metrics
+const (
+	orderAccepted = "accepted"
+	orderFailed   = "failed"
+	// orderReplayed is order sent before and returned again for retried idempotency key
+	orderReplayed = "replayed"
+)
+
+var (
+	ordersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "trade_bot",
+		Name:      "orders_total",
+		Help:      "Orders by exchange and result: accepted, failed or replayed.",
+	}, []string{"exchange", "result"})
+
+	activeTradingSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "trade_bot",
+		Name:      "active_trading_sessions",
+		Help:      "Trading sessions waiting for strategy to close position.",
+	})
+)
+
+func observeOrders(exchange, result string, count int) {
+	if exchange == "" {
+		exchange = web.DefaultExchange
+	}
+	ordersTotal.WithLabelValues(exchange, result).Add(float64(count))
+}
@@ -6,10 +6,12 @@ package mock_service
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 	models "trade-bot/internal/pkg/models"
 	types "trade-bot/internal/pkg/tradeAlgorithm/types"
-	krakenFuturesSDK "trade-bot/pkg/krakenFuturesSDK"
+	krakenFuturesWSSDK "trade-bot/pkg/krakenFuturesWSSDK"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -37,6 +39,21 @@ func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
 	return m.recorder
 }
 
+// ConfirmTOTP mocks base method.
+func (m *MockAuthorization) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, userID, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockAuthorizationMockRecorder) ConfirmTOTP(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockAuthorization)(nil).ConfirmTOTP), ctx, userID, code)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthorization) CreateUser(user models.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -52,19 +69,63 @@ func (mr *MockAuthorizationMockRecorder) CreateUser(user interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
 }
 
+// DisableTOTP mocks base method.
+func (m *MockAuthorization) DisableTOTP(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockAuthorizationMockRecorder) DisableTOTP(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockAuthorization)(nil).DisableTOTP), ctx, userID)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockAuthorization) EnrollTOTP(userID int) (models.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", userID)
+	ret0, _ := ret[0].(models.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockAuthorizationMockRecorder) EnrollTOTP(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockAuthorization)(nil).EnrollTOTP), userID)
+}
+
 // GenerateJWT mocks base method.
-func (m *MockAuthorization) GenerateJWT(username, password string) (string, error) {
+func (m *MockAuthorization) GenerateJWT(ctx context.Context, credentials models.Credentials, device models.Device) (models.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateJWT", username, password)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GenerateJWT", ctx, credentials, device)
+	ret0, _ := ret[0].(models.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateJWT indicates an expected call of GenerateJWT.
-func (mr *MockAuthorizationMockRecorder) GenerateJWT(username, password interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GenerateJWT(ctx, credentials, device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockAuthorization)(nil).GenerateJWT), ctx, credentials, device)
+}
+
+// GetSessions mocks base method.
+func (m *MockAuthorization) GetSessions(userID int, token string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", userID, token)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockAuthorizationMockRecorder) GetSessions(userID, token interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockAuthorization)(nil).GenerateJWT), username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockAuthorization)(nil).GetSessions), userID, token)
 }
 
 // GetUserAPIKeys mocks base method.
@@ -98,18 +159,91 @@ func (mr *MockAuthorizationMockRecorder) GetUserIDByJWT(token interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByJWT", reflect.TypeOf((*MockAuthorization)(nil).GetUserIDByJWT), token)
 }
 
+// GetUserRole mocks base method.
+func (m *MockAuthorization) GetUserRole(userID int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRole", userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRole indicates an expected call of GetUserRole.
+func (mr *MockAuthorizationMockRecorder) GetUserRole(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRole", reflect.TypeOf((*MockAuthorization)(nil).GetUserRole), userID)
+}
+
 // LogoutUser mocks base method.
-func (m *MockAuthorization) LogoutUser(token string) error {
+func (m *MockAuthorization) LogoutUser(ctx context.Context, token string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LogoutUser", token)
+	ret := m.ctrl.Call(m, "LogoutUser", ctx, token)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // LogoutUser indicates an expected call of LogoutUser.
-func (mr *MockAuthorizationMockRecorder) LogoutUser(token interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) LogoutUser(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutUser", reflect.TypeOf((*MockAuthorization)(nil).LogoutUser), ctx, token)
+}
+
+// RefreshJWT mocks base method.
+func (m *MockAuthorization) RefreshJWT(ctx context.Context, refreshToken string) (models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshJWT", ctx, refreshToken)
+	ret0, _ := ret[0].(models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshJWT indicates an expected call of RefreshJWT.
+func (mr *MockAuthorizationMockRecorder) RefreshJWT(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshJWT", reflect.TypeOf((*MockAuthorization)(nil).RefreshJWT), ctx, refreshToken)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockAuthorization) RegenerateRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockAuthorizationMockRecorder) RegenerateRecoveryCodes(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockAuthorization)(nil).RegenerateRecoveryCodes), ctx, userID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAuthorization) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAuthorizationMockRecorder) RevokeSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAuthorization)(nil).RevokeSession), ctx, userID, sessionID)
+}
+
+// VerifySecondFactor mocks base method.
+func (m *MockAuthorization) VerifySecondFactor(ctx context.Context, userID int, ip, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySecondFactor", ctx, userID, ip, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySecondFactor indicates an expected call of VerifySecondFactor.
+func (mr *MockAuthorizationMockRecorder) VerifySecondFactor(ctx, userID, ip, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutUser", reflect.TypeOf((*MockAuthorization)(nil).LogoutUser), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySecondFactor", reflect.TypeOf((*MockAuthorization)(nil).VerifySecondFactor), ctx, userID, ip, code)
 }
 
 // MockKrakenOrdersManager is a mock of KrakenOrdersManager interface.
@@ -135,19 +269,63 @@ func (m *MockKrakenOrdersManager) EXPECT() *MockKrakenOrdersManagerMockRecorder
 	return m.recorder
 }
 
+// BatchOrder mocks base method.
+func (m *MockKrakenOrdersManager) BatchOrder(ctx context.Context, userID int, exchange string, instructions []models.BatchInstruction) (models.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchOrder", ctx, userID, exchange, instructions)
+	ret0, _ := ret[0].(models.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchOrder indicates an expected call of BatchOrder.
+func (mr *MockKrakenOrdersManagerMockRecorder) BatchOrder(ctx, userID, exchange, instructions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchOrder", reflect.TypeOf((*MockKrakenOrdersManager)(nil).BatchOrder), ctx, userID, exchange, instructions)
+}
+
+// ExportUserOrders mocks base method.
+func (m *MockKrakenOrdersManager) ExportUserOrders(filter models.OrderFilter, format string, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserOrders", filter, format, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUserOrders indicates an expected call of ExportUserOrders.
+func (mr *MockKrakenOrdersManagerMockRecorder) ExportUserOrders(filter, format, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserOrders", reflect.TypeOf((*MockKrakenOrdersManager)(nil).ExportUserOrders), filter, format, w)
+}
+
+// GetUserOrders mocks base method.
+func (m *MockKrakenOrdersManager) GetUserOrders(filter models.OrderFilter) (models.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrders", filter)
+	ret0, _ := ret[0].(models.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrders indicates an expected call of GetUserOrders.
+func (mr *MockKrakenOrdersManagerMockRecorder) GetUserOrders(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockKrakenOrdersManager)(nil).GetUserOrders), filter)
+}
+
 // SendOrder mocks base method.
-func (m *MockKrakenOrdersManager) SendOrder(userID int, args krakenFuturesSDK.SendOrderArguments) (models.Order, error) {
+func (m *MockKrakenOrdersManager) SendOrder(ctx context.Context, userID int, idempotencyKey string, request models.OrderRequest) (models.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendOrder", userID, args)
+	ret := m.ctrl.Call(m, "SendOrder", ctx, userID, idempotencyKey, request)
 	ret0, _ := ret[0].(models.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SendOrder indicates an expected call of SendOrder.
-func (mr *MockKrakenOrdersManagerMockRecorder) SendOrder(userID, args interface{}) *gomock.Call {
+func (mr *MockKrakenOrdersManagerMockRecorder) SendOrder(ctx, userID, idempotencyKey, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendOrder", reflect.TypeOf((*MockKrakenOrdersManager)(nil).SendOrder), userID, args)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendOrder", reflect.TypeOf((*MockKrakenOrdersManager)(nil).SendOrder), ctx, userID, idempotencyKey, request)
 }
 
 // StartTrading mocks base method.
@@ -164,3 +342,534 @@ func (mr *MockKrakenOrdersManagerMockRecorder) StartTrading(ctx, userID, details
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTrading", reflect.TypeOf((*MockKrakenOrdersManager)(nil).StartTrading), ctx, userID, details)
 }
+
+// MockReports is a mock of Reports interface.
+type MockReports struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportsMockRecorder
+}
+
+// MockReportsMockRecorder is the mock recorder for MockReports.
+type MockReportsMockRecorder struct {
+	mock *MockReports
+}
+
+// NewMockReports creates a new mock instance.
+func NewMockReports(ctrl *gomock.Controller) *MockReports {
+	mock := &MockReports{ctrl: ctrl}
+	mock.recorder = &MockReportsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReports) EXPECT() *MockReportsMockRecorder {
+	return m.recorder
+}
+
+// GetReport mocks base method.
+func (m *MockReports) GetReport(filter models.ReportFilter) (models.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReport", filter)
+	ret0, _ := ret[0].(models.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReport indicates an expected call of GetReport.
+func (mr *MockReportsMockRecorder) GetReport(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReport", reflect.TypeOf((*MockReports)(nil).GetReport), filter)
+}
+
+// MockCandles is a mock of Candles interface.
+type MockCandles struct {
+	ctrl     *gomock.Controller
+	recorder *MockCandlesMockRecorder
+}
+
+// MockCandlesMockRecorder is the mock recorder for MockCandles.
+type MockCandlesMockRecorder struct {
+	mock *MockCandles
+}
+
+// NewMockCandles creates a new mock instance.
+func NewMockCandles(ctrl *gomock.Controller) *MockCandles {
+	mock := &MockCandles{ctrl: ctrl}
+	mock.recorder = &MockCandlesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCandles) EXPECT() *MockCandlesMockRecorder {
+	return m.recorder
+}
+
+// Backfill mocks base method.
+func (m *MockCandles) Backfill(candlesType krakenFuturesWSSDK.CandlesType, symbol string, interval krakenFuturesWSSDK.CandlesInterval, from, to time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backfill", candlesType, symbol, interval, from, to)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backfill indicates an expected call of Backfill.
+func (mr *MockCandlesMockRecorder) Backfill(candlesType, symbol, interval, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backfill", reflect.TypeOf((*MockCandles)(nil).Backfill), candlesType, symbol, interval, from, to)
+}
+
+// GetCandles mocks base method.
+func (m *MockCandles) GetCandles(candlesType krakenFuturesWSSDK.CandlesType, symbol string, interval krakenFuturesWSSDK.CandlesInterval, from, to time.Time) ([]models.Candle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCandles", candlesType, symbol, interval, from, to)
+	ret0, _ := ret[0].([]models.Candle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCandles indicates an expected call of GetCandles.
+func (mr *MockCandlesMockRecorder) GetCandles(candlesType, symbol, interval, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCandles", reflect.TypeOf((*MockCandles)(nil).GetCandles), candlesType, symbol, interval, from, to)
+}
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// Reconcile mocks base method.
+func (m *MockReconciler) Reconcile() (models.ReconcileReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile")
+	ret0, _ := ret[0].(models.ReconcileReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockReconcilerMockRecorder) Reconcile() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockReconciler)(nil).Reconcile))
+}
+
+// Run mocks base method.
+func (m *MockReconciler) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockReconcilerMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockReconciler)(nil).Run), ctx)
+}
+
+// MockJournal is a mock of Journal interface.
+type MockJournal struct {
+	ctrl     *gomock.Controller
+	recorder *MockJournalMockRecorder
+}
+
+// MockJournalMockRecorder is the mock recorder for MockJournal.
+type MockJournalMockRecorder struct {
+	mock *MockJournal
+}
+
+// NewMockJournal creates a new mock instance.
+func NewMockJournal(ctrl *gomock.Controller) *MockJournal {
+	mock := &MockJournal{ctrl: ctrl}
+	mock.recorder = &MockJournalMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJournal) EXPECT() *MockJournalMockRecorder {
+	return m.recorder
+}
+
+// ExportAuditEntries mocks base method.
+func (m *MockJournal) ExportAuditEntries(filter models.AuditFilter, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportAuditEntries", filter, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportAuditEntries indicates an expected call of ExportAuditEntries.
+func (mr *MockJournalMockRecorder) ExportAuditEntries(filter, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportAuditEntries", reflect.TypeOf((*MockJournal)(nil).ExportAuditEntries), filter, w)
+}
+
+// GetAuditEntries mocks base method.
+func (m *MockJournal) GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEntries", filter)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEntries indicates an expected call of GetAuditEntries.
+func (mr *MockJournalMockRecorder) GetAuditEntries(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEntries", reflect.TypeOf((*MockJournal)(nil).GetAuditEntries), filter)
+}
+
+// Record mocks base method.
+func (m *MockJournal) Record(ctx context.Context, entry models.AuditEntry, data interface{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Record", ctx, entry, data)
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockJournalMockRecorder) Record(ctx, entry, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockJournal)(nil).Record), ctx, entry, data)
+}
+
+// MockAdmin is a mock of Admin interface.
+type MockAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminMockRecorder
+}
+
+// MockAdminMockRecorder is the mock recorder for MockAdmin.
+type MockAdminMockRecorder struct {
+	mock *MockAdmin
+}
+
+// NewMockAdmin creates a new mock instance.
+func NewMockAdmin(ctrl *gomock.Controller) *MockAdmin {
+	mock := &MockAdmin{ctrl: ctrl}
+	mock.recorder = &MockAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdmin) EXPECT() *MockAdminMockRecorder {
+	return m.recorder
+}
+
+// DisableUser mocks base method.
+func (m *MockAdmin) DisableUser(ctx context.Context, adminID, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUser", ctx, adminID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockAdminMockRecorder) DisableUser(ctx, adminID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockAdmin)(nil).DisableUser), ctx, adminID, userID)
+}
+
+// EnableUser mocks base method.
+func (m *MockAdmin) EnableUser(ctx context.Context, adminID, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUser", ctx, adminID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUser indicates an expected call of EnableUser.
+func (mr *MockAdminMockRecorder) EnableUser(ctx, adminID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUser", reflect.TypeOf((*MockAdmin)(nil).EnableUser), ctx, adminID, userID)
+}
+
+// ForceLogout mocks base method.
+func (m *MockAdmin) ForceLogout(ctx context.Context, adminID, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceLogout", ctx, adminID, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceLogout indicates an expected call of ForceLogout.
+func (mr *MockAdminMockRecorder) ForceLogout(ctx, adminID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceLogout", reflect.TypeOf((*MockAdmin)(nil).ForceLogout), ctx, adminID, userID)
+}
+
+// GetAllSessions mocks base method.
+func (m *MockAdmin) GetAllSessions() ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllSessions")
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllSessions indicates an expected call of GetAllSessions.
+func (mr *MockAdminMockRecorder) GetAllSessions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSessions", reflect.TypeOf((*MockAdmin)(nil).GetAllSessions))
+}
+
+// GetRiskUsage mocks base method.
+func (m *MockAdmin) GetRiskUsage() ([]models.RiskUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskUsage")
+	ret0, _ := ret[0].([]models.RiskUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskUsage indicates an expected call of GetRiskUsage.
+func (mr *MockAdminMockRecorder) GetRiskUsage() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskUsage", reflect.TypeOf((*MockAdmin)(nil).GetRiskUsage))
+}
+
+// GetUsers mocks base method.
+func (m *MockAdmin) GetUsers() ([]models.UserSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers")
+	ret0, _ := ret[0].([]models.UserSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockAdminMockRecorder) GetUsers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockAdmin)(nil).GetUsers))
+}
+
+// SetUserRole mocks base method.
+func (m *MockAdmin) SetUserRole(ctx context.Context, adminID, userID int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", ctx, adminID, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockAdminMockRecorder) SetUserRole(ctx, adminID, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockAdmin)(nil).SetUserRole), ctx, adminID, userID, role)
+}
+
+// MockAccount is a mock of Account interface.
+type MockAccount struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountMockRecorder
+}
+
+// MockAccountMockRecorder is the mock recorder for MockAccount.
+type MockAccountMockRecorder struct {
+	mock *MockAccount
+}
+
+// NewMockAccount creates a new mock instance.
+func NewMockAccount(ctrl *gomock.Controller) *MockAccount {
+	mock := &MockAccount{ctrl: ctrl}
+	mock.recorder = &MockAccountMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccount) EXPECT() *MockAccountMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockAccount) ChangePassword(ctx context.Context, userID int, password, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, password, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAccountMockRecorder) ChangePassword(ctx, userID, password, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAccount)(nil).ChangePassword), ctx, userID, password, newPassword)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockAccount) DeleteAccount(ctx context.Context, userID int, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAccountMockRecorder) DeleteAccount(ctx, userID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccount)(nil).DeleteAccount), ctx, userID, password)
+}
+
+// GetProfile mocks base method.
+func (m *MockAccount) GetProfile(userID int) (models.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", userID)
+	ret0, _ := ret[0].(models.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockAccountMockRecorder) GetProfile(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockAccount)(nil).GetProfile), userID)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockAccount) UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (models.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, userID, update)
+	ret0, _ := ret[0].(models.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockAccountMockRecorder) UpdateProfile(ctx, userID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockAccount)(nil).UpdateProfile), ctx, userID, update)
+}
+
+// MockAPITokens is a mock of APITokens interface.
+type MockAPITokens struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPITokensMockRecorder
+}
+
+// MockAPITokensMockRecorder is the mock recorder for MockAPITokens.
+type MockAPITokensMockRecorder struct {
+	mock *MockAPITokens
+}
+
+// NewMockAPITokens creates a new mock instance.
+func NewMockAPITokens(ctrl *gomock.Controller) *MockAPITokens {
+	mock := &MockAPITokens{ctrl: ctrl}
+	mock.recorder = &MockAPITokensMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPITokens) EXPECT() *MockAPITokensMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateAPIToken mocks base method.
+func (m *MockAPITokens) AuthenticateAPIToken(token, ip string) (models.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIToken", token, ip)
+	ret0, _ := ret[0].(models.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIToken indicates an expected call of AuthenticateAPIToken.
+func (mr *MockAPITokensMockRecorder) AuthenticateAPIToken(token, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIToken", reflect.TypeOf((*MockAPITokens)(nil).AuthenticateAPIToken), token, ip)
+}
+
+// CreateAPIToken mocks base method.
+func (m *MockAPITokens) CreateAPIToken(ctx context.Context, userID int, input models.APITokenInput) (models.NewAPIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIToken", ctx, userID, input)
+	ret0, _ := ret[0].(models.NewAPIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIToken indicates an expected call of CreateAPIToken.
+func (mr *MockAPITokensMockRecorder) CreateAPIToken(ctx, userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIToken", reflect.TypeOf((*MockAPITokens)(nil).CreateAPIToken), ctx, userID, input)
+}
+
+// GetAPITokens mocks base method.
+func (m *MockAPITokens) GetAPITokens(userID int) ([]models.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPITokens", userID)
+	ret0, _ := ret[0].([]models.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPITokens indicates an expected call of GetAPITokens.
+func (mr *MockAPITokensMockRecorder) GetAPITokens(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPITokens", reflect.TypeOf((*MockAPITokens)(nil).GetAPITokens), userID)
+}
+
+// RevokeAPIToken mocks base method.
+func (m *MockAPITokens) RevokeAPIToken(ctx context.Context, userID, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIToken", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIToken indicates an expected call of RevokeAPIToken.
+func (mr *MockAPITokensMockRecorder) RevokeAPIToken(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIToken", reflect.TypeOf((*MockAPITokens)(nil).RevokeAPIToken), ctx, userID, id)
+}
+
+// MockRateLimiter is a mock of RateLimiter interface.
+type MockRateLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimiterMockRecorder
+}
+
+// MockRateLimiterMockRecorder is the mock recorder for MockRateLimiter.
+type MockRateLimiterMockRecorder struct {
+	mock *MockRateLimiter
+}
+
+// NewMockRateLimiter creates a new mock instance.
+func NewMockRateLimiter(ctrl *gomock.Controller) *MockRateLimiter {
+	mock := &MockRateLimiter{ctrl: ctrl}
+	mock.recorder = &MockRateLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimiter) EXPECT() *MockRateLimiterMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockRateLimiter) Allow(scope, client string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", scope, client)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockRateLimiterMockRecorder) Allow(scope, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockRateLimiter)(nil).Allow), scope, client)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrGetUserOrders       = errors.New("get user orders")
+	ErrExportUserOrders    = errors.New("export user orders")
+	ErrUnknownExportFormat = errors.New("unknown export format")
+)
+
+// Formats of order history export
+const (
+	CSVExport  = "csv"
+	JSONExport = "json"
+)
+
+// defaultOrdersPageSize is the number of orders on page when filter has no limit
+const defaultOrdersPageSize = 100
+
+var ordersCSVHeader = []string{
+	"id", "client_order_id", "type", "symbol", "side", "quantity", "filled", "price", "timestamp", "last_update_timestamp",
+}
+
+// GetUserOrders returns page of orders matching filter, the page starts after order filter.Cursor points at
+func (k *KrakenOrdersManagerService) GetUserOrders(filter models.OrderFilter) (models.OrderPage, error) {
+	if filter.Cursor != "" {
+		after, err := models.DecodeOrderCursor(filter.Cursor)
+		if err != nil {
+			return models.OrderPage{}, fmt.Errorf("%s: %w", ErrGetUserOrders, err)
+		}
+		filter.After = &after
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultOrdersPageSize
+	}
+	// one more order tells whether there is the next page
+	filter.Limit = limit + 1
+
+	orders, err := k.repo.FindUserOrders(filter)
+	if err != nil {
+		return models.OrderPage{}, fmt.Errorf("%s: %w", ErrGetUserOrders, err)
+	}
+
+	page := models.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		page.NextCursor = models.NewOrderCursor(orders[limit-1]).Encode()
+	}
+	return page, nil
+}
+
+// ExportUserOrders writes all orders matching filter to w as CSV with header or as JSON array,
+// limit and cursor of filter are ignored
+func (k *KrakenOrdersManagerService) ExportUserOrders(filter models.OrderFilter, format string, w io.Writer) error {
+	var write func(order models.Order) error
+	var finish func() error
+
+	switch format {
+	case CSVExport, "":
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write(ordersCSVHeader); err != nil {
+			return fmt.Errorf("%s: %w", ErrExportUserOrders, err)
+		}
+		write = func(order models.Order) error {
+			return csvWriter.Write([]string{order.ID, order.ClientOrderID, order.Type, order.Symbol, order.Side,
+				order.Quantity.String(), order.Filled.String(), order.Price.String(), order.Timestamp, order.LastUpdateTimestamp})
+		}
+		finish = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	case JSONExport:
+		separator := "["
+		write = func(order models.Order) error {
+			encoded, err := json.Marshal(order)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, separator); err != nil {
+				return err
+			}
+			separator = ","
+			_, err = w.Write(encoded)
+			return err
+		}
+		finish = func() error {
+			if separator == "[" {
+				_, err := io.WriteString(w, "[]")
+				return err
+			}
+			_, err := io.WriteString(w, "]")
+			return err
+		}
+	default:
+		return fmt.Errorf("%s: %s: %s", ErrExportUserOrders, ErrUnknownExportFormat, format)
+	}
+
+	filter.Limit, filter.After = exportPageSize, nil
+	for {
+		orders, err := k.repo.FindUserOrders(filter)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrExportUserOrders, err)
+		}
+
+		for _, order := range orders {
+			if err := write(order); err != nil {
+				return fmt.Errorf("%s: %w", ErrExportUserOrders, err)
+			}
+		}
+
+		if len(orders) < exportPageSize {
+			break
+		}
+		after := models.NewOrderCursor(orders[len(orders)-1])
+		filter.After = &after
+	}
+
+	if err := finish(); err != nil {
+		return fmt.Errorf("%s: %w", ErrExportUserOrders, err)
+	}
+	return nil
+}
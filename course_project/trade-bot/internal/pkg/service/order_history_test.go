@@ -0,0 +1,93 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository/memoryRepo"
+)
+
+func newOrderHistoryService(t *testing.T, n int) *KrakenOrdersManagerService {
+	repo := memoryRepo.NewKrakenOrdersManagerMemory(memoryRepo.NewDB())
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		order := models.Order{
+			ID:        fmt.Sprintf("order-%03d", i),
+			UserID:    1,
+			Type:      models.ExecutionOrder,
+			Symbol:    "pi_xbtusd",
+			Side:      "buy",
+			Quantity:  decimal.NewFromInt(1),
+			Filled:    decimal.NewFromInt(1),
+			Price:     decimal.NewFromInt(int64(100 + i)),
+			Timestamp: start.Add(time.Duration(i/2) * time.Second).Format(time.RFC3339),
+		}
+		require.NoError(t, repo.CreateOrder(1, order))
+	}
+	return NewKrakenOrdersManagerService(nil, repo, nil, nil, NewJournalService(&auditRepoStub{}))
+}
+
+func TestKrakenOrdersManagerService_GetUserOrders(t *testing.T) {
+	k := newOrderHistoryService(t, 5)
+
+	var ids []string
+	filter := models.OrderFilter{UserID: 1, Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+
+		page, err := k.GetUserOrders(filter)
+		require.NoError(t, err)
+		for _, order := range page.Orders {
+			ids = append(ids, order.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"order-000", "order-001", "order-002", "order-003", "order-004"}, ids)
+
+	page, err := k.GetUserOrders(models.OrderFilter{UserID: 1, Sort: models.SortDesc, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 5)
+	assert.Equal(t, "order-004", page.Orders[0].ID)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = k.GetUserOrders(models.OrderFilter{UserID: 1, Cursor: "not a cursor"})
+	assert.True(t, errors.Is(err, models.ErrInvalidOrderCursor))
+}
+
+func TestKrakenOrdersManagerService_ExportUserOrders(t *testing.T) {
+	k := newOrderHistoryService(t, exportPageSize+1)
+
+	var csvExport bytes.Buffer
+	require.NoError(t, k.ExportUserOrders(models.OrderFilter{UserID: 1}, CSVExport, &csvExport))
+	lines := bytes.Split(bytes.TrimSpace(csvExport.Bytes()), []byte("\n"))
+	require.Len(t, lines, exportPageSize+2)
+	assert.Equal(t, "id,client_order_id,type,symbol,side,quantity,filled,price,timestamp,last_update_timestamp", string(lines[0]))
+	assert.Equal(t, "order-000,,EXECUTION,pi_xbtusd,buy,1,1,100,2022-01-01T00:00:00Z,", string(lines[1]))
+
+	var jsonExport bytes.Buffer
+	require.NoError(t, k.ExportUserOrders(models.OrderFilter{UserID: 1, Sort: models.SortDesc}, JSONExport, &jsonExport))
+	var orders []models.Order
+	require.NoError(t, json.Unmarshal(jsonExport.Bytes(), &orders))
+	require.Len(t, orders, exportPageSize+1)
+	assert.Equal(t, fmt.Sprintf("order-%03d", exportPageSize), orders[0].ID)
+
+	var empty bytes.Buffer
+	require.NoError(t, k.ExportUserOrders(models.OrderFilter{UserID: 2}, JSONExport, &empty))
+	assert.Equal(t, "[]", empty.String())
+
+	err := k.ExportUserOrders(models.OrderFilter{UserID: 1}, "xml", &empty)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrUnknownExportFormat.Error())
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/repository"
+)
+
+var (
+	ErrRateLimit         = errors.New("rate limit")
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+)
+
+// AuthRateLimitScope limits requests to /auth group
+const AuthRateLimitScope = "auth"
+
+const (
+	defaultRateLimitRequests        = 20
+	defaultRateLimitWindowInSeconds = 60
+)
+
+func withDefaultRateLimitConfig(cfg configs.RateLimitConfiguration) configs.RateLimitConfiguration {
+	if cfg.Requests <= 0 {
+		cfg.Requests = defaultRateLimitRequests
+	}
+	if cfg.WindowInSeconds <= 0 {
+		cfg.WindowInSeconds = defaultRateLimitWindowInSeconds
+	}
+	return cfg
+}
+
+// RateLimiterService allows configured number of requests of client per window in every scope,
+// scopes without configuration get the default limit
+type RateLimiterService struct {
+	limits repository.Limits
+	scopes map[string]configs.RateLimitConfiguration
+}
+
+func NewRateLimiterService(limits repository.Limits, scopes map[string]configs.RateLimitConfiguration) *RateLimiterService {
+	configured := make(map[string]configs.RateLimitConfiguration, len(scopes))
+	for scope, cfg := range scopes {
+		configured[scope] = withDefaultRateLimitConfig(cfg)
+	}
+	return &RateLimiterService{limits: limits, scopes: configured}
+}
+
+// Allow counts request of client in scope, ErrRateLimitExceeded is wrapped with time left until window ends
+func (r *RateLimiterService) Allow(scope, client string) (time.Duration, error) {
+	cfg, ok := r.scopes[scope]
+	if !ok {
+		cfg = withDefaultRateLimitConfig(configs.RateLimitConfiguration{})
+	}
+
+	requests, left, err := r.limits.IncrementCounter("rate:"+scope+":"+client,
+		time.Duration(cfg.WindowInSeconds)*time.Second)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrRateLimit, err)
+	}
+	if requests > cfg.Requests {
+		return left, fmt.Errorf("%s: %w", ErrRateLimit, ErrRateLimitExceeded)
+	}
+	return 0, nil
+}
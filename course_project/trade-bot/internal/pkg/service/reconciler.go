@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/internal/pkg/web"
+	"trade-bot/pkg/utils"
+)
+
+var (
+	ErrReconcileOrders = errors.New("reconcile orders")
+)
+
+const (
+	defaultReconcileIntervalInSeconds = 60
+	defaultFillsLookbackInHours       = 24
+	// reconciledExchange is the exchange of orders source, orders of other exchanges are never on it
+	reconciledExchange = web.KrakenFuturesExchange
+)
+
+func withDefaultReconcilerConfig(cfg configs.ReconcilerConfiguration) configs.ReconcilerConfiguration {
+	if cfg.IntervalInSeconds == 0 {
+		cfg.IntervalInSeconds = defaultReconcileIntervalInSeconds
+	}
+	if cfg.FillsLookbackInHours <= 0 {
+		cfg.FillsLookbackInHours = defaultFillsLookbackInHours
+	}
+	return cfg
+}
+
+// ReconcilerService keeps stored orders in line with open orders and fills of the exchange
+type ReconcilerService struct {
+	source web.OrdersSource
+	orders repository.KrakenOrdersManager
+	audit  repository.Audit
+	config configs.ReconcilerConfiguration
+	now    func() time.Time
+
+	mu sync.Mutex
+	// flagged keeps orders found on one side only by the last run, so they are reported once
+	// and forgotten when reconciled or gone from both sides
+	flagged map[string]bool
+}
+
+func NewReconcilerService(source web.OrdersSource, orders repository.KrakenOrdersManager, audit repository.Audit,
+	config configs.ReconcilerConfiguration) *ReconcilerService {
+	return &ReconcilerService{
+		source:  source,
+		orders:  orders,
+		audit:   audit,
+		config:  withDefaultReconcilerConfig(config),
+		now:     time.Now,
+		flagged: make(map[string]bool),
+	}
+}
+
+// Run reconciles orders every configured interval until ctx is done, negative interval disables reconciliation
+func (r *ReconcilerService) Run(ctx context.Context) {
+	if r.config.IntervalInSeconds < 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(r.config.IntervalInSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		report, err := r.Reconcile()
+		if err != nil {
+			log.Error(err)
+		} else if report != (models.ReconcileReport{}) {
+			log.Infof("orders reconciled: %d corrected, %d restored, %d exchange only, %d database only",
+				report.Corrected, report.Restored, report.ExchangeOnly, report.DatabaseOnly)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile compares stored orders of the exchange with it once. Stored orders missing updates are corrected,
+// exchange orders which were not stored after sending are restored by their submissions,
+// other orders found on one side only are flagged. Every correction and flag is written to audit log
+// with correlation id of the run
+func (r *ReconcilerService) Reconcile() (models.ReconcileReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders, err := r.orders.GetOrders()
+	if err != nil {
+		return models.ReconcileReport{}, fmt.Errorf("%s: %w", ErrReconcileOrders, err)
+	}
+
+	stored := make([]models.Order, 0, len(orders))
+	for _, order := range orders {
+		if order.Exchange == reconciledExchange {
+			stored = append(stored, order)
+		}
+	}
+
+	open, err := r.source.OpenOrders()
+	if err != nil {
+		return models.ReconcileReport{}, fmt.Errorf("%s: %w", ErrReconcileOrders, err)
+	}
+
+	lookback := time.Duration(r.config.FillsLookbackInHours) * time.Hour
+	fills, err := r.source.Fills(r.now().Add(-lookback))
+	if err != nil {
+		return models.ReconcileReport{}, fmt.Errorf("%s: %w", ErrReconcileOrders, err)
+	}
+
+	byID := make(map[string]models.Order, len(stored))
+	byClientID := make(map[string]models.Order)
+	for _, order := range stored {
+		byID[order.ID] = order
+		if order.ClientOrderID != "" {
+			byClientID[order.ClientOrderID] = order
+		}
+	}
+
+	var (
+		report      models.ReconcileReport
+		entries     []models.AuditEntry
+		flagged     = make(map[string]bool)
+		corrections = make(map[int][]models.Order)
+		matched     = make(map[string]bool)
+	)
+
+	for _, exchangeOrder := range append(open, executedOrders(open, fills)...) {
+		order, ok := byID[exchangeOrder.ID]
+		if !ok && exchangeOrder.ClientOrderID != "" {
+			order, ok = byClientID[exchangeOrder.ClientOrderID]
+		}
+
+		if !ok && exchangeOrder.ClientOrderID != "" {
+			submission, err := r.orders.GetOrderSubmission(exchangeOrder.ClientOrderID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return models.ReconcileReport{}, fmt.Errorf("%s: %w", ErrReconcileOrders, err)
+			}
+			if err == nil {
+				exchangeOrder.UserID, exchangeOrder.Exchange = submission.UserID, reconciledExchange
+				report.Restored++
+				corrections[submission.UserID] = append(corrections[submission.UserID], exchangeOrder)
+				entries = append(entries, models.AuditEntry{
+					UserID:   submission.UserID,
+					Action:   models.AuditOrderRestored,
+					EntityID: exchangeOrder.ID,
+					Details:  fmt.Sprintf("%s is restored by client order id %s", describeOrder(exchangeOrder), exchangeOrder.ClientOrderID),
+				})
+				continue
+			}
+		}
+
+		if !ok {
+			flagged[exchangeOrder.ID] = true
+			if r.flagged[exchangeOrder.ID] {
+				continue
+			}
+			report.ExchangeOnly++
+			entries = append(entries, models.AuditEntry{
+				Action:   models.AuditExchangeOnlyOrder,
+				EntityID: exchangeOrder.ID,
+				Details:  fmt.Sprintf("%s is not stored", describeOrder(exchangeOrder)),
+			})
+			continue
+		}
+		matched[order.ID] = true
+
+		corrected, changes := correctOrder(order, exchangeOrder)
+		if len(changes) == 0 {
+			continue
+		}
+		report.Corrected++
+		corrections[order.UserID] = append(corrections[order.UserID], corrected)
+		entries = append(entries, models.AuditEntry{
+			UserID:   order.UserID,
+			Action:   models.AuditOrderCorrected,
+			EntityID: order.ID,
+			Details:  strings.Join(changes, ", "),
+		})
+	}
+
+	for _, order := range stored {
+		if matched[order.ID] || !isResting(order) {
+			continue
+		}
+		flagged[order.ID] = true
+		if r.flagged[order.ID] {
+			continue
+		}
+		report.DatabaseOnly++
+		entries = append(entries, models.AuditEntry{
+			UserID:   order.UserID,
+			Action:   models.AuditDatabaseOnlyOrder,
+			EntityID: order.ID,
+			Details:  fmt.Sprintf("%s is neither open nor filled on exchange", describeOrder(order)),
+		})
+	}
+
+	for userID, orders := range corrections {
+		if err := r.orders.CreateOrders(userID, orders); err != nil {
+			return models.ReconcileReport{}, fmt.Errorf("%s: %w", ErrReconcileOrders, err)
+		}
+	}
+
+	correlationID := utils.NewCorrelationID()
+	for _, entry := range entries {
+		entry.CorrelationID = correlationID
+		if err := r.audit.CreateAuditEntry(entry); err != nil {
+			return models.ReconcileReport{}, fmt.Errorf("%s: %w", ErrReconcileOrders, err)
+		}
+	}
+
+	r.flagged = flagged
+
+	return report, nil
+}
+
+// executedOrders builds orders which are no longer open from their fills,
+// price of such order is the average price of its fills
+func executedOrders(open []models.Order, fills []models.Fill) []models.Order {
+	isOpen := make(map[string]bool, len(open))
+	for _, order := range open {
+		isOpen[order.ID] = true
+	}
+
+	var (
+		orders []models.Order
+		index  = make(map[string]int)
+		cost   = make(map[string]decimal.Decimal)
+	)
+
+	for _, fill := range fills {
+		if isOpen[fill.OrderID] {
+			continue
+		}
+
+		fillTime := fill.Time.UTC().Format(time.RFC3339)
+		i, ok := index[fill.OrderID]
+		if !ok {
+			index[fill.OrderID] = len(orders)
+			orders = append(orders, models.Order{
+				ID:                  fill.OrderID,
+				ClientOrderID:       fill.ClientOrderID,
+				Type:                models.ExecutionOrder,
+				Symbol:              fill.Symbol,
+				Side:                fill.Side,
+				Quantity:            fill.Size,
+				Filled:              fill.Size,
+				Timestamp:           fillTime,
+				LastUpdateTimestamp: fillTime,
+			})
+			cost[fill.OrderID] = fill.Size.Mul(fill.Price)
+			continue
+		}
+
+		order := &orders[i]
+		order.Quantity = order.Quantity.Add(fill.Size)
+		order.Filled = order.Filled.Add(fill.Size)
+		cost[fill.OrderID] = cost[fill.OrderID].Add(fill.Size.Mul(fill.Price))
+		if fillTime < order.Timestamp {
+			order.Timestamp = fillTime
+		}
+		if fillTime > order.LastUpdateTimestamp {
+			order.LastUpdateTimestamp = fillTime
+		}
+	}
+
+	for i := range orders {
+		if orders[i].Filled.IsPositive() {
+			orders[i].Price = cost[orders[i].ID].Div(orders[i].Filled)
+		}
+	}
+
+	return orders
+}
+
+// correctOrder applies exchange state to stored order and describes changes made.
+// Filled size only grows, since fills older than lookback are not seen
+func correctOrder(stored, exchange models.Order) (models.Order, []string) {
+	var (
+		corrected = stored
+		changes   []string
+	)
+
+	if exchange.Type == models.ExecutionOrder && stored.Type != models.ExecutionOrder {
+		corrected.Type = exchange.Type
+		changes = append(changes, fmt.Sprintf("type: %s -> %s", stored.Type, exchange.Type))
+	}
+
+	if exchange.Filled.GreaterThan(stored.Filled) {
+		corrected.Filled = exchange.Filled
+		changes = append(changes, fmt.Sprintf("filled: %s -> %s", stored.Filled, exchange.Filled))
+	}
+
+	quantityChanged := exchange.Type != models.ExecutionOrder && exchange.Quantity.IsPositive() &&
+		!exchange.Quantity.Equal(stored.Quantity)
+	if quantityChanged || corrected.Filled.GreaterThan(stored.Quantity) {
+		quantity := exchange.Quantity
+		if !quantityChanged {
+			quantity = corrected.Filled
+		}
+		corrected.Quantity = quantity
+		changes = append(changes, fmt.Sprintf("quantity: %s -> %s", stored.Quantity, quantity))
+	}
+
+	priceComparable := exchange.Type == models.ExecutionOrder || corrected.Type != models.ExecutionOrder
+	if priceComparable && exchange.Price.IsPositive() && !exchange.Price.Equal(stored.Price) {
+		corrected.Price = exchange.Price
+		changes = append(changes, fmt.Sprintf("price: %s -> %s", stored.Price, exchange.Price))
+	}
+
+	if len(changes) > 0 && exchange.LastUpdateTimestamp != "" {
+		corrected.LastUpdateTimestamp = exchange.LastUpdateTimestamp
+	}
+
+	return corrected, changes
+}
+
+// isResting reports whether stored order is expected to be open on exchange
+func isResting(order models.Order) bool {
+	return order.Type == models.PlaceOrder || order.Type == models.EditOrder
+}
+
+func describeOrder(order models.Order) string {
+	return fmt.Sprintf("%s order %s: %s %s of %s, filled %s", order.Type, order.ID, order.Side, order.Quantity,
+		order.Symbol, order.Filled)
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/web"
+)
+
+type ordersSourceStub struct {
+	open  []models.Order
+	fills []models.Fill
+}
+
+func (s *ordersSourceStub) OpenOrders() ([]models.Order, error) {
+	return s.open, nil
+}
+
+func (s *ordersSourceStub) Fills(since time.Time) ([]models.Fill, error) {
+	return s.fills, nil
+}
+
+type ordersRepoStub struct {
+	orders      []models.Order
+	submissions []models.OrderSubmission
+	created     map[int][]models.Order
+}
+
+func (r *ordersRepoStub) CreateOrder(userID int, order models.Order) error {
+	return r.CreateOrders(userID, []models.Order{order})
+}
+
+func (r *ordersRepoStub) CreateOrders(userID int, orders []models.Order) error {
+	r.created[userID] = append(r.created[userID], orders...)
+	return nil
+}
+
+func (r *ordersRepoStub) GetUserOrders(userID int) ([]models.Order, error) {
+	return nil, nil
+}
+
+func (r *ordersRepoStub) FindUserOrders(filter models.OrderFilter) ([]models.Order, error) {
+	return nil, nil
+}
+
+func (r *ordersRepoStub) GetOrder(orderID string) (models.Order, error) {
+	return models.Order{}, nil
+}
+
+func (r *ordersRepoStub) GetOrders() ([]models.Order, error) {
+	return r.orders, nil
+}
+
+func (r *ordersRepoStub) CreateOrderSubmission(submission models.OrderSubmission) (models.OrderSubmission, bool, error) {
+	return submission, true, nil
+}
+
+func (r *ordersRepoStub) RetryOrderSubmission(cliOrderID string) (bool, error) {
+	return false, nil
+}
+
+func (r *ordersRepoStub) FailOrderSubmission(cliOrderID string) error {
+	return nil
+}
+
+func (r *ordersRepoStub) GetOrderSubmission(cliOrderID string) (models.OrderSubmission, error) {
+	for _, submission := range r.submissions {
+		if submission.CliOrderID == cliOrderID {
+			return submission, nil
+		}
+	}
+	return models.OrderSubmission{}, sql.ErrNoRows
+}
+
+type auditRepoStub struct {
+	entries []models.AuditEntry
+}
+
+func (a *auditRepoStub) CreateAuditEntry(entry models.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func (a *auditRepoStub) GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	return a.entries, nil
+}
+
+func TestReconcilerService_Reconcile(t *testing.T) {
+	fillTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	source := &ordersSourceStub{
+		open: []models.Order{
+			// partially filled since stored
+			{ID: "resting", Type: models.PlaceOrder, Symbol: "PI_XBTUSD", Side: models.BuySide,
+				Quantity: decimal.NewFromInt(5), Filled: decimal.NewFromInt(2), Price: decimal.NewFromInt(100)},
+			// accepted by exchange, server stopped before storing it
+			{ID: "lost", ClientOrderID: "lost-cli", Type: models.PlaceOrder, Symbol: "PI_XBTUSD", Side: models.BuySide,
+				Quantity: decimal.NewFromInt(1), Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(90)},
+			// placed on exchange but never stored
+			{ID: "unknown", Type: models.PlaceOrder, Symbol: "PI_XBTUSD", Side: models.SellSide,
+				Quantity: decimal.NewFromInt(1), Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(120)},
+		},
+		fills: []models.Fill{
+			{ID: "f1", OrderID: "filled", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: decimal.NewFromInt(1),
+				Price: decimal.NewFromInt(100), Time: fillTime},
+			{ID: "f2", OrderID: "filled", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: decimal.NewFromInt(3),
+				Price: decimal.NewFromInt(104), Time: fillTime.Add(time.Second)},
+			{ID: "f3", OrderID: "resting", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: decimal.NewFromInt(2),
+				Price: decimal.NewFromInt(100), Time: fillTime},
+		},
+	}
+	orders := &ordersRepoStub{
+		created:     make(map[int][]models.Order),
+		submissions: []models.OrderSubmission{{UserID: 3, CliOrderID: "lost-cli"}},
+		orders: []models.Order{
+			{ID: "resting", UserID: 1, Exchange: web.KrakenFuturesExchange, Type: models.PlaceOrder, Symbol: "PI_XBTUSD", Side: models.BuySide,
+				Quantity: decimal.NewFromInt(5), Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(100)},
+			// limit order filled while nobody listened
+			{ID: "filled", UserID: 2, Exchange: web.KrakenFuturesExchange, Type: models.PlaceOrder, Symbol: "PI_XBTUSD", Side: models.BuySide,
+				Quantity: decimal.NewFromInt(4), Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(105)},
+			// cancelled on exchange, update missed
+			{ID: "gone", UserID: 2, Exchange: web.KrakenFuturesExchange, Type: models.PlaceOrder, Symbol: "PI_XBTUSD", Side: models.SellSide,
+				Quantity: decimal.NewFromInt(1), Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(130)},
+			// executed long ago, not expected among open orders or fills
+			{ID: "old", UserID: 1, Exchange: web.KrakenFuturesExchange, Type: models.ExecutionOrder, Symbol: "PI_XBTUSD", Side: models.BuySide,
+				Quantity: decimal.NewFromInt(1), Filled: decimal.NewFromInt(1), Price: decimal.NewFromInt(90)},
+			// resting on paper exchange, kraken doesn't know it
+			{ID: "paper", UserID: 1, Exchange: web.PaperExchange, Type: models.PlaceOrder, Symbol: "PI_XBTUSD",
+				Side: models.BuySide, Quantity: decimal.NewFromInt(1), Filled: decimal.NewFromInt(0), Price: decimal.NewFromInt(80)},
+		},
+	}
+	audit := &auditRepoStub{}
+
+	r := NewReconcilerService(source, orders, audit, configs.ReconcilerConfiguration{})
+
+	report, err := r.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, models.ReconcileReport{Corrected: 2, Restored: 1, ExchangeOnly: 1, DatabaseOnly: 1}, report)
+
+	require.Len(t, orders.created[1], 1)
+	assert.Equal(t, "resting", orders.created[1][0].ID)
+	assert.True(t, orders.created[1][0].Filled.Equal(decimal.NewFromInt(2)))
+	assert.Equal(t, models.PlaceOrder, orders.created[1][0].Type)
+
+	require.Len(t, orders.created[2], 1)
+	filled := orders.created[2][0]
+	assert.Equal(t, models.ExecutionOrder, filled.Type)
+	assert.True(t, filled.Filled.Equal(decimal.NewFromInt(4)))
+	assert.True(t, filled.Quantity.Equal(decimal.NewFromInt(4)))
+	assert.True(t, filled.Price.Equal(decimal.NewFromInt(103)))
+	assert.Equal(t, "2022-01-02T03:04:06Z", filled.LastUpdateTimestamp)
+
+	require.Len(t, orders.created[3], 1)
+	assert.Equal(t, "lost", orders.created[3][0].ID)
+	assert.Equal(t, 3, orders.created[3][0].UserID)
+	assert.Equal(t, web.KrakenFuturesExchange, orders.created[3][0].Exchange)
+
+	actions := make(map[string]string)
+	for _, entry := range audit.entries {
+		actions[entry.EntityID] = entry.Action
+		assert.Equal(t, audit.entries[0].CorrelationID, entry.CorrelationID)
+	}
+	assert.NotEmpty(t, audit.entries[0].CorrelationID)
+	assert.Equal(t, map[string]string{
+		"resting": models.AuditOrderCorrected,
+		"filled":  models.AuditOrderCorrected,
+		"lost":    models.AuditOrderRestored,
+		"unknown": models.AuditExchangeOnlyOrder,
+		"gone":    models.AuditDatabaseOnlyOrder,
+	}, actions)
+
+	// orders found on one side only are flagged once
+	orders.orders = []models.Order{orders.created[1][0], orders.created[2][0], orders.created[3][0],
+		orders.orders[2], orders.orders[3], orders.orders[4]}
+	audit.entries = nil
+
+	report, err = r.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, models.ReconcileReport{}, report)
+	assert.Empty(t, audit.entries)
+	assert.Equal(t, map[string]bool{"unknown": true, "gone": true}, r.flagged)
+
+	// flags are dropped once orders are reconciled or no longer returned by exchange
+	source.open = source.open[:2]
+	gone := orders.orders[3]
+	gone.Type = models.CancelOrder
+	orders.orders[3] = gone
+
+	report, err = r.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, models.ReconcileReport{}, report)
+	assert.Empty(t, r.flagged)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+)
+
+var (
+	ErrGetReport = errors.New("get report")
+)
+
+// defaultFeeRate is taker fee of kraken futures
+const defaultFeeRate = 0.0005
+
+// ReportService summarizes trades of finished trading sessions
+type ReportService struct {
+	repo    repository.TradingSessions
+	feeRate decimal.Decimal
+}
+
+// NewReportService returns service estimating fees by fee rate of config, negative rate means no fees
+func NewReportService(repo repository.TradingSessions, config configs.ReportsConfiguration) *ReportService {
+	feeRate := config.FeeRate
+	switch {
+	case feeRate < 0:
+		feeRate = 0
+	case feeRate == 0:
+		feeRate = defaultFeeRate
+	}
+	return &ReportService{repo: repo, feeRate: decimal.NewFromFloat(feeRate)}
+}
+
+// GetReport summarizes trades finished in range of filter in total and by strategy and symbol,
+// trades are also summarized by days or months when period of filter is set
+func (r *ReportService) GetReport(filter models.ReportFilter) (models.Report, error) {
+	trades, err := r.repo.GetTrades(filter)
+	if err != nil {
+		return models.Report{}, fmt.Errorf("%s: %w", ErrGetReport, err)
+	}
+
+	report := models.Report{
+		UserID:  filter.UserID,
+		From:    filter.From,
+		To:      filter.To,
+		Period:  filter.Period,
+		FeeRate: r.feeRate,
+	}
+	report.Total, report.Strategies = r.summarize(trades)
+
+	if filter.Period == "" {
+		return report, nil
+	}
+
+	// trades are sorted by finish time, so trades of one period go in a row
+	for start := 0; start < len(trades); {
+		periodStart := startOfPeriod(*trades[start].FinishedAt, filter.Period)
+		end := start + 1
+		for end < len(trades) && startOfPeriod(*trades[end].FinishedAt, filter.Period).Equal(periodStart) {
+			end++
+		}
+
+		period := models.PeriodReport{Start: periodStart}
+		period.Total, period.Strategies = r.summarize(trades[start:end])
+		report.Periods = append(report.Periods, period)
+		start = end
+	}
+
+	return report, nil
+}
+
+func startOfPeriod(t time.Time, period string) time.Time {
+	t = t.UTC()
+	if period == models.MonthlyReport {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// summarize returns stats of all trades and stats of trades of every strategy and symbol sorted by them
+func (r *ReportService) summarize(trades []models.Trade) (models.TradeStats, []models.StrategyStats) {
+	type key struct{ strategy, symbol string }
+	groups := make(map[key][]models.Trade)
+	for _, trade := range trades {
+		k := key{strategy: trade.Strategy, symbol: trade.Symbol}
+		groups[k] = append(groups[k], trade)
+	}
+
+	strategies := make([]models.StrategyStats, 0, len(groups))
+	for k, group := range groups {
+		strategies = append(strategies, models.StrategyStats{Strategy: k.strategy, Symbol: k.symbol, TradeStats: r.stats(group)})
+	}
+	sort.Slice(strategies, func(i, j int) bool {
+		if strategies[i].Strategy != strategies[j].Strategy {
+			return strategies[i].Strategy < strategies[j].Strategy
+		}
+		return strategies[i].Symbol < strategies[j].Symbol
+	})
+
+	return r.stats(trades), strategies
+}
+
+// stats summarizes trades in order they finished. Trade wins when its profit net of fees is positive,
+// drawdown is the largest fall of cumulative net profit from its peak
+func (r *ReportService) stats(trades []models.Trade) models.TradeStats {
+	var (
+		stats                   models.TradeStats
+		grossProfit, grossLoss  decimal.Decimal
+		cumulative, peak, worst decimal.Decimal
+		exposure                time.Duration
+	)
+
+	for _, trade := range trades {
+		fee := trade.EntryPrice.Add(trade.ExitPrice).Mul(trade.Size()).Mul(r.feeRate)
+		profit := trade.Profit().Sub(fee)
+
+		stats.Trades++
+		stats.Fees = stats.Fees.Add(fee)
+		if profit.IsPositive() {
+			stats.Wins++
+			grossProfit = grossProfit.Add(profit)
+		} else {
+			stats.Losses++
+			grossLoss = grossLoss.Sub(profit)
+		}
+
+		cumulative = cumulative.Add(profit)
+		peak = decimal.Max(peak, cumulative)
+		worst = decimal.Max(worst, peak.Sub(cumulative))
+
+		if trade.FinishedAt != nil {
+			exposure += trade.FinishedAt.Sub(trade.StartedAt)
+		}
+	}
+
+	stats.NetProfit = cumulative
+	stats.MaxDrawdown = worst
+	stats.ExposureSeconds = int64(exposure / time.Second)
+	if stats.Trades > 0 {
+		stats.WinRate = decimal.NewFromInt(int64(stats.Wins)).Div(decimal.NewFromInt(int64(stats.Trades))).Round(4)
+	}
+	if stats.Wins > 0 {
+		stats.AverageWin = grossProfit.Div(decimal.NewFromInt(int64(stats.Wins)))
+	}
+	if stats.Losses > 0 {
+		stats.AverageLoss = grossLoss.Div(decimal.NewFromInt(int64(stats.Losses)))
+	}
+	if grossLoss.IsPositive() {
+		stats.ProfitFactor = grossProfit.Div(grossLoss).Round(4)
+	}
+
+	return stats
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/configs"
+	"trade-bot/internal/pkg/models"
+)
+
+type tradingSessionsStub struct {
+	trades []models.Trade
+	filter models.ReportFilter
+	err    error
+}
+
+func (s *tradingSessionsStub) CreateTradingSession(models.TradingSession) (int, error) { return 0, nil }
+
+func (s *tradingSessionsStub) FinishTradingSession(int, string, string, time.Time) error { return nil }
+
+func (s *tradingSessionsStub) GetTrades(filter models.ReportFilter) ([]models.Trade, error) {
+	s.filter = filter
+	return s.trades, s.err
+}
+
+func newTestTrade(symbol, side string, entry, exit, quantity, filled int64, start time.Time, d time.Duration) models.Trade {
+	finish := start.Add(d)
+	return models.Trade{
+		TradingSession: models.TradingSession{Strategy: "stop_loss_take_profit", Symbol: symbol, Side: side,
+			StartedAt: start, FinishedAt: &finish},
+		EntryPrice: decimal.NewFromInt(entry),
+		ExitPrice:  decimal.NewFromInt(exit),
+		Quantity:   decimal.NewFromInt(quantity),
+		Filled:     decimal.NewFromInt(filled),
+	}
+}
+
+func requireDecimal(t *testing.T, expected string, actual decimal.Decimal) {
+	t.Helper()
+	require.True(t, decimal.RequireFromString(expected).Equal(actual), "expected %s, actual %s", expected, actual)
+}
+
+func TestReportService_GetReport(t *testing.T) {
+	jan := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	feb := time.Date(2022, 2, 2, 0, 0, 0, 0, time.UTC)
+	repo := &tradingSessionsStub{trades: []models.Trade{
+		// 10 of profit minus 0.21 of fees
+		newTestTrade("PI_XBTUSD", "buy", 100, 110, 1, 1, jan, time.Hour),
+		// short position of ordered size loses 10 and 0.41 of fees
+		newTestTrade("PI_XBTUSD", "sell", 100, 105, 2, 0, jan.Add(2*time.Hour), 30*time.Minute),
+		// filled part of order earns 6 minus 0.066 of fees
+		newTestTrade("PI_ETHUSD", "buy", 10, 12, 5, 3, feb, time.Hour),
+	}}
+	r := NewReportService(repo, configs.ReportsConfiguration{FeeRate: 0.001})
+
+	filter := models.ReportFilter{UserID: 1, From: jan.AddDate(0, -1, 0), Period: models.MonthlyReport}
+	report, err := r.GetReport(filter)
+	require.NoError(t, err)
+	assert.Equal(t, filter, repo.filter)
+	assert.Equal(t, 1, report.UserID)
+	requireDecimal(t, "0.001", report.FeeRate)
+
+	total := report.Total
+	assert.Equal(t, 3, total.Trades)
+	assert.Equal(t, 2, total.Wins)
+	assert.Equal(t, 1, total.Losses)
+	requireDecimal(t, "0.6667", total.WinRate)
+	requireDecimal(t, "7.862", total.AverageWin)
+	requireDecimal(t, "10.41", total.AverageLoss)
+	requireDecimal(t, "1.5105", total.ProfitFactor)
+	requireDecimal(t, "5.314", total.NetProfit)
+	requireDecimal(t, "10.41", total.MaxDrawdown)
+	requireDecimal(t, "0.686", total.Fees)
+	assert.Equal(t, int64(9000), total.ExposureSeconds)
+
+	require.Len(t, report.Strategies, 2)
+	assert.Equal(t, "PI_ETHUSD", report.Strategies[0].Symbol)
+	assert.Equal(t, 1, report.Strategies[0].Trades)
+	assert.Equal(t, "PI_XBTUSD", report.Strategies[1].Symbol)
+	requireDecimal(t, "-0.62", report.Strategies[1].NetProfit)
+	requireDecimal(t, "0.9404", report.Strategies[1].ProfitFactor)
+
+	require.Len(t, report.Periods, 2)
+	assert.True(t, report.Periods[0].Start.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 2, report.Periods[0].Total.Trades)
+	assert.True(t, report.Periods[1].Start.Equal(time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)))
+	requireDecimal(t, "5.934", report.Periods[1].Total.NetProfit)
+	requireDecimal(t, "0", report.Periods[1].Total.ProfitFactor)
+
+	report, err = r.GetReport(models.ReportFilter{UserID: 1, Period: models.DailyReport})
+	require.NoError(t, err)
+	require.Len(t, report.Periods, 2)
+	assert.True(t, report.Periods[0].Start.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, report.Periods[1].Start.Equal(time.Date(2022, 2, 2, 0, 0, 0, 0, time.UTC)))
+
+	report, err = r.GetReport(models.ReportFilter{UserID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, report.Periods)
+}
+
+func TestReportService_FeeRate(t *testing.T) {
+	repo := &tradingSessionsStub{trades: []models.Trade{
+		newTestTrade("PI_XBTUSD", "buy", 100, 100, 1, 1, time.Now(), time.Minute),
+	}}
+
+	// breakeven trade loses its fees
+	report, err := NewReportService(repo, configs.ReportsConfiguration{}).GetReport(models.ReportFilter{})
+	require.NoError(t, err)
+	requireDecimal(t, "0.1", report.Total.Fees)
+	assert.Equal(t, 1, report.Total.Losses)
+	requireDecimal(t, "0.1", report.Total.MaxDrawdown)
+
+	report, err = NewReportService(repo, configs.ReportsConfiguration{FeeRate: -1}).GetReport(models.ReportFilter{})
+	require.NoError(t, err)
+	requireDecimal(t, "0", report.Total.Fees)
+	requireDecimal(t, "0", report.Total.NetProfit)
+
+	repo.err = errors.New("some error")
+	_, err = NewReportService(repo, configs.ReportsConfiguration{}).GetReport(models.ReportFilter{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrGetReport.Error())
+}
@@ -2,36 +2,122 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
+	"trade-bot/configs"
 	"trade-bot/internal/pkg/models"
 	"trade-bot/internal/pkg/repository"
 	"trade-bot/internal/pkg/tradeAlgorithm"
 	"trade-bot/internal/pkg/tradeAlgorithm/types"
 	"trade-bot/internal/pkg/web"
-	"trade-bot/pkg/krakenFuturesSDK"
+	"trade-bot/pkg/krakenFuturesWSSDK"
 )
 
 type Authorization interface {
 	CreateUser(user models.User) (int, error)
-	GenerateJWT(username string, password string) (string, error)
+	GenerateJWT(ctx context.Context, credentials models.Credentials, device models.Device) (models.Tokens, error)
+	RefreshJWT(ctx context.Context, refreshToken string) (models.Tokens, error)
 	GetUserIDByJWT(token string) (int, error)
-	LogoutUser(token string) error
+	LogoutUser(ctx context.Context, token string) error
+	GetSessions(userID int, token string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+	GetUserRole(userID int) (string, error)
+	EnrollTOTP(userID int) (models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID int) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int) ([]string, error)
+	VerifySecondFactor(ctx context.Context, userID int, ip, code string) error
 	GetUserAPIKeys(userID int) (string, string, error)
 }
 
 type KrakenOrdersManager interface {
-	SendOrder(userID int, args krakenFuturesSDK.SendOrderArguments) (models.Order, error)
-	GetUserOrders(userID int) ([]models.Order, error)
+	SendOrder(ctx context.Context, userID int, idempotencyKey string, request models.OrderRequest) (models.Order, error)
+	GetUserOrders(filter models.OrderFilter) (models.OrderPage, error)
+	ExportUserOrders(filter models.OrderFilter, format string, w io.Writer) error
+	BatchOrder(ctx context.Context, userID int, exchange string, instructions []models.BatchInstruction) (models.BatchResult, error)
 	StartTrading(ctx context.Context, userID int, details types.TradingDetails) (models.Order, error)
 }
 
+type Reports interface {
+	GetReport(filter models.ReportFilter) (models.Report, error)
+}
+
+type Candles interface {
+	Backfill(candlesType krakenFuturesWSSDK.CandlesType, symbol string, interval krakenFuturesWSSDK.CandlesInterval,
+		from, to time.Time) (int, error)
+	GetCandles(candlesType krakenFuturesWSSDK.CandlesType, symbol string, interval krakenFuturesWSSDK.CandlesInterval,
+		from, to time.Time) ([]models.Candle, error)
+}
+
+type Reconciler interface {
+	Reconcile() (models.ReconcileReport, error)
+	Run(ctx context.Context)
+}
+
+type Journal interface {
+	Record(ctx context.Context, entry models.AuditEntry, data interface{})
+	GetAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error)
+	ExportAuditEntries(filter models.AuditFilter, w io.Writer) error
+}
+
+type Admin interface {
+	GetUsers() ([]models.UserSummary, error)
+	SetUserRole(ctx context.Context, adminID, userID int, role string) error
+	DisableUser(ctx context.Context, adminID, userID int) error
+	EnableUser(ctx context.Context, adminID, userID int) error
+	ForceLogout(ctx context.Context, adminID, userID int) (int, error)
+	GetAllSessions() ([]models.Session, error)
+	GetRiskUsage() ([]models.RiskUsage, error)
+}
+
+type Account interface {
+	GetProfile(userID int) (models.Profile, error)
+	UpdateProfile(ctx context.Context, userID int, update models.ProfileUpdate) (models.Profile, error)
+	ChangePassword(ctx context.Context, userID int, password, newPassword string) error
+	DeleteAccount(ctx context.Context, userID int, password string) error
+}
+
+type APITokens interface {
+	CreateAPIToken(ctx context.Context, userID int, input models.APITokenInput) (models.NewAPIToken, error)
+	GetAPITokens(userID int) ([]models.APIToken, error)
+	RevokeAPIToken(ctx context.Context, userID, id int) error
+	AuthenticateAPIToken(token, ip string) (models.APIToken, error)
+}
+
+type RateLimiter interface {
+	Allow(scope, client string) (time.Duration, error)
+}
+
 type Service struct {
 	Authorization
 	KrakenOrdersManager
+	Reports
+	Candles
+	Reconciler
+	Journal
+	Admin
+	Account
+	APITokens
+	RateLimiter
 }
 
-func NewService(r *repository.Repository, w *web.Web, a *tradeAlgorithm.TradeAlgorithm) *Service {
+func NewService(r *repository.Repository, w *web.Web, a *tradeAlgorithm.TradeAlgorithm,
+	reconcilerConfig configs.ReconcilerConfiguration, authConfig configs.AuthConfiguration,
+	reportsConfig configs.ReportsConfiguration) *Service {
+	journal := NewJournalService(r.Audit)
+
 	return &Service{
-		Authorization:       NewAuthService(r.Authorization, r.JWT),
-		KrakenOrdersManager: NewKrakenOrdersManagerService(w.KrakenOrdersManager, r.KrakenOrdersManager, a.Trader),
+		Authorization:       NewAuthService(r.Authorization, r.JWT, r.Limits, journal, authConfig.LoginProtection),
+		KrakenOrdersManager: NewKrakenOrdersManagerService(w.Exchanges, r.KrakenOrdersManager, r.TradingSessions, a.Trader, journal),
+		Reports:             NewReportService(r.TradingSessions, reportsConfig),
+		Candles:             NewCandlesService(r.Candles, w.KrakenCharts),
+		Reconciler:          NewReconcilerService(w.OrdersSource, r.KrakenOrdersManager, r.Audit, reconcilerConfig),
+		Journal:             journal,
+		Admin:               NewAdminService(r.Admin, r.JWT, journal),
+		Account:             NewAccountService(r.Authorization, r.JWT, journal),
+		APITokens:           NewAPITokenService(r.APITokens, r.Authorization, journal),
+		RateLimiter: NewRateLimiterService(r.Limits, map[string]configs.RateLimitConfiguration{
+			AuthRateLimitScope: authConfig.RateLimit,
+		}),
 	}
 }
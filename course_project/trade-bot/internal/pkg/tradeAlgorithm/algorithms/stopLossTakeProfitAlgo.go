@@ -3,10 +3,11 @@ package algorithms
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
 
 	"trade-bot/internal/pkg/tradeAlgorithm/types"
 	"trade-bot/internal/pkg/web"
@@ -28,30 +29,73 @@ func NewStopLossTakeProfitAlgo(krakenAnalyzer web.KrakenAnalyzer) *StopLossTakeP
 	}
 }
 
-func (a *StopLossTakeProfitAlgo) StartAnalyzing(ctx context.Context, buyTime time.Time, details types.TradingDetails) error {
-	candles, err := a.krakenWebsocketSDK.LookForCandles(ctx, krakenFuturesWSSDK.OneMinuteCandlesFeed, []string{details.Symbol})
+// StartAnalyzing waits until price crosses take profit or stop loss border and returns decision made on it
+func (a *StopLossTakeProfitAlgo) StartAnalyzing(ctx context.Context, buyTime time.Time, details types.TradingDetails) (types.Decision, error) {
+	candles, gaps, err := a.lookForCandles(ctx, details)
 	if err != nil {
-		return fmt.Errorf("%s: %w", ErrStartAnalyzing, err)
+		return types.Decision{}, fmt.Errorf("%s: %w", ErrStartAnalyzing, err)
 	}
 
-	for candle := range candles {
-		price, err := strconv.ParseFloat(candle.Close, 64)
+	// after a gap the price could cross the borders unnoticed,
+	// so the whole range of the next candle is checked
+	var afterGap bool
 
-		if time.Unix(int64(candle.Time), 0).Before(buyTime) {
-			continue
-		}
+	for {
+		select {
+		case gap, ok := <-gaps:
+			if !ok {
+				gaps = nil
+				continue
+			}
+			log.Warnf("%s: %s: candles from %s to %s may have been missed",
+				ErrStartAnalyzing, details.Symbol, gap.From, gap.To)
+			afterGap = true
 
-		if err != nil {
-			return fmt.Errorf("%s: %w", ErrStartAnalyzing, err)
-		}
+		case candle, ok := <-candles:
+			if !ok {
+				return types.Decision{}, fmt.Errorf("%s: %s", ErrStartAnalyzing, ErrUnableToGetCandles)
+			}
 
-		if price > details.BuyPrice+details.TakeProfitBorder {
-			return nil
-		}
-		if price < details.BuyPrice-details.StopLossBorder {
-			return nil
+			candleEnd := time.Unix(int64(candle.Time), 0).Add(details.CandleDuration())
+			if candleEnd.Before(buyTime) {
+				continue
+			}
+
+			prices := []decimal.Decimal{candle.Close}
+			decision := types.Decision{
+				BuyPrice:   details.BuyPrice,
+				TakeProfit: details.BuyPrice.Add(details.TakeProfitBorder),
+				StopLoss:   details.BuyPrice.Sub(details.StopLossBorder),
+				CandleTime: time.Unix(int64(candle.Time), 0).UTC(),
+				Open:       candle.Open,
+				High:       candle.High,
+				Low:        candle.Low,
+				Close:      candle.Close,
+				AfterGap:   afterGap,
+			}
+			if afterGap {
+				prices = append(prices, candle.High, candle.Low)
+				afterGap = false
+			}
+
+			for _, price := range prices {
+				decision.Price = price
+				if price.GreaterThan(decision.TakeProfit) {
+					decision.Reason = types.TakeProfitReason
+					return decision, nil
+				}
+				if price.LessThan(decision.StopLoss) {
+					decision.Reason = types.StopLossReason
+					return decision, nil
+				}
+			}
 		}
 	}
+}
 
-	return fmt.Errorf("%s: %s", ErrStartAnalyzing, ErrUnableToGetCandles)
+func (a *StopLossTakeProfitAlgo) lookForCandles(ctx context.Context, details types.TradingDetails) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	if spec, ok := details.BarSpec(); ok {
+		return a.krakenWebsocketSDK.LookForTradeCandles(ctx, details.Symbol, spec)
+	}
+	return a.krakenWebsocketSDK.LookForCandles(ctx, details.CandlesFeed(), []string{details.Symbol})
 }
@@ -0,0 +1,104 @@
+package tradeAlgorithm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
+	"trade-bot/internal/pkg/web"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+var (
+	ErrRecordCandle   = errors.New("record candle")
+	ErrCandlesHistory = errors.New("candles history")
+)
+
+// candlesRecorder stores closed candles of kraken candles feeds received by strategies
+type candlesRecorder struct {
+	web.KrakenAnalyzer
+	repo repository.Candles
+}
+
+func newCandlesRecorder(analyzer web.KrakenAnalyzer, repo repository.Candles) *candlesRecorder {
+	return &candlesRecorder{KrakenAnalyzer: analyzer, repo: repo}
+}
+
+// LookForCandles records candles of a single product, candles of several products
+// could not be told apart and are passed as is
+func (r *candlesRecorder) LookForCandles(ctx context.Context, feed string, productsIDs []string) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	candles, gaps, err := r.KrakenAnalyzer.LookForCandles(ctx, feed, productsIDs)
+	if err != nil || len(productsIDs) != 1 {
+		return candles, gaps, err
+	}
+
+	typ, interval, err := krakenFuturesWSSDK.ParseCandlesFeed(feed)
+	if err != nil {
+		return candles, gaps, nil
+	}
+
+	recordedCandles := make(chan krakenFuturesWSSDK.Candle)
+	go func() {
+		defer close(recordedCandles)
+
+		// feed sends updates of the current candle, it is saved once the next candle starts
+		// and the last one is saved when feed stops
+		var (
+			current krakenFuturesWSSDK.Candle
+			started bool
+		)
+		for candle := range candles {
+			recordedCandles <- candle
+
+			if started && candle.Time != current.Time {
+				r.save(current, productsIDs[0], typ, interval)
+			}
+			current, started = candle, true
+		}
+		if started {
+			r.save(current, productsIDs[0], typ, interval)
+		}
+	}()
+
+	return recordedCandles, gaps, nil
+}
+
+func (r *candlesRecorder) save(candle krakenFuturesWSSDK.Candle, symbol string, typ krakenFuturesWSSDK.CandlesType,
+	interval krakenFuturesWSSDK.CandlesInterval) {
+	if err := r.repo.SaveCandles([]models.Candle{toModelCandle(candle, symbol, typ, interval)}); err != nil {
+		log.Warnf("%s: %s", ErrRecordCandle, err)
+	}
+}
+
+func toModelCandle(c krakenFuturesWSSDK.Candle, symbol string, typ krakenFuturesWSSDK.CandlesType,
+	interval krakenFuturesWSSDK.CandlesInterval) models.Candle {
+	return models.Candle{
+		Symbol:   symbol,
+		Type:     string(typ),
+		Interval: string(interval),
+		Time:     time.Unix(int64(c.Time), 0).UTC(),
+		Open:     c.Open,
+		High:     c.High,
+		Low:      c.Low,
+		Close:    c.Close,
+		Volume:   c.Volume,
+	}
+}
+
+type candlesHistory struct {
+	repo repository.Candles
+}
+
+// History returns n latest stored trade candles of the interval sorted by time
+func (h *candlesHistory) History(symbol string, interval krakenFuturesWSSDK.CandlesInterval, n int) ([]models.Candle, error) {
+	candles, err := h.repo.GetLastCandles(symbol, string(krakenFuturesWSSDK.TradeCandles), string(interval), n)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrCandlesHistory, err)
+	}
+	return candles, nil
+}
@@ -0,0 +1,80 @@
+package tradeAlgorithm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/pkg/candleAggregator"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+type analyzerStub struct {
+	candles []krakenFuturesWSSDK.Candle
+}
+
+func (a *analyzerStub) LookForCandles(ctx context.Context, feed string, productsIDs []string) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	candles := make(chan krakenFuturesWSSDK.Candle, len(a.candles))
+	for _, c := range a.candles {
+		candles <- c
+	}
+	close(candles)
+	return candles, make(chan krakenFuturesWSSDK.Gap), nil
+}
+
+func (a *analyzerStub) LookForTradeCandles(ctx context.Context, productID string, spec candleAggregator.BarSpec) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	return nil, nil, nil
+}
+
+type candlesRepoStub struct {
+	saved []models.Candle
+	calls int
+}
+
+func (r *candlesRepoStub) SaveCandles(candles []models.Candle) error {
+	r.calls++
+	r.saved = append(r.saved, candles...)
+	return nil
+}
+
+func (r *candlesRepoStub) GetLastCandles(symbol, candlesType, interval string, n int) ([]models.Candle, error) {
+	return nil, nil
+}
+
+func (r *candlesRepoStub) GetCandles(symbol, candlesType, interval string, from, to time.Time) ([]models.Candle, error) {
+	return nil, nil
+}
+
+func TestCandlesRecorder_SavesClosedCandles(t *testing.T) {
+	analyzer := &analyzerStub{candles: []krakenFuturesWSSDK.Candle{
+		{Time: 1641168000, Close: decimal.NewFromInt(1)},
+		{Time: 1641168000, Close: decimal.NewFromInt(2)},
+		{Time: 1641168000, Close: decimal.NewFromInt(3)},
+		{Time: 1641168060, Close: decimal.NewFromInt(4)},
+		{Time: 1641168060, Close: decimal.NewFromInt(5)},
+	}}
+	repo := &candlesRepoStub{}
+
+	candles, _, err := newCandlesRecorder(analyzer, repo).LookForCandles(context.Background(), "candles_trade_1m", []string{"PI_XBTUSD"})
+	require.NoError(t, err)
+
+	received := 0
+	for range candles {
+		received++
+	}
+	assert.Equal(t, 5, received)
+
+	// updates of a candle are saved once with its last values
+	require.Len(t, repo.saved, 2)
+	assert.Equal(t, 2, repo.calls)
+	assert.Equal(t, time.Unix(1641168000, 0).UTC(), repo.saved[0].Time)
+	assert.True(t, decimal.NewFromInt(3).Equal(repo.saved[0].Close))
+	assert.Equal(t, time.Unix(1641168060, 0).UTC(), repo.saved[1].Time)
+	assert.True(t, decimal.NewFromInt(5).Equal(repo.saved[1].Close))
+	assert.Equal(t, "PI_XBTUSD", repo.saved[1].Symbol)
+}
@@ -3,19 +3,33 @@ package tradeAlgorithm
 import (
 	"context"
 	"time"
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/repository"
 	"trade-bot/internal/pkg/tradeAlgorithm/algorithms"
 	"trade-bot/internal/pkg/tradeAlgorithm/types"
 	"trade-bot/internal/pkg/web"
+	"trade-bot/pkg/krakenFuturesWSSDK"
 )
 
 type Trader interface {
-	StartAnalyzing(ctx context.Context, buyTime time.Time, details types.TradingDetails) error
+	StartAnalyzing(ctx context.Context, buyTime time.Time, details types.TradingDetails) (types.Decision, error)
+}
+
+// CandlesHistory gives strategies stored candles for warm-up
+type CandlesHistory interface {
+	History(symbol string, interval krakenFuturesWSSDK.CandlesInterval, n int) ([]models.Candle, error)
 }
 
 type TradeAlgorithm struct {
 	Trader
+	CandlesHistory
 }
 
-func NewTradeAlgorithm(w *web.Web) *TradeAlgorithm {
-	return &TradeAlgorithm{Trader: algorithms.NewStopLossTakeProfitAlgo(w.KrakenAnalyzer)}
+func NewTradeAlgorithm(w *web.Web, candlesRepo repository.Candles) *TradeAlgorithm {
+	analyzer := newCandlesRecorder(w.KrakenAnalyzer, candlesRepo)
+
+	return &TradeAlgorithm{
+		Trader:         algorithms.NewStopLossTakeProfitAlgo(analyzer),
+		CandlesHistory: &candlesHistory{repo: candlesRepo},
+	}
 }
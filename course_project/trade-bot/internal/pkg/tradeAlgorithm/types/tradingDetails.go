@@ -1,11 +1,112 @@
 package types
 
+import (
+	"reflect"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"trade-bot/pkg/candleAggregator"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
 type TradingDetails struct {
-	OrderType        string  `json:"order_type" validate:"required"`
-	Symbol           string  `json:"symbol" validate:"required"`
-	Side             string  `json:"side" validate:"required"`
-	Size             uint    `json:"size" validate:"required,gte=0"`
-	StopLossBorder   float64 `json:"stop_loss_border" validate:"required,gte=0"`
-	TakeProfitBorder float64 `json:"take_profit_border" validate:"required,gte=0"`
-	BuyPrice         float64
+	Exchange         string                             `json:"exchange" validate:"omitempty,oneof=kraken_futures paper"`
+	OrderType        string                             `json:"order_type" validate:"required"`
+	Symbol           string                             `json:"symbol" validate:"required"`
+	Side             string                             `json:"side" validate:"required"`
+	Size             uint                               `json:"size" validate:"required,gte=0"`
+	StopLossBorder   decimal.Decimal                    `json:"stop_loss_border" validate:"required,gte=0"`
+	TakeProfitBorder decimal.Decimal                    `json:"take_profit_border" validate:"required,gte=0"`
+	CandlesType      krakenFuturesWSSDK.CandlesType     `json:"candles_type" validate:"omitempty,oneof=trade mark spot"`
+	Interval         krakenFuturesWSSDK.CandlesInterval `json:"interval" validate:"omitempty,oneof=1m 5m 15m 1h 4h 12h 1d 1w"`
+	BarType          candleAggregator.BarType           `json:"bar_type" validate:"omitempty,oneof=time tick volume"`
+	BarSize          decimal.Decimal                    `json:"bar_size"`
+	BuyPrice         decimal.Decimal
+}
+
+// CandlesFeed returns the feed strategy evaluates on, trade candles of 1 minute by default
+func (d TradingDetails) CandlesFeed() string {
+	typ, interval := d.CandlesType, d.Interval
+	if typ == "" {
+		typ = krakenFuturesWSSDK.TradeCandles
+	}
+	if interval == "" {
+		interval = krakenFuturesWSSDK.OneMinute
+	}
+	return krakenFuturesWSSDK.CandlesFeed(typ, interval)
+}
+
+// CandlesInterval returns the timeframe strategy evaluates on
+func (d TradingDetails) CandlesInterval() krakenFuturesWSSDK.CandlesInterval {
+	if d.Interval == "" {
+		return krakenFuturesWSSDK.OneMinute
+	}
+	return d.Interval
+}
+
+// BarSpec returns spec of candles aggregated locally from trades.
+// False is returned when BarType is empty and kraken candles feed should be used
+func (d TradingDetails) BarSpec() (candleAggregator.BarSpec, bool) {
+	if d.BarType == "" {
+		return candleAggregator.BarSpec{}, false
+	}
+	return candleAggregator.BarSpec{
+		Type:   d.BarType,
+		Period: d.CandlesInterval().Duration(),
+		Size:   d.BarSize,
+	}, true
+}
+
+// Validate checks rules validator tags can't describe: size of bars is checked
+// by the aggregator rule, so that details rejected by it never pass binding
+func (d TradingDetails) Validate() error {
+	if spec, ok := d.BarSpec(); ok {
+		return spec.Validate()
+	}
+	return nil
+}
+
+// CandleDuration returns the length of candles strategy evaluates on,
+// zero for tick and volume bars which have no fixed length
+func (d TradingDetails) CandleDuration() time.Duration {
+	if d.BarType == candleAggregator.TickBars || d.BarType == candleAggregator.VolumeBars {
+		return 0
+	}
+	return d.CandlesInterval().Duration()
+}
+
+// DecimalValue lets validator check decimal fields with numeric tags, register it with
+// validate.RegisterCustomTypeFunc(types.DecimalValue, decimal.Decimal{})
+func DecimalValue(field reflect.Value) interface{} {
+	d, ok := field.Interface().(decimal.Decimal)
+	if !ok {
+		return nil
+	}
+	f, _ := d.Float64()
+	return f
+}
+
+const (
+	TakeProfitReason = "take_profit"
+	StopLossReason   = "stop_loss"
+)
+
+// StopLossTakeProfitStrategy is name of strategy trading sessions and reports refer to
+const StopLossTakeProfitStrategy = "stop_loss_take_profit"
+
+// Decision describes why strategy closed position and indicator values at decision time
+type Decision struct {
+	Reason     string          `json:"reason"`
+	Price      decimal.Decimal `json:"price"`
+	BuyPrice   decimal.Decimal `json:"buy_price"`
+	TakeProfit decimal.Decimal `json:"take_profit"`
+	StopLoss   decimal.Decimal `json:"stop_loss"`
+	CandleTime time.Time       `json:"candle_time"`
+	Open       decimal.Decimal `json:"open"`
+	High       decimal.Decimal `json:"high"`
+	Low        decimal.Decimal `json:"low"`
+	Close      decimal.Decimal `json:"close"`
+	// AfterGap is set when the whole range of candle was checked since candles before it may have been missed
+	AfterGap bool `json:"after_gap"`
 }
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/pkg/candleAggregator"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+func TestTradingDetails_CandlesFeed(t *testing.T) {
+	tests := []struct {
+		name     string
+		details  TradingDetails
+		feed     string
+		duration time.Duration
+	}{
+		{
+			name:     "Default",
+			feed:     "candles_trade_1m",
+			duration: time.Minute,
+		},
+		{
+			name:     "Mark candles of hour",
+			details:  TradingDetails{CandlesType: krakenFuturesWSSDK.MarkCandles, Interval: krakenFuturesWSSDK.OneHour},
+			feed:     "candles_mark_1h",
+			duration: time.Hour,
+		},
+		{
+			name:     "Spot candles of week",
+			details:  TradingDetails{CandlesType: krakenFuturesWSSDK.SpotCandles, Interval: krakenFuturesWSSDK.OneWeek},
+			feed:     "candles_spot_1w",
+			duration: 7 * 24 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.feed, test.details.CandlesFeed())
+			assert.Equal(t, test.duration, test.details.CandleDuration())
+
+			typ, interval, err := krakenFuturesWSSDK.ParseCandlesFeed(test.feed)
+			assert.NoError(t, err)
+			assert.Equal(t, test.feed, krakenFuturesWSSDK.CandlesFeed(typ, interval))
+		})
+	}
+}
+
+func TestTradingDetails_Validate(t *testing.T) {
+	validate := validator.New()
+	validate.RegisterCustomTypeFunc(DecimalValue, decimal.Decimal{})
+
+	valid := TradingDetails{OrderType: "mkt", Symbol: "PI_XBTUSD", Side: "buy", Size: 1,
+		StopLossBorder: decimal.NewFromInt(10), TakeProfitBorder: decimal.NewFromInt(10),
+		CandlesType: krakenFuturesWSSDK.MarkCandles, Interval: krakenFuturesWSSDK.FourHours}
+	assert.NoError(t, validate.Struct(valid))
+
+	invalid := valid
+	invalid.Interval = "2m"
+	assert.Error(t, validate.Struct(invalid))
+
+	invalid = valid
+	invalid.CandlesType = "index"
+	assert.Error(t, validate.Struct(invalid))
+}
+
+func TestTradingDetails_ValidateBarSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		barType candleAggregator.BarType
+		size    string
+		wantErr bool
+	}{
+		{name: "Tick bars", barType: candleAggregator.TickBars, size: "100"},
+		{name: "Fractional tick bars", barType: candleAggregator.TickBars, size: "0.5", wantErr: true},
+		{name: "Fractional volume bars", barType: candleAggregator.VolumeBars, size: "0.5"},
+		{name: "Empty volume bars", barType: candleAggregator.VolumeBars, size: "0", wantErr: true},
+		{name: "Time bars", barType: candleAggregator.TimeBars, size: "0"},
+		{name: "Kraken candles", size: "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			details := TradingDetails{BarType: test.barType, BarSize: decimal.RequireFromString(test.size)}
+
+			// binding and aggregator apply the same rule
+			_, specErr := candleAggregator.NewAggregator(candleAggregator.BarSpec{
+				Type: test.barType, Period: time.Minute, Size: details.BarSize})
+			err := details.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if test.barType != "" {
+				assert.Equal(t, err != nil, specErr != nil)
+			}
+		})
+	}
+}
@@ -2,33 +2,93 @@ package web
 
 import (
 	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 
 	"trade-bot/internal/pkg/models"
 	"trade-bot/internal/pkg/web/webKraken"
+	"trade-bot/internal/pkg/web/webPaper"
+	"trade-bot/pkg/candleAggregator"
 	"trade-bot/pkg/krakenFuturesSDK"
 	"trade-bot/pkg/krakenFuturesWSSDK"
 )
 
-type KrakenOrdersManager interface {
-	SendOrder(args krakenFuturesSDK.SendOrderArguments) (krakenFuturesSDK.SendStatus, error)
-	EditOrder(args krakenFuturesSDK.EditOrderArguments) (krakenFuturesSDK.EditStatus, error)
-	CancelOrder(args krakenFuturesSDK.CancelOrderArguments) (krakenFuturesSDK.CancelStatus, error)
-	CancelAllOrders(symbol string) (krakenFuturesSDK.CancelAllStatus, error)
-	ParseSendStatusToExecutedOrder(userID int, sendStatus krakenFuturesSDK.SendStatus) (models.Order, error)
-}
+var (
+	ErrUnknownExchange = errors.New("unknown exchange")
+)
+
+const (
+	KrakenFuturesExchange = webKraken.ExchangeName
+	PaperExchange         = webPaper.ExchangeName
+	DefaultExchange       = KrakenFuturesExchange
+)
 
 type KrakenAnalyzer interface {
-	LookForCandles(ctx context.Context, feed string, productsIDs []string) (<-chan krakenFuturesWSSDK.Candle, error)
+	LookForCandles(ctx context.Context, feed string, productsIDs []string) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error)
+	LookForTradeCandles(ctx context.Context, productID string, spec candleAggregator.BarSpec) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error)
+}
+
+type KrakenCharts interface {
+	GetCandles(candlesType krakenFuturesWSSDK.CandlesType, symbol string, interval krakenFuturesWSSDK.CandlesInterval,
+		from, to time.Time) ([]models.Candle, error)
+}
+
+// Exchange is a trading venue described with exchange-neutral models
+type Exchange interface {
+	SendOrder(userID int, request models.OrderRequest) (models.Order, error)
+	LastPrice(symbol string) (decimal.Decimal, error)
+	Instruments() ([]models.Instrument, error)
+	GetCandles(symbol string, interval string, from, to time.Time) ([]models.Candle, error)
+}
+
+// BatchExchange is implemented by exchanges executing many instructions in one request
+type BatchExchange interface {
+	BatchOrder(userID int, instructions []models.BatchInstruction) (models.BatchResult, error)
+}
+
+// OrdersSource reports state of exchange orders for reconciliation
+type OrdersSource interface {
+	OpenOrders() ([]models.Order, error)
+	Fills(since time.Time) ([]models.Fill, error)
+}
+
+// Exchanges maps exchange names to their adapters
+type Exchanges map[string]Exchange
+
+// Get returns exchange by name, the default exchange is returned for empty name
+func (e Exchanges) Get(name string) (Exchange, error) {
+	if name == "" {
+		name = DefaultExchange
+	}
+
+	exchange, ok := e[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %s", ErrUnknownExchange, name)
+	}
+	return exchange, nil
 }
 
 type Web struct {
-	KrakenOrdersManager
 	KrakenAnalyzer
+	KrakenCharts
+	Exchanges Exchanges
+	// OrdersSource is the exchange stored orders are reconciled with
+	OrdersSource OrdersSource
 }
 
 func NewWeb(krakenAPISDK *krakenFuturesSDK.API, krakenWebsocketSDK *krakenFuturesWSSDK.WSAPI) *Web {
+	krakenFutures := webKraken.NewKrakenFuturesExchange(krakenAPISDK)
+
 	return &Web{
-		KrakenOrdersManager: webKraken.NewKrakenOrdersManagerWebSDK(krakenAPISDK),
-		KrakenAnalyzer:      webKraken.NewKrakenAnalyzerWebSDK(krakenWebsocketSDK),
+		KrakenAnalyzer: webKraken.NewKrakenAnalyzerWebSDK(krakenWebsocketSDK),
+		KrakenCharts:   webKraken.NewKrakenChartsWebSDK(krakenAPISDK),
+		Exchanges: Exchanges{
+			KrakenFuturesExchange: krakenFutures,
+			PaperExchange:         webPaper.NewPaperExchange(krakenFutures),
+		},
+		OrdersSource: krakenFutures,
 	}
 }
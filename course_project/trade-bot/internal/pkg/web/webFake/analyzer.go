@@ -0,0 +1,54 @@
+package webFake
+
+import (
+	"context"
+
+	"trade-bot/pkg/candleAggregator"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+// Analyzer streams candles of script to every strategy whatever feed or product it asks for
+type Analyzer struct {
+	script *Script
+}
+
+func NewAnalyzer(script *Script) *Analyzer {
+	return &Analyzer{script: script}
+}
+
+func (a *Analyzer) LookForCandles(ctx context.Context, _ string, _ []string) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	return a.stream(ctx), nil, nil
+}
+
+func (a *Analyzer) LookForTradeCandles(ctx context.Context, _ string, _ candleAggregator.BarSpec) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	return a.stream(ctx), nil, nil
+}
+
+// stream sends candles of script until ctx is done, then channel is closed like the one of websocket
+func (a *Analyzer) stream(ctx context.Context) <-chan krakenFuturesWSSDK.Candle {
+	candles := make(chan krakenFuturesWSSDK.Candle)
+
+	go func() {
+		defer close(candles)
+
+		for {
+			candle, pushed, ok := a.script.next()
+			if !ok {
+				select {
+				case <-pushed:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case candles <- candle:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return candles
+}
@@ -0,0 +1,90 @@
+package webFake
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/web/webPaper"
+)
+
+// market is market data of script, every symbol has the price of script
+type market struct {
+	script *Script
+}
+
+func (m market) LastPrice(string) (decimal.Decimal, error) {
+	return m.script.Price(), nil
+}
+
+func (m market) Instruments() ([]models.Instrument, error) {
+	return []models.Instrument{}, nil
+}
+
+func (m market) GetCandles(symbol string, interval string, from, to time.Time) ([]models.Candle, error) {
+	return m.script.candles(symbol, "trade", interval, from, to), nil
+}
+
+// Exchange fills orders like paper exchange does at prices of script and remembers them,
+// so they can be reconciled with stored orders
+type Exchange struct {
+	*webPaper.PaperExchange
+
+	mu     sync.Mutex
+	orders []models.Order
+}
+
+func NewExchange(script *Script) *Exchange {
+	return &Exchange{PaperExchange: webPaper.NewPaperExchange(market{script: script})}
+}
+
+func (e *Exchange) SendOrder(userID int, request models.OrderRequest) (models.Order, error) {
+	order, err := e.PaperExchange.SendOrder(userID, request)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.orders = append(e.orders, order)
+	return order, nil
+}
+
+// Orders returns orders exchange filled in order they were sent
+func (e *Exchange) Orders() []models.Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]models.Order(nil), e.orders...)
+}
+
+// OpenOrders returns no orders since every order is filled once it is sent
+func (e *Exchange) OpenOrders() ([]models.Order, error) {
+	return []models.Order{}, nil
+}
+
+// Fills returns fills of orders sent since the time
+func (e *Exchange) Fills(since time.Time) ([]models.Fill, error) {
+	fills := []models.Fill{}
+	for _, order := range e.Orders() {
+		t, err := time.Parse(time.RFC3339, order.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if t.Before(since) {
+			continue
+		}
+		fills = append(fills, models.Fill{
+			ID:            order.ID,
+			OrderID:       order.ID,
+			ClientOrderID: order.ClientOrderID,
+			Symbol:        order.Symbol,
+			Side:          order.Side,
+			Size:          order.Filled,
+			Price:         order.Price,
+			Time:          t,
+		})
+	}
+	return fills, nil
+}
@@ -0,0 +1,98 @@
+// Package webFake simulates exchange for tests, market moves only by scripted candles
+package webFake
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+// Script is scripted price of market, candles are handed out in order they were pushed
+// and the close of the last handed out candle becomes the price orders are filled at
+type Script struct {
+	mu      sync.Mutex
+	price   decimal.Decimal
+	pending []decimal.Decimal
+	sent    []krakenFuturesWSSDK.Candle
+	now     func() time.Time
+	// pushed is closed and replaced when new candles are pushed
+	pushed chan struct{}
+}
+
+// NewScript returns script starting at price with candles closed at closes
+func NewScript(price decimal.Decimal, closes ...decimal.Decimal) *Script {
+	return &Script{price: price, pending: closes, now: time.Now, pushed: make(chan struct{})}
+}
+
+// Push adds candles closed at closes to the end of script
+func (s *Script) Push(closes ...decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, closes...)
+	close(s.pushed)
+	s.pushed = make(chan struct{})
+}
+
+// Price returns the current price of market
+func (s *Script) Price() decimal.Decimal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.price
+}
+
+// next returns the next candle started now and moves price to its close. When script is over
+// false is returned with channel closed by the next push
+func (s *Script) next() (krakenFuturesWSSDK.Candle, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return krakenFuturesWSSDK.Candle{}, s.pushed, false
+	}
+
+	closePrice := s.pending[0]
+	s.pending = s.pending[1:]
+
+	candle := krakenFuturesWSSDK.Candle{
+		Time:   int(s.now().Unix()),
+		Open:   s.price,
+		High:   decimal.Max(s.price, closePrice),
+		Low:    decimal.Min(s.price, closePrice),
+		Close:  closePrice,
+		Volume: decimal.NewFromInt(1),
+	}
+	s.price = closePrice
+	s.sent = append(s.sent, candle)
+	return candle, nil, true
+}
+
+// candles returns handed out candles started in [from, to)
+func (s *Script) candles(symbol, candlesType, interval string, from, to time.Time) []models.Candle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var candles []models.Candle
+	for _, c := range s.sent {
+		t := time.Unix(int64(c.Time), 0).UTC()
+		if t.Before(from) || !t.Before(to) {
+			continue
+		}
+		candles = append(candles, models.Candle{
+			Symbol:   symbol,
+			Type:     candlesType,
+			Interval: interval,
+			Time:     t,
+			Open:     c.Open,
+			High:     c.High,
+			Low:      c.Low,
+			Close:    c.Close,
+			Volume:   c.Volume,
+		})
+	}
+	return candles
+}
@@ -0,0 +1,33 @@
+package webFake
+
+import (
+	"time"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/internal/pkg/web"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+// charts returns candles script handed out
+type charts struct {
+	script *Script
+}
+
+func (c charts) GetCandles(candlesType krakenFuturesWSSDK.CandlesType, symbol string, interval krakenFuturesWSSDK.CandlesInterval,
+	from, to time.Time) ([]models.Candle, error) {
+	return c.script.candles(symbol, string(candlesType), string(interval), from, to), nil
+}
+
+// NewWeb returns web where both kraken futures and paper exchanges are exchange of script and strategies
+// are fed by candles of script
+func NewWeb(script *Script, exchange *Exchange) *web.Web {
+	return &web.Web{
+		KrakenAnalyzer: NewAnalyzer(script),
+		KrakenCharts:   charts{script: script},
+		Exchanges: web.Exchanges{
+			web.KrakenFuturesExchange: exchange,
+			web.PaperExchange:         exchange,
+		},
+		OrdersSource: exchange,
+	}
+}
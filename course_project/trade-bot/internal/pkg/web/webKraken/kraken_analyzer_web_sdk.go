@@ -4,16 +4,21 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"trade-bot/pkg/candleAggregator"
 	"trade-bot/pkg/krakenFuturesWSSDK"
 )
 
 var (
 	ErrConvertTradeDataToCandle = errors.New("convert trade data to candle")
 	ErrLookForCandles           = errors.New("look for candles")
+	ErrFilterCandles            = errors.New("filter candles")
+	ErrLookForTradeCandles      = errors.New("look for trade candles")
+	ErrInvalidCandleTime        = errors.New("invalid candle time")
 )
 
 const unixTimeLen = 10
@@ -26,21 +31,45 @@ func NewKrakenAnalyzerWebSDK(krakenWebsocketAPI *krakenFuturesWSSDK.WSAPI) *Krak
 	return &KrakenAnalyzerWebSDK{krakenWebsocketAPI: krakenWebsocketAPI}
 }
 
-func (k *KrakenAnalyzerWebSDK) LookForCandles(ctx context.Context, feed string, productsIDs []string) (<-chan krakenFuturesWSSDK.Candle, error) {
-	tradeDataCh, err := k.krakenWebsocketAPI.CandlesTrade(ctx, feed, productsIDs)
+// LookForCandles streams candles of the interval encoded in feed name (candles_trade_1h, candles_mark_5m, ...).
+// Every update of the current candle is sent, outdated candles are dropped
+func (k *KrakenAnalyzerWebSDK) LookForCandles(ctx context.Context, feed string, productsIDs []string) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	_, interval, err := krakenFuturesWSSDK.ParseCandlesFeed(feed)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", ErrLookForCandles, err)
+		return nil, nil, fmt.Errorf("%s: %w", ErrLookForCandles, err)
+	}
+
+	tradeDataCh, gapCh, err := k.krakenWebsocketAPI.CandlesTrade(ctx, feed, productsIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrLookForCandles, err)
 	}
 
 	candleCh, errCh := convertTradeDataToCandle(tradeDataCh)
 	go logErrors(errCh)
 
-	filteredCandles := filterCandles(candleCh)
+	unixTimeCandles, errCh := filterCandlesUnixTime(candleCh)
+	go logErrors(errCh)
 
-	filteredUnixTimeCandles, errCh := filterCandlesUnixTime(filteredCandles)
+	filteredCandles, errCh := filterCandles(unixTimeCandles, interval)
 	go logErrors(errCh)
 
-	return filteredUnixTimeCandles, nil
+	return filteredCandles, gapCh, nil
+}
+
+// LookForTradeCandles streams candles built locally from trade feed of productID.
+// The current candle is sent after every trade, so strategies may react within it
+func (k *KrakenAnalyzerWebSDK) LookForTradeCandles(ctx context.Context, productID string, spec candleAggregator.BarSpec) (<-chan krakenFuturesWSSDK.Candle, <-chan krakenFuturesWSSDK.Gap, error) {
+	aggregator, err := candleAggregator.NewAggregator(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrLookForTradeCandles, err)
+	}
+
+	tradeDataCh, gapCh, err := k.krakenWebsocketAPI.Trade(ctx, []string{productID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ErrLookForTradeCandles, err)
+	}
+
+	return aggregateTrades(tradeDataCh, aggregator), gapCh, nil
 }
 
 func logErrors(errs <-chan error) {
@@ -70,33 +99,45 @@ func convertTradeDataToCandle(tradeData <-chan *krakenFuturesWSSDK.CandlesTradeD
 	return candlesChan, errCh
 }
 
-func filterCandles(candles <-chan krakenFuturesWSSDK.Candle) <-chan krakenFuturesWSSDK.Candle {
+// filterCandles drops candles older than the current one, repeated updates of the current candle
+// and candles which do not start at the interval boundary
+func filterCandles(candles <-chan krakenFuturesWSSDK.Candle, interval krakenFuturesWSSDK.CandlesInterval) (<-chan krakenFuturesWSSDK.Candle, <-chan error) {
+	errCh := make(chan error, 1)
 	candlesChan := make(chan krakenFuturesWSSDK.Candle)
 
 	go func() {
+		defer close(errCh)
 		defer close(candlesChan)
 
-		var lastUpdateTime *int
+		var lastCandle *krakenFuturesWSSDK.Candle
 
 		for candle := range candles {
-			if lastUpdateTime == nil {
-				t := candle.Time
-				lastUpdateTime = &t
-				candlesChan <- candle
+			candleTime := time.Unix(int64(candle.Time), 0)
+			if !isAligned(candleTime, interval) {
+				errCh <- fmt.Errorf("%s: %s: candle time %s is not aligned to %s", ErrFilterCandles, ErrInvalidCandleTime, candleTime, interval)
 				continue
 			}
-			if candle.Time <= *lastUpdateTime {
+
+			if lastCandle != nil && (candle.Time < lastCandle.Time || candle.Equal(*lastCandle)) {
 				continue
 			}
 
-			*lastUpdateTime = candle.Time
+			c := candle
+			lastCandle = &c
 			candlesChan <- candle
 		}
 	}()
 
-	return candlesChan
+	return candlesChan, errCh
 }
 
+// isAligned reports whether candle starts at the interval boundary. Truncate counts from zero time, which is
+// monday 00:00 UTC, so weekly candles start on monday like kraken ones rather than on thursday like unix weeks
+func isAligned(candleTime time.Time, interval krakenFuturesWSSDK.CandlesInterval) bool {
+	return candleTime.Truncate(interval.Duration()).Equal(candleTime)
+}
+
+// filterCandlesUnixTime converts candles time from milliseconds to seconds
 func filterCandlesUnixTime(candles <-chan krakenFuturesWSSDK.Candle) (<-chan krakenFuturesWSSDK.Candle, <-chan error) {
 	errCh := make(chan error, 1)
 	candlesChan := make(chan krakenFuturesWSSDK.Candle)
@@ -118,10 +159,46 @@ func filterCandlesUnixTime(candles <-chan krakenFuturesWSSDK.Candle) (<-chan kra
 				}
 
 				candle.Time = int(newTime)
-				candlesChan <- candle
 			}
+			candlesChan <- candle
 		}
 	}()
 
 	return candlesChan, errCh
 }
+
+// aggregateTrades builds candles from live trades, trades of snapshot are skipped
+// because they were made before subscription
+func aggregateTrades(tradeData <-chan *krakenFuturesWSSDK.TradeData, aggregator *candleAggregator.Aggregator) <-chan krakenFuturesWSSDK.Candle {
+	candlesChan := make(chan krakenFuturesWSSDK.Candle)
+
+	go func() {
+		defer close(candlesChan)
+
+		for data := range tradeData {
+			if data.Feed != krakenFuturesWSSDK.TradeFeed {
+				continue
+			}
+
+			candle, ok := aggregator.Add(candleAggregator.Trade{
+				Time:  time.Unix(0, data.Time*int64(time.Millisecond)),
+				Price: data.Price,
+				Qty:   data.Qty,
+			})
+			if !ok {
+				continue
+			}
+
+			candlesChan <- krakenFuturesWSSDK.Candle{
+				Time:   int(candle.Start.Unix()),
+				Open:   candle.Open,
+				High:   candle.High,
+				Low:    candle.Low,
+				Close:  candle.Close,
+				Volume: candle.Volume,
+			}
+		}
+	}()
+
+	return candlesChan
+}
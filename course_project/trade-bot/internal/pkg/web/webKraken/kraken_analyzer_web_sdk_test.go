@@ -0,0 +1,100 @@
+package webKraken
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+func sendCandles(candles ...krakenFuturesWSSDK.Candle) <-chan krakenFuturesWSSDK.Candle {
+	ch := make(chan krakenFuturesWSSDK.Candle, len(candles))
+	for _, candle := range candles {
+		ch <- candle
+	}
+	close(ch)
+	return ch
+}
+
+func candleAt(t time.Time, closePrice int64) krakenFuturesWSSDK.Candle {
+	return krakenFuturesWSSDK.Candle{Time: int(t.Unix()), Close: decimal.NewFromInt(closePrice)}
+}
+
+func TestFilterCandles(t *testing.T) {
+	// 2022-01-03 00:00 UTC is a monday, the boundary of every interval
+	const first = 1641168000
+
+	tests := []struct {
+		interval krakenFuturesWSSDK.CandlesInterval
+		next     int64
+	}{
+		{interval: krakenFuturesWSSDK.OneMinute, next: 1641168060},
+		{interval: krakenFuturesWSSDK.FiveMinutes, next: 1641168300},
+		{interval: krakenFuturesWSSDK.FifteenMinutes, next: 1641168900},
+		{interval: krakenFuturesWSSDK.OneHour, next: 1641171600},
+		{interval: krakenFuturesWSSDK.FourHours, next: 1641182400},
+		{interval: krakenFuturesWSSDK.TwelveHours, next: 1641211200},
+		{interval: krakenFuturesWSSDK.OneDay, next: 1641254400},
+		{interval: krakenFuturesWSSDK.OneWeek, next: 1641772800},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.interval), func(t *testing.T) {
+			step := test.next - first
+			candles, errCh := filterCandles(sendCandles(
+				candleAt(time.Unix(first, 0), 1),
+				candleAt(time.Unix(first, 0), 1),      // repeated update
+				candleAt(time.Unix(first, 0), 2),      // changed update of the current candle
+				candleAt(time.Unix(first-step, 0), 3), // outdated candle
+				candleAt(time.Unix(first+1, 0), 4),    // not aligned to interval
+				candleAt(time.Unix(test.next, 0), 5),
+			), test.interval)
+
+			var closes []int64
+			for candle := range candles {
+				closes = append(closes, candle.Close.IntPart())
+			}
+			assert.Equal(t, []int64{1, 2, 5}, closes)
+
+			err, ok := <-errCh
+			require.True(t, ok)
+			assert.Contains(t, err.Error(), ErrInvalidCandleTime.Error())
+		})
+	}
+}
+
+func TestFilterCandlesWeekStartsOnMonday(t *testing.T) {
+	candles, errCh := filterCandles(sendCandles(
+		candleAt(time.Unix(1641168000, 0), 1), // monday 2022-01-03 00:00 UTC
+		candleAt(time.Unix(1641427200, 0), 2), // thursday 2022-01-06 00:00 UTC, start of unix week
+		candleAt(time.Unix(1641772800, 0), 3), // monday 2022-01-10 00:00 UTC
+	), krakenFuturesWSSDK.OneWeek)
+
+	var times []int
+	for candle := range candles {
+		times = append(times, candle.Time)
+	}
+	assert.Equal(t, []int{1641168000, 1641772800}, times)
+
+	err, ok := <-errCh
+	require.True(t, ok)
+	assert.Contains(t, err.Error(), ErrInvalidCandleTime.Error())
+}
+
+func TestFilterCandlesUnixTime(t *testing.T) {
+	candles, errCh := filterCandlesUnixTime(sendCandles(
+		krakenFuturesWSSDK.Candle{Time: 1641168000000},
+		krakenFuturesWSSDK.Candle{Time: 1641168060},
+	))
+
+	var times []int
+	for candle := range candles {
+		times = append(times, candle.Time)
+	}
+	assert.Equal(t, []int{1641168000, 1641168060}, times)
+	assert.Empty(t, errCh)
+}
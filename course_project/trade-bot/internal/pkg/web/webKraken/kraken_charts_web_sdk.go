@@ -0,0 +1,73 @@
+package webKraken
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/pkg/krakenFuturesSDK"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+var (
+	ErrGetCandles = errors.New("web sdk: get candles")
+)
+
+type KrakenChartsWebSDK struct {
+	api *krakenFuturesSDK.API
+}
+
+func NewKrakenChartsWebSDK(api *krakenFuturesSDK.API) *KrakenChartsWebSDK {
+	return &KrakenChartsWebSDK{api: api}
+}
+
+// GetCandles loads candles started in [from, to) page by page
+func (k *KrakenChartsWebSDK) GetCandles(candlesType krakenFuturesWSSDK.CandlesType, symbol string,
+	interval krakenFuturesWSSDK.CandlesInterval, from, to time.Time) ([]models.Candle, error) {
+	if err := candlesType.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetCandles, err)
+	}
+	if err := interval.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrGetCandles, err)
+	}
+
+	var candles []models.Candle
+
+	for from.Before(to) {
+		response, err := k.api.Charts(string(candlesType), symbol, string(interval), from, to)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrGetCandles, err)
+		}
+		if len(response.Candles) == 0 {
+			break
+		}
+
+		for _, chartCandle := range response.Candles {
+			candle := convertChartCandle(chartCandle)
+			candle.Symbol = symbol
+			candle.Type = string(candlesType)
+			candle.Interval = string(interval)
+			candles = append(candles, candle)
+		}
+
+		if !response.MoreCandles {
+			break
+		}
+		from = candles[len(candles)-1].Time.Add(interval.Duration())
+	}
+
+	return candles, nil
+}
+
+func convertChartCandle(c krakenFuturesSDK.ChartCandle) models.Candle {
+	return models.Candle{
+		Time:   time.Unix(0, c.Time*int64(time.Millisecond)).UTC(),
+		Open:   c.Open,
+		High:   c.High,
+		Low:    c.Low,
+		Close:  c.Close,
+		Volume: c.Volume,
+	}
+}
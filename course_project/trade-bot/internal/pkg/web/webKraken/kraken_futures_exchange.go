@@ -0,0 +1,231 @@
+package webKraken
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"trade-bot/internal/pkg/models"
+	"trade-bot/pkg/krakenFuturesSDK"
+	"trade-bot/pkg/krakenFuturesWSSDK"
+)
+
+var (
+	ErrLastPrice     = errors.New("web sdk: last price")
+	ErrInstruments   = errors.New("web sdk: instruments")
+	ErrUnknownSymbol = errors.New("unknown symbol")
+	ErrOpenOrders    = errors.New("web sdk: open orders")
+	ErrFills         = errors.New("web sdk: fills")
+)
+
+// fillsPageSize is the number of fills kraken returns at most
+const fillsPageSize = 100
+
+// ExchangeName is stored with orders sent to kraken futures
+const ExchangeName = "kraken_futures"
+
+// KrakenFuturesExchange adapts kraken futures to exchange-neutral models
+type KrakenFuturesExchange struct {
+	api    *krakenFuturesSDK.API
+	orders *KrakenOrdersManagerWebSDK
+	charts *KrakenChartsWebSDK
+}
+
+func NewKrakenFuturesExchange(api *krakenFuturesSDK.API) *KrakenFuturesExchange {
+	return &KrakenFuturesExchange{
+		api:    api,
+		orders: NewKrakenOrdersManagerWebSDK(api),
+		charts: NewKrakenChartsWebSDK(api),
+	}
+}
+
+// SendOrder returns the state of order after the last event kraken reported for it
+func (k *KrakenFuturesExchange) SendOrder(userID int, request models.OrderRequest) (models.Order, error) {
+	sendStatus, err := k.orders.SendOrder(krakenFuturesSDK.SendOrderArguments{
+		OrderType:     request.OrderType,
+		Symbol:        request.Symbol,
+		Side:          request.Side,
+		Size:          request.Size,
+		LimitPrice:    request.LimitPrice,
+		StopPrice:     request.StopPrice,
+		TriggerSignal: request.TriggerSignal,
+		CliOrderID:    request.CliOrderID,
+		ReduceOnly:    request.ReduceOnly,
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	var (
+		last  models.Order
+		found bool
+	)
+	for _, event := range sendStatus.OrderEvents {
+		if order, ok := orderFromEvent(userID, event); ok {
+			last, found = order, true
+		}
+	}
+	if !found {
+		return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrder, ErrUnknownSendStatusType)
+	}
+
+	if last.ID == "" {
+		last.ID = sendStatus.OrderID
+	}
+	return last, nil
+}
+
+func (k *KrakenFuturesExchange) BatchOrder(userID int, instructions []models.BatchInstruction) (models.BatchResult, error) {
+	batch := make([]krakenFuturesSDK.BatchInstruction, 0, len(instructions))
+	for _, i := range instructions {
+		batch = append(batch, krakenFuturesSDK.BatchInstruction{
+			Order:         i.Order,
+			OrderTag:      i.OrderTag,
+			OrderID:       i.OrderID,
+			OrderType:     i.OrderType,
+			Symbol:        i.Symbol,
+			Side:          i.Side,
+			Size:          i.Size,
+			LimitPrice:    i.LimitPrice,
+			StopPrice:     i.StopPrice,
+			TriggerSignal: i.TriggerSignal,
+			CliOrderID:    i.CliOrderID,
+			ReduceOnly:    i.ReduceOnly,
+		})
+	}
+
+	statuses, err := k.orders.BatchOrder(batch)
+	if err != nil {
+		return models.BatchResult{}, err
+	}
+
+	return k.orders.ParseBatchStatusToOrders(userID, statuses), nil
+}
+
+// LastPrice returns price of the last trade, mark price is used for symbols without trades
+func (k *KrakenFuturesExchange) LastPrice(symbol string) (decimal.Decimal, error) {
+	response, err := k.api.Tickers()
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("%s: %w", ErrLastPrice, err)
+	}
+
+	for _, ticker := range response.Tickers {
+		if !strings.EqualFold(ticker.Symbol, symbol) {
+			continue
+		}
+		if ticker.Last.IsZero() {
+			return ticker.MarkPrice, nil
+		}
+		return ticker.Last, nil
+	}
+
+	return decimal.Decimal{}, fmt.Errorf("%s: %s: %s", ErrLastPrice, ErrUnknownSymbol, symbol)
+}
+
+func (k *KrakenFuturesExchange) Instruments() ([]models.Instrument, error) {
+	response, err := k.api.Instruments()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInstruments, err)
+	}
+
+	instruments := make([]models.Instrument, 0, len(response.Instruments))
+	for _, i := range response.Instruments {
+		instruments = append(instruments, models.Instrument{
+			Symbol:       i.Symbol,
+			Type:         i.Type,
+			Tradeable:    i.Tradeable,
+			TickSize:     i.TickSize,
+			ContractSize: decimal.NewFromInt(int64(i.ContractSize)),
+		})
+	}
+
+	return instruments, nil
+}
+
+// GetCandles loads trade candles of kraken timeframe interval started in [from, to)
+func (k *KrakenFuturesExchange) GetCandles(symbol string, interval string, from, to time.Time) ([]models.Candle, error) {
+	return k.charts.GetCandles(krakenFuturesWSSDK.TradeCandles, symbol, krakenFuturesWSSDK.CandlesInterval(interval), from, to)
+}
+
+// OpenOrders returns resting orders of the account
+func (k *KrakenFuturesExchange) OpenOrders() ([]models.Order, error) {
+	response, err := k.api.OpenOrders()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrOpenOrders, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("%s: err: %s, server time: %s", ErrOpenOrders, response.Error, response.ServerTime)
+	}
+
+	orders := make([]models.Order, 0, len(response.OpenOrders))
+	for _, o := range response.OpenOrders {
+		orders = append(orders, models.Order{
+			ID:                  o.OrderID,
+			ClientOrderID:       o.CliOrdID,
+			Type:                placeEvent,
+			Symbol:              o.Symbol,
+			Quantity:            o.FilledSize.Add(o.UnfilledSize),
+			Side:                o.Side,
+			Filled:              o.FilledSize,
+			Timestamp:           o.ReceivedTime,
+			LastUpdateTimestamp: o.LastUpdateTime,
+			Price:               o.LimitPrice,
+			Exchange:            ExchangeName,
+		})
+	}
+
+	return orders, nil
+}
+
+// Fills returns fills of the account made since given time, older fills are requested page by page
+func (k *KrakenFuturesExchange) Fills(since time.Time) ([]models.Fill, error) {
+	var (
+		fills  []models.Fill
+		before time.Time
+		seen   = make(map[string]bool)
+	)
+
+	for {
+		response, err := k.api.Fills(before)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrFills, err)
+		}
+		if response.Error != "" {
+			return nil, fmt.Errorf("%s: err: %s, server time: %s", ErrFills, response.Error, response.ServerTime)
+		}
+
+		oldest := before
+		for _, f := range response.Fills {
+			fillTime, err := time.Parse(time.RFC3339Nano, f.FillTime)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ErrFills, err)
+			}
+			if oldest.IsZero() || fillTime.Before(oldest) {
+				oldest = fillTime
+			}
+			if fillTime.Before(since) || seen[f.FillID] {
+				continue
+			}
+			seen[f.FillID] = true
+
+			fills = append(fills, models.Fill{
+				ID:            f.FillID,
+				OrderID:       f.OrderID,
+				ClientOrderID: f.CliOrdID,
+				Symbol:        f.Symbol,
+				Side:          f.Side,
+				Size:          f.Size,
+				Price:         f.Price,
+				Time:          fillTime,
+			})
+		}
+
+		if len(response.Fills) < fillsPageSize || oldest.Before(since) || oldest.Equal(before) {
+			return fills, nil
+		}
+		before = oldest
+	}
+}
@@ -16,6 +16,7 @@ var (
 	ErrCancelAllOrders       = errors.New("web sdk: cancel all orders")
 	ErrInvalidStatus         = errors.New("invalid status")
 	ErrUnknownSendStatusType = errors.New("unknown send status type")
+	ErrBatchOrder            = errors.New("web sdk: batch order")
 )
 
 type KrakenOrdersManagerWebSDK struct {
@@ -105,21 +106,104 @@ func (k *KrakenOrdersManagerWebSDK) CancelAllOrders(symbol string) (krakenFuture
 func (k *KrakenOrdersManagerWebSDK) ParseSendStatusToExecutedOrder(userID int, sendStatus krakenFuturesSDK.SendStatus) (models.Order, error) {
 	orderEvent := sendStatus.OrderEvents[0]
 
-	if orderEvent.Type == "EXECUTION" {
-		return models.Order{
-			ID:                  orderEvent.OrderPriorExecution.OrderID,
-			UserID:              userID,
-			ClientOrderID:       orderEvent.OrderPriorExecution.CliOrderID,
-			Type:                orderEvent.Type,
-			Symbol:              orderEvent.OrderPriorExecution.Symbol,
-			Quantity:            orderEvent.OrderPriorExecution.Quantity,
-			Side:                orderEvent.OrderPriorExecution.Side,
-			Price:               orderEvent.Price,
-			Filled:              orderEvent.OrderPriorExecution.Filled,
-			Timestamp:           orderEvent.OrderPriorExecution.Timestamp,
-			LastUpdateTimestamp: orderEvent.OrderPriorExecution.LastUpdateTimestamp,
-		}, nil
+	if orderEvent.Type == executionEvent {
+		order, _ := orderFromEvent(userID, orderEvent)
+		return order, nil
 	}
 
 	return models.Order{}, ErrUnknownSendStatusType
 }
+
+func (k *KrakenOrdersManagerWebSDK) BatchOrder(instructions []krakenFuturesSDK.BatchInstruction) ([]krakenFuturesSDK.BatchStatus, error) {
+	response, err := k.api.BatchOrder(instructions)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrBatchOrder, err)
+	}
+
+	if response.Error != "" {
+		err := fmt.Errorf("err: %s, server time: %s, result: %s", response.Error, response.ServerTime, response.Result)
+		return nil, fmt.Errorf("%s: %w", ErrBatchOrder, err)
+	}
+
+	return response.BatchStatus, nil
+}
+
+// ParseBatchStatusToOrders returns the latest state of every order touched by batch,
+// instructions kraken did not execute are returned as rejected
+func (k *KrakenOrdersManagerWebSDK) ParseBatchStatusToOrders(userID int, statuses []krakenFuturesSDK.BatchStatus) models.BatchResult {
+	result := models.BatchResult{Orders: []models.Order{}}
+
+	for _, status := range statuses {
+		if !status.Status.IsSuccessStatus() {
+			result.Rejected = append(result.Rejected, models.RejectedInstruction{
+				OrderTag: status.OrderTag,
+				OrderID:  status.OrderID,
+				Status:   string(status.Status),
+			})
+			continue
+		}
+
+		var (
+			last  models.Order
+			found bool
+		)
+		for _, event := range status.OrderEvents {
+			if order, ok := orderFromEvent(userID, event); ok {
+				last, found = order, true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		if last.ID == "" {
+			last.ID = status.OrderID
+		}
+		result.Orders = append(result.Orders, last)
+	}
+
+	return result
+}
+
+const (
+	executionEvent = "EXECUTION"
+	placeEvent     = "PLACE"
+	editEvent      = "EDIT"
+	cancelEvent    = "CANCEL"
+)
+
+// orderFromEvent converts order event to the order state after it
+func orderFromEvent(userID int, event krakenFuturesSDK.OrderEvent) (models.Order, bool) {
+	var (
+		order krakenFuturesSDK.Order
+		price = event.Price
+	)
+
+	switch event.Type {
+	case executionEvent:
+		order = event.OrderPriorExecution
+	case placeEvent, cancelEvent:
+		order = event.Order
+		price = order.LimitPrice
+	case editEvent:
+		order = event.New
+		price = order.LimitPrice
+	default:
+		return models.Order{}, false
+	}
+
+	return models.Order{
+		ID:                  order.OrderID,
+		UserID:              userID,
+		ClientOrderID:       order.CliOrderID,
+		Type:                event.Type,
+		Symbol:              order.Symbol,
+		Quantity:            order.Quantity,
+		Side:                order.Side,
+		Price:               price,
+		Filled:              order.Filled,
+		Timestamp:           order.Timestamp,
+		LastUpdateTimestamp: order.LastUpdateTimestamp,
+		Exchange:            ExchangeName,
+	}, true
+}
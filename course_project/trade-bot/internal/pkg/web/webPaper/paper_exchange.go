@@ -0,0 +1,120 @@
+package webPaper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"trade-bot/internal/pkg/models"
+)
+
+var (
+	ErrSendOrder             = errors.New("paper exchange: send order")
+	ErrUnsupportedOrderType  = errors.New("unsupported order type")
+	ErrInvalidSide           = errors.New("invalid side")
+	ErrInvalidSize           = errors.New("size should be positive")
+	ErrLimitPriceNotPositive = errors.New("limit price should be positive")
+)
+
+// ExchangeName is stored with orders paper exchange simulated
+const ExchangeName = "paper"
+
+const (
+	marketOrder   = "mkt"
+	limitOrder    = "lmt"
+	postOnlyOrder = "post"
+	iocOrder      = "ioc"
+)
+
+// MarketData provides prices, instruments and candles paper orders are simulated on
+type MarketData interface {
+	LastPrice(symbol string) (decimal.Decimal, error)
+	Instruments() ([]models.Instrument, error)
+	GetCandles(symbol string, interval string, from, to time.Time) ([]models.Candle, error)
+}
+
+// PaperExchange simulates order execution locally without sending orders anywhere.
+// Market orders and limit orders crossing the last price of market data are filled at the last price.
+// Other limit and post-only orders rest unfilled, ioc orders are cancelled instead. Post-only orders
+// crossing the last price are cancelled, since they would take liquidity
+type PaperExchange struct {
+	MarketData
+	now func() time.Time
+}
+
+func NewPaperExchange(marketData MarketData) *PaperExchange {
+	return &PaperExchange{MarketData: marketData, now: time.Now}
+}
+
+func (p *PaperExchange) SendOrder(userID int, request models.OrderRequest) (models.Order, error) {
+	if request.Side != models.BuySide && request.Side != models.SellSide {
+		return models.Order{}, fmt.Errorf("%s: %s: %s", ErrSendOrder, ErrInvalidSide, request.Side)
+	}
+	if request.Size == 0 {
+		return models.Order{}, fmt.Errorf("%s: %s", ErrSendOrder, ErrInvalidSize)
+	}
+
+	orderType := strings.ToLower(request.OrderType)
+	switch orderType {
+	case marketOrder:
+	case limitOrder, postOnlyOrder, iocOrder:
+		if !request.LimitPrice.IsPositive() {
+			return models.Order{}, fmt.Errorf("%s: %s", ErrSendOrder, ErrLimitPriceNotPositive)
+		}
+	default:
+		return models.Order{}, fmt.Errorf("%s: %s: %s", ErrSendOrder, ErrUnsupportedOrderType, request.OrderType)
+	}
+
+	lastPrice, err := p.LastPrice(request.Symbol)
+	if err != nil {
+		return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrder, err)
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return models.Order{}, fmt.Errorf("%s: %w", ErrSendOrder, err)
+	}
+
+	size := decimal.NewFromInt(int64(request.Size))
+	timestamp := p.now().UTC().Format(time.RFC3339)
+	order := models.Order{
+		ID:                  id.String(),
+		UserID:              userID,
+		ClientOrderID:       request.CliOrderID,
+		Type:                models.ExecutionOrder,
+		Symbol:              request.Symbol,
+		Quantity:            size,
+		Side:                request.Side,
+		Filled:              size,
+		Timestamp:           timestamp,
+		LastUpdateTimestamp: timestamp,
+		Price:               lastPrice,
+		Exchange:            ExchangeName,
+	}
+
+	marketable := orderType == marketOrder || crosses(request.Side, request.LimitPrice, lastPrice)
+	switch {
+	case marketable && orderType != postOnlyOrder:
+		return order, nil
+	case !marketable && orderType != iocOrder:
+		order.Type = models.PlaceOrder
+	default:
+		order.Type = models.CancelOrder
+	}
+	order.Filled = decimal.Zero
+	order.Price = request.LimitPrice
+
+	return order, nil
+}
+
+// crosses reports whether limit price of the side can be filled at the last price
+func crosses(side string, limitPrice, lastPrice decimal.Decimal) bool {
+	if side == models.BuySide {
+		return limitPrice.GreaterThanOrEqual(lastPrice)
+	}
+	return limitPrice.LessThanOrEqual(lastPrice)
+}
@@ -0,0 +1,149 @@
+package webPaper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/internal/pkg/models"
+)
+
+type marketDataStub struct {
+	prices map[string]decimal.Decimal
+}
+
+func (m marketDataStub) LastPrice(symbol string) (decimal.Decimal, error) {
+	price, ok := m.prices[symbol]
+	if !ok {
+		return decimal.Decimal{}, errors.New("unknown symbol")
+	}
+	return price, nil
+}
+
+func (m marketDataStub) Instruments() ([]models.Instrument, error) {
+	return nil, nil
+}
+
+func (m marketDataStub) GetCandles(string, string, time.Time, time.Time) ([]models.Candle, error) {
+	return nil, nil
+}
+
+func TestPaperExchange_SendOrder(t *testing.T) {
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	exchange := NewPaperExchange(marketDataStub{prices: map[string]decimal.Decimal{
+		"PI_XBTUSD": decimal.RequireFromString("42000.5"),
+	}})
+	exchange.now = func() time.Time { return now }
+
+	tests := []struct {
+		name       string
+		request    models.OrderRequest
+		wantType   string
+		wantPrice  decimal.Decimal
+		wantFilled bool
+		wantErr    error
+	}{
+		{
+			name:       "market order is filled at last price",
+			request:    models.OrderRequest{OrderType: "mkt", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: 3},
+			wantType:   models.ExecutionOrder,
+			wantPrice:  decimal.RequireFromString("42000.5"),
+			wantFilled: true,
+		},
+		{
+			name: "crossing limit order is filled at last price",
+			request: models.OrderRequest{OrderType: "lmt", Symbol: "PI_XBTUSD", Side: models.SellSide, Size: 1,
+				LimitPrice: decimal.RequireFromString("41000")},
+			wantType:   models.ExecutionOrder,
+			wantPrice:  decimal.RequireFromString("42000.5"),
+			wantFilled: true,
+		},
+		{
+			name: "crossing ioc order is filled at last price",
+			request: models.OrderRequest{OrderType: "ioc", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: 2,
+				LimitPrice: decimal.RequireFromString("42000.5")},
+			wantType:   models.ExecutionOrder,
+			wantPrice:  decimal.RequireFromString("42000.5"),
+			wantFilled: true,
+		},
+		{
+			name: "limit order below last price rests",
+			request: models.OrderRequest{OrderType: "lmt", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: 1,
+				LimitPrice: decimal.RequireFromString("41000")},
+			wantType:  models.PlaceOrder,
+			wantPrice: decimal.RequireFromString("41000"),
+		},
+		{
+			name: "post-only order above last price rests",
+			request: models.OrderRequest{OrderType: "post", Symbol: "PI_XBTUSD", Side: models.SellSide, Size: 1,
+				LimitPrice: decimal.RequireFromString("43000")},
+			wantType:  models.PlaceOrder,
+			wantPrice: decimal.RequireFromString("43000"),
+		},
+		{
+			name: "crossing post-only order is cancelled",
+			request: models.OrderRequest{OrderType: "post", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: 1,
+				LimitPrice: decimal.RequireFromString("43000")},
+			wantType:  models.CancelOrder,
+			wantPrice: decimal.RequireFromString("43000"),
+		},
+		{
+			name: "not crossing ioc order is cancelled",
+			request: models.OrderRequest{OrderType: "ioc", Symbol: "PI_XBTUSD", Side: models.SellSide, Size: 1,
+				LimitPrice: decimal.RequireFromString("43000")},
+			wantType:  models.CancelOrder,
+			wantPrice: decimal.RequireFromString("43000"),
+		},
+		{
+			name:    "limit order without price",
+			request: models.OrderRequest{OrderType: "lmt", Symbol: "PI_XBTUSD", Side: models.SellSide, Size: 1},
+			wantErr: ErrSendOrder,
+		},
+		{
+			name:    "market order of unknown symbol",
+			request: models.OrderRequest{OrderType: "mkt", Symbol: "PI_ETHUSD", Side: models.BuySide, Size: 1},
+			wantErr: ErrSendOrder,
+		},
+		{
+			name:    "unsupported order type",
+			request: models.OrderRequest{OrderType: "stp", Symbol: "PI_XBTUSD", Side: models.BuySide, Size: 1},
+			wantErr: ErrSendOrder,
+		},
+		{
+			name:    "invalid side",
+			request: models.OrderRequest{OrderType: "mkt", Symbol: "PI_XBTUSD", Side: "long", Size: 1},
+			wantErr: ErrSendOrder,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, err := exchange.SendOrder(1, test.request)
+			if test.wantErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			assert.NotEmpty(t, order.ID)
+			assert.Equal(t, 1, order.UserID)
+			assert.Equal(t, test.request.Symbol, order.Symbol)
+			assert.Equal(t, test.request.Side, order.Side)
+			assert.Equal(t, test.wantType, order.Type)
+			assert.Equal(t, ExchangeName, order.Exchange)
+			assert.True(t, test.wantPrice.Equal(order.Price), order.Price.String())
+			assert.True(t, order.Quantity.Equal(decimal.NewFromInt(int64(test.request.Size))))
+			if test.wantFilled {
+				assert.True(t, order.Filled.Equal(order.Quantity))
+			} else {
+				assert.True(t, order.Filled.IsZero())
+			}
+			assert.Equal(t, "2022-01-02T03:04:05Z", order.Timestamp)
+		})
+	}
+}
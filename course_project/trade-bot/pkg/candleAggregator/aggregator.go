@@ -0,0 +1,133 @@
+package candleAggregator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidBarSpec = errors.New("invalid bar spec")
+	ErrUnknownBarType = errors.New("unknown bar type")
+)
+
+type BarType string
+
+const (
+	// TimeBars close every Period
+	TimeBars BarType = "time"
+	// TickBars close after Size trades
+	TickBars BarType = "tick"
+	// VolumeBars close when traded volume reaches Size
+	VolumeBars BarType = "volume"
+)
+
+type BarSpec struct {
+	Type   BarType
+	Period time.Duration
+	Size   decimal.Decimal
+}
+
+func (s BarSpec) Validate() error {
+	switch s.Type {
+	case TimeBars:
+		if s.Period <= 0 {
+			return fmt.Errorf("%s: period of time bars should be positive", ErrInvalidBarSpec)
+		}
+	case TickBars:
+		if !s.Size.IsInteger() || s.Size.LessThan(decimal.NewFromInt(1)) {
+			return fmt.Errorf("%s: size of tick bars should be at least 1", ErrInvalidBarSpec)
+		}
+	case VolumeBars:
+		if !s.Size.IsPositive() {
+			return fmt.Errorf("%s: size of volume bars should be positive", ErrInvalidBarSpec)
+		}
+	default:
+		return fmt.Errorf("%s: %s: %s", ErrInvalidBarSpec, ErrUnknownBarType, s.Type)
+	}
+	return nil
+}
+
+type Trade struct {
+	Time  time.Time
+	Price decimal.Decimal
+	Qty   decimal.Decimal
+}
+
+type Candle struct {
+	Start      time.Time
+	LastUpdate time.Time
+	Open       decimal.Decimal
+	High       decimal.Decimal
+	Low        decimal.Decimal
+	Close      decimal.Decimal
+	Volume     decimal.Decimal
+	Trades     int
+	// Complete is set when no more trades will be added to the candle
+	Complete bool
+}
+
+// Aggregator builds OHLCV candles of the BarSpec from individual trades
+type Aggregator struct {
+	spec    BarSpec
+	current *Candle
+}
+
+func NewAggregator(spec BarSpec) (*Aggregator, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &Aggregator{spec: spec}, nil
+}
+
+// Add puts the trade to the current candle and returns its updated state.
+// Trades older than the current time bar are ignored, false is returned for them
+func (a *Aggregator) Add(t Trade) (Candle, bool) {
+	if a.current != nil && a.spec.Type == TimeBars && t.Time.Before(a.current.Start) {
+		return Candle{}, false
+	}
+
+	if a.current == nil || a.current.Complete || a.startsNewTimeBar(t) {
+		a.current = a.newCandle(t)
+	} else {
+		a.current.High = decimal.Max(a.current.High, t.Price)
+		a.current.Low = decimal.Min(a.current.Low, t.Price)
+		a.current.Close = t.Price
+		a.current.Volume = a.current.Volume.Add(t.Qty)
+		a.current.Trades++
+		a.current.LastUpdate = t.Time
+	}
+
+	switch a.spec.Type {
+	case TickBars:
+		a.current.Complete = decimal.NewFromInt(int64(a.current.Trades)).GreaterThanOrEqual(a.spec.Size)
+	case VolumeBars:
+		a.current.Complete = a.current.Volume.GreaterThanOrEqual(a.spec.Size)
+	}
+
+	return *a.current, true
+}
+
+func (a *Aggregator) startsNewTimeBar(t Trade) bool {
+	return a.spec.Type == TimeBars && !t.Time.Before(a.current.Start.Add(a.spec.Period))
+}
+
+func (a *Aggregator) newCandle(t Trade) *Candle {
+	start := t.Time
+	if a.spec.Type == TimeBars {
+		start = t.Time.Truncate(a.spec.Period)
+	}
+
+	return &Candle{
+		Start:      start,
+		LastUpdate: t.Time,
+		Open:       t.Price,
+		High:       t.Price,
+		Low:        t.Price,
+		Close:      t.Price,
+		Volume:     t.Qty,
+		Trades:     1,
+	}
+}
@@ -0,0 +1,127 @@
+package candleAggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func d(value float64) decimal.Decimal {
+	return decimal.NewFromFloat(value)
+}
+
+// assertCandle compares decimals by value, their internal representation may differ
+func assertCandle(t *testing.T, want, got Candle) {
+	for _, pair := range [][2]decimal.Decimal{
+		{want.Open, got.Open}, {want.High, got.High}, {want.Low, got.Low}, {want.Close, got.Close}, {want.Volume, got.Volume},
+	} {
+		assert.True(t, pair[0].Equal(pair[1]), "want %s, got %s", pair[0], pair[1])
+	}
+
+	want.Open, want.High, want.Low, want.Close, want.Volume = got.Open, got.High, got.Low, got.Close, got.Volume
+	assert.Equal(t, want, got)
+}
+
+func TestAggregator_Add(t *testing.T) {
+	start := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		spec    BarSpec
+		trades  []Trade
+		want    []Candle
+		wantAdd []bool
+	}{
+		{
+			name: "Time bars",
+			spec: BarSpec{Type: TimeBars, Period: time.Minute},
+			trades: []Trade{
+				{Time: start.Add(10 * time.Second), Price: d(10), Qty: d(1)},
+				{Time: start.Add(20 * time.Second), Price: d(12), Qty: d(2)},
+				{Time: start.Add(30 * time.Second), Price: d(9), Qty: d(1)},
+				{Time: start.Add(70 * time.Second), Price: d(11), Qty: d(5)},
+				{Time: start.Add(50 * time.Second), Price: d(100), Qty: d(5)},
+			},
+			want: []Candle{
+				{Start: start, LastUpdate: start.Add(10 * time.Second), Open: d(10), High: d(10), Low: d(10), Close: d(10), Volume: d(1), Trades: 1},
+				{Start: start, LastUpdate: start.Add(20 * time.Second), Open: d(10), High: d(12), Low: d(10), Close: d(12), Volume: d(3), Trades: 2},
+				{Start: start, LastUpdate: start.Add(30 * time.Second), Open: d(10), High: d(12), Low: d(9), Close: d(9), Volume: d(4), Trades: 3},
+				{Start: start.Add(time.Minute), LastUpdate: start.Add(70 * time.Second), Open: d(11), High: d(11), Low: d(11), Close: d(11), Volume: d(5), Trades: 1},
+				{},
+			},
+			wantAdd: []bool{true, true, true, true, false},
+		},
+		{
+			name: "Tick bars",
+			spec: BarSpec{Type: TickBars, Size: d(2)},
+			trades: []Trade{
+				{Time: start, Price: d(10), Qty: d(1)},
+				{Time: start.Add(time.Second), Price: d(8), Qty: d(1)},
+				{Time: start.Add(2 * time.Second), Price: d(9), Qty: d(3)},
+			},
+			want: []Candle{
+				{Start: start, LastUpdate: start, Open: d(10), High: d(10), Low: d(10), Close: d(10), Volume: d(1), Trades: 1},
+				{Start: start, LastUpdate: start.Add(time.Second), Open: d(10), High: d(10), Low: d(8), Close: d(8), Volume: d(2), Trades: 2, Complete: true},
+				{Start: start.Add(2 * time.Second), LastUpdate: start.Add(2 * time.Second), Open: d(9), High: d(9), Low: d(9), Close: d(9), Volume: d(3), Trades: 1},
+			},
+			wantAdd: []bool{true, true, true},
+		},
+		{
+			name: "Volume bars",
+			spec: BarSpec{Type: VolumeBars, Size: d(5)},
+			trades: []Trade{
+				{Time: start, Price: d(10), Qty: d(3)},
+				{Time: start.Add(time.Second), Price: d(11), Qty: d(3)},
+				{Time: start.Add(2 * time.Second), Price: d(12), Qty: d(1)},
+			},
+			want: []Candle{
+				{Start: start, LastUpdate: start, Open: d(10), High: d(10), Low: d(10), Close: d(10), Volume: d(3), Trades: 1},
+				{Start: start, LastUpdate: start.Add(time.Second), Open: d(10), High: d(11), Low: d(10), Close: d(11), Volume: d(6), Trades: 2, Complete: true},
+				{Start: start.Add(2 * time.Second), LastUpdate: start.Add(2 * time.Second), Open: d(12), High: d(12), Low: d(12), Close: d(12), Volume: d(1), Trades: 1},
+			},
+			wantAdd: []bool{true, true, true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, err := NewAggregator(test.spec)
+			assert.NoError(t, err)
+
+			for i, trade := range test.trades {
+				got, ok := a.Add(trade)
+				assert.Equal(t, test.wantAdd[i], ok)
+				assertCandle(t, test.want[i], got)
+			}
+		})
+	}
+}
+
+func TestBarSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    BarSpec
+		wantErr bool
+	}{
+		{name: "OK time bars", spec: BarSpec{Type: TimeBars, Period: time.Minute}},
+		{name: "OK tick bars", spec: BarSpec{Type: TickBars, Size: d(10)}},
+		{name: "OK volume bars", spec: BarSpec{Type: VolumeBars, Size: d(0.5)}},
+		{name: "Zero period", spec: BarSpec{Type: TimeBars}, wantErr: true},
+		{name: "Fractional tick bars", spec: BarSpec{Type: TickBars, Size: d(0.5)}, wantErr: true},
+		{name: "Zero volume", spec: BarSpec{Type: VolumeBars}, wantErr: true},
+		{name: "Unknown type", spec: BarSpec{Type: "renko", Size: d(1)}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.spec.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
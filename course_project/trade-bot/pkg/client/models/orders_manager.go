@@ -3,6 +3,8 @@ package models
 import (
 	"fmt"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type SendOrderInput struct {
@@ -14,18 +16,18 @@ type SendOrderInput struct {
 }
 
 type SendOrderResponse struct {
-	ID                  string    `json:"id"`
-	UserID              int       `json:"user_id"`
-	ClientOrderID       string    `json:"client_order_id"`
-	Type                string    `json:"type"`
-	Symbol              string    `json:"symbol"`
-	Quantity            int       `json:"quantity"`
-	Side                string    `json:"side"`
-	Filled              int       `json:"filled"`
-	Timestamp           time.Time `json:"timestamp"`
-	LastUpdateTimestamp time.Time `json:"last_update_timestamp"`
-	Price               float64   `json:"price"`
-	Message             string    `json:"message,omitempty"`
+	ID                  string          `json:"id"`
+	UserID              int             `json:"user_id"`
+	ClientOrderID       string          `json:"client_order_id"`
+	Type                string          `json:"type"`
+	Symbol              string          `json:"symbol"`
+	Quantity            decimal.Decimal `json:"quantity"`
+	Side                string          `json:"side"`
+	Filled              decimal.Decimal `json:"filled"`
+	Timestamp           time.Time       `json:"timestamp"`
+	LastUpdateTimestamp time.Time       `json:"last_update_timestamp"`
+	Price               decimal.Decimal `json:"price"`
+	Message             string          `json:"message,omitempty"`
 }
 
 func (r *SendOrderResponse) String() string {
@@ -37,11 +39,11 @@ func (r *SendOrderResponse) String() string {
 		order_id:   %s,
 		type:       %s,
 		symbol:     %s,
-		quantity:   %d,
+		quantity:   %s,
 		side:       %s,
-		filled:     %d,
+		filled:     %s,
 		timestamp:  %s,
-		price:      %f,
+		price:      %s,
 	`, r.ID, r.Type, r.Symbol, r.Quantity, r.Side, r.Filled, r.Timestamp, r.Price)
 }
 
@@ -53,8 +55,8 @@ type StartTradingInput struct {
 
 type StartTradingDetails struct {
 	SendOrderInput
-	StopLossBorder   uint `json:"stop_loss_border"`
-	TakeProfitBorder uint `json:"take_profit_border"`
+	StopLossBorder   decimal.Decimal `json:"stop_loss_border"`
+	TakeProfitBorder decimal.Decimal `json:"take_profit_border"`
 }
 
 type StartTradingResponse struct {
@@ -83,17 +85,17 @@ func (r *GetUserOrdersResponse) String() string {
 }
 
 type Order struct {
-	ID                  string    `json:"id"`
-	UserID              int       `json:"user_id"`
-	ClientOrderID       string    `json:"client_order_id"`
-	Type                string    `json:"type"`
-	Symbol              string    `json:"symbol"`
-	Quantity            int       `json:"quantity"`
-	Side                string    `json:"side"`
-	Filled              int       `json:"filled"`
-	Timestamp           time.Time `json:"timestamp"`
-	LastUpdateTimestamp time.Time `json:"last_update_timestamp"`
-	Price               float64   `json:"price"`
+	ID                  string          `json:"id"`
+	UserID              int             `json:"user_id"`
+	ClientOrderID       string          `json:"client_order_id"`
+	Type                string          `json:"type"`
+	Symbol              string          `json:"symbol"`
+	Quantity            decimal.Decimal `json:"quantity"`
+	Side                string          `json:"side"`
+	Filled              decimal.Decimal `json:"filled"`
+	Timestamp           time.Time       `json:"timestamp"`
+	LastUpdateTimestamp time.Time       `json:"last_update_timestamp"`
+	Price               decimal.Decimal `json:"price"`
 }
 
 func (o *Order) String() string {
@@ -101,10 +103,10 @@ func (o *Order) String() string {
 		order_id:   %s,
 		type:       %s,
 		symbol:     %s,
-		quantity:   %d,
+		quantity:   %s,
 		side:       %s,
-		filled:     %d,
+		filled:     %s,
 		timestamp:  %s,
-		price:      %f,
+		price:      %s,
 	`, o.ID, o.Type, o.Symbol, o.Quantity, o.Side, o.Filled, o.Timestamp, o.Price)
 }
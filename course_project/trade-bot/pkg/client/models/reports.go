@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+type GetReportInput struct {
+	JWTToken string
+}
+
+type TradeStats struct {
+	Trades       int             `json:"trades"`
+	WinRate      decimal.Decimal `json:"win_rate"`
+	ProfitFactor decimal.Decimal `json:"profit_factor"`
+	NetProfit    decimal.Decimal `json:"net_profit"`
+	MaxDrawdown  decimal.Decimal `json:"max_drawdown"`
+	Fees         decimal.Decimal `json:"fees"`
+}
+
+func (s *TradeStats) String() string {
+	return fmt.Sprintf(`
+		trades:         %d,
+		win_rate:       %s,
+		profit_factor:  %s,
+		net_profit:     %s,
+		max_drawdown:   %s,
+		fees:           %s,
+	`, s.Trades, s.WinRate, s.ProfitFactor, s.NetProfit.StringFixed(2), s.MaxDrawdown.StringFixed(2), s.Fees.StringFixed(2))
+}
+
+type StrategyStats struct {
+	Strategy string `json:"strategy"`
+	Symbol   string `json:"symbol"`
+	TradeStats
+}
+
+type GetReportResponse struct {
+	Total      TradeStats      `json:"total"`
+	Strategies []StrategyStats `json:"strategies,omitempty"`
+	Message    string          `json:"message,omitempty"`
+}
+
+func (r *GetReportResponse) String() string {
+	if r.Message != "" {
+		return fmt.Sprintf("Message: %s", r.Message)
+	}
+
+	report := fmt.Sprintf("Total: %s\n", r.Total.String())
+	for _, s := range r.Strategies {
+		report += fmt.Sprintf("%s %s: %s\n", s.Strategy, s.Symbol, s.TradeStats.String())
+	}
+	return report
+}
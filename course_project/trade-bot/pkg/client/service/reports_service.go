@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"trade-bot/pkg/client/app"
+	"trade-bot/pkg/client/models"
+)
+
+var (
+	ErrGetReport = errors.New("get report")
+)
+
+type ReportsService struct {
+	client app.ClientActions
+}
+
+func NewReportsService(client app.ClientActions) *ReportsService {
+	return &ReportsService{client: client}
+}
+
+func (s *ReportsService) GetReport(input models.GetReportInput) (models.GetReportResponse, error) {
+	req, err := s.client.NewRequest(http.MethodGet, "/reports", input.JWTToken, nil)
+	if err != nil {
+		return models.GetReportResponse{}, fmt.Errorf("%s: %w", ErrGetReport, err)
+	}
+
+	var output models.GetReportResponse
+
+	resp, err := s.client.Do(req, &output)
+	if err != nil {
+		return models.GetReportResponse{}, fmt.Errorf("%s: %w", ErrGetReport, err)
+	}
+
+	if !(resp.StatusCode >= 200 && resp.StatusCode < 400) {
+		return models.GetReportResponse{}, fmt.Errorf("%s: %s: %s", ErrGetReport, resp.Status, output.Message)
+	}
+
+	return output, err
+}
@@ -17,14 +17,20 @@ type OrdersManager interface {
 	GetUserOrders(input models.GetUserOrdersInput) (models.GetUserOrdersResponse, error)
 }
 
+type Reports interface {
+	GetReport(input models.GetReportInput) (models.GetReportResponse, error)
+}
+
 type Service struct {
 	Authorization
 	OrdersManager
+	Reports
 }
 
 func NewService(client app.ClientActions) *Service {
 	return &Service{
 		Authorization: NewAuthService(client),
 		OrdersManager: NewOrdersManagerService(client),
+		Reports:       NewReportsService(client),
 	}
 }
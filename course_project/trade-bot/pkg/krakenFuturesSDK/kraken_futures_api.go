@@ -8,13 +8,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+
+	"trade-bot/configs"
 )
 
 var (
@@ -28,28 +32,79 @@ var (
 	ErrCouldNotUnmarshalBody    = errors.New("could not unmarshal body")
 	ErrValidateSendStatus       = errors.New("validate send status")
 	ErrEmptyOrderEvents         = errors.New("empty order events")
+	ErrServerError              = errors.New("server error")
+	ErrTransfer                 = errors.New("transfer")
+	ErrBatchOrder               = errors.New("batch order")
+	ErrEmptyBatch               = errors.New("empty batch")
 )
 
 const (
 	apiUserAgent = "Kraken GO API Agent"
 )
 
+const (
+	defaultTimeoutInSeconds            = 10
+	defaultMaxRetries                  = 3
+	defaultMinRetryDelayInMilliseconds = 200
+	defaultMaxRetryDelayInSeconds      = 5
+	defaultRateLimitBudget             = 500
+	defaultRateLimitWindowInSeconds    = 10
+)
+
 type API struct {
-	apiPublicKey  string
-	apiPrivateKey string
-	apiURL        string
-	client        *http.Client
+	apiPublicKey   string
+	apiPrivateKey  string
+	apiURL         string
+	client         *http.Client
+	limiter        *rateLimiter
+	nonces         *nonceGenerator
+	clock          serverClock
+	tickSizes      tickSizes
+	requestsConfig configs.KrakenAPIRequestsConfiguration
 }
 
-func NewAPI(apiPublicKey, apiPrivateKey string, apiURL string) *API {
+func NewAPI(apiPublicKey, apiPrivateKey string, config configs.KrakenConfiguration) *API {
+	requestsConfig := withDefaultRequestsConfig(config.Requests)
+
 	return &API{
 		apiPublicKey:  apiPublicKey,
 		apiPrivateKey: apiPrivateKey,
-		apiURL:        apiURL,
-		client:        http.DefaultClient,
+		apiURL:        config.APIURL,
+		client: &http.Client{
+			Timeout: time.Duration(requestsConfig.TimeoutInSeconds) * time.Second,
+		},
+		limiter: newRateLimiter(requestsConfig.RateLimitBudget,
+			time.Duration(requestsConfig.RateLimitWindowInSeconds)*time.Second),
+		nonces:         nonceGeneratorFor(apiPublicKey),
+		requestsConfig: requestsConfig,
 	}
 }
 
+// withDefaultRequestsConfig fills not configured values with defaults described in README
+func withDefaultRequestsConfig(cfg configs.KrakenAPIRequestsConfiguration) configs.KrakenAPIRequestsConfiguration {
+	if cfg.TimeoutInSeconds <= 0 {
+		cfg.TimeoutInSeconds = defaultTimeoutInSeconds
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.MinRetryDelayInMilliseconds <= 0 {
+		cfg.MinRetryDelayInMilliseconds = defaultMinRetryDelayInMilliseconds
+	}
+	if cfg.MaxRetryDelayInSeconds <= 0 {
+		cfg.MaxRetryDelayInSeconds = defaultMaxRetryDelayInSeconds
+	}
+	if cfg.RateLimitBudget <= 0 {
+		cfg.RateLimitBudget = defaultRateLimitBudget
+	}
+	if cfg.RateLimitWindowInSeconds <= 0 {
+		cfg.RateLimitWindowInSeconds = defaultRateLimitWindowInSeconds
+	}
+	return cfg
+}
+
 // -------------------------- PUBLIC KRAKEN API ENDPOINTS -------------------------- //
 
 func (a *API) FeeSchedules() (*FeeSchedulesResponse, error) {
@@ -86,24 +141,44 @@ func (a *API) Instruments() (*InstrumentsResponse, error) {
 	return resp.(*InstrumentsResponse), nil
 }
 
+// Charts returns candles of tickType (trade, mark, spot) and resolution (1m, 5m, ...) started in [from, to).
+// Kraken limits the count of candles in one response, MoreCandles is set when some are left
+func (a *API) Charts(tickType, symbol, resolution string, from, to time.Time) (*ChartsResponse, error) {
+	values := url.Values{}
+	values.Add("from", strconv.FormatInt(from.Unix(), 10))
+	values.Add("to", strconv.FormatInt(to.Unix(), 10))
+
+	endpoint := fmt.Sprintf("/api/charts/v1/%s/%s/%s", tickType, symbol, resolution)
+	resp, err := a.queryPublic(http.MethodGet, endpoint, values, &ChartsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*ChartsResponse), nil
+}
+
 // --------------------------------------------------------------------------------- //
 
 // -------------------------- PRIVATE KRAKEN API ENDPOINTS -------------------------- //
 
+// SendOrder sends order with limit and stop prices rounded to tick size of the instrument
 func (a *API) SendOrder(args SendOrderArguments) (*SendOrderResponse, error) {
+	if err := a.roundPrices(args.Symbol, &args.LimitPrice, &args.StopPrice); err != nil {
+		return nil, err
+	}
+
 	values := url.Values{}
 	values.Add("orderType", args.OrderType)
 	values.Add("symbol", args.Symbol)
 	values.Add("side", args.Side)
 	values.Add("size", strconv.Itoa(int(args.Size)))
 
-	if args.LimitPrice != 0 {
-		values.Add("limitPrice", strconv.FormatFloat(args.LimitPrice, 'f', 2, 64))
+	if !args.LimitPrice.IsZero() {
+		values.Add("limitPrice", args.LimitPrice.String())
 	}
 
 	if args.OrderType == "stp" || args.OrderType == "take_profit" {
-		if args.StopPrice != 0 {
-			values.Add("stopPrice", strconv.FormatFloat(args.StopPrice, 'f', 2, 64))
+		if !args.StopPrice.IsZero() {
+			values.Add("stopPrice", args.StopPrice.String())
 		}
 		if args.TriggerSignal != "" {
 			values.Add("triggerSignal", args.TriggerSignal)
@@ -129,17 +204,22 @@ func (a *API) SendOrder(args SendOrderArguments) (*SendOrderResponse, error) {
 	return resp.(*SendOrderResponse), nil
 }
 
+// EditOrder edits order, prices are rounded to tick size of the instrument when Symbol is set
 func (a *API) EditOrder(args EditOrderArguments) (*EditOrderResponse, error) {
+	if err := a.roundPrices(args.Symbol, &args.LimitPrice, &args.StopPrice); err != nil {
+		return nil, err
+	}
+
 	values := url.Values{}
 	values.Add("orderId", args.OrderID)
 	if args.Size != 0 {
 		values.Add("size", strconv.Itoa(int(args.Size)))
 	}
-	if args.LimitPrice != 0 {
-		values.Add("limitPrice", strconv.FormatFloat(args.LimitPrice, 'f', 2, 64))
+	if !args.LimitPrice.IsZero() {
+		values.Add("limitPrice", args.LimitPrice.String())
 	}
-	if args.StopPrice != 0 {
-		values.Add("stopPrice", strconv.FormatFloat(args.StopPrice, 'f', 2, 64))
+	if !args.StopPrice.IsZero() {
+		values.Add("stopPrice", args.StopPrice.String())
 	}
 	if args.CliOrdID != "" {
 		values.Add("cliOrdId", args.CliOrdID)
@@ -180,6 +260,117 @@ func (a *API) CancelAllOrders(symbol string) (*CancelAllOrdersResponse, error) {
 	return resp.(*CancelAllOrdersResponse), nil
 }
 
+// BatchOrder sends, edits and cancels orders in one request. Statuses are returned in order of instructions.
+// Prices of instructions with symbol are rounded to tick size of the instrument
+func (a *API) BatchOrder(instructions []BatchInstruction) (*BatchOrderResponse, error) {
+	if len(instructions) == 0 {
+		return nil, ErrEmptyBatch
+	}
+
+	rounded := make([]BatchInstruction, len(instructions))
+	copy(rounded, instructions)
+	for i := range rounded {
+		if err := a.roundPrices(rounded[i].Symbol, &rounded[i].LimitPrice, &rounded[i].StopPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	batch, err := json.Marshal(struct {
+		BatchOrder []BatchInstruction `json:"batchOrder"`
+	}{BatchOrder: rounded})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrBatchOrder, err)
+	}
+
+	values := url.Values{}
+	values.Add("json", string(batch))
+
+	resp, err := a.queryPrivate(http.MethodPost, "/derivatives/api/v3/batchorder", values, &BatchOrderResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*BatchOrderResponse), nil
+}
+
+func (a *API) Accounts() (*AccountsResponse, error) {
+	resp, err := a.queryPrivate(http.MethodGet, "/derivatives/api/v3/accounts", nil, &AccountsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*AccountsResponse), nil
+}
+
+func (a *API) OpenPositions() (*OpenPositionsResponse, error) {
+	resp, err := a.queryPrivate(http.MethodGet, "/derivatives/api/v3/openpositions", nil, &OpenPositionsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*OpenPositionsResponse), nil
+}
+
+func (a *API) OpenOrders() (*OpenOrdersResponse, error) {
+	resp, err := a.queryPrivate(http.MethodGet, "/derivatives/api/v3/openorders", nil, &OpenOrdersResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*OpenOrdersResponse), nil
+}
+
+// Fills returns last 100 fills made before lastFillTime, the most recent ones if lastFillTime is zero
+func (a *API) Fills(lastFillTime time.Time) (*FillsResponse, error) {
+	values := url.Values{}
+	if !lastFillTime.IsZero() {
+		values.Add("lastFillTime", lastFillTime.UTC().Format(time.RFC3339Nano))
+	}
+
+	resp, err := a.queryPrivate(http.MethodGet, "/derivatives/api/v3/fills", values, &FillsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*FillsResponse), nil
+}
+
+// HistoricalOrders returns order events of the account from history API, page by page using ContinuationToken
+func (a *API) HistoricalOrders(args HistoricalOrdersArguments) (*HistoricalOrdersResponse, error) {
+	values := url.Values{}
+	if !args.Since.IsZero() {
+		values.Add("since", strconv.FormatInt(args.Since.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if !args.Before.IsZero() {
+		values.Add("before", strconv.FormatInt(args.Before.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if args.Sort != "" {
+		values.Add("sort", args.Sort)
+	}
+	if args.ContinuationToken != "" {
+		values.Add("continuation_token", args.ContinuationToken)
+	}
+
+	resp, err := a.queryPrivate(http.MethodGet, "/api/history/v2/orders", values, &HistoricalOrdersResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*HistoricalOrdersResponse), nil
+}
+
+// Transfer moves funds between margin accounts of the same user
+func (a *API) Transfer(args TransferArguments) (*TransferResponse, error) {
+	values := url.Values{}
+	values.Add("fromAccount", args.FromAccount)
+	values.Add("toAccount", args.ToAccount)
+	values.Add("unit", args.Unit)
+	values.Add("amount", args.Amount.String())
+
+	resp, err := a.queryPrivate(http.MethodPost, "/derivatives/api/v3/transfer", values, &TransferResponse{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.(*TransferResponse).Result != "success" {
+		return nil, fmt.Errorf("%s: %s", ErrTransfer, resp.(*TransferResponse).Error)
+	}
+	return resp.(*TransferResponse), nil
+}
+
 // ---------------------------------------------------------------------------------- //
 
 func (s SendStatus) ValidateSendStatus() error {
@@ -189,66 +380,146 @@ func (s SendStatus) ValidateSendStatus() error {
 	return nil
 }
 
+// ClockSkew returns the offset of kraken server time from the local clock seen in the last response
+func (a *API) ClockSkew() time.Duration {
+	return a.clock.skew()
+}
+
 // queryPublic make request to public KrakenAPI endpoint
 func (a *API) queryPublic(reqType string, endpoint string, values url.Values, typ interface{}) (interface{}, error) {
 	urlPath := fmt.Sprintf("%s%s?%s", a.apiURL, endpoint, values.Encode())
-	return a.doRequest(reqType, urlPath, nil, typ)
+	return a.doRequest(reqType, endpoint, urlPath, nil, typ)
 }
 
-// queryPrivate make request to private KrakenAPI endpoint
+// queryPrivate make request to private KrakenAPI endpoint, waiting for the endpoint cost
+// to fit into rate limit budget of the key
 func (a *API) queryPrivate(reqType string, endpoint string, values url.Values, typ interface{}) (interface{}, error) {
 	urlPath := fmt.Sprintf("%s%s?%s", a.apiURL, endpoint, values.Encode())
-	authent, err := a.createSignature(endpoint, values.Encode(), "")
-	if err != nil {
-		return nil, err
+
+	// every attempt is signed with a new nonce, kraken rejects reused ones
+	headers := func() (map[string]string, error) {
+		nonce := strconv.FormatInt(a.nonces.next(a.clock.now()), 10)
+		authent, err := a.createSignature(endpoint, values.Encode(), nonce)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]string{
+			"Authent": authent,
+			"APIKey":  a.apiPublicKey,
+			"Nonce":   nonce,
+		}, nil
 	}
 
-	headers := map[string]string{
-		"Authent": authent,
-		"APIKey":  a.apiPublicKey,
+	if wait := a.limiter.reserve(endpointCost(endpoint, values)); wait > 0 {
+		rateLimitHits.WithLabelValues(endpointLabel(endpoint), localRateLimit).Inc()
+		time.Sleep(wait)
 	}
 
-	return a.doRequest(reqType, urlPath, headers, typ)
+	return a.doRequest(reqType, endpoint, urlPath, headers, typ)
 }
 
-// doRequest executes HTTP Request to the KrakenAPI and returns the result
-func (a *API) doRequest(reqType string, reqURL string, headers map[string]string, typ interface{}) (interface{}, error) {
+// doRequest executes HTTP Request to the KrakenAPI and returns the result.
+// Only idempotent GET requests are retried, and only on network, server and rate limit errors
+func (a *API) doRequest(reqType string, endpoint string, reqURL string, headers func() (map[string]string, error), typ interface{}) (interface{}, error) {
 	if typ == nil {
 		return nil, fmt.Errorf("%s: %s", ErrDoRequest, ErrNilTyp)
 	}
 
+	attempts := 1
+	if reqType == http.MethodGet {
+		attempts += a.requestsConfig.MaxRetries
+	}
+
+	delay := time.Duration(a.requestsConfig.MinRetryDelayInMilliseconds) * time.Millisecond
+	maxDelay := time.Duration(a.requestsConfig.MaxRetryDelayInSeconds) * time.Second
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		var retryable bool
+		started := time.Now()
+		retryable, err = a.sendRequest(reqType, endpoint, reqURL, headers, typ)
+		observeRequest(reqType, endpoint, started, retryable, err)
+		if err == nil {
+			return typ, nil
+		}
+		if !retryable || i == attempts-1 {
+			break
+		}
+
+		wait := withJitter(delay)
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) && wait < rateLimitErr.RetryAfter {
+			wait = rateLimitErr.RetryAfter
+		}
+		time.Sleep(wait)
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+
+	return nil, err
+}
+
+// sendRequest makes one attempt of request, retryable is set when the attempt may succeed later
+func (a *API) sendRequest(reqType string, endpoint string, reqURL string, headers func() (map[string]string, error), typ interface{}) (bool, error) {
 	// Create request
 	req, err := http.NewRequest(reqType, reqURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotCreateRequest, err)
+		return false, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotCreateRequest, err)
 	}
 
 	req.Header.Add("User-Agent", apiUserAgent)
 
-	for key, value := range headers {
-		req.Header.Add(key, value)
+	if headers != nil {
+		values, err := headers()
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", ErrDoRequest, err)
+		}
+		for key, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
 
 	// Execute request
+	sent := time.Now()
 	resp, err := a.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotExecuteRequest, err)
+		return true, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotExecuteRequest, err)
 	}
 	defer resp.Body.Close()
 
 	// Read request
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotReadBody, err)
+		return true, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotReadBody, err)
+	}
+
+	var status KrakenErrorResponse
+	_ = json.Unmarshal(body, &status)
+	a.clock.sync(status.ServerTime, sent, time.Now())
+
+	if isRateLimited(resp, status) {
+		a.limiter.drain()
+		return true, fmt.Errorf("%s: %w", ErrDoRequest, &RateLimitError{
+			Endpoint:   endpoint,
+			RetryAfter: retryAfter(resp, a.limiter.untilAvailable(endpointCost(endpoint, nil))),
+		})
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return true, fmt.Errorf("%s: %w: status %d", ErrDoRequest, ErrServerError, resp.StatusCode)
 	}
 
 	// validate content type
 	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotParseContentType, err)
+		return false, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotParseContentType, err)
 	}
 	if contentType != "application/json" {
-		return nil, fmt.Errorf("%s: %s: %s.\n%s",
+		return false, fmt.Errorf("%s: %s: %s.\n%s",
 			ErrDoRequest, ErrInvalidContentType,
 			fmt.Sprintf("response content-yype is '%s', but should be 'application/json'", contentType),
 			fmt.Sprintf("content: '%s'", string(body)))
@@ -256,10 +527,29 @@ func (a *API) doRequest(reqType string, reqURL string, headers map[string]string
 
 	err = json.Unmarshal(body, typ)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotUnmarshalBody, err)
+		return false, fmt.Errorf("%s: %s: %w", ErrDoRequest, ErrCouldNotUnmarshalBody, err)
 	}
 
-	return typ, nil
+	return false, nil
+}
+
+// isRateLimited detects both http 429 and kraken apiLimitExceeded error in json body
+func isRateLimited(resp *http.Response, status KrakenErrorResponse) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || status.Error == krakenRateLimitError
+}
+
+// retryAfter prefers Retry-After header in seconds to the estimate of local limiter
+func retryAfter(resp *http.Response, estimate time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return estimate
+}
+
+// withJitter returns random duration in [d/2, d)
+func withJitter(d time.Duration) time.Duration {
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
 }
 
 // getSha256 creates a sha256 hash for given []byte
@@ -277,7 +567,7 @@ func getHMacSha512(message, secret []byte) []byte {
 }
 
 // createSignature creates value for krakenAPI request Authent header
-func (a API) createSignature(endPoint, postData, nonce string) (string, error) {
+func (a *API) createSignature(endPoint, postData, nonce string) (string, error) {
 	endPoint = strings.TrimPrefix(endPoint, "/derivatives")
 
 	message := postData + nonce + endPoint
@@ -0,0 +1,447 @@
+package krakenFuturesSDK
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/configs"
+)
+
+var testPrivateKey = base64.StdEncoding.EncodeToString([]byte("private key"))
+
+const testInstruments = `{"result":"success","instruments":[{"symbol":"pi_xbtusd","type":"futures_inverse","tradeable":true,"tickSize":0.5}]}`
+
+// serveInstruments answers instruments request used for rounding prices, other requests are passed to next
+func serveInstruments(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/derivatives/api/v3/instruments" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(testInstruments))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func newTestAPI(url string, requests configs.KrakenAPIRequestsConfiguration) *API {
+	requests.MinRetryDelayInMilliseconds = 1
+	return NewAPI("public key", testPrivateKey, configs.KrakenConfiguration{APIURL: url, Requests: requests})
+}
+
+func TestAPI_doRequest(t *testing.T) {
+	type response struct {
+		status int
+		header map[string]string
+		body   string
+	}
+
+	tests := []struct {
+		name         string
+		call         func(a *API) error
+		responses    []response
+		wantRequests int32
+		wantErr      error
+	}{
+		{
+			name: "Retry idempotent request on server error",
+			call: func(a *API) error {
+				_, err := a.Tickers()
+				return err
+			},
+			responses: []response{
+				{status: http.StatusBadGateway, body: "bad gateway"},
+				{status: http.StatusOK, body: `{"result":"success","tickers":[]}`},
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "Stop retrying after max retries",
+			call: func(a *API) error {
+				_, err := a.Tickers()
+				return err
+			},
+			responses: []response{
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+			},
+			wantRequests: 4,
+			wantErr:      ErrServerError,
+		},
+		{
+			name: "Do not retry orders",
+			call: func(a *API) error {
+				_, err := a.SendOrder(SendOrderArguments{OrderType: "mkt", Symbol: "PI_XBTUSD", Side: BuySide, Size: 1})
+				return err
+			},
+			responses: []response{
+				{status: http.StatusBadGateway},
+				{status: http.StatusOK, body: `{"result":"success"}`},
+			},
+			wantRequests: 1,
+			wantErr:      ErrServerError,
+		},
+		{
+			name: "Kraken rate limit error",
+			call: func(a *API) error {
+				_, err := a.CancelAllOrders("")
+				return err
+			},
+			responses: []response{
+				{status: http.StatusOK, body: `{"result":"error","error":"apiLimitExceeded"}`},
+			},
+			wantRequests: 1,
+			wantErr:      ErrRateLimitExceeded,
+		},
+		{
+			name: "Retry after too many requests",
+			call: func(a *API) error {
+				_, err := a.Tickers()
+				return err
+			},
+			responses: []response{
+				{status: http.StatusTooManyRequests},
+				{status: http.StatusOK, body: `{"result":"success","tickers":[]}`},
+			},
+			wantRequests: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := test.responses[atomic.AddInt32(&requests, 1)-1]
+				w.Header().Set("Content-Type", "application/json")
+				for key, value := range resp.header {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(resp.status)
+				_, _ = w.Write([]byte(resp.body))
+			}))
+			defer srv.Close()
+
+			err := test.call(newTestAPI(srv.URL, configs.KrakenAPIRequestsConfiguration{}))
+
+			assert.Equal(t, test.wantRequests, atomic.LoadInt32(&requests))
+			if test.wantErr != nil {
+				assert.True(t, errors.Is(err, test.wantErr), "want %s, got %v", test.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAPI_doRequestRateLimitError(t *testing.T) {
+	srv := httptest.NewServer(serveInstruments(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	a := newTestAPI(srv.URL, configs.KrakenAPIRequestsConfiguration{})
+	_, err := a.SendOrder(SendOrderArguments{OrderType: "lmt", Symbol: "PI_XBTUSD", Side: SellSide, Size: 1,
+		LimitPrice: decimal.RequireFromString("50000.5")})
+
+	var rateLimitErr *RateLimitError
+	if assert.True(t, errors.As(err, &rateLimitErr)) {
+		assert.Equal(t, "/derivatives/api/v3/sendorder", rateLimitErr.Endpoint)
+		assert.Equal(t, 7*time.Second, rateLimitErr.RetryAfter)
+	}
+}
+
+func TestAPI_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	a := newTestAPI(srv.URL, configs.KrakenAPIRequestsConfiguration{MaxRetries: -1})
+	a.client.Timeout = 50 * time.Millisecond
+
+	_, err := a.Tickers()
+	assert.Error(t, err)
+}
+
+func TestRateLimiter_reserve(t *testing.T) {
+	now := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	l := newRateLimiter(100, 10*time.Second)
+	l.now = func() time.Time { return now }
+
+	assert.Equal(t, time.Duration(0), l.reserve(60))
+	assert.Equal(t, time.Duration(0), l.reserve(40))
+	assert.Equal(t, time.Second, l.reserve(10))
+	assert.Equal(t, 2*time.Second, l.reserve(10))
+
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve(0))
+	assert.Equal(t, time.Second, l.reserve(10))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), l.reserve(100))
+	l.drain()
+	assert.Equal(t, 500*time.Millisecond, l.untilAvailable(5))
+}
+
+func TestAPI_accountEndpoints(t *testing.T) {
+	lastFillTime := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		call       func(a *API) (interface{}, error)
+		body       string
+		wantMethod string
+		wantPath   string
+		wantQuery  string
+		check      func(t *testing.T, resp interface{})
+	}{
+		{
+			name:       "Accounts",
+			call:       func(a *API) (interface{}, error) { return a.Accounts() },
+			body:       `{"result":"success","accounts":{"cash":{"type":"cashAccount","balances":{"xbt":"0.1"}},"fi_xbtusd":{"type":"marginAccount","currency":"xbt","balances":{"fi_xbtusd_211231":1},"auxiliary":{"af":0.25,"pnl":-0.01,"pv":0.26},"marginRequirements":{"im":0.01,"mm":0.005,"lt":0.004,"tt":0.003}}}}`,
+			wantMethod: http.MethodGet,
+			wantPath:   "/derivatives/api/v3/accounts",
+			check: func(t *testing.T, resp interface{}) {
+				accounts := resp.(*AccountsResponse).Accounts
+				assert.Equal(t, "0.1", accounts["cash"].Balances["xbt"].String())
+				assert.Equal(t, "0.25", accounts["fi_xbtusd"].Auxiliary.AvailableFunds.String())
+				assert.Equal(t, "0.005", accounts["fi_xbtusd"].MarginRequirements.MaintenanceMargin.String())
+			},
+		},
+		{
+			name:       "Open positions",
+			call:       func(a *API) (interface{}, error) { return a.OpenPositions() },
+			body:       `{"result":"success","openPositions":[{"side":"long","symbol":"pi_xbtusd","price":50000.5,"fillTime":"2021-12-01T10:00:00.000Z","size":10}]}`,
+			wantMethod: http.MethodGet,
+			wantPath:   "/derivatives/api/v3/openpositions",
+			check: func(t *testing.T, resp interface{}) {
+				positions := resp.(*OpenPositionsResponse).OpenPositions
+				if assert.Len(t, positions, 1) {
+					assert.Equal(t, "50000.5", positions[0].Price.String())
+					assert.Equal(t, "10", positions[0].Size.String())
+				}
+			},
+		},
+		{
+			name:       "Open orders",
+			call:       func(a *API) (interface{}, error) { return a.OpenOrders() },
+			body:       `{"result":"success","openOrders":[{"order_id":"59302619","symbol":"pi_xbtusd","side":"buy","orderType":"lmt","limitPrice":49000,"unfilledSize":5,"filledSize":1,"status":"partiallyFilled","reduceOnly":false}]}`,
+			wantMethod: http.MethodGet,
+			wantPath:   "/derivatives/api/v3/openorders",
+			check: func(t *testing.T, resp interface{}) {
+				orders := resp.(*OpenOrdersResponse).OpenOrders
+				if assert.Len(t, orders, 1) {
+					assert.Equal(t, "59302619", orders[0].OrderID)
+					assert.Equal(t, "5", orders[0].UnfilledSize.String())
+				}
+			},
+		},
+		{
+			name:       "Fills before time",
+			call:       func(a *API) (interface{}, error) { return a.Fills(lastFillTime) },
+			body:       `{"result":"success","fills":[{"fill_id":"3d57ed09","order_id":"693af756","symbol":"pi_xbtusd","side":"buy","size":5,"price":50000.5,"fillTime":"2021-12-01T09:59:00.000Z","fillType":"maker"}]}`,
+			wantMethod: http.MethodGet,
+			wantPath:   "/derivatives/api/v3/fills",
+			wantQuery:  "lastFillTime=2021-12-01T10%3A00%3A00Z",
+			check: func(t *testing.T, resp interface{}) {
+				fills := resp.(*FillsResponse).Fills
+				if assert.Len(t, fills, 1) {
+					assert.Equal(t, "693af756", fills[0].OrderID)
+					assert.Equal(t, "maker", fills[0].FillType)
+				}
+			},
+		},
+		{
+			name: "Historical orders",
+			call: func(a *API) (interface{}, error) {
+				return a.HistoricalOrders(HistoricalOrdersArguments{Since: lastFillTime, ContinuationToken: "next"})
+			},
+			body:       `{"elements":[{"uid":"e1","timestamp":1638352800000,"event":{"OrderPlaced":{"order":{"uid":"o1","tradeable":"PI_XBTUSD","direction":"Buy","quantity":"5","filled":"0","limitPrice":"49000.0","orderType":"Limit","reduceOnly":false},"reason":"new_user_order"}}}],"len":1,"continuationToken":"c2"}`,
+			wantMethod: http.MethodGet,
+			wantPath:   "/api/history/v2/orders",
+			wantQuery:  "continuation_token=next&since=1638352800000",
+			check: func(t *testing.T, resp interface{}) {
+				history := resp.(*HistoricalOrdersResponse)
+				assert.Equal(t, "c2", history.ContinuationToken)
+				if assert.Len(t, history.Elements, 1) && assert.NotNil(t, history.Elements[0].Event.OrderPlaced) {
+					assert.Equal(t, "49000", history.Elements[0].Event.OrderPlaced.Order.LimitPrice.String())
+				}
+			},
+		},
+		{
+			name: "Transfer",
+			call: func(a *API) (interface{}, error) {
+				return a.Transfer(TransferArguments{FromAccount: "cash", ToAccount: "fi_xbtusd", Unit: "xbt",
+					Amount: decimal.RequireFromString("0.05")})
+			},
+			body:       `{"result":"success"}`,
+			wantMethod: http.MethodPost,
+			wantPath:   "/derivatives/api/v3/transfer",
+			wantQuery:  "amount=0.05&fromAccount=cash&toAccount=fi_xbtusd&unit=xbt",
+			check:      func(t *testing.T, resp interface{}) {},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.wantMethod, r.Method)
+				assert.Equal(t, test.wantPath, r.URL.Path)
+				assert.Equal(t, test.wantQuery, r.URL.RawQuery)
+				assert.NotEmpty(t, r.Header.Get("Authent"))
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer srv.Close()
+
+			resp, err := test.call(newTestAPI(srv.URL, configs.KrakenAPIRequestsConfiguration{}))
+			if assert.NoError(t, err) {
+				test.check(t, resp)
+			}
+		})
+	}
+}
+
+func TestAPI_BatchOrder(t *testing.T) {
+	instructions := []BatchInstruction{
+		{Order: BatchSend, OrderTag: "1", OrderType: "lmt", Symbol: "PI_XBTUSD", Side: BuySide, Size: 2,
+			LimitPrice: decimal.RequireFromString("49000.5")},
+		{Order: BatchCancel, OrderID: "e35d61dd"},
+	}
+
+	srv := httptest.NewServer(serveInstruments(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/derivatives/api/v3/batchorder", r.URL.Path)
+		assert.JSONEq(t, `{"batchOrder":[
+			{"order":"send","order_tag":"1","orderType":"lmt","symbol":"PI_XBTUSD","side":"buy","size":2,"limitPrice":49000.5},
+			{"order":"cancel","order_id":"e35d61dd"}]}`, r.URL.Query().Get("json"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"result":"success","batchStatus":[
+			{"status":"placed","order_tag":"1","order_id":"022774bc","orderEvents":[{"type":"PLACE","order":{"orderId":"022774bc","symbol":"PI_XBTUSD","quantity":2,"limitPrice":49000.5}}]},
+			{"status":"cancelled","order_id":"e35d61dd","orderEvents":[{"type":"CANCEL","uid":"e35d61dd"}]}]}`))
+	}))
+	defer srv.Close()
+
+	a := newTestAPI(srv.URL, configs.KrakenAPIRequestsConfiguration{})
+
+	resp, err := a.BatchOrder(instructions)
+	if assert.NoError(t, err) && assert.Len(t, resp.BatchStatus, 2) {
+		assert.Equal(t, "1", resp.BatchStatus[0].OrderTag)
+		assert.True(t, resp.BatchStatus[0].Status.IsSuccessStatus())
+		assert.Equal(t, "49000.5", resp.BatchStatus[0].OrderEvents[0].Order.LimitPrice.String())
+		assert.Equal(t, "e35d61dd", resp.BatchStatus[1].OrderID)
+	}
+
+	_, err = a.BatchOrder(nil)
+	assert.True(t, errors.Is(err, ErrEmptyBatch))
+}
+
+func TestAPI_roundPrices(t *testing.T) {
+	var (
+		instrumentsRequests int32
+		query               url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/derivatives/api/v3/instruments":
+			atomic.AddInt32(&instrumentsRequests, 1)
+			_, _ = w.Write([]byte(testInstruments))
+		case "/derivatives/api/v3/sendorder":
+			query = r.URL.Query()
+			_, _ = w.Write([]byte(`{"result":"success","sendStatus":{"status":"placed","orderEvents":[{"type":"PLACE"}]}}`))
+		case "/derivatives/api/v3/editorder":
+			query = r.URL.Query()
+			_, _ = w.Write([]byte(`{"result":"success","editStatus":{"status":"edited"}}`))
+		case "/derivatives/api/v3/batchorder":
+			query = r.URL.Query()
+			_, _ = w.Write([]byte(`{"result":"success","batchStatus":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	a := newTestAPI(srv.URL, configs.KrakenAPIRequestsConfiguration{})
+
+	_, err := a.SendOrder(SendOrderArguments{OrderType: "stp", Symbol: "PI_XBTUSD", Side: BuySide, Size: 1,
+		LimitPrice: decimal.RequireFromString("49000.3"), StopPrice: decimal.RequireFromString("48999.74")})
+	assert.NoError(t, err)
+	assert.Equal(t, "49000.5", query.Get("limitPrice"))
+	assert.Equal(t, "48999.5", query.Get("stopPrice"))
+
+	_, err = a.EditOrder(EditOrderArguments{OrderID: "022774bc", Symbol: "PI_XBTUSD",
+		LimitPrice: decimal.RequireFromString("49100.26")})
+	assert.NoError(t, err)
+	assert.Equal(t, "49100.5", query.Get("limitPrice"))
+	assert.Empty(t, query.Get("symbol"))
+
+	instructions := []BatchInstruction{
+		{Order: BatchSend, OrderType: "lmt", Symbol: "PI_XBTUSD", Side: SellSide, Size: 1,
+			LimitPrice: decimal.RequireFromString("50000.1")},
+		// prices of unknown instruments are sent as they are
+		{Order: BatchSend, OrderType: "lmt", Symbol: "PI_ETHUSD", Side: SellSide, Size: 1,
+			LimitPrice: decimal.RequireFromString("3000.123")},
+	}
+	_, err = a.BatchOrder(instructions)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"batchOrder":[
+		{"order":"send","orderType":"lmt","symbol":"PI_XBTUSD","side":"sell","size":1,"limitPrice":50000},
+		{"order":"send","orderType":"lmt","symbol":"PI_ETHUSD","side":"sell","size":1,"limitPrice":3000.123}]}`,
+		query.Get("json"))
+	assert.Equal(t, "50000.1", instructions[0].LimitPrice.String())
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&instrumentsRequests))
+}
+
+func TestEndpointCost(t *testing.T) {
+	batch, err := json.Marshal(map[string]interface{}{"batchOrder": make([]BatchInstruction, 5)})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 14, endpointCost("/derivatives/api/v3/batchorder", url.Values{"json": {string(batch)}}))
+	assert.Equal(t, 2, endpointCost("/derivatives/api/v3/fills", nil))
+	assert.Equal(t, 25, endpointCost("/derivatives/api/v3/fills", url.Values{"lastFillTime": {"2021-12-01T10:00:00Z"}}))
+	assert.Equal(t, 0, endpointCost("/derivatives/api/v3/tickers", nil))
+}
+
+func TestAPI_metrics(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	endpoint := "/api/charts/v1"
+	rateLimited := testutil.ToFloat64(requestErrors.WithLabelValues(endpoint, rateLimitErrorReason))
+	serverErrors := testutil.ToFloat64(requestErrors.WithLabelValues(endpoint, serverErrorReason))
+	hits := testutil.ToFloat64(rateLimitHits.WithLabelValues(endpoint, exchangeRateLimit))
+
+	a := newTestAPI(srv.URL, configs.KrakenAPIRequestsConfiguration{MaxRetries: 1})
+	_, err := a.Charts("trade", "PI_XBTUSD", "1h", time.Unix(0, 0), time.Unix(3600, 0))
+	assert.Error(t, err)
+
+	// symbol of charts endpoint is not a label
+	assert.Equal(t, rateLimited+1, testutil.ToFloat64(requestErrors.WithLabelValues(endpoint, rateLimitErrorReason)))
+	assert.Equal(t, serverErrors+1, testutil.ToFloat64(requestErrors.WithLabelValues(endpoint, serverErrorReason)))
+	assert.Equal(t, hits+1, testutil.ToFloat64(rateLimitHits.WithLabelValues(endpoint, exchangeRateLimit)))
+}
@@ -0,0 +1,79 @@
+package krakenFuturesSDK
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons of failed requests in metrics
+const (
+	networkErrorReason   = "network"
+	serverErrorReason    = "server"
+	rateLimitErrorReason = "rate_limit"
+	responseErrorReason  = "response"
+)
+
+// Sources of rate limit hits in metrics: kraken refused request or local limiter delayed it
+const (
+	exchangeRateLimit = "exchange"
+	localRateLimit    = "local"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "trade_bot",
+		Subsystem: "kraken",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of kraken futures REST requests, every retry is observed separately.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"endpoint", "method"})
+
+	requestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "trade_bot",
+		Subsystem: "kraken",
+		Name:      "request_errors_total",
+		Help:      "Failed kraken futures REST requests by reason: network, server, rate_limit or response.",
+	}, []string{"endpoint", "reason"})
+
+	rateLimitHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "trade_bot",
+		Subsystem: "kraken",
+		Name:      "rate_limit_hits_total",
+		Help:      "Kraken futures requests refused by exchange rate limit or delayed by local limiter.",
+	}, []string{"endpoint", "source"})
+)
+
+// endpointLabel removes path parameters from endpoint, so that labels are not created for every symbol
+func endpointLabel(endpoint string) string {
+	const charts = "/api/charts/v1"
+	if strings.HasPrefix(endpoint, charts+"/") {
+		return charts
+	}
+	return endpoint
+}
+
+// observeRequest records duration and result of one attempt of request
+func observeRequest(method, endpoint string, started time.Time, retryable bool, err error) {
+	endpoint = endpointLabel(endpoint)
+	requestDuration.WithLabelValues(endpoint, method).Observe(time.Since(started).Seconds())
+	if err == nil {
+		return
+	}
+
+	var rateLimitErr *RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		rateLimitHits.WithLabelValues(endpoint, exchangeRateLimit).Inc()
+		requestErrors.WithLabelValues(endpoint, rateLimitErrorReason).Inc()
+	case errors.Is(err, ErrServerError):
+		requestErrors.WithLabelValues(endpoint, serverErrorReason).Inc()
+	case retryable:
+		requestErrors.WithLabelValues(endpoint, networkErrorReason).Inc()
+	default:
+		requestErrors.WithLabelValues(endpoint, responseErrorReason).Inc()
+	}
+}
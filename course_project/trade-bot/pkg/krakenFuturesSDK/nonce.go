@@ -0,0 +1,81 @@
+package krakenFuturesSDK
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxClockSkew is the difference with kraken server time reported as clock drift
+const maxClockSkew = time.Second
+
+// nonces keeps one generator per public key, so API instances sharing the key never reuse a nonce
+var nonces sync.Map
+
+// nonceGenerator returns strictly increasing millisecond timestamps,
+// bumping the last one when called several times within a millisecond
+type nonceGenerator struct {
+	last int64
+}
+
+func nonceGeneratorFor(apiPublicKey string) *nonceGenerator {
+	g, _ := nonces.LoadOrStore(apiPublicKey, &nonceGenerator{})
+	return g.(*nonceGenerator)
+}
+
+func (g *nonceGenerator) next(now time.Time) int64 {
+	nonce := now.UnixNano() / int64(time.Millisecond)
+	for {
+		last := atomic.LoadInt64(&g.last)
+		if nonce <= last {
+			nonce = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&g.last, last, nonce) {
+			return nonce
+		}
+	}
+}
+
+// serverClock tracks the offset of kraken server time from the local clock
+type serverClock struct {
+	offset int64
+}
+
+func (c *serverClock) now() time.Time {
+	return time.Now().Add(c.skew())
+}
+
+func (c *serverClock) skew() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.offset))
+}
+
+// sync updates the offset from serverTime of response to the request sent at sent and received at received.
+// The server is assumed to answer in the middle of the round trip
+func (c *serverClock) sync(serverTime string, sent, received time.Time) {
+	if serverTime == "" {
+		return
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, serverTime)
+	if err != nil {
+		log.Debugf("unable to parse kraken server time %q: %s", serverTime, err)
+		return
+	}
+
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := t.Sub(local)
+
+	previous := time.Duration(atomic.SwapInt64(&c.offset, int64(offset)))
+	if abs(offset) > maxClockSkew && abs(previous) <= maxClockSkew {
+		log.Warnf("local clock differs from kraken server time by %s, nonces are adjusted", offset)
+	}
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
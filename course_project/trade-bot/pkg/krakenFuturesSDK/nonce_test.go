@@ -0,0 +1,145 @@
+package krakenFuturesSDK
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"trade-bot/configs"
+)
+
+// Expected signatures are computed with openssl by the algorithm of Kraken docs, not by the code under test:
+// echo -n "$postData$nonce$endpoint" | openssl dgst -sha256 -binary |
+// openssl dgst -sha512 -mac HMAC -macopt hexkey:$(echo -n "$secret" | base64 -d | xxd -p -c 256) -binary | base64
+func TestAPI_createSignature(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		endpoint string
+		postData string
+		nonce    string
+		want     string
+	}{
+		{
+			name:     "Secret of kraken length",
+			secret:   "tp36M4r1mxRpbma8fBdiDubLPr86CaTo+5kjUf/Zv5oUH42jlaM+UTt24vB2oAmKXyTaKalWg7YFkGcMjU18vA==",
+			endpoint: "/derivatives/api/v3/sendorder",
+			postData: "orderType=lmt&symbol=PI_XBTUSD&side=buy&size=10000&limitPrice=9400.5&cliOrdId=my-order",
+			nonce:    "1415957147987",
+			want:     "4YtZOsQk9NnUhTg17/SvUijK+LeowjObPrQSZ/rRpm8C/CXVMpJ/vMH2Hz5+PAvppQmJVA/lcobBAUMHJ8JLuw==",
+		},
+		{
+			name:     "Send order",
+			endpoint: "/derivatives/api/v3/sendorder",
+			postData: "orderType=mkt&side=buy&size=1&symbol=PI_XBTUSD",
+			nonce:    "1639000000000",
+			want:     "4cvhkve4bA/1e4sueLA1bFnlaOI5J3txWlAj8AMKUqovBmohqBaS2tJTCR9ClGizyOGnEJmFy/VThZYrbqct5g==",
+		},
+		{
+			name:     "Without post data and nonce",
+			endpoint: "/derivatives/api/v3/openpositions",
+			want:     "am0PG1KaAhy2//QFsWpzJdTV0lDLcN1/KUPaYHB6P+N7BjFYldTPdrubDcLX61QkJ3G6AvfD6v5ZoiJoAwMCPQ==",
+		},
+		{
+			name:     "Endpoint without derivatives prefix",
+			endpoint: "/api/v3/cancelallorders",
+			postData: "symbol=PI_XBTUSD",
+			nonce:    "1639000000001",
+			want:     "vAW2oOvVRSMaxOqyzObxUz4iEpVkyOpzprY3sC/M+TNi4xA4imBuYLtSypTNqAEsKA8eSsWmD9MX73Mt19G3kQ==",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := test.secret
+			if secret == "" {
+				secret = testPrivateKey
+			}
+			a := NewAPI("public key", secret, configs.KrakenConfiguration{})
+
+			got, err := a.createSignature(test.endpoint, test.postData, test.nonce)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestNonceGenerator_next(t *testing.T) {
+	now := time.Unix(1639000000, 0)
+	g := &nonceGenerator{}
+
+	assert.Equal(t, int64(1639000000000), g.next(now))
+	assert.Equal(t, int64(1639000000001), g.next(now))
+	assert.Equal(t, int64(1639000000002), g.next(now.Add(-time.Second)))
+	assert.Equal(t, int64(1639000001000), g.next(now.Add(time.Second)))
+}
+
+func TestNonceGenerator_nextConcurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+
+	g := nonceGeneratorFor("concurrent test key")
+	assert.Same(t, g, nonceGeneratorFor("concurrent test key"))
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64]struct{})
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var last int64
+			for j := 0; j < perGoroutine; j++ {
+				nonce := g.next(time.Now())
+				assert.Greater(t, nonce, last)
+				last = nonce
+
+				mu.Lock()
+				seen[nonce] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, goroutines*perGoroutine)
+}
+
+func TestAPI_queryPrivateNonceAndClockSkew(t *testing.T) {
+	const skew = time.Hour
+
+	var nonces []int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, err := strconv.ParseInt(r.Header.Get("Nonce"), 10, 64)
+		assert.NoError(t, err)
+		nonces = append(nonces, nonce)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"result":"success","serverTime":%q}`,
+			time.Now().Add(skew).UTC().Format(time.RFC3339Nano))
+	}))
+	defer srv.Close()
+
+	a := NewAPI("clock skew test key", testPrivateKey, configs.KrakenConfiguration{APIURL: srv.URL})
+
+	_, err := a.CancelAllOrders("PI_XBTUSD")
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(skew), float64(a.ClockSkew()), float64(time.Second))
+
+	_, err = a.CancelAllOrders("PI_XBTUSD")
+	assert.NoError(t, err)
+
+	if assert.Len(t, nonces, 2) {
+		serverNow := time.Now().Add(skew).UnixNano() / int64(time.Millisecond)
+		assert.Greater(t, nonces[1], nonces[0])
+		assert.InDelta(t, serverNow, nonces[1], float64(time.Second/time.Millisecond))
+	}
+}
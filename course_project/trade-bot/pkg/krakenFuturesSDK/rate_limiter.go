@@ -0,0 +1,140 @@
+package krakenFuturesSDK
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+)
+
+// krakenRateLimitError is the error kraken returns when cost budget of the key is spent
+const krakenRateLimitError = "apiLimitExceeded"
+
+// endpointCosts models kraken futures cost-based limits of private derivatives endpoints,
+// endpoints missing here are not limited. History API has its own pool and is not modeled
+var endpointCosts = map[string]int{
+	"/derivatives/api/v3/sendorder":       10,
+	"/derivatives/api/v3/editorder":       10,
+	"/derivatives/api/v3/cancelorder":     10,
+	"/derivatives/api/v3/cancelallorders": 25,
+	"/derivatives/api/v3/accounts":        2,
+	"/derivatives/api/v3/openpositions":   2,
+	"/derivatives/api/v3/openorders":      2,
+	"/derivatives/api/v3/fills":           2,
+	"/derivatives/api/v3/transfer":        10,
+}
+
+const (
+	// fillsWithTimeCost is the cost of fills requested before lastFillTime
+	fillsWithTimeCost = 25
+	// batchOrderBaseCost is added to the count of instructions in batch
+	batchOrderBaseCost = 9
+)
+
+func endpointCost(endpoint string, values url.Values) int {
+	switch endpoint {
+	case "/derivatives/api/v3/fills":
+		if values.Get("lastFillTime") != "" {
+			return fillsWithTimeCost
+		}
+	case "/derivatives/api/v3/batchorder":
+		var batch struct {
+			BatchOrder []json.RawMessage `json:"batchOrder"`
+		}
+		_ = json.Unmarshal([]byte(values.Get("json")), &batch)
+		return batchOrderBaseCost + len(batch.BatchOrder)
+	}
+	return endpointCosts[endpoint]
+}
+
+// RateLimitError is returned when kraken or the local limiter refuses the request
+type RateLimitError struct {
+	Endpoint   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s: retry after %s", ErrRateLimitExceeded, e.Endpoint, e.RetryAfter)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimitExceeded
+}
+
+// rateLimiter is a token bucket of budget tokens refilled evenly during window
+type rateLimiter struct {
+	mu       sync.Mutex
+	budget   float64
+	tokens   float64
+	perToken time.Duration
+	last     time.Time
+	now      func() time.Time
+}
+
+func newRateLimiter(budget int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		budget:   float64(budget),
+		tokens:   float64(budget),
+		perToken: window / time.Duration(budget),
+		now:      time.Now,
+	}
+}
+
+// reserve takes cost tokens and returns how long the caller should wait before using them.
+// Tokens may go below zero, so concurrent callers queue up instead of racing for refills
+func (l *rateLimiter) reserve(cost int) time.Duration {
+	if cost <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	l.tokens -= float64(cost)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.perToken))
+}
+
+// drain empties the bucket after kraken refused a request, it knows better what is left
+func (l *rateLimiter) drain() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if l.tokens > 0 {
+		l.tokens = 0
+	}
+}
+
+// untilAvailable returns time needed to collect cost tokens
+func (l *rateLimiter) untilAvailable(cost int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if missing := float64(cost) - l.tokens; missing > 0 {
+		return time.Duration(missing * float64(l.perToken))
+	}
+	return 0
+}
+
+func (l *rateLimiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.perToken)
+		if l.tokens > l.budget {
+			l.tokens = l.budget
+		}
+	}
+	l.last = now
+}
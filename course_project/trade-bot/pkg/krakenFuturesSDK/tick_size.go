@@ -0,0 +1,74 @@
+package krakenFuturesSDK
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrRoundPrice = errors.New("round price")
+)
+
+// tickSizesTTL is how long instruments are kept before they are requested again
+const tickSizesTTL = time.Hour
+
+// tickSizes caches instruments by lower case symbol, so that prices of orders are rounded
+// without requesting instruments for every order
+type tickSizes struct {
+	mu          sync.Mutex
+	instruments map[string]Instrument
+	loadedAt    time.Time
+}
+
+// roundPrices rounds non-zero prices in place to tick size of symbol instrument,
+// prices of unknown symbols are left as they are
+func (a *API) roundPrices(symbol string, prices ...*decimal.Decimal) error {
+	needed := false
+	for _, price := range prices {
+		needed = needed || !price.IsZero()
+	}
+	if !needed || symbol == "" {
+		return nil
+	}
+
+	instrument, ok, err := a.instrument(symbol)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrRoundPrice, err)
+	}
+	if !ok {
+		return nil
+	}
+
+	for _, price := range prices {
+		if !price.IsZero() {
+			*price = instrument.RoundPrice(*price)
+		}
+	}
+	return nil
+}
+
+func (a *API) instrument(symbol string) (Instrument, bool, error) {
+	a.tickSizes.mu.Lock()
+	defer a.tickSizes.mu.Unlock()
+
+	if a.tickSizes.instruments == nil || time.Since(a.tickSizes.loadedAt) > tickSizesTTL {
+		response, err := a.Instruments()
+		if err != nil {
+			return Instrument{}, false, err
+		}
+
+		instruments := make(map[string]Instrument, len(response.Instruments))
+		for _, i := range response.Instruments {
+			instruments[strings.ToLower(i.Symbol)] = i
+		}
+		a.tickSizes.instruments, a.tickSizes.loadedAt = instruments, time.Now()
+	}
+
+	instrument, ok := a.tickSizes.instruments[strings.ToLower(symbol)]
+	return instrument, ok, nil
+}
@@ -1,5 +1,12 @@
 package krakenFuturesSDK
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 const SellSide = "sell"
 const BuySide = "buy"
 
@@ -23,6 +30,16 @@ func (s EditOrderStatus) IsSuccessStatus() bool {
 	return false
 }
 
+type BatchOrderStatus string
+
+func (s BatchOrderStatus) IsSuccessStatus() bool {
+	statuses := map[string]struct{}{"placed": {}, "edited": {}, "cancelled": {}}
+	if _, ok := statuses[string(s)]; ok {
+		return true
+	}
+	return false
+}
+
 type CancelOrderStatus string
 
 func (s CancelOrderStatus) IsSuccessStatus() bool {
@@ -76,6 +93,12 @@ type InstrumentsResponse struct {
 	Instruments []Instrument `json:"instruments,omitempty"`
 }
 
+// ChartsResponse wraps the Kraken API JSON Charts method
+type ChartsResponse struct {
+	Candles     []ChartCandle `json:"candles"`
+	MoreCandles bool          `json:"more_candles"`
+}
+
 // --------------------------------------------------------------------------------------- //
 
 // -------------------------- PRIVATE KRAKEN API ENDPOINTS DATA -------------------------- //
@@ -86,15 +109,15 @@ type SendOrderResponse struct {
 }
 
 type SendOrderArguments struct {
-	OrderType     string  `json:"order_type" binding:"required"`
-	Symbol        string  `json:"symbol" binding:"required"`
-	Side          string  `json:"side" binding:"required"`
-	Size          uint    `json:"size" binding:"required"`
-	LimitPrice    float64 `json:"limit_price"`
-	StopPrice     float64 `json:"stop_price"`
-	TriggerSignal string  `json:"trigger_signal"`
-	CliOrderID    string  `json:"cli_order_id"`
-	ReduceOnly    bool    `json:"reduce_only"`
+	OrderType     string          `json:"order_type" binding:"required"`
+	Symbol        string          `json:"symbol" binding:"required"`
+	Side          string          `json:"side" binding:"required"`
+	Size          uint            `json:"size" binding:"required"`
+	LimitPrice    decimal.Decimal `json:"limit_price"`
+	StopPrice     decimal.Decimal `json:"stop_price"`
+	TriggerSignal string          `json:"trigger_signal"`
+	CliOrderID    string          `json:"cli_order_id"`
+	ReduceOnly    bool            `json:"reduce_only"`
 }
 
 func (s *SendOrderArguments) ChangeToOpositeOrderSide() {
@@ -111,10 +134,12 @@ type EditOrderResponse struct {
 }
 
 type EditOrderArguments struct {
-	OrderID    string
+	OrderID string
+	// Symbol of the order is used to round prices only, it is not sent
+	Symbol     string
 	Size       uint
-	LimitPrice float64
-	StopPrice  float64
+	LimitPrice decimal.Decimal
+	StopPrice  decimal.Decimal
 	CliOrdID   string
 }
 
@@ -133,6 +158,126 @@ type CancelAllOrdersResponse struct {
 	CancelStatus CancelAllStatus `json:"cancelStatus,omitempty"`
 }
 
+const (
+	BatchSend   = "send"
+	BatchEdit   = "edit"
+	BatchCancel = "cancel"
+)
+
+type BatchOrderResponse struct {
+	KrakenErrorResponse
+	BatchStatus []BatchStatus `json:"batchStatus,omitempty"`
+}
+
+// BatchInstruction is one send, edit or cancel instruction of batch order.
+// OrderTag binds send instruction to its status in response
+type BatchInstruction struct {
+	Order         string          `json:"order" binding:"required,oneof=send edit cancel"`
+	OrderTag      string          `json:"order_tag"`
+	OrderID       string          `json:"order_id"`
+	OrderType     string          `json:"order_type"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Size          uint            `json:"size"`
+	LimitPrice    decimal.Decimal `json:"limit_price"`
+	StopPrice     decimal.Decimal `json:"stop_price"`
+	TriggerSignal string          `json:"trigger_signal"`
+	CliOrderID    string          `json:"cli_order_id"`
+	ReduceOnly    bool            `json:"reduce_only"`
+}
+
+// MarshalJSON encodes instruction with kraken field names, leaving out fields which are not set
+func (i BatchInstruction) MarshalJSON() ([]byte, error) {
+	fields := map[string]interface{}{"order": i.Order}
+
+	optional := map[string]string{
+		"order_tag":     i.OrderTag,
+		"order_id":      i.OrderID,
+		"orderType":     i.OrderType,
+		"symbol":        i.Symbol,
+		"side":          i.Side,
+		"triggerSignal": i.TriggerSignal,
+		"cliOrdId":      i.CliOrderID,
+	}
+	for key, value := range optional {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+
+	if i.Size != 0 {
+		fields["size"] = i.Size
+	}
+	if !i.LimitPrice.IsZero() {
+		fields["limitPrice"] = json.Number(i.LimitPrice.String())
+	}
+	if !i.StopPrice.IsZero() {
+		fields["stopPrice"] = json.Number(i.StopPrice.String())
+	}
+	if i.ReduceOnly {
+		fields["reduceOnly"] = true
+	}
+
+	return json.Marshal(fields)
+}
+
+type BatchStatus struct {
+	Status           BatchOrderStatus `json:"status"`
+	OrderTag         string           `json:"order_tag,omitempty"`
+	OrderID          string           `json:"order_id,omitempty"`
+	CliOrderID       string           `json:"cliOrdId,omitempty"`
+	DateTimeReceived string           `json:"dateTimeReceived,omitempty"`
+	OrderEvents      []OrderEvent     `json:"orderEvents,omitempty"`
+}
+
+// AccountsResponse wraps the Kraken API JSON Accounts method, accounts are keyed by name (cash, fi_xbtusd, flex)
+type AccountsResponse struct {
+	KrakenErrorResponse
+	Accounts map[string]Account `json:"accounts,omitempty"`
+}
+
+type OpenPositionsResponse struct {
+	KrakenErrorResponse
+	OpenPositions []OpenPosition `json:"openPositions,omitempty"`
+}
+
+type OpenOrdersResponse struct {
+	KrakenErrorResponse
+	OpenOrders []OpenOrder `json:"openOrders,omitempty"`
+}
+
+type FillsResponse struct {
+	KrakenErrorResponse
+	Fills []Fill `json:"fills,omitempty"`
+}
+
+// HistoricalOrdersResponse wraps the Kraken history API orders method,
+// ContinuationToken is set when more events are left
+type HistoricalOrdersResponse struct {
+	KrakenErrorResponse
+	Elements          []HistoricalOrderElement `json:"elements,omitempty"`
+	Len               int                      `json:"len,omitempty"`
+	ContinuationToken string                   `json:"continuationToken,omitempty"`
+}
+
+type HistoricalOrdersArguments struct {
+	Since             time.Time
+	Before            time.Time
+	Sort              string
+	ContinuationToken string
+}
+
+type TransferResponse struct {
+	KrakenErrorResponse
+}
+
+type TransferArguments struct {
+	FromAccount string
+	ToAccount   string
+	Unit        string
+	Amount      decimal.Decimal
+}
+
 // --------------------------------------------------------------------------------------- //
 
 type CancelStatus struct {
@@ -173,56 +318,64 @@ type CanceledOrder struct {
 }
 
 type OrderEvent struct {
-	Type                string  `json:"type,omitempty"`
-	ReducedQuantity     int     `json:"reducedQuantity,omitempty"`
-	Order               Order   `json:"order,omitempty"`
-	UID                 string  `json:"uid,omitempty"`
-	Old                 Order   `json:"old,omitempty"`
-	New                 Order   `json:"new,omitempty"`
-	Reason              string  `json:"reason,omitempty"`
-	Amount              int     `json:"amount,omitempty"`
-	Price               float64 `json:"price,omitempty"`
-	ExecutionID         string  `json:"executionId,omitempty"`
-	TakeReducedQuantity int     `json:"takeReducedQuantity,omitempty"`
-	OrderPriorEdit      Order   `json:"orderPriorEdit,omitempty"`
-	OrderPriorExecution Order   `json:"orderPriorExecution,omitempty"`
+	Type                string          `json:"type,omitempty"`
+	ReducedQuantity     int             `json:"reducedQuantity,omitempty"`
+	Order               Order           `json:"order,omitempty"`
+	UID                 string          `json:"uid,omitempty"`
+	Old                 Order           `json:"old,omitempty"`
+	New                 Order           `json:"new,omitempty"`
+	Reason              string          `json:"reason,omitempty"`
+	Amount              int             `json:"amount,omitempty"`
+	Price               decimal.Decimal `json:"price,omitempty"`
+	ExecutionID         string          `json:"executionId,omitempty"`
+	TakeReducedQuantity int             `json:"takeReducedQuantity,omitempty"`
+	OrderPriorEdit      Order           `json:"orderPriorEdit,omitempty"`
+	OrderPriorExecution Order           `json:"orderPriorExecution,omitempty"`
 }
 
 type Order struct {
-	OrderID             string  `json:"orderId,omitempty"`
-	CliOrderID          string  `json:"cliOrdID,omitempty"`
-	ReduceOnly          bool    `json:"reduceOnly"`
-	Symbol              string  `json:"symbol,omitempty"`
-	Quantity            float64 `json:"quantity,omitempty"`
-	Side                string  `json:"side,omitempty"`
-	LimitPrice          float64 `json:"limitPrice,omitempty"`
-	StopPrice           float64 `json:"stopPrice,omitempty"`
-	Filled              float64 `json:"filled"`
-	Type                string  `json:"type,omitempty"`
-	Timestamp           string  `json:"timestamp,omitempty"`
-	LastUpdateTimestamp string  `json:"lastUpdateTimestamp,omitempty"`
+	OrderID             string          `json:"orderId,omitempty"`
+	CliOrderID          string          `json:"cliOrdID,omitempty"`
+	ReduceOnly          bool            `json:"reduceOnly"`
+	Symbol              string          `json:"symbol,omitempty"`
+	Quantity            decimal.Decimal `json:"quantity,omitempty"`
+	Side                string          `json:"side,omitempty"`
+	LimitPrice          decimal.Decimal `json:"limitPrice,omitempty"`
+	StopPrice           decimal.Decimal `json:"stopPrice,omitempty"`
+	Filled              decimal.Decimal `json:"filled"`
+	Type                string          `json:"type,omitempty"`
+	Timestamp           string          `json:"timestamp,omitempty"`
+	LastUpdateTimestamp string          `json:"lastUpdateTimestamp,omitempty"`
 }
 
 type Instrument struct {
-	Symbol          string        `json:"symbol"`
-	Type            string        `json:"type"`
-	Tradeable       bool          `json:"tradeable"`
-	Underlying      string        `json:"underlying,omitempty"`
-	LastTradingTime string        `json:"lastTradingTime,omitempty"`
-	TickSize        float64       `json:"tickSize,omitempty"`
-	ContractSize    int           `json:"contractSize,omitempty"`
-	MarginLevels    []MarginLevel `json:"marginLevels,omitempty"`
+	Symbol          string          `json:"symbol"`
+	Type            string          `json:"type"`
+	Tradeable       bool            `json:"tradeable"`
+	Underlying      string          `json:"underlying,omitempty"`
+	LastTradingTime string          `json:"lastTradingTime,omitempty"`
+	TickSize        decimal.Decimal `json:"tickSize,omitempty"`
+	ContractSize    int             `json:"contractSize,omitempty"`
+	MarginLevels    []MarginLevel   `json:"marginLevels,omitempty"`
+}
+
+// RoundPrice rounds price to the nearest multiple of instrument tick size
+func (i Instrument) RoundPrice(price decimal.Decimal) decimal.Decimal {
+	if i.TickSize.IsZero() {
+		return price
+	}
+	return price.DivRound(i.TickSize, 0).Mul(i.TickSize)
 }
 
 type MarginLevel struct {
-	Contracts         int     `json:"contracts"`
-	InitialMargin     float64 `json:"initialMargin"`
-	MaintenanceMargin float64 `json:"maintenanceMargin"`
+	Contracts         int             `json:"contracts"`
+	InitialMargin     decimal.Decimal `json:"initialMargin"`
+	MaintenanceMargin decimal.Decimal `json:"maintenanceMargin"`
 }
 
 type OrderBook struct {
-	Bids [][2]float64 `json:"bids"`
-	Asks [][2]float64 `json:"asks"`
+	Bids [][2]decimal.Decimal `json:"bids"`
+	Asks [][2]decimal.Decimal `json:"asks"`
 }
 
 type FeeSchedules struct {
@@ -232,27 +385,154 @@ type FeeSchedules struct {
 }
 
 type Tier struct {
-	MakerFee  float64 `json:"makerFee"`
-	TakerFee  float64 `json:"takerFee"`
-	UsdVolume float64 `json:"usdVolume"`
+	MakerFee  decimal.Decimal `json:"makerFee"`
+	TakerFee  decimal.Decimal `json:"takerFee"`
+	UsdVolume decimal.Decimal `json:"usdVolume"`
 }
 
 type Ticker struct {
-	Tag                   string  `json:"tag,omitempty"`
-	Pair                  string  `json:"pair,omitempty"`
-	Symbol                string  `json:"symbol,omitempty"`
-	MarkPrice             float64 `json:"markPrice,omitempty"`
-	Bid                   float64 `json:"bid,omitempty"`
-	BidSize               int     `json:"bidSize,omitempty"`
-	Ask                   float64 `json:"ask,omitempty"`
-	AskSize               int     `json:"askSize,omitempty"`
-	Vol24h                int     `json:"vol24h,omitempty"`
-	OpenInterest          float64 `json:"openInterest,omitempty"`
-	Open24H               float64 `json:"open24h,omitempty"`
-	Last                  float64 `json:"last,omitempty"`
-	LastTime              string  `json:"lastTime,omitempty"`
-	LastSize              int     `json:"lastSize,omitempty"`
-	Suspended             bool    `json:"suspended,omitempty"`
-	FundingRate           float64 `json:"funding_rate,omitempty"`
-	FundingRatePrediction float64 `json:"funding_rate_prediction,omitempty"`
+	Tag                   string          `json:"tag,omitempty"`
+	Pair                  string          `json:"pair,omitempty"`
+	Symbol                string          `json:"symbol,omitempty"`
+	MarkPrice             decimal.Decimal `json:"markPrice,omitempty"`
+	Bid                   decimal.Decimal `json:"bid,omitempty"`
+	BidSize               int             `json:"bidSize,omitempty"`
+	Ask                   decimal.Decimal `json:"ask,omitempty"`
+	AskSize               int             `json:"askSize,omitempty"`
+	Vol24h                int             `json:"vol24h,omitempty"`
+	OpenInterest          decimal.Decimal `json:"openInterest,omitempty"`
+	Open24H               decimal.Decimal `json:"open24h,omitempty"`
+	Last                  decimal.Decimal `json:"last,omitempty"`
+	LastTime              string          `json:"lastTime,omitempty"`
+	LastSize              int             `json:"lastSize,omitempty"`
+	Suspended             bool            `json:"suspended,omitempty"`
+	FundingRate           decimal.Decimal `json:"funding_rate,omitempty"`
+	FundingRatePrediction decimal.Decimal `json:"funding_rate_prediction,omitempty"`
+}
+
+type ChartCandle struct {
+	Time   int64           `json:"time"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+}
+
+type Account struct {
+	Type               string                     `json:"type"`
+	Currency           string                     `json:"currency,omitempty"`
+	Balances           map[string]decimal.Decimal `json:"balances,omitempty"`
+	Auxiliary          *AccountAuxiliary          `json:"auxiliary,omitempty"`
+	MarginRequirements *MarginRequirements        `json:"marginRequirements,omitempty"`
+	TriggerEstimates   *MarginRequirements        `json:"triggerEstimates,omitempty"`
+	// fields of multi-collateral flex account
+	Currencies        map[string]FlexCurrency `json:"currencies,omitempty"`
+	BalanceValue      decimal.Decimal         `json:"balanceValue,omitempty"`
+	PortfolioValue    decimal.Decimal         `json:"portfolioValue,omitempty"`
+	CollateralValue   decimal.Decimal         `json:"collateralValue,omitempty"`
+	InitialMargin     decimal.Decimal         `json:"initialMargin,omitempty"`
+	MaintenanceMargin decimal.Decimal         `json:"maintenanceMargin,omitempty"`
+	PnL               decimal.Decimal         `json:"pnl,omitempty"`
+	UnrealizedFunding decimal.Decimal         `json:"unrealizedFunding,omitempty"`
+	TotalUnrealized   decimal.Decimal         `json:"totalUnrealized,omitempty"`
+	AvailableMargin   decimal.Decimal         `json:"availableMargin,omitempty"`
+	MarginEquity      decimal.Decimal         `json:"marginEquity,omitempty"`
+}
+
+type AccountAuxiliary struct {
+	AvailableFunds decimal.Decimal `json:"af"`
+	PnL            decimal.Decimal `json:"pnl"`
+	PortfolioValue decimal.Decimal `json:"pv"`
+	Funding        decimal.Decimal `json:"funding,omitempty"`
+}
+
+type MarginRequirements struct {
+	InitialMargin     decimal.Decimal `json:"im"`
+	MaintenanceMargin decimal.Decimal `json:"mm"`
+	LiquidationLevel  decimal.Decimal `json:"lt"`
+	TerminationLevel  decimal.Decimal `json:"tt"`
+}
+
+type FlexCurrency struct {
+	Quantity        decimal.Decimal `json:"quantity"`
+	Value           decimal.Decimal `json:"value"`
+	CollateralValue decimal.Decimal `json:"collateral"`
+	Available       decimal.Decimal `json:"available"`
+}
+
+type OpenPosition struct {
+	Side              string          `json:"side"`
+	Symbol            string          `json:"symbol"`
+	Price             decimal.Decimal `json:"price"`
+	FillTime          string          `json:"fillTime"`
+	Size              decimal.Decimal `json:"size"`
+	UnrealizedFunding decimal.Decimal `json:"unrealizedFunding,omitempty"`
+	MaxFixedLeverage  decimal.Decimal `json:"maxFixedLeverage,omitempty"`
+	PnLCurrency       string          `json:"pnlCurrency,omitempty"`
+}
+
+type OpenOrder struct {
+	OrderID        string          `json:"order_id"`
+	CliOrdID       string          `json:"cliOrdId,omitempty"`
+	Symbol         string          `json:"symbol"`
+	Side           string          `json:"side"`
+	OrderType      string          `json:"orderType"`
+	LimitPrice     decimal.Decimal `json:"limitPrice,omitempty"`
+	StopPrice      decimal.Decimal `json:"stopPrice,omitempty"`
+	UnfilledSize   decimal.Decimal `json:"unfilledSize"`
+	FilledSize     decimal.Decimal `json:"filledSize"`
+	ReceivedTime   string          `json:"receivedTime"`
+	LastUpdateTime string          `json:"lastUpdateTime"`
+	Status         string          `json:"status"`
+	ReduceOnly     bool            `json:"reduceOnly"`
+	TriggerSignal  string          `json:"triggerSignal,omitempty"`
+}
+
+type Fill struct {
+	FillID   string          `json:"fill_id"`
+	OrderID  string          `json:"order_id"`
+	CliOrdID string          `json:"cliOrdId,omitempty"`
+	Symbol   string          `json:"symbol"`
+	Side     string          `json:"side"`
+	Size     decimal.Decimal `json:"size"`
+	Price    decimal.Decimal `json:"price"`
+	FillTime string          `json:"fillTime"`
+	FillType string          `json:"fillType"`
+}
+
+type HistoricalOrderElement struct {
+	UID       string               `json:"uid"`
+	Timestamp int64                `json:"timestamp"`
+	Event     HistoricalOrderEvent `json:"event"`
+}
+
+// HistoricalOrderEvent has exactly one of the fields set, named after the kind of event
+type HistoricalOrderEvent struct {
+	OrderPlaced       *HistoricalOrderEventData `json:"OrderPlaced,omitempty"`
+	OrderUpdated      *HistoricalOrderEventData `json:"OrderUpdated,omitempty"`
+	OrderCancelled    *HistoricalOrderEventData `json:"OrderCancelled,omitempty"`
+	OrderRejected     *HistoricalOrderEventData `json:"OrderRejected,omitempty"`
+	OrderEditRejected *HistoricalOrderEventData `json:"OrderEditRejected,omitempty"`
+	OrderNotFound     *HistoricalOrderEventData `json:"OrderNotFound,omitempty"`
+}
+
+type HistoricalOrderEventData struct {
+	Order  HistoricalOrder `json:"order"`
+	Reason string          `json:"reason,omitempty"`
+}
+
+type HistoricalOrder struct {
+	UID                 string          `json:"uid"`
+	AccountUID          string          `json:"accountUid"`
+	Tradeable           string          `json:"tradeable"`
+	Direction           string          `json:"direction"`
+	Quantity            decimal.Decimal `json:"quantity"`
+	Filled              decimal.Decimal `json:"filled"`
+	Timestamp           int64           `json:"timestamp"`
+	LimitPrice          decimal.Decimal `json:"limitPrice"`
+	OrderType           string          `json:"orderType"`
+	ClientID            string          `json:"clientId"`
+	ReduceOnly          bool            `json:"reduceOnly"`
+	LastUpdateTimestamp int64           `json:"lastUpdateTimestamp"`
 }
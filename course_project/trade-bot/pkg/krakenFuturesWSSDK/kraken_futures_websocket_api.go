@@ -3,7 +3,9 @@ package krakenFuturesWSSDK
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -22,10 +24,22 @@ var (
 	ErrCouldNotSubscribeToFeed  = errors.New("could not subscribe to feed")
 	ErrConnect                  = errors.New("connect to ws")
 	ErrLoopOverWS               = errors.New("loop over ws")
+	ErrConnectionLost           = errors.New("connection lost")
 )
 
 const (
 	maxEstablishConnectCounter = 10
+	minReconnectDelay          = 500 * time.Millisecond
+	maxReconnectDelay          = 30 * time.Second
+	gapBufferSize              = 16
+)
+
+const (
+	defaultWriteWaitInSeconds  = 10
+	defaultPongWaitInSeconds   = 60
+	defaultPingPeriodInSeconds = 10
+	// defaultMaxMessageSize fits trade_snapshot of trade feed, the largest message of subscribed feeds
+	defaultMaxMessageSize = 64 * 1024
 )
 
 type WSAPI struct {
@@ -38,10 +52,27 @@ func NewWSAPI(config configs.KrakenWSConfiguration) *WSAPI {
 	return &WSAPI{
 		ws:             websocket.DefaultDialer,
 		wsAPIURL:       config.Kraken.WSAPIURL,
-		requestsConfig: config.Requests,
+		requestsConfig: withDefaultRequestsConfig(config.Requests),
 	}
 }
 
+// withDefaultRequestsConfig fills not configured values with defaults described in README
+func withDefaultRequestsConfig(cfg configs.KrakenWSAPIRequestsConfiguration) configs.KrakenWSAPIRequestsConfiguration {
+	if cfg.WriteWaitInSeconds <= 0 {
+		cfg.WriteWaitInSeconds = defaultWriteWaitInSeconds
+	}
+	if cfg.PongWaitInSeconds <= 0 {
+		cfg.PongWaitInSeconds = defaultPongWaitInSeconds
+	}
+	if cfg.PingPeriodInSeconds <= 0 || cfg.PingPeriodInSeconds >= cfg.PongWaitInSeconds {
+		cfg.PingPeriodInSeconds = defaultPingPeriodInSeconds
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = defaultMaxMessageSize
+	}
+	return cfg
+}
+
 // -------------------------- PUBLIC KRAKEN WEBSOCKET API ENDPOINTS -------------------------- //
 
 func (a *WSAPI) Heartbeat(ctx context.Context) (<-chan *HeartbeatSubscriptionData, error) {
@@ -51,12 +82,13 @@ func (a *WSAPI) Heartbeat(ctx context.Context) (<-chan *HeartbeatSubscriptionDat
 		Feed:  "heartbeat",
 	}
 
-	dataCh, errCh, err := a.serveWS(ctx, hearbeatArgs, &HeartbeatSubscriptionData{})
+	dataCh, gapCh, errCh, err := a.serveWS(ctx, hearbeatArgs, &HeartbeatSubscriptionData{})
 	if err != nil {
 		return nil, err
 	}
 
 	go logErrors(errCh)
+	go logGaps(gapCh)
 	go func() {
 		defer close(heartbeatCh)
 		for val := range dataCh {
@@ -67,7 +99,10 @@ func (a *WSAPI) Heartbeat(ctx context.Context) (<-chan *HeartbeatSubscriptionDat
 	return heartbeatCh, nil
 }
 
-func (a *WSAPI) CandlesTrade(ctx context.Context, feed string, productIDs []string) (<-chan *CandlesTradeData, error) {
+// CandlesTrade subscribes to candles feed. Every time the connection is restored after
+// a failure, Gap is sent to the second channel: candles between Gap.From and Gap.To
+// may have been missed
+func (a *WSAPI) CandlesTrade(ctx context.Context, feed string, productIDs []string) (<-chan *CandlesTradeData, <-chan Gap, error) {
 	candlesTradeCh := make(chan *CandlesTradeData)
 	candlesArgs := KrakenSendMessageArguments{
 		Event:      "subscribe",
@@ -75,9 +110,9 @@ func (a *WSAPI) CandlesTrade(ctx context.Context, feed string, productIDs []stri
 		ProductIDs: productIDs,
 	}
 
-	dataCh, errCh, err := a.serveWS(ctx, candlesArgs, &CandlesTradeData{})
+	dataCh, gapCh, errCh, err := a.serveWS(ctx, candlesArgs, &CandlesTradeData{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	go logErrors(errCh)
@@ -88,7 +123,33 @@ func (a *WSAPI) CandlesTrade(ctx context.Context, feed string, productIDs []stri
 		}
 	}()
 
-	return candlesTradeCh, nil
+	return candlesTradeCh, gapCh, nil
+}
+
+// Trade subscribes to trade feed. The first message is trade_snapshot with recent trades,
+// then every trade is sent separately
+func (a *WSAPI) Trade(ctx context.Context, productIDs []string) (<-chan *TradeData, <-chan Gap, error) {
+	tradeCh := make(chan *TradeData)
+	tradeArgs := KrakenSendMessageArguments{
+		Event:      "subscribe",
+		Feed:       TradeFeed,
+		ProductIDs: productIDs,
+	}
+
+	dataCh, gapCh, errCh, err := a.serveWS(ctx, tradeArgs, &TradeData{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	go logErrors(errCh)
+	go func() {
+		defer close(tradeCh)
+		for val := range dataCh {
+			tradeCh <- val.(*TradeData)
+		}
+	}()
+
+	return tradeCh, gapCh, nil
 }
 
 // ------------------------------------------------------------------------------------------- //
@@ -99,41 +160,95 @@ func logErrors(errCh <-chan error) {
 	}
 }
 
-func (a *WSAPI) serveWS(ctx context.Context, args KrakenSendMessageArguments, typ interface{}) (<-chan interface{}, <-chan error, error) {
-	conn, err := a.connect(args)
+func logGaps(gapCh <-chan Gap) {
+	for gap := range gapCh {
+		log.Warnf("feed %s: data gap from %s to %s", gap.Feed, gap.From, gap.To)
+	}
+}
+
+func (a *WSAPI) serveWS(ctx context.Context, args KrakenSendMessageArguments, typ interface{}) (<-chan interface{}, <-chan Gap, <-chan error, error) {
+	conn, err := a.connect(ctx, args)
 	if err != nil {
-		return nil, nil, fmt.Errorf("%s: %w", ErrServeWS, err)
+		return nil, nil, nil, fmt.Errorf("%s: %w", ErrServeWS, err)
 	}
 
-	dataCh, errCh := a.loopOverWS(ctx, conn, args, typ)
-	return dataCh, errCh, nil
+	dataCh, gapCh, errCh := a.loopOverWS(ctx, conn, args, typ)
+	return dataCh, gapCh, errCh, nil
 }
 
-func (a *WSAPI) establishConnect() (*websocket.Conn, error) {
-	var initResp map[string]interface{}
+// establishConnect dials kraken websocket until it answers with info event.
+// Attempts are separated by exponential backoff with jitter
+func (a *WSAPI) establishConnect(ctx context.Context) (*websocket.Conn, error) {
+	delay := minReconnectDelay
 
 	for i := 0; i < maxEstablishConnectCounter; i++ {
-		conn, resp, err := a.ws.Dial(a.wsAPIURL, nil)
-		if err != nil {
-			continue
-		}
-		if resp.StatusCode != http.StatusSwitchingProtocols {
-			continue
+		if i > 0 {
+			if err := sleepContext(ctx, withJitter(delay)); err != nil {
+				return nil, err
+			}
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
 		}
 
-		err = conn.ReadJSON(&initResp)
+		conn, err := a.dial()
 		if err != nil {
+			log.Debugf("%s: attempt %d: %s", ErrUnableToEstablishConnect, i+1, err)
 			continue
 		}
-		if val, ok := initResp["event"]; ok && val == "info" {
-			return conn, nil
-		}
+		return conn, nil
 	}
 
 	return nil, ErrUnableToEstablishConnect
 }
 
+func (a *WSAPI) dial() (*websocket.Conn, error) {
+	var initResp map[string]interface{}
+
+	conn, resp, err := a.ws.Dial(a.wsAPIURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := conn.ReadJSON(&initResp); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if val, ok := initResp["event"]; !ok || val != "info" {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected init event: %v", initResp["event"])
+	}
+
+	return conn, nil
+}
+
+// withJitter returns random duration in [d/2, d)
+func withJitter(d time.Duration) time.Duration {
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func (a *WSAPI) sendEvent(conn *websocket.Conn, args KrakenSendMessageArguments) (KrakenSendMessageResponse, error) {
+	if err := conn.SetWriteDeadline(time.Now().Add(a.writeWait())); err != nil {
+		return KrakenSendMessageResponse{}, fmt.Errorf("%s: %s: %w", ErrSubscribeToFeed, ErrUnableToWriteMessage, err)
+	}
 	if err := conn.WriteJSON(args); err != nil {
 		return KrakenSendMessageResponse{}, fmt.Errorf("%s: %s: %w", ErrSubscribeToFeed, ErrUnableToWriteMessage, err)
 	}
@@ -149,51 +264,117 @@ func (a *WSAPI) sendEvent(conn *websocket.Conn, args KrakenSendMessageArguments)
 	return response, nil
 }
 
-func (a *WSAPI) loopOverWS(ctx context.Context, conn *websocket.Conn, args KrakenSendMessageArguments, typ interface{}) (<-chan interface{}, <-chan error) {
+// loopOverWS reads messages from conn until ctx is done. When the connection is lost
+// it reconnects, resubscribes to the feed and reports a Gap for the time without data
+func (a *WSAPI) loopOverWS(ctx context.Context, conn *websocket.Conn, args KrakenSendMessageArguments, typ interface{}) (<-chan interface{}, <-chan Gap, <-chan error) {
 	loopChan := make(chan interface{})
+	gapChan := make(chan Gap, gapBufferSize)
 	errChan := make(chan error, 1)
 
-	conn.SetReadLimit(int64(a.requestsConfig.MaxMessageSize))
-	conn.SetPongHandler(func(string) error {
-		return conn.SetReadDeadline(time.Now().Add(time.Second * time.Duration(a.requestsConfig.PongWaitInSeconds)))
-	})
-
-	go func() {
-		<-ctx.Done()
-		conn.Close()
-	}()
-
 	go func() {
 		defer close(loopChan)
+		defer close(gapChan)
 		defer close(errChan)
 
+		lastMessageTime := time.Now()
+
 		for {
-			err := conn.ReadJSON(&typ)
+			err := a.readConn(ctx, conn, args.Feed, typ, loopChan, &lastMessageTime)
+			if ctx.Err() != nil {
+				return
+			}
+			errChan <- fmt.Errorf("%s: %s: %w", ErrLoopOverWS, ErrConnectionLost, err)
+
+			conn, err = a.connect(ctx, args)
 			if err != nil {
-				if websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					conn, err = a.connect(args)
-					if err != nil {
-						errChan <- fmt.Errorf("%s: %w", ErrLoopOverWS, err)
-						break
-					}
-					continue
+				if ctx.Err() == nil {
+					errChan <- fmt.Errorf("%s: %w", ErrLoopOverWS, err)
 				}
-				break
+				return
+			}
+			reconnects.WithLabelValues(args.Feed).Inc()
+
+			select {
+			case gapChan <- Gap{Feed: args.Feed, ProductIDs: args.ProductIDs, From: lastMessageTime, To: time.Now()}:
+			default:
+				log.Warnf("%s: gap channel is full, feed %s", ErrLoopOverWS, args.Feed)
 			}
-			loopChan <- typ
 		}
 	}()
 
-	return loopChan, errChan
+	return loopChan, gapChan, errChan
+}
+
+// readConn sends messages of feed from conn to dataCh while keeping the connection alive with pings.
+// It closes conn and returns on read error or when ctx is done
+func (a *WSAPI) readConn(ctx context.Context, conn *websocket.Conn, feed string, typ interface{}, dataCh chan<- interface{}, lastMessageTime *time.Time) error {
+	done := make(chan struct{})
+	defer close(done)
+	defer conn.Close()
+
+	pongWait := time.Second * time.Duration(a.requestsConfig.PongWaitInSeconds)
+
+	conn.SetReadLimit(int64(a.requestsConfig.MaxMessageSize))
+	if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
+		return err
+	}
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	go a.keepAlive(ctx, conn, done)
+
+	for {
+		val := reflect.New(reflect.TypeOf(typ).Elem()).Interface()
+		if err := conn.ReadJSON(val); err != nil {
+			return err
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
+			return err
+		}
+		*lastMessageTime = time.Now()
+		observeMessage(feed, val, *lastMessageTime)
+
+		select {
+		case dataCh <- val:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// keepAlive pings conn every ping period and closes it when ctx is done
+func (a *WSAPI) keepAlive(ctx context.Context, conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second * time.Duration(a.requestsConfig.PingPeriodInSeconds))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			conn.Close()
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(a.writeWait())); err != nil {
+				log.Debugf("%s: ping: %s", ErrLoopOverWS, err)
+			}
+		}
+	}
 }
 
-func (a *WSAPI) connect(args KrakenSendMessageArguments) (*websocket.Conn, error) {
-	conn, err := a.establishConnect()
+func (a *WSAPI) writeWait() time.Duration {
+	return time.Second * time.Duration(a.requestsConfig.WriteWaitInSeconds)
+}
+
+func (a *WSAPI) connect(ctx context.Context, args KrakenSendMessageArguments) (*websocket.Conn, error) {
+	conn, err := a.establishConnect(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", ErrConnect, err)
 	}
 
 	if _, err := a.sendEvent(conn, args); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("%s: %w", ErrConnect, err)
 	}
 
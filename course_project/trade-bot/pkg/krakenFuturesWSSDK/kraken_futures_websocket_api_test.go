@@ -0,0 +1,138 @@
+package krakenFuturesWSSDK
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"trade-bot/configs"
+)
+
+// newTestWSServer answers every connection like kraken: info event, then subscribed event for
+// subscription sent by client, then serve writes feed messages
+func newTestWSServer(t *testing.T, serve func(conn *websocket.Conn, connection int32)) (*WSAPI, <-chan KrakenSendMessageArguments) {
+	subscriptions := make(chan KrakenSendMessageArguments, 8)
+	var connections int32
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if err := conn.WriteJSON(map[string]string{"event": "info"}); err != nil {
+			return
+		}
+		var args KrakenSendMessageArguments
+		if err := conn.ReadJSON(&args); err != nil {
+			return
+		}
+		subscriptions <- args
+		if err := conn.WriteJSON(KrakenSendMessageResponse{Event: "subscribed", Feed: args.Feed, ProductIDs: args.ProductIDs}); err != nil {
+			return
+		}
+
+		serve(conn, atomic.AddInt32(&connections, 1))
+	}))
+	t.Cleanup(srv.Close)
+
+	a := NewWSAPI(configs.KrakenWSConfiguration{Kraken: configs.KrakenWSAPIConfiguration{
+		WSAPIURL: "ws" + strings.TrimPrefix(srv.URL, "http"),
+	}})
+	return a, subscriptions
+}
+
+func TestWSAPI_TradeReconnect(t *testing.T) {
+	a, subscriptions := newTestWSServer(t, func(conn *websocket.Conn, connection int32) {
+		_ = conn.WriteJSON(TradeData{Feed: TradeFeed, ProductID: "PI_XBTUSD", Seq: int(connection), Time: time.Now().UnixNano() / 1e6})
+		if connection == 1 {
+			// the first connection is lost right after the message
+			return
+		}
+		_, _, _ = conn.ReadMessage()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	before := time.Now()
+	tradeCh, gapCh, err := a.Trade(ctx, []string{"PI_XBTUSD"})
+	require.NoError(t, err)
+
+	first := <-tradeCh
+	assert.Equal(t, 1, first.Seq)
+
+	// feed is resubscribed after reconnect and the time without connection is reported as gap
+	second := <-tradeCh
+	assert.Equal(t, 2, second.Seq)
+
+	select {
+	case gap := <-gapCh:
+		assert.Equal(t, TradeFeed, gap.Feed)
+		assert.Equal(t, []string{"PI_XBTUSD"}, gap.ProductIDs)
+		assert.False(t, gap.From.Before(before))
+		assert.True(t, gap.From.Before(gap.To))
+	case <-time.After(time.Second):
+		t.Fatal("gap is not reported")
+	}
+
+	for i := 0; i < 2; i++ {
+		args := <-subscriptions
+		assert.Equal(t, KrakenSendMessageArguments{Event: "subscribe", Feed: TradeFeed, ProductIDs: []string{"PI_XBTUSD"}}, args)
+	}
+
+	// channels are closed when context is done
+	cancel()
+	for range tradeCh {
+	}
+}
+
+func TestWSAPI_TradeSnapshot(t *testing.T) {
+	snapshot := TradeData{Feed: TradeSnapshotFeed, ProductID: "PI_XBTUSD"}
+	for i := 0; i < 100; i++ {
+		snapshot.Trades = append(snapshot.Trades, TradeData{
+			Feed:      TradeFeed,
+			ProductID: "PI_XBTUSD",
+			UID:       fmt.Sprintf("%08d-1f4e-4e0b-bd1c-3e1d5c5a0b6f", i),
+			Side:      "buy",
+			Type:      "fill",
+			Seq:       i,
+			Time:      1641168000000 + int64(i),
+		})
+	}
+	message, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.Greater(t, len(message), 16*1024)
+
+	a, _ := newTestWSServer(t, func(conn *websocket.Conn, connection int32) {
+		_ = conn.WriteMessage(websocket.TextMessage, message)
+		_, _, _ = conn.ReadMessage()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// snapshot is read with default limit of message size instead of breaking connection
+	tradeCh, gapCh, err := a.Trade(ctx, []string{"PI_XBTUSD"})
+	require.NoError(t, err)
+	select {
+	case received := <-tradeCh:
+		assert.Equal(t, TradeSnapshotFeed, received.Feed)
+		assert.Len(t, received.Trades, 100)
+	case <-time.After(5 * time.Second):
+		t.Fatal("snapshot is not received")
+	}
+	assert.Empty(t, gapCh)
+}
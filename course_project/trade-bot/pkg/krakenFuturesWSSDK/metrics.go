@@ -0,0 +1,71 @@
+package krakenFuturesWSSDK
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	reconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "trade_bot",
+		Subsystem: "kraken_ws",
+		Name:      "reconnects_total",
+		Help:      "Lost kraken futures websocket connections which were reconnected.",
+	}, []string{"feed"})
+
+	messages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "trade_bot",
+		Subsystem: "kraken_ws",
+		Name:      "messages_total",
+		Help:      "Messages received from kraken futures websocket feeds.",
+	}, []string{"feed"})
+
+	lastMessage = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "trade_bot",
+		Subsystem: "kraken_ws",
+		Name:      "last_message_timestamp_seconds",
+		Help:      "Unix time of the last message of feed, stalled feeds are found by its age.",
+	}, []string{"feed"})
+
+	lag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "trade_bot",
+		Subsystem: "kraken_ws",
+		Name:      "lag_seconds",
+		Help:      "Delay between kraken event time and receiving of the last message of feed with event time.",
+	}, []string{"feed"})
+)
+
+// timestamped is implemented by messages carrying time of event
+type timestamped interface {
+	eventTime() time.Time
+}
+
+func (d *HeartbeatSubscriptionData) eventTime() time.Time {
+	return unixMilli(int64(d.Time))
+}
+
+// eventTime of trade snapshot is zero, it describes past trades
+func (d *TradeData) eventTime() time.Time {
+	return unixMilli(d.Time)
+}
+
+func unixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// observeMessage records message of feed received at time received
+func observeMessage(feed string, val interface{}, received time.Time) {
+	messages.WithLabelValues(feed).Inc()
+	lastMessage.WithLabelValues(feed).Set(float64(received.UnixNano()) / float64(time.Second))
+
+	if message, ok := val.(timestamped); ok {
+		if t := message.eventTime(); !t.IsZero() {
+			lag.WithLabelValues(feed).Set(received.Sub(t).Seconds())
+		}
+	}
+}
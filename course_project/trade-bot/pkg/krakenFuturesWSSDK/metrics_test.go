@@ -0,0 +1,30 @@
+package krakenFuturesWSSDK
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveMessage(t *testing.T) {
+	received := time.Date(2022, 1, 1, 0, 0, 3, 0, time.UTC)
+	event := received.Add(-1500 * time.Millisecond)
+	count := testutil.ToFloat64(messages.WithLabelValues(TradeFeed))
+
+	observeMessage(TradeFeed, &TradeData{Feed: TradeFeed, Time: event.UnixNano() / int64(time.Millisecond)}, received)
+	assert.Equal(t, count+1, testutil.ToFloat64(messages.WithLabelValues(TradeFeed)))
+	assert.Equal(t, 1.5, testutil.ToFloat64(lag.WithLabelValues(TradeFeed)))
+	assert.Equal(t, float64(received.Unix()), testutil.ToFloat64(lastMessage.WithLabelValues(TradeFeed)))
+
+	// snapshot of past trades doesn't change lag
+	observeMessage(TradeFeed, &TradeData{Feed: "trade_snapshot"}, received.Add(time.Minute))
+	assert.Equal(t, 1.5, testutil.ToFloat64(lag.WithLabelValues(TradeFeed)))
+	assert.Equal(t, float64(received.Add(time.Minute).Unix()), testutil.ToFloat64(lastMessage.WithLabelValues(TradeFeed)))
+
+	// candles have no event time
+	count = testutil.ToFloat64(messages.WithLabelValues("candles_trade_1m"))
+	observeMessage("candles_trade_1m", &CandlesTradeData{}, received)
+	assert.Equal(t, count+1, testutil.ToFloat64(messages.WithLabelValues("candles_trade_1m")))
+}
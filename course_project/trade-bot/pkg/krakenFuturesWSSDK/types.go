@@ -1,7 +1,105 @@
 package krakenFuturesWSSDK
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidCandlesFeed     = errors.New("invalid candles feed")
+	ErrUnknownCandlesType     = errors.New("unknown candles type")
+	ErrUnknownCandlesInterval = errors.New("unknown candles interval")
+)
+
 const OneMinuteCandlesFeed = "candles_trade_1m"
 
+const candlesFeedPrefix = "candles"
+
+const (
+	TradeFeed         = "trade"
+	TradeSnapshotFeed = "trade_snapshot"
+)
+
+// CandlesType is the price source candles are built from
+type CandlesType string
+
+const (
+	TradeCandles CandlesType = "trade"
+	MarkCandles  CandlesType = "mark"
+	SpotCandles  CandlesType = "spot"
+)
+
+func (t CandlesType) Validate() error {
+	switch t {
+	case TradeCandles, MarkCandles, SpotCandles:
+		return nil
+	}
+	return fmt.Errorf("%s: %s", ErrUnknownCandlesType, t)
+}
+
+// CandlesInterval is the timeframe of kraken candles feed
+type CandlesInterval string
+
+const (
+	OneMinute      CandlesInterval = "1m"
+	FiveMinutes    CandlesInterval = "5m"
+	FifteenMinutes CandlesInterval = "15m"
+	OneHour        CandlesInterval = "1h"
+	FourHours      CandlesInterval = "4h"
+	TwelveHours    CandlesInterval = "12h"
+	OneDay         CandlesInterval = "1d"
+	OneWeek        CandlesInterval = "1w"
+)
+
+var candlesIntervalDurations = map[CandlesInterval]time.Duration{
+	OneMinute:      time.Minute,
+	FiveMinutes:    5 * time.Minute,
+	FifteenMinutes: 15 * time.Minute,
+	OneHour:        time.Hour,
+	FourHours:      4 * time.Hour,
+	TwelveHours:    12 * time.Hour,
+	OneDay:         24 * time.Hour,
+	OneWeek:        7 * 24 * time.Hour,
+}
+
+func (i CandlesInterval) Validate() error {
+	if _, ok := candlesIntervalDurations[i]; !ok {
+		return fmt.Errorf("%s: %s", ErrUnknownCandlesInterval, i)
+	}
+	return nil
+}
+
+// Duration returns the length of one candle, zero for unknown interval
+func (i CandlesInterval) Duration() time.Duration {
+	return candlesIntervalDurations[i]
+}
+
+// CandlesFeed returns kraken feed name, for example candles_mark_1h
+func CandlesFeed(typ CandlesType, interval CandlesInterval) string {
+	return fmt.Sprintf("%s_%s_%s", candlesFeedPrefix, typ, interval)
+}
+
+// ParseCandlesFeed splits kraken candles feed name to its type and interval
+func ParseCandlesFeed(feed string) (CandlesType, CandlesInterval, error) {
+	parts := strings.Split(feed, "_")
+	if len(parts) != 3 || parts[0] != candlesFeedPrefix {
+		return "", "", fmt.Errorf("%s: %s", ErrInvalidCandlesFeed, feed)
+	}
+
+	typ, interval := CandlesType(parts[1]), CandlesInterval(parts[2])
+	if err := typ.Validate(); err != nil {
+		return "", "", fmt.Errorf("%s: %w", ErrInvalidCandlesFeed, err)
+	}
+	if err := interval.Validate(); err != nil {
+		return "", "", fmt.Errorf("%s: %w", ErrInvalidCandlesFeed, err)
+	}
+	return typ, interval, nil
+}
+
 // -------------------------- PUBLIC KRAKEN WEBSOCKET API DATA -------------------------- //
 
 type KrakenSendMessageArguments struct {
@@ -28,13 +126,42 @@ type CandlesTradeData struct {
 	ProductID string `json:"product_id"`
 }
 
+// TradeData is either a single trade of trade feed or
+// the list of recent trades in Trades when Feed is trade_snapshot
+type TradeData struct {
+	Feed      string          `json:"feed"`
+	ProductID string          `json:"product_id"`
+	UID       string          `json:"uid,omitempty"`
+	Side      string          `json:"side,omitempty"`
+	Type      string          `json:"type,omitempty"`
+	Seq       int             `json:"seq,omitempty"`
+	Time      int64           `json:"time,omitempty"`
+	Qty       decimal.Decimal `json:"qty,omitempty"`
+	Price     decimal.Decimal `json:"price,omitempty"`
+	Trades    []TradeData     `json:"trades,omitempty"`
+}
+
 // -------------------------------------------------------------------------------------- //
 
 type Candle struct {
-	Time   int    `json:"time"`
-	Open   string `json:"open"`
-	High   string `json:"high"`
-	Low    string `json:"low"`
-	Close  string `json:"close"`
-	Volume int    `json:"volume"`
+	Time   int             `json:"time"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+}
+
+// Equal reports whether candles have the same time and values, decimals are compared by value
+func (c Candle) Equal(o Candle) bool {
+	return c.Time == o.Time && c.Open.Equal(o.Open) && c.High.Equal(o.High) &&
+		c.Low.Equal(o.Low) && c.Close.Equal(o.Close) && c.Volume.Equal(o.Volume)
+}
+
+// Gap describes the period when feed data could not be received due to reconnect
+type Gap struct {
+	Feed       string
+	ProductIDs []string
+	From       time.Time
+	To         time.Time
 }
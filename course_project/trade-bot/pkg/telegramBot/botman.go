@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 
 	"trade-bot/pkg/client/models"
@@ -36,6 +37,7 @@ const (
 	startTradingCommand         = "/start_trading"
 	exitFromStartTradingCommand = "/exit_from_start_trading"
 	getUserOrdersCommand        = "/get_user_orders"
+	getReportCommand            = "/get_report"
 	logoutCommand               = "/logout"
 )
 
@@ -182,6 +184,26 @@ func (b *BotMan) ServeTelegram() {
 				successMessage := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s\n%s", utils.SendOrderSuccessMessage, resp.String()))
 				b.sendMessage(chatID, successMessage)
 
+			case getReportCommand:
+				token, err := b.userIdentity(update.Message.From.UserName)
+				if err != nil {
+					log.Warn(err)
+					errMessage := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s: %s", utils.GetReportErrMessage, err.Error()))
+					b.sendMessage(chatID, errMessage)
+					continue
+				}
+
+				resp, err := b.tradeBotServices.Reports.GetReport(models.GetReportInput{JWTToken: token})
+				if err != nil {
+					log.Warn(err)
+					errMessage := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s: %s", utils.GetReportErrMessage, err.Error()))
+					b.sendMessage(chatID, errMessage)
+					continue
+				}
+
+				successMessage := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s\n%s", utils.GetReportSuccessMessage, resp.String()))
+				b.sendMessage(chatID, successMessage)
+
 			case startTradingCommand:
 				token, err := b.userIdentity(update.Message.From.UserName)
 				if err != nil {
@@ -287,11 +309,11 @@ func (b *BotMan) getStartTradingInput(updates tgbotapi.UpdatesChannel) (models.S
 			if err != nil {
 				return models.StartTradingInput{}, fmt.Errorf("invalid start trading Size argument")
 			}
-			stopLoss, err := strconv.ParseFloat(inputValues[3], 64)
+			stopLoss, err := decimal.NewFromString(inputValues[3])
 			if err != nil {
 				return models.StartTradingInput{}, fmt.Errorf("invalid start trading Stop loss argument")
 			}
-			takeProfit, err := strconv.ParseFloat(inputValues[4], 64)
+			takeProfit, err := decimal.NewFromString(inputValues[4])
 			if err != nil {
 				return models.StartTradingInput{}, fmt.Errorf("invalid start trading Take profit argument")
 			}
@@ -305,8 +327,8 @@ func (b *BotMan) getStartTradingInput(updates tgbotapi.UpdatesChannel) (models.S
 						Side:      inputValues[1],
 						Size:      uint(amount),
 					},
-					StopLossBorder:   uint(stopLoss),
-					TakeProfitBorder: uint(takeProfit),
+					StopLossBorder:   stopLoss,
+					TakeProfitBorder: takeProfit,
 				},
 			}, nil
 		}
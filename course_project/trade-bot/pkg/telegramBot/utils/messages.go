@@ -18,6 +18,7 @@ Commands 📟:
 	🔵 /exit_from_sign_in - stop getting input data to login you in the bot
 	🔵 /send_order - allow to send market order with symbol, side and amount arguments to kraken futures
 	🔵 /exit_from_send_order - stop getting input data to send order to kraken futures
+	🔵 /get_report - show win rate, profit factor, net profit, drawdown and fees of your finished trading sessions
 	🔵 /logout - logout you from trading bot system on every telegram device associated with your username
 `
 
@@ -123,3 +124,11 @@ const StartTradingSuccessMessage = `
 const GetUserOrdersErrMessage = `
 ⛔ Unable to continue further execution of get user orders due to
 `
+
+const GetReportErrMessage = `
+⛔ Unable to continue further execution of get report due to
+`
+
+const GetReportSuccessMessage = `
+📊 Performance of your trading sessions, profits are net of estimated fees:
+`
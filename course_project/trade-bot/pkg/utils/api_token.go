@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// APITokenPrefix marks api tokens, so they are told apart from jwt tokens
+	APITokenPrefix = "tbt_"
+
+	apiTokenSize = 32
+	// apiTokenDisplayLen is number of leading characters of token shown in token list
+	apiTokenDisplayLen = len(APITokenPrefix) + 8
+)
+
+// GenerateAPIToken returns random api token and its prefix shown to user to recognize the token
+func GenerateAPIToken() (string, string, error) {
+	b := make([]byte, apiTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := APITokenPrefix + hex.EncodeToString(b)
+	return token, token[:apiTokenDisplayLen], nil
+}
+
+// IsAPIToken reports whether bearer token is api token rather than jwt
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+)
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns context carrying id which ties together records made while serving one request
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns id stored in context by WithCorrelationID, empty string if there is none
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// NewCorrelationID generates random correlation id
+func NewCorrelationID() string {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return ""
+	}
+	return id.String()
+}
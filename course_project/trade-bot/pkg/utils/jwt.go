@@ -14,15 +14,18 @@ import (
 )
 
 const (
-	accessUUIDTokenClaim = "access_UUID"
-	authorizedTokenClaim = "authorized"
-	userIDTokenClaim     = "user_id"
-	expiresTokenClaim    = "exp"
+	accessUUIDTokenClaim  = "access_UUID"
+	refreshUUIDTokenClaim = "refresh_UUID"
+	sessionIDTokenClaim   = "session_id"
+	authorizedTokenClaim  = "authorized"
+	userIDTokenClaim      = "user_id"
+	expiresTokenClaim     = "exp"
 )
 
 const jwtHeaderAlgo = "alg"
 const authorizationHeader = "Authorization"
 const jwtAccessSigningKey = "JWT_ACCESS_SIGNING_KEY"
+const jwtRefreshSigningKey = "JWT_REFRESH_SIGNING_KEY"
 
 var (
 	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
@@ -30,6 +33,8 @@ var (
 	ErrExtractTokenMetadata    = errors.New("extract token metadata")
 	ErrCantAssignToMapClaims   = errors.New("can't assign to map claims")
 	ErrInvalidAccessUUID       = errors.New("invalid access uuid")
+	ErrInvalidRefreshUUID      = errors.New("invalid refresh uuid")
+	ErrInvalidSessionID        = errors.New("invalid session id")
 	ErrInvalidUserID           = errors.New("invalid user id")
 	ErrInvalidToken            = errors.New("invalid token")
 	ErrEmptyAuthHeader         = errors.New("empty auth header")
@@ -38,16 +43,27 @@ var (
 )
 
 type TokenDetails struct {
-	AccessToken string
-	AccessUUID  string
-	AtExpires   int64
+	AccessToken  string
+	AccessUUID   string
+	AtExpires    int64
+	RefreshToken string
+	RefreshUUID  string
+	RtExpires    int64
+	SessionID    string
 }
 
 type AccessDetails struct {
 	AccessUUID string
+	SessionID  string
 	UserID     int64
 }
 
+type RefreshDetails struct {
+	RefreshUUID string
+	SessionID   string
+	UserID      int64
+}
+
 func GetBearerToken(r *http.Request) (string, error) {
 	authHeader := r.Header.Get(authorizationHeader)
 
@@ -90,19 +106,57 @@ func ExtractTokenMetadata(token string) (AccessDetails, error) {
 	if err != nil {
 		return AccessDetails{}, fmt.Errorf("%s: %w", ErrExtractTokenMetadata, ErrInvalidUserID)
 	}
+	// tokens issued before sessions were introduced have no session id
+	sessionID, _ := claims[sessionIDTokenClaim].(string)
 
 	return AccessDetails{
 		AccessUUID: accessUUID,
+		SessionID:  sessionID,
 		UserID:     int64(userID),
 	}, nil
 }
 
+func ExtractRefreshTokenMetadata(token string) (RefreshDetails, error) {
+	verifiedToken, err := verifyToken(token, jwtRefreshSigningKey)
+	if err != nil {
+		return RefreshDetails{}, fmt.Errorf("%s: %w", ErrExtractTokenMetadata, err)
+	}
+
+	claims, ok := verifiedToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return RefreshDetails{}, fmt.Errorf("%s: %w", ErrExtractTokenMetadata, ErrCantAssignToMapClaims)
+	}
+
+	refreshUUID, ok := claims[refreshUUIDTokenClaim].(string)
+	if !ok {
+		return RefreshDetails{}, fmt.Errorf("%s: %w", ErrExtractTokenMetadata, ErrInvalidRefreshUUID)
+	}
+	sessionID, ok := claims[sessionIDTokenClaim].(string)
+	if !ok {
+		return RefreshDetails{}, fmt.Errorf("%s: %w", ErrExtractTokenMetadata, ErrInvalidSessionID)
+	}
+	userID, err := strconv.ParseUint(fmt.Sprintf("%.f", claims[userIDTokenClaim]), 10, 64)
+	if err != nil {
+		return RefreshDetails{}, fmt.Errorf("%s: %w", ErrExtractTokenMetadata, ErrInvalidUserID)
+	}
+
+	return RefreshDetails{
+		RefreshUUID: refreshUUID,
+		SessionID:   sessionID,
+		UserID:      int64(userID),
+	}, nil
+}
+
 func VerifyToken(token string) (*jwt.Token, error) {
+	return verifyToken(token, jwtAccessSigningKey)
+}
+
+func verifyToken(token string, signingKeyEnv string) (*jwt.Token, error) {
 	verified, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("%s: %v", ErrUnexpectedSigningMethod, token.Header[jwtHeaderAlgo])
 		}
-		return []byte(os.Getenv(jwtAccessSigningKey)), nil
+		return []byte(os.Getenv(signingKeyEnv)), nil
 	})
 	if err != nil {
 		return nil, err
@@ -114,8 +168,17 @@ func VerifyToken(token string) (*jwt.Token, error) {
 	return verified, nil
 }
 
-func GenerateJWTToken(userID int, d time.Duration) (TokenDetails, error) {
-	td := TokenDetails{}
+// GenerateJWTToken issues access token of session, session id is generated if it is empty
+func GenerateJWTToken(userID int, sessionID string, d time.Duration) (TokenDetails, error) {
+	td := TokenDetails{SessionID: sessionID}
+
+	if td.SessionID == "" {
+		sUUID, err := uuid.NewV4()
+		if err != nil {
+			return TokenDetails{}, err
+		}
+		td.SessionID = sUUID.String()
+	}
 
 	td.AtExpires = time.Now().Add(d).Unix()
 	aUUID, err := uuid.NewV4()
@@ -127,6 +190,7 @@ func GenerateJWTToken(userID int, d time.Duration) (TokenDetails, error) {
 	atClaims := jwt.MapClaims{}
 	atClaims[authorizedTokenClaim] = true
 	atClaims[accessUUIDTokenClaim] = td.AccessUUID
+	atClaims[sessionIDTokenClaim] = td.SessionID
 	atClaims[userIDTokenClaim] = userID
 	atClaims[expiresTokenClaim] = td.AtExpires
 
@@ -135,3 +199,29 @@ func GenerateJWTToken(userID int, d time.Duration) (TokenDetails, error) {
 
 	return td, err
 }
+
+// GenerateTokenPair issues access token and refresh token of session, session id is generated if it is empty
+func GenerateTokenPair(userID int, sessionID string, accessTTL, refreshTTL time.Duration) (TokenDetails, error) {
+	td, err := GenerateJWTToken(userID, sessionID, accessTTL)
+	if err != nil {
+		return TokenDetails{}, err
+	}
+
+	td.RtExpires = time.Now().Add(refreshTTL).Unix()
+	rUUID, err := uuid.NewV4()
+	if err != nil {
+		return TokenDetails{}, err
+	}
+	td.RefreshUUID = rUUID.String()
+
+	rtClaims := jwt.MapClaims{}
+	rtClaims[refreshUUIDTokenClaim] = td.RefreshUUID
+	rtClaims[sessionIDTokenClaim] = td.SessionID
+	rtClaims[userIDTokenClaim] = userID
+	rtClaims[expiresTokenClaim] = td.RtExpires
+
+	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, rtClaims)
+	td.RefreshToken, err = rt.SignedString([]byte(os.Getenv(jwtRefreshSigningKey)))
+
+	return td, err
+}
@@ -0,0 +1,13 @@
+CREATE TABLE candles
+(
+    symbol       varchar(255) not null,
+    candles_type varchar(255) not null,
+    timeframe    varchar(255) not null,
+    time         timestamptz  not null,
+    open         float8       not null,
+    high         float8       not null,
+    low          float8       not null,
+    close        float8       not null,
+    volume       float8       not null,
+    primary key (symbol, candles_type, timeframe, time)
+);
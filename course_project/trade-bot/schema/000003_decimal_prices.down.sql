@@ -0,0 +1,11 @@
+ALTER TABLE orders
+    ALTER COLUMN quantity TYPE float8,
+    ALTER COLUMN filled TYPE float8,
+    ALTER COLUMN price TYPE float8;
+
+ALTER TABLE candles
+    ALTER COLUMN open TYPE float8,
+    ALTER COLUMN high TYPE float8,
+    ALTER COLUMN low TYPE float8,
+    ALTER COLUMN close TYPE float8,
+    ALTER COLUMN volume TYPE float8;
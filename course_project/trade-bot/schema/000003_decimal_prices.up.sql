@@ -0,0 +1,11 @@
+ALTER TABLE orders
+    ALTER COLUMN quantity TYPE numeric,
+    ALTER COLUMN filled TYPE numeric,
+    ALTER COLUMN price TYPE numeric;
+
+ALTER TABLE candles
+    ALTER COLUMN open TYPE numeric,
+    ALTER COLUMN high TYPE numeric,
+    ALTER COLUMN low TYPE numeric,
+    ALTER COLUMN close TYPE numeric,
+    ALTER COLUMN volume TYPE numeric;
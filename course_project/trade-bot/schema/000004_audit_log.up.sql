@@ -0,0 +1,11 @@
+CREATE TABLE audit_log
+(
+    id         serial       not null unique,
+    user_id    integer      not null default 0,
+    action     varchar(255) not null,
+    entity_id  varchar(255) not null default '',
+    details    text         not null default '',
+    created_at timestamptz  not null default now()
+);
+
+CREATE INDEX audit_log_user_id_created_at_idx ON audit_log (user_id, created_at);
@@ -0,0 +1,13 @@
+CREATE TABLE order_submissions
+(
+    id              serial                                      not null unique,
+    user_id         int references users (id) on delete cascade not null,
+    idempotency_key varchar(255)                                not null default '',
+    cli_order_id    varchar(255)                                not null unique,
+    order_id        varchar(255)                                not null default '',
+    failed          boolean                                     not null default false,
+    created_at      timestamptz                                 not null default now()
+);
+
+CREATE UNIQUE INDEX order_submissions_user_id_idempotency_key_idx
+    ON order_submissions (user_id, idempotency_key) WHERE idempotency_key <> '';
@@ -0,0 +1,9 @@
+DROP TRIGGER audit_log_append_only ON audit_log;
+
+DROP FUNCTION audit_log_append_only();
+
+DROP INDEX audit_log_correlation_id_idx;
+
+ALTER TABLE audit_log
+    DROP COLUMN correlation_id,
+    DROP COLUMN data;
@@ -0,0 +1,18 @@
+ALTER TABLE audit_log
+    ADD COLUMN correlation_id varchar(255) not null default '',
+    ADD COLUMN data           jsonb        not null default '{}';
+
+CREATE INDEX audit_log_correlation_id_idx ON audit_log (correlation_id);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE
+    ON audit_log
+    FOR EACH ROW
+EXECUTE PROCEDURE audit_log_append_only();
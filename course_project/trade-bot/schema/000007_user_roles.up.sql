@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN role     varchar(32) not null default 'trader' CHECK (role IN ('trader', 'viewer', 'admin')),
+    ADD COLUMN disabled boolean     not null default false;
@@ -0,0 +1,15 @@
+CREATE TABLE api_tokens
+(
+    id           serial                                       not null unique,
+    user_id      int references users (id) on delete cascade not null,
+    name         varchar(255)                                 not null,
+    prefix       varchar(16)                                  not null,
+    token_hash   varchar(64)                                  not null unique,
+    scopes       varchar(255)                                 not null,
+    allowed_ips  text                                         not null default '',
+    created_at   timestamptz                                  not null default now(),
+    expires_at   timestamptz,
+    last_used_at timestamptz
+);
+
+CREATE INDEX api_tokens_user_id_idx ON api_tokens (user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE users_orders
+    DROP CONSTRAINT users_orders_pkey,
+    ADD COLUMN id serial not null unique;
@@ -0,0 +1,9 @@
+DELETE FROM users_orders a
+    USING users_orders b
+WHERE a.user_id = b.user_id
+  AND a.order_id = b.order_id
+  AND a.id > b.id;
+
+ALTER TABLE users_orders
+    DROP COLUMN id,
+    ADD PRIMARY KEY (user_id, order_id);
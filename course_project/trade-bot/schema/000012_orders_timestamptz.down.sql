@@ -0,0 +1,9 @@
+ALTER TABLE orders
+    ALTER COLUMN timestamp TYPE varchar(255)
+        USING COALESCE(to_char(timestamp AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), ''),
+    ALTER COLUMN last_update_timestamp TYPE varchar(255)
+        USING COALESCE(to_char(last_update_timestamp AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), '');
+
+ALTER TABLE orders
+    ALTER COLUMN timestamp SET NOT NULL,
+    ALTER COLUMN last_update_timestamp SET NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE orders
+    ALTER COLUMN timestamp DROP NOT NULL,
+    ALTER COLUMN last_update_timestamp DROP NOT NULL;
+
+ALTER TABLE orders
+    ALTER COLUMN timestamp TYPE timestamptz USING NULLIF(timestamp, '')::timestamptz,
+    ALTER COLUMN last_update_timestamp TYPE timestamptz USING NULLIF(last_update_timestamp, '')::timestamptz;
@@ -0,0 +1 @@
+CREATE INDEX orders_user_id_time_idx ON orders (user_id, (COALESCE(timestamp, 'epoch'::timestamptz)), order_id);
@@ -0,0 +1,16 @@
+CREATE TABLE trading_sessions
+(
+    id              serial                                       not null unique,
+    user_id         int references users (id) on delete cascade not null,
+    strategy        varchar(255)                                 not null,
+    exchange        varchar(255)                                 not null default '',
+    symbol          varchar(255)                                 not null,
+    side            varchar(255)                                 not null,
+    start_order_id  varchar(255)                                 not null,
+    finish_order_id varchar(255)                                 not null default '',
+    reason          varchar(255)                                 not null default '',
+    started_at      timestamptz                                  not null,
+    finished_at     timestamptz
+);
+
+CREATE INDEX trading_sessions_user_id_finished_at_idx ON trading_sessions (user_id, finished_at);
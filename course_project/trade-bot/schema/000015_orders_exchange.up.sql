@@ -0,0 +1,2 @@
+ALTER TABLE orders
+    ADD COLUMN exchange varchar(255) not null default 'kraken_futures';
@@ -0,0 +1,6 @@
+UPDATE order_submissions
+SET idempotency_key = substring(idempotency_key FROM position(':' IN idempotency_key) + 1)
+WHERE idempotency_key <> '';
+
+ALTER TABLE order_submissions
+    ALTER COLUMN idempotency_key TYPE varchar(255);
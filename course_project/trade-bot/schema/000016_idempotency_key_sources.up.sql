@@ -0,0 +1,7 @@
+-- keys are prefixed by their source, batches used client order ids as keys
+ALTER TABLE order_submissions
+    ALTER COLUMN idempotency_key TYPE varchar(263);
+
+UPDATE order_submissions
+SET idempotency_key = CASE WHEN idempotency_key = cli_order_id THEN 'batch:' ELSE 'request:' END || idempotency_key
+WHERE idempotency_key <> '';
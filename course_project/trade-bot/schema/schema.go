@@ -0,0 +1,8 @@
+// Package schema embeds migrations of postgres database, files are named
+// {version}_{name}.up.sql and {version}_{name}.down.sql like golang-migrate expects
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var Migrations embed.FS